@@ -0,0 +1,174 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// AccessLogHandler can handle the access logs of web servers like Apache
+// or Nginx, in the Common or the Combined Log Format.
+type AccessLogHandler struct {
+	renderer *Renderer
+
+	Opts *HandlerOptions
+
+	Level   string
+	Time    time.Time
+	Message string
+	Fields  map[string]string
+}
+
+func (h *AccessLogHandler) clear() {
+	h.Level = ""
+	h.Time = time.Time{}
+	h.Message = ""
+	h.Fields = make(map[string]string)
+}
+
+// parse tells if d was handled by this handler, which then holds it until
+// the next call to Prettify.
+func (h *AccessLogHandler) parse(d []byte) bool {
+	if !bytes.Contains(d, []byte(`] "`)) {
+		return false
+	}
+	err := h.UnmarshalAccessLog(d)
+	if err != nil {
+		h.clear()
+		return false
+	}
+	return true
+}
+
+const (
+	accessLogQuoted     = `"((?:[^"\\]|\\.)*)"`
+	accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+)
+
+var accessLogLine = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] ` + accessLogQuoted + ` (\d{3}) (\d+|-)(?: ` + accessLogQuoted + ` ` + accessLogQuoted + `)?`)
+
+// UnmarshalAccessLog sets the fields of the handler from a line like:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://ref/" "Mozilla/5.0"
+//
+// The level is derived from the class of the status code.
+func (h *AccessLogHandler) UnmarshalAccessLog(data []byte) error {
+	m := accessLogLine.FindStringSubmatch(string(data))
+	if m == nil {
+		return fmt.Errorf("not an access log line")
+	}
+	var err error
+	h.Time, err = time.Parse(accessLogTimeLayout, m[4])
+	if err != nil {
+		return err
+	}
+
+	if h.Fields == nil {
+		h.Fields = make(map[string]string)
+	}
+	for _, kv := range [][2]string{
+		{"remote", m[1]},
+		{"ident", m[2]},
+		{"user", m[3]},
+		{"bytes", m[7]},
+	} {
+		if kv[1] != "-" {
+			h.Fields[kv[0]] = kv[1]
+		}
+	}
+	h.Fields["status"] = m[6]
+	for _, kv := range [][2]string{
+		{"referer", m[8]},
+		{"user_agent", m[9]},
+	} {
+		if kv[1] != "" && kv[1] != "-" {
+			h.Fields[kv[0]] = strconv.Quote(unescapeAccessLog(kv[1]))
+		}
+	}
+
+	request := strings.Split(unescapeAccessLog(m[5]), " ")
+	if len(request) == 3 {
+		h.Message = request[0] + " " + request[1]
+		h.Fields["method"] = request[0]
+		h.Fields["path"] = request[1]
+		h.Fields["proto"] = request[2]
+	} else {
+		h.Message = unescapeAccessLog(m[5])
+	}
+
+	switch m[6][0] {
+	case '5':
+		h.Level = ErrorLevel
+	case '4':
+		h.Level = WarnLevel
+	default:
+		h.Level = InfoLevel
+	}
+	return nil
+}
+
+// unescapeAccessLog undoes the escaping of quoted strings of access logs,
+// which use backslashes like Go does.
+func unescapeAccessLog(s string) string {
+	if unquoted, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return unquoted
+	}
+	return s
+}
+
+// statusColor picks the color of an HTTP status code, by its class.
+func (h *HandlerOptions) statusColor(status string) *color.Color {
+	if status == "" {
+		return h.ValColor
+	}
+	switch status[0] {
+	case '2':
+		return h.StatusSuccessColor
+	case '4':
+		return h.StatusClientErrColor
+	case '5':
+		return h.StatusServerErrColor
+	default:
+		return h.ValColor
+	}
+}
+
+// TryHandle parses d into an event, if it is in the format of the handler.
+// The handler holds the line until the next call to Prettify.
+func (h *AccessLogHandler) TryHandle(d []byte) (Event, bool) {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if !h.parse(d) {
+		return Event{}, false
+	}
+	return h.event(), true
+}
+
+// Prettify the output in a logrus like fashion.
+func (h *AccessLogHandler) Prettify(skipUnchanged bool) []byte {
+	defer h.clear()
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if h.renderer == nil {
+		h.renderer = NewRenderer(h.Opts)
+	}
+	return h.renderer.Render(h.event(), skipUnchanged)
+}
+
+func (h *AccessLogHandler) event() Event {
+	return Event{
+		Time:   h.Time,
+		Level:  h.Level,
+		Msg:    h.Message,
+		Fields: h.Fields,
+
+		httpStatus: true,
+	}
+}
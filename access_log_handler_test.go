@@ -0,0 +1,80 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestAccessLogHandler(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := AccessLogHandler{Opts: &opts}
+
+	if _, ok := h.TryHandle([]byte(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`)); !ok {
+		t.Fatal("expected the common log format line to be handled")
+	}
+	if want := time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	if h.Message != "GET /apache_pb.gif" || h.Level != InfoLevel {
+		t.Fatalf("want an info GET message, got %q at %q", h.Message, h.Level)
+	}
+	for k, want := range map[string]string{
+		"remote": "127.0.0.1",
+		"user":   "frank",
+		"method": "GET",
+		"path":   "/apache_pb.gif",
+		"proto":  "HTTP/1.0",
+		"status": "200",
+		"bytes":  "2326",
+	} {
+		if got := h.Fields[k]; got != want {
+			t.Fatalf("want field %q to be %q, got %q", k, want, got)
+		}
+	}
+	if _, ok := h.Fields["ident"]; ok {
+		t.Fatal("want the - ident omitted")
+	}
+	h.Prettify(false)
+
+	if _, ok := h.TryHandle([]byte(`10.0.0.2 - - [10/Oct/2000:13:55:37 +0000] "POST /login HTTP/1.1" 503 - "http://example.com/\"home\"" "Mozilla/5.0 (X11)"`)); !ok {
+		t.Fatal("expected the combined log format line to be handled")
+	}
+	if h.Level != ErrorLevel {
+		t.Fatalf("want a 5xx to be an error, got %q", h.Level)
+	}
+	if h.Fields["referer"] != `"http://example.com/\"home\""` || h.Fields["user_agent"] != `"Mozilla/5.0 (X11)"` {
+		t.Fatalf("want the referer and user agent, got %v", h.Fields)
+	}
+	if _, ok := h.Fields["bytes"]; ok {
+		t.Fatal("want the - bytes omitted")
+	}
+	h.Prettify(false)
+
+	if _, ok := h.TryHandle([]byte(`[10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 1`)); ok {
+		t.Fatal("want a line without client rejected")
+	}
+}
+
+func TestAccessLogHandlerStatusColors(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	h := AccessLogHandler{Opts: DefaultOptions}
+	for status, c := range map[string]*color.Color{
+		"204": DefaultOptions.StatusSuccessColor,
+		"404": DefaultOptions.StatusClientErrColor,
+		"500": DefaultOptions.StatusServerErrColor,
+	} {
+		if _, ok := h.TryHandle([]byte(`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" ` + status + ` 1`)); !ok {
+			t.Fatal("expected line to be handled")
+		}
+		out := string(h.Prettify(false))
+		if want := c.Sprint(status); !strings.Contains(out, want) {
+			t.Fatalf("want status %s painted %q, got %q", status, want, out)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	// BinaryLinesEscape writes the control characters and invalid UTF-8 of
+	// lines as escapes, like `cat -v`: ^@ for NUL, \xff for a stray byte.
+	BinaryLinesEscape = "escape"
+	// BinaryLinesHex writes a hex preview of binary lines, with their size.
+	BinaryLinesHex = "hex"
+	// BinaryLinesRaw writes binary lines as they are.
+	BinaryLinesRaw = "raw"
+)
+
+// binaryPreviewLen is how many bytes of a binary line BinaryLinesHex shows.
+const binaryPreviewLen = 16
+
+// isBinary reports whether line can't be written as is to a terminal
+// without messing it up, because it isn't UTF-8 or holds control
+// characters other than tabs.
+func isBinary(line []byte) bool {
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRune(line[i:])
+		if r == utf8.RuneError && size == 1 || isControl(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 && r != '\t' || r == 0x7f || r >= 0x80 && r < 0xa0
+}
+
+// binaryLine renders line, which no handler recognized, safely for a
+// terminal as BinaryLines tells.
+func (h *HandlerOptions) binaryLine(line []byte) []byte {
+	if h.BinaryLines == BinaryLinesRaw || !isBinary(line) {
+		return line
+	}
+	if h.BinaryLines == BinaryLinesHex {
+		preview := line
+		if len(preview) > binaryPreviewLen {
+			preview = preview[:binaryPreviewLen]
+		}
+		more := ""
+		if len(preview) < len(line) {
+			more = " ..."
+		}
+		return []byte(h.paint(h.RawColor, fmt.Sprintf("<binary, %d bytes: % x%s>", len(line), preview, more)))
+	}
+	return escapeBinary(line)
+}
+
+// escapeBinary writes the control characters of line in caret notation,
+// and its invalid UTF-8 as hex escapes.
+func escapeBinary(line []byte) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRune(line[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&buf, `\x%02x`, line[i])
+		case r == 0x7f:
+			buf.WriteString("^?")
+		case r < 0x20 && r != '\t':
+			buf.WriteByte('^')
+			buf.WriteByte(byte(r) + '@')
+		case isControl(r):
+			fmt.Fprintf(&buf, `\u%04x`, r)
+		default:
+			buf.Write(line[i : i+size])
+		}
+		i += size
+	}
+	return buf.Bytes()
+}
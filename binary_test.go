@@ -0,0 +1,43 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBinaryLines(t *testing.T) {
+	tests := []struct {
+		mode string
+		line string
+		want string
+	}{
+		{BinaryLinesEscape, "plain\ttext, née", "plain\ttext, née"},
+		{BinaryLinesEscape, "bell\x07 and nul\x00", "bell^G and nul^@"},
+		{BinaryLinesEscape, "del\x7f", "del^?"},
+		{BinaryLinesEscape, "stray \xff byte", `stray \xff byte`},
+		{BinaryLinesEscape, "c1 \u009b control", `c1 \u009b control`},
+		{BinaryLinesHex, "\x89PNG\r\n\x1a\n", "<binary, 8 bytes: 89 50 4e 47 0d 0a 1a 0a>"},
+		{BinaryLinesHex, "\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10", "<binary, 17 bytes: 00 01 02 03 04 05 06 07 08 09 0a 0b 0c 0d 0e 0f ...>"},
+		{BinaryLinesRaw, "bell\x07", "bell\x07"},
+	}
+	for _, test := range tests {
+		opts := *DefaultOptions
+		opts.BinaryLines = test.mode
+		if got := string(opts.binaryLine([]byte(test.line))); got != test.want {
+			t.Errorf("%s %q: want %q, got %q", test.mode, test.line, test.want, got)
+		}
+	}
+}
+
+func TestScannerBinaryLines(t *testing.T) {
+	opts := *DefaultOptions
+	dst := bytes.NewBuffer(nil)
+	src := strings.NewReader("\x1b[31mred\x1b[0m\nblob\x00\x01\n")
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "\x1b[31mred\x1b[0m\nblob^@^A\n", dst.String(); want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,145 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// BunyanHandler can handle the JSON logs of node-bunyan and pino, which have
+// numeric levels.
+type BunyanHandler struct {
+	renderer *Renderer
+
+	Opts *HandlerOptions
+
+	Level   string
+	Time    time.Time
+	Message string
+	Fields  map[string]string
+
+	// Pino tells if the line came from pino rather than bunyan.
+	Pino bool
+
+	// Nested holds the nested objects expanded below the line, as
+	// indented JSON by key, when NestedObjects is NestedObjectsExpand.
+	Nested map[string]string
+}
+
+func (h *BunyanHandler) clear() {
+	h.Level = ""
+	h.Time = time.Time{}
+	h.Message = ""
+	h.Pino = false
+	h.Nested = nil
+	h.Fields = make(map[string]string)
+}
+
+// parse tells if d was handled by this handler, which then holds it until
+// the next call to Prettify.
+func (h *BunyanHandler) parse(d []byte) bool {
+	if !bytes.Contains(d, []byte(`"level":`)) {
+		return false
+	}
+	// pino has no v, but its time is a number
+	if !bytes.Contains(d, []byte(`"v":`)) && !numberFollows(d, []byte(`"time":`)) {
+		return false
+	}
+	err := h.UnmarshalJSON(d)
+	if err != nil {
+		h.clear()
+		return false
+	}
+	return true
+}
+
+// numberFollows reports whether key is found in d followed by a number,
+// to tell cheaply if a JSON line may hold a number under key.
+func numberFollows(d, key []byte) bool {
+	i := bytes.Index(d, key)
+	if i < 0 {
+		return false
+	}
+	rest := bytes.TrimLeft(d[i+len(key):], " \t")
+	return len(rest) > 0 && (rest[0] == '-' || rest[0] >= '0' && rest[0] <= '9')
+}
+
+// UnmarshalJSON sets the fields of the handler.
+func (h *BunyanHandler) UnmarshalJSON(data []byte) error {
+	raw, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	defer releaseObject(raw)
+	if _, ok := raw["v"].(float64); ok {
+		delete(raw, "v")
+	} else if _, ok := raw["time"].(float64); ok {
+		// pino has no v, but always has its time in epoch milliseconds
+		h.Pino = true
+	} else {
+		return fmt.Errorf("not a bunyan or pino record, missing v")
+	}
+
+	lvl, ok := raw["level"].(float64)
+	if !ok {
+		return fmt.Errorf("not a bunyan or pino record, level isn't a number: %v", raw["level"])
+	}
+	h.Level = fmt.Sprintf("%d", int(lvl))
+	delete(raw, "level")
+
+	if timestamp, ok := raw["time"]; ok {
+		delete(raw, "time")
+		h.Time, ok = tryParseTime(timestamp)
+		if !ok {
+			return fmt.Errorf("field time is not a known timestamp: %v", timestamp)
+		}
+	}
+
+	if msg, ok := raw["msg"].(string); ok {
+		h.Message = msg
+		delete(raw, "msg")
+	}
+
+	if h.Fields == nil {
+		h.Fields = make(map[string]string)
+	}
+
+	h.Nested = h.Opts.setFields(h.Fields, raw)
+
+	return nil
+}
+
+// TryHandle parses d into an event, if it is in the format of the handler.
+// The handler holds the line until the next call to Prettify.
+func (h *BunyanHandler) TryHandle(d []byte) (Event, bool) {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if !h.parse(d) {
+		return Event{}, false
+	}
+	return h.event(), true
+}
+
+// Prettify the output in a logrus like fashion.
+func (h *BunyanHandler) Prettify(skipUnchanged bool) []byte {
+	defer h.clear()
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if h.renderer == nil {
+		h.renderer = NewRenderer(h.Opts)
+	}
+	return h.renderer.Render(h.event(), skipUnchanged)
+}
+
+func (h *BunyanHandler) event() Event {
+	return Event{
+		Time:   h.Time,
+		Level:  h.Opts.mapLevel(h.Level, normalizeBunyanLevel),
+		Msg:    h.Message,
+		Fields: h.Fields,
+
+		nested: h.Nested,
+	}
+}
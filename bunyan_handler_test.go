@@ -0,0 +1,78 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBunyanHandler(t *testing.T) {
+	h := BunyanHandler{Opts: DefaultOptions}
+	ev := []byte(`{"name":"myapp","hostname":"box","pid":4242,"level":40,"msg":"slow request","time":"2012-02-03T18:12:48.123Z","v":0,"path":"/users"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Date(2012, 2, 3, 18, 12, 48, 123000000, time.UTC); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	out := string(h.Prettify(false))
+	for _, want := range []string{"|WARN| slow request", `name="myapp"`, `hostname="box"`, "pid=4242", `path="/users"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+	if strings.Contains(out, "v=") {
+		t.Fatalf("want v hidden, got %q", out)
+	}
+
+	for lvl, want := range map[string]string{"10": "TRAC", "20": "DEBU", "30": "INFO", "50": "ERRO", "60": "FATA"} {
+		if _, ok := h.TryHandle([]byte(`{"level":` + lvl + `,"msg":"hi","time":"2012-02-03T18:12:48.123Z","v":0}`)); !ok {
+			t.Fatal("should handle the line")
+		}
+		if out := string(h.Prettify(false)); !strings.Contains(out, "|"+want+"|") {
+			t.Fatalf("want level %s shown as %s, got %q", lvl, want, out)
+		}
+	}
+
+	if _, ok := h.TryHandle([]byte(`{"level":"info","msg":"hi","time":"2012-02-03T18:12:48.123Z","v":0}`)); ok {
+		t.Fatal("want a non numeric level rejected")
+	}
+}
+
+func TestScannerBunyan(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+	out, ok := Prettify([]byte(`{"name":"myapp","level":30,"msg":"hi","time":"2012-02-03T18:12:48.123Z","v":0}`), &opts)
+	if !ok || !strings.HasPrefix(string(out), "[bunyan]") {
+		t.Fatalf("want the bunyan handler used, got %q", out)
+	}
+}
+
+func TestBunyanHandlerPino(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+	out, ok := Prettify([]byte(`{"level":50,"time":1646255200123,"pid":4242,"hostname":"box","msg":"failed","err":"timeout"}`), &opts)
+	if !ok {
+		t.Fatal("should handle the line")
+	}
+	got := string(out)
+	for _, want := range []string{"[pino]", "|ERRO| failed", "pid=4242", `err="timeout"`} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q in %q", want, got)
+		}
+	}
+	if strings.Contains(got, "time=") {
+		t.Fatalf("want the time parsed rather than shown as a field, got %q", got)
+	}
+
+	h := BunyanHandler{Opts: DefaultOptions}
+	if _, ok := h.TryHandle([]byte(`{"level":30,"time":1646255200123,"msg":"hi"}`)); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Unix(1646255200, 123000000); !h.Time.Equal(want) {
+		t.Fatalf("want the time in milliseconds %v, got %v", want, h.Time)
+	}
+	if _, ok := h.TryHandle([]byte(`{"level":30,"time":"2012-02-03T18:12:48.123Z","msg":"hi"}`)); ok {
+		t.Fatal("want a line with neither v nor an epoch time rejected")
+	}
+}
@@ -0,0 +1,69 @@
+package humanlog
+
+import "strings"
+
+// callerKeys are the keys holding where a line was logged from, like zap's
+// `caller`, log/slog's `source`, or logrus' `file` and `func`.
+var callerKeys = []string{"caller", "source", "file"}
+
+// caller finds where ev was logged from, trimmed as CallerTrimPrefixes
+// tell, and the keys of the fields it was found in, which aren't to be
+// written along with the others.
+func (h *HandlerOptions) caller(ev Event) (string, []string) {
+	var loc string
+	var used []string
+	for _, key := range callerKeys {
+		v := unquoteValue(ev.Fields[key])
+		if looksLikeLocation(v) {
+			loc, used = v, append(used, key)
+			break
+		}
+	}
+	if loc == "" {
+		// a file and a line apart, only taken as such together
+		file, line := unquoteValue(ev.Fields["file"]), ev.Fields["line"]
+		if file == "" || !isNumber(line) {
+			return "", nil
+		}
+		loc, used = file+":"+line, append(used, "file", "line")
+	}
+	loc = h.trimCaller(loc)
+	if fn := unquoteValue(ev.Fields["func"]); fn != "" {
+		// the package path is in the location already
+		loc += " " + fn[strings.LastIndexByte(fn, '/')+1:]
+		used = append(used, "func")
+	}
+	return loc, used
+}
+
+// trimCaller strips the first of CallerTrimPrefixes loc starts with, or
+// else keeps only the file and its directory, like zap's short callers.
+func (h *HandlerOptions) trimCaller(loc string) string {
+	for _, prefix := range h.CallerTrimPrefixes {
+		if strings.HasPrefix(loc, prefix) {
+			return loc[len(prefix):]
+		}
+	}
+	if i := strings.LastIndexByte(loc, '/'); i > 0 {
+		if j := strings.LastIndexByte(loc[:i], '/'); j >= 0 {
+			return loc[j+1:]
+		}
+	}
+	return loc
+}
+
+// looksLikeLocation tells if s is written like a source file and a line,
+// like `app/main.go:42`, unlike the names of services often found under
+// `source`, or the files a program works on under `file`.
+func looksLikeLocation(s string) bool {
+	i := strings.LastIndexByte(s, ':')
+	if i <= 0 || i == len(s)-1 {
+		return false
+	}
+	for _, r := range s[i+1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return strings.ContainsAny(s[:i], "./")
+}
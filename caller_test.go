@@ -0,0 +1,45 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCaller(t *testing.T) {
+	tests := []struct {
+		fields   map[string]string
+		prefixes []string
+		want     string
+		used     []string
+	}{
+		{map[string]string{"caller": "app/main.go:42"}, nil, "app/main.go:42", []string{"caller"}},
+		{map[string]string{"caller": `"/go/src/github.com/acme/shop/cart/cart.go:12"`}, nil, "cart/cart.go:12", []string{"caller"}},
+		{map[string]string{"caller": "/go/src/github.com/acme/shop/cart/cart.go:12"}, []string{"/go/src/github.com/acme/shop/"}, "cart/cart.go:12", []string{"caller"}},
+		{map[string]string{"source": "/app/internal/db/conn.go:7"}, []string{"/app/"}, "internal/db/conn.go:7", []string{"source"}},
+		{map[string]string{"file": `"/app/main.go:42"`, "func": `"github.com/acme/shop.main"`}, nil, "app/main.go:42 shop.main", []string{"file", "func"}},
+		{map[string]string{"file": "main.go", "line": "42"}, nil, "main.go:42", []string{"file", "line"}},
+		// not locations
+		{map[string]string{"source": "checkout-api", "file": "report.csv", "func": "main"}, nil, "", nil},
+		{map[string]string{"caller": "10.0.0.1:8080x"}, nil, "", nil},
+	}
+	for _, test := range tests {
+		opts := HandlerOptions{CallerTrimPrefixes: test.prefixes}
+		got, used := opts.caller(Event{Fields: test.fields})
+		if got != test.want || strings.Join(used, ",") != strings.Join(test.used, ",") {
+			t.Errorf("%v: want %q from %v, got %q from %v", test.fields, test.want, test.used, got, used)
+		}
+	}
+}
+
+func TestRendererCompactCaller(t *testing.T) {
+	opts := *DefaultOptions
+	opts.DisableColors = true
+	r := NewRenderer(&opts)
+	out := string(r.Render(Event{Level: InfoLevel, Msg: "hi", Fields: map[string]string{
+		"caller": "/app/cmd/api/main.go:42",
+		"user":   `"bob"`,
+	}}, false))
+	if !strings.HasSuffix(out, ` user="bob" api/main.go:42`) {
+		t.Fatalf("want the caller at the end of the line, got %q", out)
+	}
+}
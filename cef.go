@@ -0,0 +1,165 @@
+package humanlog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cefHeaderKeys name the fields of the header of a CEF event, after its
+// version and before its extension.
+var cefHeaderKeys = [...]string{"vendor", "product", "product_version", "signature", "name", "severity"}
+
+// matchCEF tells if the line held by lh is an event in ArcSight's Common
+// Event Format, like
+//
+//	CEF:0|Vendor|Product|1.0|100|Port scan|5|src=10.0.0.1 act=blocked
+//
+// in which case the logrus handler gets its parts. The name of the event is
+// its message and its severity, from 0 to 10, sets the level.
+func (lh *lineHandler) matchCEF() bool {
+	if !bytes.HasPrefix(lh.lineData, []byte("CEF:")) {
+		return false
+	}
+	parts := splitCEFHeader(string(lh.lineData[len("CEF:"):]))
+	if len(parts) != len(cefHeaderKeys)+2 {
+		return false
+	}
+	h := &lh.logrusEntry
+	h.setField([]byte("cef_version"), []byte(parts[0]))
+	for i, key := range cefHeaderKeys {
+		if key == "name" {
+			continue
+		}
+		h.setField([]byte(key), []byte(parts[i+1]))
+	}
+	h.setMessage([]byte(parts[5]))
+	h.setLevel([]byte(cefLevel(parts[6])))
+
+	for _, kv := range parseCEFExtension(parts[7]) {
+		if kv[0] == "rt" && h.setCEFTime(kv[1]) {
+			continue
+		}
+		h.setField([]byte(kv[0]), []byte(kv[1]))
+	}
+	return true
+}
+
+// splitCEFHeader splits the 7 fields of the header from the extension.
+// Pipes are escaped in the header as `\|`.
+func splitCEFHeader(line string) []string {
+	var (
+		parts []string
+		part  strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case len(parts) == 7:
+			return append(parts, line[i:])
+		case c == '\\' && i+1 < len(line) && (line[i+1] == '|' || line[i+1] == '\\'):
+			part.WriteByte(line[i+1])
+			i++
+		case c == '|':
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	if len(parts) == 7 {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
+
+// parseCEFExtension parses the `key=value` pairs of an extension. Values
+// may have spaces, so they stop right before the next key. Equal signs are
+// escaped in values as `\=`.
+func parseCEFExtension(ext string) [][2]string {
+	// where the keys start, and where their `=` are
+	var starts, eqs []int
+	for i := 0; i < len(ext); i++ {
+		switch ext[i] {
+		case '\\':
+			i++
+		case '=':
+			start := strings.LastIndexByte(ext[:i], ' ') + 1
+			if start == i {
+				continue
+			}
+			starts = append(starts, start)
+			eqs = append(eqs, i)
+		}
+	}
+	kvs := make([][2]string, 0, len(starts))
+	for i, start := range starts {
+		end := len(ext)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		val := strings.TrimRight(ext[eqs[i]+1:end], " ")
+		kvs = append(kvs, [2]string{ext[start:eqs[i]], unescapeCEF(val)})
+	}
+	return kvs
+}
+
+var cefUnescaper = strings.NewReplacer(`\=`, `=`, `\\`, `\`, `\n`, "\n", `\r`, "\r")
+
+func unescapeCEF(val string) string {
+	if !strings.Contains(val, `\`) {
+		return val
+	}
+	return cefUnescaper.Replace(val)
+}
+
+// cefTimeLayouts are the layouts of the rt extension that aren't
+// milliseconds since the epoch.
+var cefTimeLayouts = []string{
+	"Jan 02 2006 15:04:05.000 MST",
+	"Jan 02 2006 15:04:05 MST",
+	"Jan 02 2006 15:04:05.000",
+	"Jan 02 2006 15:04:05",
+}
+
+func (h *LogrusHandler) setCEFTime(val string) bool {
+	if ms, err := strconv.ParseInt(val, 10, 64); err == nil {
+		h.Time = time.Unix(0, ms*int64(time.Millisecond))
+		return true
+	}
+	for _, layout := range cefTimeLayouts {
+		if t, err := time.ParseInLocation(layout, val, time.Local); err == nil {
+			h.Time = t
+			return true
+		}
+	}
+	return false
+}
+
+// cefLevel maps CEF severities, from 0 to 10 or named, onto one of the
+// normalized level names.
+func cefLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "low":
+		return InfoLevel
+	case "medium":
+		return WarnLevel
+	case "high":
+		return ErrorLevel
+	case "very-high":
+		return FatalLevel
+	}
+	n, err := strconv.Atoi(severity)
+	switch {
+	case err != nil:
+		return UnknownLevel
+	case n <= 3:
+		return InfoLevel
+	case n <= 6:
+		return WarnLevel
+	case n <= 8:
+		return ErrorLevel
+	default:
+		return FatalLevel
+	}
+}
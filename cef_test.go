@@ -0,0 +1,77 @@
+package humanlog
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCEFLine(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+	lh := newLineHandler(&opts)
+
+	line := `CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232 rt=1388577600000 msg=Detected a threat. No action needed`
+	if format := lh.match([]byte(line)); format != "cef" {
+		t.Fatalf("want cef format, got %q", format)
+	}
+	if want := time.Date(2014, 1, 1, 12, 0, 0, 0, time.UTC); !lh.ev.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, lh.ev.Time)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{
+		"[cef]", "|FATA| worm successfully stopped",
+		"vendor=Security", "product=threatmanager", "signature=100",
+		"src=10.0.0.1", "spt=1232", "msg=Detected a threat. No action needed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestCEFLevels(t *testing.T) {
+	for severity, want := range map[string]string{
+		"0":         InfoLevel,
+		"3":         InfoLevel,
+		"5":         WarnLevel,
+		"8":         ErrorLevel,
+		"9":         FatalLevel,
+		"High":      ErrorLevel,
+		"Very-High": FatalLevel,
+		"unknown":   UnknownLevel,
+	} {
+		if got := cefLevel(severity); got != want {
+			t.Fatalf("want severity %s to be %s, got %s", severity, want, got)
+		}
+	}
+}
+
+func TestSplitCEFHeader(t *testing.T) {
+	got := splitCEFHeader(`0|Vendor|Pipe\|Product|1.0|sig|name|3|a=b`)
+	want := []string{"0", "Vendor", "Pipe|Product", "1.0", "sig", "name", "3", "a=b"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if got := splitCEFHeader(`0|Vendor|Product`); len(got) == len(want) {
+		t.Fatalf("want a truncated header rejected, got %q", got)
+	}
+}
+
+func TestParseCEFExtension(t *testing.T) {
+	got := parseCEFExtension(`act=blocked a\=b request=http://x/?q\=1 msg=two words  cs1Label=x`)
+	want := [][2]string{
+		{"act", "blocked a=b"},
+		{"request", "http://x/?q=1"},
+		{"msg", "two words"},
+		{"cs1Label", "x"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
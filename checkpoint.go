@@ -0,0 +1,111 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Checkpoint keeps how far the files followed through it were read in a
+// state file, by their identity rather than their path, so that following
+// them again resumes where it left off, like log shippers do.
+type Checkpoint struct {
+	path string
+
+	mu        sync.Mutex
+	files     map[string]checkpointFile
+	followers []*Follower
+}
+
+// checkpointFile is how far a file was read, by the identity of the file.
+type checkpointFile struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+}
+
+// OpenCheckpoint reads the state file at path, if there is one already.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	cp := &Checkpoint{path: path, files: make(map[string]checkpointFile)}
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return cp, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cp.files); err != nil {
+		return nil, fmt.Errorf("%s isn't a checkpoint: %v", path, err)
+	}
+	return cp, nil
+}
+
+// Follow follows the file at path from where it was last read, or from its
+// beginning if it was rotated or truncated since, so that no line is
+// missed. Files never read before are followed from the start of their
+// last `lines` lines, as with Follow.
+func (cp *Checkpoint) Follow(path string, lines int) (*Follower, error) {
+	abs := absPath(path)
+	fl, err := follow(path, func(f *os.File) (int64, error) {
+		info, err := f.Stat()
+		if err != nil {
+			return 0, err
+		}
+		cp.mu.Lock()
+		defer cp.mu.Unlock()
+		if file, ok := cp.files[fileID(path, info)]; ok && file.Offset <= info.Size() {
+			return file.Offset, nil
+		}
+		for _, file := range cp.files {
+			if file.Path == abs {
+				// another file had the path then, or this one was truncated
+				return 0, nil
+			}
+		}
+		return tailOffset(f, lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cp.mu.Lock()
+	cp.followers = append(cp.followers, fl)
+	cp.mu.Unlock()
+	return fl, nil
+}
+
+// Save writes how far the files followed were read to the state file,
+// replacing it at once so that it is never left half written. The files
+// not followed this time are kept as they were.
+func (cp *Checkpoint) Save() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	for _, fl := range cp.followers {
+		id, offset := fl.position()
+		path := absPath(fl.path)
+		for other, file := range cp.files {
+			if file.Path == path && other != id {
+				delete(cp.files, other)
+			}
+		}
+		cp.files[id] = checkpointFile{Path: path, Offset: offset}
+	}
+	data, err := json.MarshalIndent(cp.files, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := cp.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, cp.path)
+}
+
+// absPath is path made absolute, or as is if it can't be.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
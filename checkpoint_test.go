@@ -0,0 +1,84 @@
+package humanlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	state := filepath.Join(dir, "state.json")
+
+	appendTo := func(text string) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// follows the file through a checkpoint, reading what it has, and
+	// then saves how far it got
+	follow := func(want string) {
+		t.Helper()
+		cp, err := OpenCheckpoint(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fl, err := cp.Follow(path, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fl.Close()
+		var got []byte
+		buf := make([]byte, 64)
+		for {
+			n, more, err := fl.read(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, buf[:n]...)
+			if n == 0 && !more {
+				break
+			}
+		}
+		if string(got) != want {
+			t.Fatalf("want %q, got %q", want, got)
+		}
+		if err := cp.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	appendTo("one\ntwo\n")
+	// the last lines of a file never read before
+	follow("two\n")
+	appendTo("three\nfour\nfi")
+	// where it was left off, up to the last line read in full
+	follow("three\nfour\nfi")
+	appendTo("ve\n")
+	follow("five\n")
+
+	// rotated while not followed
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendTo("six\nseven\n")
+	follow("six\nseven\n")
+
+	// truncated while not followed
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendTo("eight\n")
+	follow("eight\n")
+}
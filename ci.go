@@ -0,0 +1,78 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	// `::error file=app.js,line=1::Missing semicolon`, a workflow command
+	// of GitHub Actions as echoed in the logs
+	workflowCommand = regexp.MustCompile(`^::([a-z-]+)(?: ([^:]*))?::(.*)$`)
+	// `##[error]Process completed with exit code 1.`, as the runners of
+	// GitHub Actions and Azure Pipelines write them
+	runnerCommand = regexp.MustCompile(`^##\[([a-z-]+)\](.*)$`)
+)
+
+// ciLevels are the levels of the annotations of CI logs.
+var ciLevels = map[string]string{
+	"error":   ErrorLevel,
+	"warning": WarnLevel,
+	"notice":  InfoLevel,
+	"debug":   DebugLevel,
+}
+
+// ciDataEscapes and ciPropertyEscapes undo the escaping of the messages
+// and of the properties of workflow commands.
+var (
+	ciDataEscapes     = strings.NewReplacer("%25", "%", "%0D", "\r", "%0A", "\n")
+	ciPropertyEscapes = strings.NewReplacer("%25", "%", "%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",")
+)
+
+// matchCI tells if the line held by lh is one of the commands CI runners
+// write in their logs, like `::group::Run tests` or `##[error]boom`, in
+// which case the logrus handler gets its parts. Groups are written as a
+// header, and their end as a footer repeating their title, since they
+// can't be collapsed. The properties of annotations, like the file and
+// line they are about, are given as fields.
+func (lh *lineHandler) matchCI() bool {
+	line := lh.lineData
+	if !bytes.HasPrefix(line, []byte("::")) && !bytes.HasPrefix(line, []byte("##[")) {
+		return false
+	}
+	var name, props, msg string
+	if m := workflowCommand.FindSubmatch(line); m != nil {
+		name, props, msg = string(m[1]), string(m[2]), ciDataEscapes.Replace(string(m[3]))
+	} else if m := runnerCommand.FindSubmatch(line); m != nil {
+		name, msg = string(m[1]), string(m[2])
+	} else {
+		return false
+	}
+
+	h := &lh.logrusEntry
+	h.Level = InfoLevel
+	switch name {
+	case "group", "section":
+		lh.ciGroup = msg
+		h.Message = "▼ " + msg
+	case "endgroup":
+		h.Message = "▲ " + lh.ciGroup
+		lh.ciGroup = ""
+	case "command":
+		h.Message = "$ " + msg
+	case "error", "warning", "notice", "debug":
+		h.Level = ciLevels[name]
+		h.Message = msg
+		for _, prop := range strings.Split(props, ",") {
+			kv := strings.SplitN(strings.TrimSpace(prop), "=", 2)
+			if len(kv) == 2 && kv[0] != "" {
+				h.setField([]byte(kv[0]), []byte(ciPropertyEscapes.Replace(kv[1])))
+			}
+		}
+	default:
+		h.Level = ""
+		return false
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCILog(t *testing.T) {
+	src := strings.Join([]string{
+		"\ufeff2024-01-02T03:04:05.1234567Z ##[group]Run npm test",
+		"2024-01-02T03:04:05.2000000Z ##[command]npm test",
+		`2024-01-02T03:04:06.0000000Z {"time":"2024-01-02T03:04:06Z","level":"info","msg":"inner json"}`,
+		"2024-01-02T03:04:07.0000000Z Tests: 1 failed, 41 passed",
+		"2024-01-02T03:04:07.5000000Z ::error file=src/app.js,line=12,col=5,title=Lint%3A semi::Missing semicolon%0Aat the end",
+		"2024-01-02T03:04:08.0000000Z ##[endgroup]",
+		"2024-01-02T03:04:09.0000000Z ##[error]Process completed with exit code 1.",
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	stamp := func(sec int) string {
+		return time.Date(2024, 1, 2, 3, 4, sec, 0, time.UTC).Local().Format(opts.TimeFormat)
+	}
+	want := [][]string{
+		{"[ci]", stamp(5), "|INFO| ▼ Run npm test"},
+		{"[ci]", "|INFO| $ npm test"},
+		{"[json]", "|INFO| inner json"},
+		{"[actions]", stamp(7), "Tests: 1 failed, 41 passed"},
+		{"[ci]", "|ERRO| Missing semicolon"},
+		{"at the end", "src/app.js:12", "col=5", "title=Lint: semi"},
+		{"[ci]", "|INFO| ▲ Run npm test"},
+		{"[ci]", "|ERRO| Process completed with exit code 1."},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("want %d lines, got %q", len(want), lines)
+	}
+	for i, ws := range want {
+		for _, w := range ws {
+			if !strings.Contains(lines[i], w) {
+				t.Fatalf("want line %d to contain %q, got %q", i, w, lines[i])
+			}
+		}
+	}
+}
+
+func TestCIUnknownCommand(t *testing.T) {
+	lh := newLineHandler(DefaultOptions)
+	if format := lh.match([]byte("::add-mask::secret")); format == "ci" {
+		t.Fatal("want unknown commands left alone")
+	}
+}
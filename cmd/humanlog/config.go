@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// configEntry is an option of the config file, named like the flag it
+// gives a default to.
+type configEntry struct {
+	line   int
+	name   string
+	values []string
+}
+
+// defaultConfigPath is where the config file is looked for when --config
+// isn't given, following the XDG base directories.
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "humanlog", "config.yaml")
+}
+
+// readConfig reads the options of a config file, which is the subset of
+// YAML needed to give flags values, like:
+//
+//	truncate-length: 30
+//	time-format: "Jan _2 15:04:05"
+//	skip: [password, token]
+//	keep:
+//	  - status
+//	  - path
+func readConfig(r io.Reader) ([]configEntry, error) {
+	var (
+		entries []configEntry
+		n       int
+		// the option whose values are listed on the lines after it
+		list *configEntry
+	)
+	in := bufio.NewScanner(r)
+	for in.Scan() {
+		n++
+		line := strings.TrimRight(in.Text(), " \t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if list == nil {
+				return nil, fmt.Errorf("line %d: a list item must follow an option", n)
+			}
+			value, err := configValue(strings.TrimPrefix(trimmed, "-"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			list.values = append(list.values, value)
+			continue
+		}
+		if line != trimmed {
+			return nil, fmt.Errorf("line %d: options can't be nested", n)
+		}
+		i := strings.IndexByte(line, ':')
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d: want name: value, got %q", n, line)
+		}
+		entry := configEntry{line: n, name: line[:i]}
+		value := strings.TrimSpace(line[i+1:])
+		switch {
+		case value == "" || strings.HasPrefix(value, "#"):
+			entries = append(entries, entry)
+			list = &entries[len(entries)-1]
+			continue
+		case strings.HasPrefix(value, "["):
+			end := strings.LastIndexByte(value, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated list", n)
+			}
+			for _, item := range strings.Split(value[1:end], ",") {
+				if strings.TrimSpace(item) == "" {
+					continue
+				}
+				v, err := configValue(item)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", n, err)
+				}
+				entry.values = append(entry.values, v)
+			}
+		default:
+			v, err := configValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			entry.values = []string{v}
+		}
+		entries = append(entries, entry)
+		list = nil
+	}
+	return entries, in.Err()
+}
+
+// configValue reads a scalar value, either quoted or plain, in which case
+// a comment may follow it.
+func configValue(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := strings.LastIndexByte(s, '"')
+		if end == 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strconv.Unquote(s[:end+1])
+	case strings.HasPrefix(s, "'"):
+		end := strings.LastIndexByte(s, '\'')
+		if end == 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.Replace(s[1:end], "''", "'", -1), nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// applyConfig gives the flags of c that weren't set on the command line
+// the values of the config file at path. A missing file is only an error
+// if it was asked for.
+func applyConfig(c *cli.Context, path string, required bool) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entries, err := readConfig(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, entry := range entries {
+		flag := findFlag(c.App.Flags, entry.name)
+		if flag == nil {
+			return fmt.Errorf("%s: line %d: unknown option %q", path, entry.line, entry.name)
+		}
+		if flagIsSet(c, flag) {
+			// the command line has the last word
+			continue
+		}
+		if len(entry.values) > 1 && !isSliceFlag(flag) {
+			return fmt.Errorf("%s: line %d: %q takes a single value", path, entry.line, entry.name)
+		}
+		for _, value := range entry.values {
+			if err := c.Set(entry.name, value); err != nil {
+				return fmt.Errorf("%s: line %d: invalid %q: %v", path, entry.line, entry.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// flagNames are the name of a flag and its aliases.
+func flagNames(flag cli.Flag) []string {
+	var names []string
+	for _, name := range strings.Split(flag.GetName(), ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+func findFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, flag := range flags {
+		for _, n := range flagNames(flag) {
+			if n == name {
+				return flag
+			}
+		}
+	}
+	return nil
+}
+
+func flagIsSet(c *cli.Context, flag cli.Flag) bool {
+	for _, name := range flagNames(flag) {
+		if c.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSliceFlag(flag cli.Flag) bool {
+	switch flag.(type) {
+	case cli.StringSliceFlag, cli.IntSliceFlag, cli.Int64SliceFlag:
+		return true
+	}
+	return false
+}
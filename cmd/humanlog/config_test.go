@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	src := `# my defaults
+---
+truncate-length: 30 # long enough
+time-format: "Jan _2 15:04:05 # not a comment"
+theme: 'solarized'
+skip: [password, "token", ]
+keep:
+  - status
+  - path
+
+light-bg: true
+`
+	entries, err := readConfig(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []configEntry{
+		{line: 3, name: "truncate-length", values: []string{"30"}},
+		{line: 4, name: "time-format", values: []string{"Jan _2 15:04:05 # not a comment"}},
+		{line: 5, name: "theme", values: []string{"solarized"}},
+		{line: 6, name: "skip", values: []string{"password", "token"}},
+		{line: 7, name: "keep", values: []string{"status", "path"}},
+		{line: 11, name: "light-bg", values: []string{"true"}},
+	}
+	if !reflect.DeepEqual(want, entries) {
+		t.Fatalf("want %+v, got %+v", want, entries)
+	}
+
+	for _, bad := range []string{"- orphan", "nested:\n  deeper: 1", "no colon", `unterminated: "oops`, "list: [a, b"} {
+		if _, err := readConfig(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: want an error", bad)
+		}
+	}
+}
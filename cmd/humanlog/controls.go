@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/jigish/humanlog"
+)
+
+// startControlKeys changes options through controls as keys are pressed
+// on the terminal while lines stream by, telling on stderr what they were
+// changed to, until restore is called.
+func startControlKeys(controls *humanlog.Controls) (restore func(), err error) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil, err
+	}
+	restoreTerminal, err := keysTerminal(tty)
+	if err != nil {
+		tty.Close()
+		return nil, err
+	}
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := tty.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, key := range decodeKeys(buf[:n]) {
+				if changed := controlKey(controls, key); changed != "" {
+					log.Print(changed)
+				}
+			}
+		}
+	}()
+	return func() {
+		restoreTerminal()
+		tty.Close()
+	}, nil
+}
+
+// controlKey acts on key, if it's one of s, c or l, which toggle
+// skip-unchanged, toggle colors and cycle through the minimum levels, and
+// tells what changed.
+func controlKey(controls *humanlog.Controls, key string) string {
+	switch key {
+	case "s":
+		return "skip-unchanged " + onOff(controls.ToggleSkipUnchanged())
+	case "c":
+		return "colors " + onOff(controls.ToggleColors())
+	case "l":
+		if level := controls.CycleMinLevel(); level != "" {
+			return "lines from level " + level
+		}
+		return "lines of all levels"
+	default:
+		return ""
+	}
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
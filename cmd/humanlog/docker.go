@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/jigish/humanlog"
+)
+
+// dockerClient talks to the Docker API, on the daemon DOCKER_HOST points
+// to or else on the local one.
+type dockerClient struct {
+	http *http.Client
+	base string
+}
+
+func newDockerClient() (*dockerClient, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = "unix:///var/run/docker.sock"
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCKER_HOST: %v", err)
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		return &dockerClient{
+			http: &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socket)
+				},
+			}},
+			base: "http://docker",
+		}, nil
+	case "tcp", "http":
+		return &dockerClient{http: &http.Client{}, base: "http://" + u.Host}, nil
+	}
+	return nil, fmt.Errorf("unsupported DOCKER_HOST %q", host)
+}
+
+func (dc *dockerClient) get(path string) (*http.Response, error) {
+	resp, err := dc.http.Get(dc.base + path)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	return resp, nil
+}
+
+// dockerContainer is a container whose logs are streamed.
+type dockerContainer struct {
+	name string
+	tty  bool
+}
+
+// inspect looks up the container of the given name or ID.
+func (dc *dockerClient) inspect(container string) (dockerContainer, error) {
+	resp, err := dc.get("/containers/" + url.PathEscape(container) + "/json")
+	if err != nil {
+		return dockerContainer{}, fmt.Errorf("can't find container %s: %v", container, err)
+	}
+	defer resp.Body.Close()
+	var info struct {
+		Name   string `json:"Name"`
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return dockerContainer{}, fmt.Errorf("can't find container %s: %v", container, err)
+	}
+	return dockerContainer{name: strings.TrimPrefix(info.Name, "/"), tty: info.Config.Tty}, nil
+}
+
+// logs streams the last lines of the logs of the container, and then the
+// ones that follow.
+func (dc *dockerClient) logs(c dockerContainer) (io.ReadCloser, error) {
+	resp, err := dc.get("/containers/" + url.PathEscape(c.name) + "/logs?follow=1&stdout=1&stderr=1&timestamps=1&tail=10")
+	if err != nil {
+		return nil, fmt.Errorf("can't read the logs of %s: %v", c.name, err)
+	}
+	return resp.Body, nil
+}
+
+// streamDockerLogs prettifies the logs of the containers as they come,
+// each line labelled with its container when there are many of them,
+// until they all end.
+func streamDockerLogs(out io.Writer, opts *humanlog.HandlerOptions, containers []string) error {
+	dc, err := newDockerClient()
+	if err != nil {
+		return err
+	}
+	srcs := make([]io.Reader, 0, len(containers))
+	names := make([]string, 0, len(containers))
+	for _, container := range containers {
+		c, err := dc.inspect(container)
+		if err != nil {
+			return err
+		}
+		body, err := dc.logs(c)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		srcs = append(srcs, humanlog.NewDockerLogReader(body, c.tty))
+		names = append(names, c.name)
+	}
+	if len(names) > 1 {
+		opts.SourceNames = names
+	}
+	log.Printf("streaming the logs of %s...", strings.Join(names, ", "))
+	return humanlog.ScannerInterleave(srcs, out, opts)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jigish/humanlog"
+)
+
+// kubeContainer is a container of a pod whose logs are streamed.
+type kubeContainer struct {
+	pod       string
+	container string
+}
+
+func (kc kubeContainer) String() string { return kc.pod + "/" + kc.container }
+
+// kubePodList is the part of the pods listed by `kubectl get pods -o json`
+// that is needed to stream their logs.
+type kubePodList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Containers []struct {
+				Name string `json:"name"`
+			} `json:"containers"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// kubeContainers lists the containers of the pods of namespace matching
+// selector, either all of them, only the one named container, or else the
+// first one of each pod as kubectl logs does.
+func kubeContainers(namespace, selector, container string, all bool) ([]kubeContainer, error) {
+	args := []string{"get", "pods", "--output=json"}
+	if namespace != "" {
+		args = append(args, "--namespace="+namespace)
+	}
+	if selector != "" {
+		args = append(args, "--selector="+selector)
+	}
+	cmd := exec.Command("kubectl", args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("can't list the pods: %v", err)
+	}
+	var pods kubePodList
+	if err := json.Unmarshal(out, &pods); err != nil {
+		return nil, fmt.Errorf("can't list the pods: %v", err)
+	}
+	return pods.containers(container, all), nil
+}
+
+// containers picks the containers of the pods as kubeContainers says.
+func (pods *kubePodList) containers(container string, all bool) []kubeContainer {
+	var kcs []kubeContainer
+	for _, pod := range pods.Items {
+		for i, c := range pod.Spec.Containers {
+			if container != "" && c.Name != container {
+				continue
+			}
+			if container == "" && !all && i > 0 {
+				break
+			}
+			kcs = append(kcs, kubeContainer{pod: pod.Metadata.Name, container: c.Name})
+		}
+	}
+	return kcs
+}
+
+// streamKubeLogs prettifies the logs of the containers as they come,
+// each line labelled with its pod and container, until they all end.
+func streamKubeLogs(out io.Writer, opts *humanlog.HandlerOptions, namespace string, kcs []kubeContainer) error {
+	srcs := make([]io.Reader, 0, len(kcs))
+	names := make([]string, 0, len(kcs))
+	for _, kc := range kcs {
+		args := []string{"logs", "--follow", kc.pod, "--container=" + kc.container}
+		if namespace != "" {
+			args = append(args, "--namespace="+namespace)
+		}
+		cmd := exec.Command("kubectl", args...)
+		cmd.Stderr = os.Stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		defer func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}()
+		srcs = append(srcs, stdout)
+		names = append(names, kc.String())
+	}
+	opts.SourceNames = names
+	log.Printf("streaming the logs of %s...", strings.Join(names, ", "))
+	return humanlog.ScannerInterleave(srcs, out, opts)
+}
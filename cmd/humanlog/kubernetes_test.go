@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const kubePodsJSON = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "metadata": {"name": "api-7d9f", "namespace": "default"},
+      "spec": {"containers": [{"name": "api", "image": "api:1"}, {"name": "envoy", "image": "envoy:1"}]},
+      "status": {"phase": "Running"}
+    },
+    {
+      "metadata": {"name": "worker-5c2b", "namespace": "default"},
+      "spec": {"containers": [{"name": "worker", "image": "worker:1"}]},
+      "status": {"phase": "Running"}
+    }
+  ]
+}`
+
+func TestKubePodListContainers(t *testing.T) {
+	var pods kubePodList
+	if err := json.Unmarshal([]byte(kubePodsJSON), &pods); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name      string
+		container string
+		all       bool
+		want      []kubeContainer
+	}{
+		{
+			name: "first container by default",
+			want: []kubeContainer{{"api-7d9f", "api"}, {"worker-5c2b", "worker"}},
+		},
+		{
+			name: "all containers",
+			all:  true,
+			want: []kubeContainer{{"api-7d9f", "api"}, {"api-7d9f", "envoy"}, {"worker-5c2b", "worker"}},
+		},
+		{
+			name:      "named container",
+			container: "envoy",
+			want:      []kubeContainer{{"api-7d9f", "envoy"}},
+		},
+		{
+			name:      "named container with all containers",
+			container: "worker",
+			all:       true,
+			want:      []kubeContainer{{"worker-5c2b", "worker"}},
+		},
+		{
+			name:      "missing container",
+			container: "sidecar",
+		},
+	} {
+		if got := pods.containers(tc.container, tc.all); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
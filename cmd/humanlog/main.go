@@ -1,12 +1,24 @@
 package main
 
 import (
+	"errors"
+	"io"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aybabtme/rgbterm"
+	"github.com/fatih/color"
 	"github.com/jigish/humanlog"
 	"github.com/mattn/go-colorable"
 	"github.com/urfave/cli"
@@ -23,7 +35,10 @@ func fatalf(c *cli.Context, format string, args ...interface{}) {
 func main() {
 	app := newApp()
 
-	prefix := rgbterm.FgString(app.Name+"> ", 99, 99, 99)
+	prefix := app.Name + "> "
+	if isTerminal(os.Stderr) && os.Getenv("NO_COLOR") == "" {
+		prefix = rgbterm.FgString(prefix, 99, 99, 99)
+	}
 
 	log.SetOutput(colorable.NewColorableStderr())
 	log.SetFlags(0)
@@ -39,18 +54,55 @@ func newApp() *cli.App {
 	skip := cli.StringSlice{}
 	keep := cli.StringSlice{}
 
+	levelLabels := cli.StringSlice{}
+	levelMapping := cli.StringSlice{}
+	humanizeKeys := cli.StringSlice{}
+	renameKeys := cli.StringSlice{}
+	highlights := cli.StringSlice{}
+	palette := cli.StringSlice{}
+	prefixKeys := cli.StringSlice{}
+	where := cli.StringSlice{}
+	selectKeys := cli.StringSlice{}
+	firstKeys := cli.StringSlice{}
+	grep := cli.StringSlice{}
+	grepInvert := cli.StringSlice{}
+	timeFields := cli.StringSlice{}
+	timeLayouts := cli.StringSlice{}
+	msgFields := cli.StringSlice{}
+	errorKeys := cli.StringSlice{}
+	disabledHandlers := cli.StringSlice{}
+	callerTrimPrefixes := cli.StringSlice{}
+	levelFields := cli.StringSlice{}
+	watchKeys := cli.StringSlice{}
+	truncateKeys := cli.StringSlice{}
+	noTruncateKeys := cli.StringSlice{}
+	expandKeys := cli.StringSlice{}
+	journalUnits := cli.StringSlice{}
+
 	skipFlag := cli.StringSliceFlag{
 		Name:  "skip",
-		Usage: "keys to skip when parsing a log entry",
+		Usage: "keys to skip when parsing a log entry, or patterns of keys like the glob http.* or the regexp /^kube/",
 		Value: &skip,
 	}
 
 	keepFlag := cli.StringSliceFlag{
 		Name:  "keep",
-		Usage: "keys to keep when parsing a log entry",
+		Usage: "keys to keep when parsing a log entry, or patterns of keys like the glob http.* or the regexp /^kube/",
 		Value: &keep,
 	}
 
+	selectFlag := cli.StringSliceFlag{
+		Name:  "select",
+		Usage: "only show these keys, separated by commas, with paths like http.status reaching into nested JSON objects",
+		Value: &selectKeys,
+	}
+
+	firstKeysFlag := cli.StringSliceFlag{
+		Name:  "first",
+		Usage: "show these keys first and in this order, separated by commas, before the other keys",
+		Value: &firstKeys,
+	}
+
 	sortLongest := cli.BoolTFlag{
 		Name:  "sort-longest",
 		Usage: "sort by longest key after having sorted lexicographically",
@@ -72,6 +124,24 @@ func newApp() *cli.App {
 		Value: humanlog.DefaultOptions.TruncateLength,
 	}
 
+	truncateKeysFlag := cli.StringSliceFlag{
+		Name:  "truncate-key",
+		Usage: "truncate the values of a key past a length of their own, as key=length, whatever --truncate is",
+		Value: &truncateKeys,
+	}
+
+	noTruncateKeysFlag := cli.StringSliceFlag{
+		Name:  "no-truncate",
+		Usage: "never truncate the values of this key",
+		Value: &noTruncateKeys,
+	}
+
+	expandKeysFlag := cli.StringSliceFlag{
+		Name:  "expand",
+		Usage: "write the value of this key in full, on lines of its own below the line",
+		Value: &expandKeys,
+	}
+
 	lightBg := cli.BoolFlag{
 		Name:  "light-bg",
 		Usage: "use black as the base foreground color (for terminals with light backgrounds)",
@@ -83,6 +153,572 @@ func newApp() *cli.App {
 		Value: humanlog.DefaultOptions.TimeFormat,
 	}
 
+	timeMode := cli.StringFlag{
+		Name:  "time-mode",
+		Usage: "how to render times, one of absolute, relative (to the first line) or delta (to the line before)",
+		Value: humanlog.DefaultOptions.TimeMode,
+	}
+
+	utc := cli.BoolFlag{
+		Name:  "utc",
+		Usage: "convert times to UTC before formatting them",
+	}
+
+	local := cli.BoolFlag{
+		Name:  "local",
+		Usage: "convert times to the local time zone before formatting them",
+	}
+
+	tz := cli.StringFlag{
+		Name:  "tz",
+		Usage: "convert times to this IANA time zone before formatting them, like Europe/Paris",
+	}
+
+	timeFieldsFlag := cli.StringSliceFlag{
+		Name:  "time-field",
+		Usage: "keys holding the time of a line, in addition to time and ts",
+		Value: &timeFields,
+	}
+
+	timeLayoutsFlag := cli.StringSliceFlag{
+		Name:  "time-layout",
+		Usage: "input time layouts to try before the usual ones, see https://golang.org/pkg/time/ for details",
+		Value: &timeLayouts,
+	}
+
+	msgFieldsFlag := cli.StringSliceFlag{
+		Name:  "msg-fields",
+		Usage: "keys holding the message of a line, in addition to msg and message",
+		Value: &msgFields,
+	}
+
+	errorKeysFlag := cli.StringSliceFlag{
+		Name:  "error-keys",
+		Usage: "keys holding errors, written right after the message in the color of errors with their stack traces below the line, instead of err, error, error.message and exception",
+		Value: &errorKeys,
+	}
+
+	compactCaller := cli.BoolTFlag{
+		Name:  "compact-caller",
+		Usage: "write where lines were logged from, like caller or source, dimmed at the end of lines and trimmed to the file and its directory",
+	}
+
+	callerTrimPrefixesFlag := cli.StringSliceFlag{
+		Name:  "caller-trim-prefix",
+		Usage: "with --compact-caller, strip this prefix from where lines were logged from, like a module path, rather than keeping only the file and its directory",
+		Value: &callerTrimPrefixes,
+	}
+
+	levelFieldsFlag := cli.StringSliceFlag{
+		Name:  "level-fields",
+		Usage: "keys holding the level of a line, in addition to level and lvl",
+		Value: &levelFields,
+	}
+
+	autoSkipUnderscore := cli.BoolTFlag{
+		Name:  "skip-underscored",
+		Usage: "skip keys of the journal starting with an underscore unless they are explicitly kept",
+	}
+
+	stripANSI := cli.BoolFlag{
+		Name:  "strip-ansi",
+		Usage: "remove ANSI escape sequences found in the input messages and values, and from the lines no format was recognized in",
+	}
+
+	unquote := cli.BoolFlag{
+		Name:  "unquote",
+		Usage: "only quote string values that need it, like ones with spaces",
+	}
+
+	parseEmbeddedJSON := cli.BoolFlag{
+		Name:  "parse-embedded-json",
+		Usage: "explode string values that are JSON objects into fields, or write them below the line with --nested expand",
+	}
+
+	prefixKeysFlag := cli.StringSliceFlag{
+		Name:  "prefix",
+		Usage: "keys whose values are shown as a [value/value] prefix of the message",
+		Value: &prefixKeys,
+	}
+
+	maxLineLength := cli.StringFlag{
+		Name:  "max-line-length",
+		Usage: "truncate the lines read that are longer than this, like 4MB, rather than giving up on them (default 1MB)",
+	}
+
+	gapThreshold := cli.DurationFlag{
+		Name:  "gap",
+		Usage: "write a rule telling how long it has been between two lines when it has been longer than this, like 5s",
+	}
+
+	collapseRepeats := cli.BoolFlag{
+		Name:  "collapse",
+		Usage: "write the lines repeating the one before them, but for their time, as a count of repeats",
+	}
+
+	alignColumns := cli.BoolFlag{
+		Name:  "align",
+		Usage: "line up the messages and fields of consecutive lines like the columns of a table",
+	}
+
+	overflow := cli.StringFlag{
+		Name:  "overflow",
+		Usage: "what to do with the lines wider than the terminal, one of none, truncate or wrap the fields that don't fit onto indented lines",
+		Value: humanlog.DefaultOptions.Overflow,
+	}
+
+	width := cli.IntFlag{
+		Name:  "width",
+		Usage: "with --overflow, how wide lines can be rather than as wide as the terminal",
+	}
+
+	messageWidth := cli.IntFlag{
+		Name:  "message-width",
+		Usage: "pad or truncate messages to this many characters, so that fields line up",
+	}
+
+	foldMultiline := cli.BoolFlag{
+		Name:  "fold",
+		Usage: "attach stack traces and other continuation lines to the line they follow",
+	}
+
+	nestedObjects := cli.StringFlag{
+		Name:  "nested",
+		Usage: "how to render nested JSON objects, one of inline, flatten or expand",
+		Value: humanlog.DefaultOptions.NestedObjects,
+	}
+
+	binaryLines := cli.StringFlag{
+		Name:  "binary",
+		Usage: "how to write the lines holding control characters or invalid UTF-8, one of escape, hex or raw",
+		Value: humanlog.DefaultOptions.BinaryLines,
+	}
+
+	maxArrayElements := cli.IntFlag{
+		Name:  "max-array-elements",
+		Usage: "show this many elements of arrays and count the rest (0 for no limit)",
+	}
+
+	appendRaw := cli.BoolFlag{
+		Name:  "append-raw",
+		Usage: "print the original line after each prettified line",
+	}
+
+	humanizeKeysFlag := cli.StringSliceFlag{
+		Name:  "humanize",
+		Usage: "render the values of a key in human units, as key=unit where unit is one of ns, us, ms, s, bytes or bytes_si",
+		Value: &humanizeKeys,
+	}
+
+	humanizeDurations := cli.BoolTFlag{
+		Name:  "humanize-durations",
+		Usage: "write the values of keys named like latency or elapsed_ms, and Go durations, in human units like 1.24s",
+	}
+
+	humanizeSizes := cli.BoolFlag{
+		Name:  "humanize-sizes",
+		Usage: "write the values of keys named like bytes, size or content_length in human units like 1.4MiB",
+	}
+
+	slowDuration := cli.DurationFlag{
+		Name:  "slow",
+		Usage: "paint durations at least this long, like 1s, in the color of errors",
+	}
+
+	shortenTraceIDs := cli.BoolTFlag{
+		Name:  "shorten-trace-ids",
+		Usage: "write trace and span IDs, like trace_id or traceparent, shortened, and each trace ID in a color of its own",
+	}
+
+	traceURL := cli.StringFlag{
+		Name:  "trace-url",
+		Usage: "link trace IDs to this URL on terminals, {trace_id} and {span_id} being replaced with the IDs, like http://localhost:16686/trace/{trace_id}",
+	}
+
+	gutterKey := cli.StringFlag{
+		Name:  "gutter",
+		Usage: "mark lines in front with a bar in a color of their value of this key, like request_id, to tell concurrent requests apart",
+	}
+
+	highlightsFlag := cli.StringSliceFlag{
+		Name:  "highlight",
+		Usage: "highlight the matches of this regexp in messages and values, as pattern[:color] where color is like red, hiblue or bold",
+		Value: &highlights,
+	}
+
+	renameKeysFlag := cli.StringSliceFlag{
+		Name:  "rename",
+		Usage: "show a key under another name, as key=name (i.e. kubernetes.pod_name=pod)",
+		Value: &renameKeys,
+	}
+
+	showHandler := cli.BoolFlag{
+		Name:  "show-handler",
+		Usage: "prefix each line with the name of the format that recognized it",
+	}
+
+	levelLabelsFlag := cli.StringSliceFlag{
+		Name:  "level-label",
+		Usage: "text of a level label, as level=label (i.e. warn=WARNING)",
+		Value: &levelLabels,
+	}
+
+	levelMappingFlag := cli.StringSliceFlag{
+		Name:  "level-map",
+		Usage: "level a logged level stands for, as logged=level (i.e. notice=info or 30=warn)",
+		Value: &levelMapping,
+	}
+
+	levelStyle := cli.StringFlag{
+		Name:  "level-style",
+		Usage: "decoration around level labels, one of bars, bracket or plain",
+		Value: humanlog.DefaultOptions.LevelStyle,
+	}
+
+	theme := cli.StringFlag{
+		Name:  "theme",
+		Usage: "color theme to use, one of " + strings.Join(humanlog.Themes(), ", "),
+		Value: humanlog.DefaultTheme,
+	}
+
+	colorDepth := cli.StringFlag{
+		Name:  "color-depth",
+		Usage: "how many colors the terminal has, for --palette and --format to paint with, one of 16, 256, truecolor or auto to tell from TERM and COLORTERM",
+		Value: "auto",
+	}
+
+	paletteFlag := cli.StringSliceFlag{
+		Name:  "palette",
+		Usage: "paint an element of lines in other colors than those of the theme, like key=hiblue or error=bold+bgred, elements being one of " + strings.Join(humanlog.DefaultOptions.ThemeElements(), ", "),
+		Value: &palette,
+	}
+
+	parallel := cli.IntFlag{
+		Name:  "parallel",
+		Usage: "prettify the lines of stdin or of a single file on this many goroutines, ignores --skip-unchanged (0 to prettify serially, -1 for one per CPU)",
+	}
+
+	flushInterval := cli.DurationFlag{
+		Name:  "flush-interval",
+		Usage: "write out partial lines after the input has been idle this long, and the output held after this long when it isn't a terminal (0 for the input to never, and the output after 100ms)",
+	}
+
+	flushEvery := cli.IntFlag{
+		Name:  "flush-every",
+		Usage: "write out the output every this many lines, rather than line by line to a terminal and after --flush-interval otherwise",
+	}
+
+	autoDetectTime := cli.BoolFlag{
+		Name:  "detect-time",
+		Usage: "look for timestamps in other JSON fields when there's no time or ts field",
+	}
+
+	jsonDetection := cli.StringFlag{
+		Name:  "json-detection",
+		Usage: "how strictly lines are told to be JSON logs, one of time, for JSON objects with a time, strict, for those also having a level or a message, or loose, for any JSON object",
+		Value: humanlog.JSONDetectionTime,
+	}
+
+	disabledHandlersFlag := cli.StringSliceFlag{
+		Name:  "disable-handler",
+		Usage: "don't try lines against these handlers, separated by commas, like json or journal, for when one takes lines for what they aren't",
+		Value: &disabledHandlers,
+	}
+
+	noJSON := cli.BoolFlag{
+		Name:  "no-json",
+		Usage: "don't try lines against the json handler, like --disable-handler json",
+	}
+
+	noJournal := cli.BoolFlag{
+		Name:  "no-journal",
+		Usage: "don't try lines against the journal handler, like --disable-handler journal",
+	}
+
+	detectLines := cli.IntFlag{
+		Name:  "detect-lines",
+		Usage: "sample this many lines at a time to detect the format of the stream, for the JSON objects without a time in a stream of JSON logs to be taken for logs too (0 to never detect it)",
+		Value: humanlog.DefaultOptions.DetectLines,
+	}
+
+	jsonOutput := cli.BoolFlag{
+		Name:  "json",
+		Usage: "write each line as a JSON object of its time, level, msg and fields instead of prettifying it",
+	}
+
+	logfmtOutput := cli.BoolFlag{
+		Name:  "logfmt",
+		Usage: "write each line as logfmt instead of prettifying it",
+	}
+
+	rawOutput := cli.BoolFlag{
+		Name:  "raw",
+		Usage: "write the lines that pass the filters as they were read, without prettifying them",
+	}
+
+	format := cli.StringFlag{
+		Name:  "format",
+		Usage: "render lines with this Go template of .Time, .Level, .Msg and .Fields, with the functions color, levelcolor, pad, lpad, trunc, timefmt and logfmt",
+	}
+
+	htmlOutput := cli.BoolFlag{
+		Name:  "html",
+		Usage: "write a standalone HTML page of the prettified lines, to share them with their colors",
+	}
+
+	rawCopy := cli.StringFlag{
+		Name:  "raw-copy",
+		Usage: "also append every line read, untouched, to this file",
+	}
+
+	outputFile := cli.StringFlag{
+		Name:  "output",
+		Usage: "write to this file rather than to stdout, without colors",
+	}
+
+	maxSizeFlag := cli.StringFlag{
+		Name:  "max-size",
+		Usage: "with --output, rotate the file once it would grow past this size, like 100MB",
+	}
+
+	maxFiles := cli.IntFlag{
+		Name:  "max-files",
+		Usage: "with --output and --max-size, how many rotated files to keep, named like the file with .1, .2, etc appended",
+		Value: 5,
+	}
+
+	colorMode := cli.StringFlag{
+		Name:  "color",
+		Usage: "when to use colors, one of auto, to use them on a terminal unless NO_COLOR is set, always or never",
+		Value: "auto",
+	}
+
+	noColor := cli.BoolFlag{
+		Name:  "no-color",
+		Usage: "don't use colors, like --color never",
+	}
+
+	jsonArrayInput := cli.BoolFlag{
+		Name:  "json-array",
+		Usage: "read the input as a single JSON array of entries, as produced by `jq -s`",
+	}
+
+	journalExportInput := cli.BoolFlag{
+		Name:  "journal-export",
+		Usage: "read the input in the export format of journald, as produced by journalctl -o export",
+	}
+
+	csvInput := cli.BoolFlag{
+		Name:  "csv",
+		Usage: "read the input as CSV records, named by their first one unless --columns is set",
+	}
+
+	tsvInput := cli.BoolFlag{
+		Name:  "tsv",
+		Usage: "read the input as tab separated values, named by their first line unless --columns is set",
+	}
+
+	csvColumns := cli.StringFlag{
+		Name:  "columns",
+		Usage: "with --csv or --tsv, the names of the columns, separated by commas, when the input has no header",
+	}
+
+	mysqlSlowLogInput := cli.BoolFlag{
+		Name:  "mysql-slow-log",
+		Usage: "read the input as the slow query log of MySQL, writing each query as one line",
+	}
+
+	since := cli.StringFlag{
+		Name:  "since",
+		Usage: "only print lines timestamped at or after this time, like 2006-01-02T15:04:05Z07:00, 2006-01-02 15:04 or a duration ago like 15m or 2d",
+	}
+
+	until := cli.StringFlag{
+		Name:  "until",
+		Usage: "only print lines timestamped at or before this time, in the same forms as --since",
+	}
+
+	strictTimeRange := cli.BoolFlag{
+		Name:  "strict-time",
+		Usage: "with --since or --until, also drop lines that have no timestamp",
+	}
+
+	minLevel := cli.StringFlag{
+		Name:  "level",
+		Usage: "only print lines at least this severe, one of trace, debug, info, warn, error, panic or fatal",
+	}
+
+	emphasize := cli.StringFlag{
+		Name:  "emphasize",
+		Usage: "paint whole lines at least this severe, like error, in the --emphasis style",
+	}
+
+	emphasis := cli.StringFlag{
+		Name:  "emphasis",
+		Usage: "how --emphasize paints lines, one of bold, reverse or background",
+		Value: "bold",
+	}
+
+	strictLevel := cli.BoolFlag{
+		Name:  "strict-level",
+		Usage: "with --level, also drop lines whose level can't be told",
+	}
+
+	sample := cli.StringFlag{
+		Name:  "sample",
+		Usage: "only print one in so many lines, like 1/100, to keep up with a busy stream",
+	}
+
+	sampleErrorsAlways := cli.BoolTFlag{
+		Name:  "sample-errors-always",
+		Usage: "with --sample, print every warning or more severe line regardless, which is the default, unless set to false",
+	}
+
+	whereFlag := cli.StringSliceFlag{
+		Name:  "where",
+		Usage: "only print lines whose field satisfies a condition, like status>=500 or service=checkout, with one of = != > >= < <=",
+		Value: &where,
+	}
+
+	grepFlag := cli.StringSliceFlag{
+		Name:  "grep",
+		Usage: "only print lines matching one of these regexps, in the raw line or its message",
+		Value: &grep,
+	}
+
+	grepInvertFlag := cli.StringSliceFlag{
+		Name:  "grep-v",
+		Usage: "don't print lines matching any of these regexps, in the raw line or its message",
+		Value: &grepInvert,
+	}
+
+	grepContext := cli.IntFlag{
+		Name:  "context, C",
+		Usage: "with --grep or --grep-v, also print this many lines around each line printed",
+	}
+
+	contextBefore := cli.IntFlag{
+		Name:  "before",
+		Usage: "with --level, --where, --grep, --grep-v or --sample, also print this many of the lines left out before each line printed, dimmed",
+	}
+
+	contextAfter := cli.IntFlag{
+		Name:  "after",
+		Usage: "with --level, --where, --grep, --grep-v or --sample, also print this many of the lines left out after each line printed, dimmed",
+	}
+
+	plugin := cli.StringFlag{
+		Name:  "plugin",
+		Usage: "hand the lines no handler recognizes to this command, which answers each with a JSON object of its time, level, msg and fields, or null",
+	}
+
+	skipLines := cli.Uint64Flag{
+		Name:  "skip-lines",
+		Usage: "skip this many lines before printing any",
+	}
+
+	maxLines := cli.Uint64Flag{
+		Name:  "max-lines, n",
+		Usage: "stop after printing this many lines (0 for no limit)",
+	}
+
+	checkpoint := cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "with --follow, keep how far each file was read in this state file, to resume from there when following them again",
+	}
+
+	journal := cli.BoolFlag{
+		Name:  "journal",
+		Usage: "read the journal by running journalctl -o json -f, rather than reading stdin",
+	}
+
+	journalUnit := cli.StringSliceFlag{
+		Name:  "journal-unit",
+		Usage: "with --journal, only show the messages of this systemd unit, or of units matching this pattern",
+		Value: &journalUnits,
+	}
+
+	journalPriority := cli.StringFlag{
+		Name:  "journal-priority",
+		Usage: "with --journal, only show the messages of this priority or range of priorities, like err or warning..emerg",
+	}
+
+	journalBoot := cli.StringFlag{
+		Name:  "journal-boot",
+		Usage: "with --journal, only show the messages of this boot, like 0 for the current one or -1 for the one before",
+	}
+
+	journalVerbose := cli.BoolFlag{
+		Name:  "journal-verbose",
+		Usage: "show every field of the entries of the journal, rather than their unit, process and host in short",
+	}
+
+	kafkaBrokers := cli.StringFlag{
+		Name:  "kafka-brokers",
+		Usage: "consume the records of --kafka-topic from these comma separated brokers with kcat, rather than reading stdin",
+	}
+
+	kafkaTopic := cli.StringFlag{
+		Name:  "kafka-topic",
+		Usage: "with --kafka-brokers, the topic to consume, from its end unless --kafka-group is set",
+	}
+
+	kafkaGroup := cli.StringFlag{
+		Name:  "kafka-group",
+		Usage: "with --kafka-brokers, consume the topic as a member of this consumer group, from its committed offsets",
+	}
+
+	kafkaMetadata := cli.BoolFlag{
+		Name:  "kafka-metadata",
+		Usage: "show the partition, offset and headers of Kafka records, as consumed with kcat -J, as fields",
+	}
+
+	follow := cli.BoolFlag{
+		Name:  "follow, f",
+		Usage: "keep reading the files given as arguments as they grow, starting from their last 10 lines, and across truncations and rotations, like tail -F",
+	}
+
+	stats := cli.BoolFlag{
+		Name:  "stats",
+		Usage: "write a summary of the lines by level, format and key to stderr once done, when interrupted, or on SIGUSR1",
+	}
+
+	sortWindow := cli.DurationFlag{
+		Name:  "sort-window",
+		Usage: "when following files or reading docker or kubernetes sources, hold lines this long to write them in the order of their time rather than as they come",
+	}
+
+	watchFlag := cli.StringSliceFlag{
+		Name:  "watch",
+		Usage: "keep the last value of this key, and how many times it changed, at the bottom of the terminal",
+		Value: &watchKeys,
+	}
+
+	tui := cli.BoolFlag{
+		Name:  "tui",
+		Usage: "browse the lines full screen, to scroll back, search, filter them by level or fields, pause the stream and see each line as it was read",
+	}
+
+	keys := cli.BoolFlag{
+		Name:  "keys",
+		Usage: "with the output on a terminal, toggle skip-unchanged with s and colors with c, and cycle through the minimum levels with l, as lines stream by",
+	}
+
+	strict := cli.BoolFlag{
+		Name:  "strict",
+		Usage: "report the lines no handler could parse to stderr with their number, and exit with an error once done if there were any",
+	}
+
+	metricsAddr := cli.StringFlag{
+		Name:  "metrics",
+		Usage: "serve counts of the lines read by format and level to Prometheus at /metrics on this address, like :9100, to watch over the logs followed or received",
+	}
+
+	config := cli.StringFlag{
+		Name:  "config",
+		Usage: "read the defaults of the other flags from this file rather than from ~/.config/humanlog/config.yaml, named like the flags, like truncate-length: 30 or skip: [password, token]",
+	}
+
 	ignoreInterrupts := cli.BoolFlag{
 		Name:  "ignore-interrupts, i",
 		Usage: "ignore interrupts",
@@ -94,18 +730,380 @@ func newApp() *cli.App {
 	app.Name = "humanlog"
 	app.Version = version
 	app.Usage = "reads structured logs from stdin, makes them pretty on stdout!"
+	app.ArgsUsage = "[files or globs to merge chronologically, each line labelled with its file, instead of reading stdin...]"
 
-	app.Flags = []cli.Flag{skipFlag, keepFlag, sortLongest, skipUnchanged, truncates, truncateLength, lightBg, timeFormat, ignoreInterrupts}
+	app.Flags = []cli.Flag{skipFlag, keepFlag, selectFlag, firstKeysFlag, sortLongest, skipUnchanged, truncates, truncateLength, truncateKeysFlag, noTruncateKeysFlag, expandKeysFlag, lightBg, timeFormat, timeMode, utc, local, tz, timeFieldsFlag, timeLayoutsFlag, msgFieldsFlag, errorKeysFlag, compactCaller, callerTrimPrefixesFlag, levelFieldsFlag, autoSkipUnderscore, stripANSI, unquote, parseEmbeddedJSON, prefixKeysFlag, maxLineLength, gapThreshold, collapseRepeats, alignColumns, overflow, width, messageWidth, foldMultiline, nestedObjects, binaryLines, maxArrayElements, appendRaw, humanizeKeysFlag, humanizeDurations, humanizeSizes, slowDuration, shortenTraceIDs, traceURL, gutterKey, highlightsFlag, renameKeysFlag, showHandler, levelLabelsFlag, levelMappingFlag, levelStyle, theme, colorDepth, paletteFlag, parallel, flushInterval, flushEvery, autoDetectTime, jsonDetection, disabledHandlersFlag, noJSON, noJournal, detectLines, jsonOutput, logfmtOutput, rawOutput, format, htmlOutput, rawCopy, outputFile, maxSizeFlag, maxFiles, colorMode, noColor, jsonArrayInput, journalExportInput, mysqlSlowLogInput, csvInput, tsvInput, csvColumns, since, until, strictTimeRange, minLevel, strictLevel, sample, sampleErrorsAlways, emphasize, emphasis, whereFlag, grepFlag, grepInvertFlag, grepContext, contextBefore, contextAfter, plugin, skipLines, maxLines, follow, checkpoint, journal, journalUnit, journalPriority, journalBoot, journalVerbose, kafkaBrokers, kafkaTopic, kafkaGroup, kafkaMetadata, stats, metricsAddr, sortWindow, watchFlag, tui, keys, strict, config, ignoreInterrupts}
 
-	app.Action = func(c *cli.Context) error {
+	// input is set by the commands that get lines from elsewhere than
+	// stdin or files
+	var input func(out io.Writer, opts *humanlog.HandlerOptions) error
+
+	action := func(c *cli.Context) error {
+		if path := c.String(config.Name); path != "" {
+			if err := applyConfig(c, path, true); err != nil {
+				fatalf(c, "invalid %q: %v", config.Name, err)
+			}
+		} else if path := defaultConfigPath(); path != "" {
+			if err := applyConfig(c, path, false); err != nil {
+				log.Fatalf("can't read the config file: %v", err)
+			}
+		}
 
 		opts := humanlog.DefaultOptions
 		opts.SortLongest = c.BoolT(sortLongest.Name)
 		opts.SkipUnchanged = c.BoolT(skipUnchanged.Name)
 		opts.Truncates = c.BoolT(truncates.Name)
 		opts.TruncateLength = c.Int(truncateLength.Name)
+		for _, kv := range truncateKeys {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				fatalf(c, "invalid %q, want key=length: %q", truncateKeysFlag.Name, kv)
+			}
+			n, err := strconv.Atoi(parts[1])
+			if err != nil || n <= 0 {
+				fatalf(c, "invalid %q, want a positive length: %q", truncateKeysFlag.Name, kv)
+			}
+			if opts.TruncateKeys == nil {
+				opts.TruncateKeys = make(map[string]int)
+			}
+			opts.TruncateKeys[parts[0]] = n
+		}
+		for _, key := range noTruncateKeys {
+			if opts.TruncateKeys == nil {
+				opts.TruncateKeys = make(map[string]int)
+			}
+			opts.TruncateKeys[key] = 0
+		}
+		opts.ExpandKeys = expandKeys
 		opts.LightBg = c.BoolT(lightBg.Name)
 		opts.TimeFormat = c.String(timeFormat.Name)
+		if c.Bool(utc.Name) && c.Bool(local.Name) || c.String(tz.Name) != "" && (c.Bool(utc.Name) || c.Bool(local.Name)) {
+			fatalf(c, "only one of %q, %q and %q can be used", utc.Name, local.Name, tz.Name)
+		}
+		switch {
+		case c.String(tz.Name) != "":
+			loc, err := time.LoadLocation(c.String(tz.Name))
+			if err != nil {
+				fatalf(c, "invalid %q: %v", tz.Name, err)
+			}
+			opts.Location = loc
+		case c.Bool(utc.Name):
+			opts.Location = time.UTC
+		case c.Bool(local.Name):
+			opts.Location = time.Local
+		}
+		opts.AutoSkipUnderscore = c.BoolT(autoSkipUnderscore.Name)
+		opts.KafkaMetadata = c.Bool(kafkaMetadata.Name)
+		opts.StripInputANSI = c.Bool(stripANSI.Name)
+		opts.UnquoteSimpleStrings = c.Bool(unquote.Name)
+		opts.ParseEmbeddedJSON = c.Bool(parseEmbeddedJSON.Name)
+		opts.PrefixKeys = prefixKeys
+		for _, keys := range selectKeys {
+			for _, key := range strings.Split(keys, ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					opts.Select = append(opts.Select, key)
+				}
+			}
+		}
+		for _, keys := range firstKeys {
+			for _, key := range strings.Split(keys, ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					opts.FirstKeys = append(opts.FirstKeys, key)
+				}
+			}
+		}
+		opts.TimeFields = timeFields
+		opts.TimeLayouts = timeLayouts
+		opts.MessageFields = msgFields
+		opts.CompactCaller = c.BoolT(compactCaller.Name)
+		opts.CallerTrimPrefixes = callerTrimPrefixes
+		if c.IsSet(errorKeysFlag.Name) {
+			opts.ErrorKeys = nil
+			for _, keys := range errorKeys {
+				for _, key := range strings.Split(keys, ",") {
+					if key = strings.TrimSpace(key); key != "" {
+						opts.ErrorKeys = append(opts.ErrorKeys, key)
+					}
+				}
+			}
+		}
+		opts.LevelFields = levelFields
+		opts.MessageWidth = c.Int(messageWidth.Name)
+		opts.AlignColumns = c.Bool(alignColumns.Name)
+		opts.CollapseRepeats = c.Bool(collapseRepeats.Name)
+		opts.GapThreshold = c.Duration(gapThreshold.Name)
+		switch mode := c.String(overflow.Name); mode {
+		case humanlog.OverflowNone, humanlog.OverflowTruncate, humanlog.OverflowWrap:
+			opts.Overflow = mode
+		default:
+			fatalf(c, "invalid %q: %q", overflow.Name, mode)
+		}
+		opts.Width = c.Int(width.Name)
+		if opts.Width == 0 && opts.Overflow != humanlog.OverflowNone {
+			opts.Width = terminalWidth(os.Stdout)
+		}
+		if length := c.String(maxLineLength.Name); length != "" {
+			n, err := humanlog.ParseSize(length)
+			if err != nil || n <= 0 {
+				fatalf(c, "invalid %q: %q", maxLineLength.Name, length)
+			}
+			opts.MaxLineLength = int(n)
+		}
+		opts.FoldMultiline = c.Bool(foldMultiline.Name)
+		opts.MaxArrayElements = c.Int(maxArrayElements.Name)
+		opts.AppendRaw = c.Bool(appendRaw.Name)
+		opts.ShowHandler = c.Bool(showHandler.Name)
+		opts.FlushInterval = c.Duration(flushInterval.Name)
+		opts.AutoDetectTime = c.Bool(autoDetectTime.Name)
+		switch mode := c.String(jsonDetection.Name); mode {
+		case humanlog.JSONDetectionTime, humanlog.JSONDetectionStrict, humanlog.JSONDetectionLoose:
+			opts.JSONDetection = mode
+		default:
+			fatalf(c, "invalid %q: %q", jsonDetection.Name, mode)
+		}
+		outputs := 0
+		for _, flag := range []cli.BoolFlag{jsonOutput, logfmtOutput, rawOutput} {
+			if c.Bool(flag.Name) {
+				outputs++
+			}
+		}
+		switch {
+		case outputs > 1:
+			fatalf(c, "can only use one of %q, %q and %q", jsonOutput.Name, logfmtOutput.Name, rawOutput.Name)
+		case c.Bool(jsonOutput.Name):
+			opts.Output = humanlog.OutputJSON
+		case c.Bool(logfmtOutput.Name):
+			opts.Output = humanlog.OutputLogfmt
+		case c.Bool(rawOutput.Name):
+			opts.Output = humanlog.OutputRaw
+		}
+		switch mode := c.String(colorMode.Name); mode {
+		case "auto":
+			// colors are already left out when stdout isn't a terminal
+			opts.DisableColors = c.Bool(noColor.Name) || os.Getenv("NO_COLOR") != ""
+		case "always":
+			if c.Bool(noColor.Name) {
+				fatalf(c, "can only use one of %q and %q", colorMode.Name, noColor.Name)
+			}
+			color.NoColor = false
+		case "never":
+			opts.DisableColors = true
+		default:
+			fatalf(c, "invalid %q: %q", colorMode.Name, mode)
+		}
+		opts.JSONArrayInput = c.Bool(jsonArrayInput.Name)
+		opts.JournalExportInput = c.Bool(journalExportInput.Name)
+		opts.MySQLSlowLogInput = c.Bool(mysqlSlowLogInput.Name)
+		switch {
+		case c.Bool(csvInput.Name) && c.Bool(tsvInput.Name):
+			fatalf(c, "can only use one of %q and %q", csvInput.Name, tsvInput.Name)
+		case c.Bool(csvInput.Name):
+			opts.CSVInput = true
+		case c.Bool(tsvInput.Name):
+			opts.CSVInput, opts.CSVComma = true, '\t'
+		case c.String(csvColumns.Name) != "":
+			fatalf(c, "can only use %q along with %q or %q", csvColumns.Name, csvInput.Name, tsvInput.Name)
+		}
+		if columns := c.String(csvColumns.Name); columns != "" {
+			opts.CSVColumns = strings.Split(columns, ",")
+		}
+		opts.JournalVerbose = c.Bool(journalVerbose.Name)
+		if opts.SortWindow = c.Duration(sortWindow.Name); opts.SortWindow < 0 {
+			fatalf(c, "invalid %q: %v", sortWindow.Name, opts.SortWindow)
+		}
+		now := time.Now()
+		if bound := c.String(since.Name); bound != "" {
+			t, err := humanlog.ParseTimeBound(bound, now)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", since.Name, err)
+			}
+			opts.Since = t
+		}
+		if bound := c.String(until.Name); bound != "" {
+			t, err := humanlog.ParseTimeBound(bound, now)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", until.Name, err)
+			}
+			opts.Until = t
+		}
+		opts.StrictTimeRange = c.Bool(strictTimeRange.Name)
+		if level := strings.ToLower(c.String(minLevel.Name)); level != "" {
+			if !humanlog.IsLevel(level) || level == humanlog.UnknownLevel {
+				fatalf(c, "invalid %q: %q", minLevel.Name, level)
+			}
+			opts.MinLevel = level
+		}
+		if level := strings.ToLower(c.String(emphasize.Name)); level != "" {
+			if !humanlog.IsLevel(level) || level == humanlog.UnknownLevel {
+				fatalf(c, "invalid %q: %q", emphasize.Name, level)
+			}
+			opts.EmphasizeLevel = level
+		}
+		switch style := c.String(emphasis.Name); style {
+		case "bold":
+			opts.EmphasisColor = color.New(color.Bold)
+		case "reverse":
+			opts.EmphasisColor = color.New(color.ReverseVideo)
+		case "background":
+			opts.EmphasisColor = color.New(color.BgRed)
+		default:
+			fatalf(c, "invalid %q: %q", emphasis.Name, style)
+		}
+		opts.StrictLevel = c.Bool(strictLevel.Name)
+		if rate := c.String(sample.Name); rate != "" {
+			n, err := humanlog.ParseSampleRate(rate)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", sample.Name, err)
+			}
+			opts.SampleRate = n
+			if c.BoolT(sampleErrorsAlways.Name) {
+				opts.SampleKeepLevel = humanlog.WarnLevel
+			}
+		}
+		for _, expr := range where {
+			p, err := humanlog.ParsePredicate(expr)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", whereFlag.Name, err)
+			}
+			opts.Where = append(opts.Where, p)
+		}
+		for _, expr := range grep {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", grepFlag.Name, err)
+			}
+			opts.Grep = append(opts.Grep, re)
+		}
+		for _, expr := range grepInvert {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", grepInvertFlag.Name, err)
+			}
+			opts.GrepInvert = append(opts.GrepInvert, re)
+		}
+		opts.GrepContext = c.Int(strings.Split(grepContext.Name, ",")[0])
+		opts.ContextBefore = c.Int(contextBefore.Name)
+		opts.ContextAfter = c.Int(contextAfter.Name)
+		if opts.ContextBefore < 0 || opts.ContextAfter < 0 {
+			fatalf(c, "%q and %q can't be negative", contextBefore.Name, contextAfter.Name)
+		}
+		opts.SkipLines = c.Uint64(skipLines.Name)
+		opts.MaxLines = c.Uint64(strings.Split(maxLines.Name, ",")[0])
+		for _, kv := range humanizeKeys {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				fatalf(c, "invalid %q, want key=unit: %q", humanizeKeysFlag.Name, kv)
+			}
+			if opts.HumanizeKeys == nil {
+				opts.HumanizeKeys = make(map[string]string)
+			}
+			opts.HumanizeKeys[parts[0]] = parts[1]
+		}
+		opts.HumanizeDurations = c.BoolT(humanizeDurations.Name)
+		opts.SlowDuration = c.Duration(slowDuration.Name)
+		opts.HumanizeSizes = c.Bool(humanizeSizes.Name)
+		opts.ShortenTraceIDs = c.BoolT(shortenTraceIDs.Name)
+		opts.TraceURL = c.String(traceURL.Name)
+		opts.GutterKey = c.String(gutterKey.Name)
+		for _, s := range highlights {
+			hl, err := humanlog.ParseHighlight(s)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", highlightsFlag.Name, err)
+			}
+			opts.Highlights = append(opts.Highlights, hl)
+		}
+		for _, kv := range renameKeys {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				fatalf(c, "invalid %q, want key=name: %q", renameKeysFlag.Name, kv)
+			}
+			if opts.RenameKeys == nil {
+				opts.RenameKeys = make(map[string]string)
+			}
+			opts.RenameKeys[parts[0]] = parts[1]
+		}
+		for _, kv := range levelLabels {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				fatalf(c, "invalid %q, want level=label: %q", levelLabelsFlag.Name, kv)
+			}
+			if opts.LevelLabels == nil {
+				opts.LevelLabels = make(map[string]string)
+			}
+			opts.LevelLabels[strings.ToLower(parts[0])] = parts[1]
+		}
+		for _, kv := range levelMapping {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				fatalf(c, "invalid %q, want logged=level: %q", levelMappingFlag.Name, kv)
+			}
+			level := strings.ToLower(parts[1])
+			if !humanlog.IsLevel(level) {
+				fatalf(c, "invalid %q, unknown level %q: %q", levelMappingFlag.Name, parts[1], kv)
+			}
+			if opts.LevelMapping == nil {
+				opts.LevelMapping = make(map[string]string)
+			}
+			opts.LevelMapping[parts[0]] = level
+		}
+		switch style := c.String(levelStyle.Name); style {
+		case humanlog.LevelStyleBars, humanlog.LevelStyleBracket, humanlog.LevelStylePlain:
+			opts.LevelStyle = style
+		default:
+			fatalf(c, "invalid %q: %q", levelStyle.Name, style)
+		}
+		switch mode := c.String(timeMode.Name); mode {
+		case humanlog.TimeModeAbsolute, humanlog.TimeModeRelative, humanlog.TimeModeDelta:
+			opts.TimeMode = mode
+		default:
+			fatalf(c, "invalid %q: %q", timeMode.Name, mode)
+		}
+		switch nested := c.String(nestedObjects.Name); nested {
+		case humanlog.NestedObjectsInline, humanlog.NestedObjectsFlatten, humanlog.NestedObjectsExpand:
+			opts.NestedObjects = nested
+		default:
+			fatalf(c, "invalid %q: %q", nestedObjects.Name, nested)
+		}
+		switch binary := c.String(binaryLines.Name); binary {
+		case humanlog.BinaryLinesEscape, humanlog.BinaryLinesHex, humanlog.BinaryLinesRaw:
+			opts.BinaryLines = binary
+		default:
+			fatalf(c, "invalid %q: %q", binaryLines.Name, binary)
+		}
+		switch depth := c.String(colorDepth.Name); depth {
+		case "auto":
+			opts.ColorDepth = humanlog.ColorDepthFromEnv(os.Getenv("TERM"), os.Getenv("COLORTERM"))
+		case "16":
+			opts.ColorDepth = humanlog.Colors16
+		case "256":
+			opts.ColorDepth = humanlog.Colors256
+		case "truecolor":
+			opts.ColorDepth = humanlog.ColorsTrue
+		default:
+			fatalf(c, "invalid %q: %q", colorDepth.Name, depth)
+		}
+		if err := opts.ApplyTheme(c.String(theme.Name)); err != nil {
+			fatalf(c, "invalid %q: %v", theme.Name, err)
+		}
+		for _, kv := range palette {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				fatalf(c, "invalid %q, want element=color: %q", paletteFlag.Name, kv)
+			}
+			if err := opts.SetColor(parts[0], parts[1]); err != nil {
+				fatalf(c, "invalid %q: %v", paletteFlag.Name, err)
+			}
+		}
+		if text := c.String(format.Name); text != "" {
+			if opts.Output != humanlog.OutputPretty {
+				fatalf(c, "can't use %q along with another output", format.Name)
+			}
+			tmpl, err := opts.ParseTemplate(text)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", format.Name, err)
+			}
+			opts.Template = tmpl
+		}
 
 		switch {
 		case c.IsSet(skipFlag.Name) && c.IsSet(keepFlag.Name):
@@ -119,12 +1117,551 @@ func newApp() *cli.App {
 		if c.IsSet(strings.Split(ignoreInterrupts.Name, ",")[0]) {
 			signal.Ignore(os.Interrupt)
 		}
+		if c.Bool(stats.Name) {
+			opts.Stats = humanlog.NewStats()
+			defer opts.Stats.WriteTo(os.Stderr)
+			notifyStats(opts.Stats)
+		}
+		var unparsed uint64
+		if c.Bool(strict.Name) {
+			opts.Unparsed = func(n uint64, line []byte) {
+				atomic.AddUint64(&unparsed, 1)
+				log.Printf("line %d isn't structured: %s", n, line)
+			}
+		}
+		if addr := c.String(metricsAddr.Name); addr != "" {
+			opts.Metrics = humanlog.NewMetrics()
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", opts.Metrics)
+			go func() {
+				log.Fatalf("can't serve metrics: %v", http.ListenAndServe(addr, mux))
+			}()
+		}
 
-		log.Print("reading stdin...")
-		if err := humanlog.Scanner(os.Stdin, colorable.NewColorableStdout(), opts); err != nil {
+		if command := strings.Fields(c.String(plugin.Name)); len(command) > 0 {
+			h, err := humanlog.NewExecHandler(opts, command[0], command[1:]...)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", plugin.Name, err)
+			}
+			defer h.Close()
+			// after all the built-in handlers
+			humanlog.RegisterHandler(h, 0)
+		}
+		names := humanlog.HandlerNames()
+		for _, list := range disabledHandlers {
+			for _, name := range strings.Split(list, ",") {
+				name = strings.TrimSpace(name)
+				known := false
+				for _, n := range names {
+					known = known || n == name
+				}
+				if !known {
+					fatalf(c, "invalid %q: no handler is named %q, only %s", disabledHandlersFlag.Name, name, strings.Join(names, ", "))
+				}
+				opts.DisabledHandlers = append(opts.DisabledHandlers, name)
+			}
+		}
+		if c.Bool(noJSON.Name) {
+			opts.DisabledHandlers = append(opts.DisabledHandlers, "json")
+		}
+		if c.Bool(noJournal.Name) {
+			opts.DisabledHandlers = append(opts.DisabledHandlers, "journal")
+		}
+		if opts.DetectLines = c.Int(detectLines.Name); opts.DetectLines < 0 {
+			fatalf(c, "invalid %q: %d", detectLines.Name, opts.DetectLines)
+		}
+
+		if filename := c.String(rawCopy.Name); filename != "" {
+			f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err != nil {
+				fatalf(c, "invalid %q: %v", rawCopy.Name, err)
+			}
+			// not buffered, so that nothing is lost when interrupted
+			defer f.Close()
+			opts.RawCopy = f
+		}
+
+		var out io.Writer = colorable.NewColorableStdout()
+		var stdout io.Writer = os.Stdout
+		if filename := c.String(outputFile.Name); filename != "" {
+			var maxSize int64
+			if size := c.String(maxSizeFlag.Name); size != "" {
+				var err error
+				if maxSize, err = humanlog.ParseSize(size); err != nil {
+					fatalf(c, "invalid %q: %v", maxSizeFlag.Name, err)
+				}
+			}
+			w, err := humanlog.NewRotatingWriter(filename, maxSize, c.Int(maxFiles.Name))
+			if err != nil {
+				log.Fatalf("can't open the output file: %v", err)
+			}
+			defer w.Close()
+			// escape sequences would only get in the way in a file, unless
+			// asked for
+			if c.String(colorMode.Name) != "always" {
+				color.NoColor = true
+			}
+			out, stdout = w, w
+		}
+
+		// the output is buffered, but never withholds what it got for long:
+		// line by line to a terminal, and after a while otherwise
+		every := c.Int(flushEvery.Name)
+		interval := c.Duration(flushInterval.Name)
+		if interval == 0 {
+			interval = defaultOutputFlushInterval
+		}
+		if every == 0 && c.String(outputFile.Name) == "" && isTerminal(os.Stdout) {
+			every = 1
+		}
+		if len(watchKeys) > 0 {
+			if c.String(outputFile.Name) != "" || c.Bool(htmlOutput.Name) || c.Bool(tui.Name) || !isTerminal(os.Stdout) {
+				fatalf(c, "%q needs a terminal, without %q, %q or %q", watchFlag.Name, outputFile.Name, htmlOutput.Name, tui.Name)
+			}
+			opts.Watch = humanlog.NewWatch(watchKeys)
+			footer := &footerWriter{
+				out:   out,
+				watch: opts.Watch,
+				width: func() int { return terminalWidth(os.Stdout) },
+			}
+			defer footer.Close()
+			out = footer
+		}
+		flushed := humanlog.NewFlushWriter(out, every, interval)
+		defer flushed.Flush()
+		out = flushed
+		if c.Bool(htmlOutput.Name) {
+			// colors end up in the page rather than on a terminal
+			color.NoColor = false
+			page := humanlog.NewHTMLWriter(stdout, opts.LightBg)
+			defer func() {
+				if err := page.Close(); err != nil {
+					log.Fatalf("can't write out the page: %v", err)
+				}
+			}()
+			out = page
+		}
+
+		var viewer *viewerSession
+		if c.Bool(tui.Name) {
+			if c.String(outputFile.Name) != "" || c.Bool(htmlOutput.Name) {
+				fatalf(c, "can't use %q along with %q or %q", tui.Name, outputFile.Name, htmlOutput.Name)
+			}
+			var err error
+			if viewer, err = startViewer(); err != nil {
+				log.Fatalf("can't run the viewer: %v", err)
+			}
+			// the screen is a terminal even when stdout isn't
+			if !opts.DisableColors {
+				color.NoColor = false
+			}
+			opts.Written = viewer.viewer.Add
+			out = ioutil.Discard
+			// and logs would mess it up
+			log.SetOutput(ioutil.Discard)
+		}
+
+		// lines are as wide as the terminal even once it's resized, and
+		// keys change some options without restarting the stream
+		resize := opts.Overflow != humanlog.OverflowNone && c.Int(width.Name) == 0 && viewer == nil && isTerminal(os.Stdout)
+		if resize || c.Bool(keys.Name) {
+			opts.Controls = humanlog.NewControls(opts)
+		}
+		if resize {
+			onResize(func() { opts.Controls.SetWidth(terminalWidth(os.Stdout)) })
+		}
+		if c.Bool(keys.Name) {
+			if c.String(outputFile.Name) != "" || c.Bool(htmlOutput.Name) || viewer != nil || !isTerminal(os.Stdout) {
+				fatalf(c, "%q needs a terminal, without %q, %q or %q", keys.Name, outputFile.Name, htmlOutput.Name, tui.Name)
+			}
+			restore, err := startControlKeys(opts.Controls)
+			if err != nil {
+				log.Fatalf("can't read keys: %v", err)
+			}
+			defer restore()
+			onInterrupt(restore)
+		}
+
+		var filenames []string
+		if input == nil {
+			var err error
+			filenames, err = expandGlobs(c.Args())
+			if err != nil {
+				fatalf(c, "invalid file pattern: %v", err)
+			}
+		}
+		if len(filenames) > 1 {
+			opts.SourceNames = filenames
+		}
+
+		if c.String(checkpoint.Name) != "" && !c.Bool(strings.Split(follow.Name, ",")[0]) {
+			fatalf(c, "%q needs %q", checkpoint.Name, follow.Name)
+		}
+
+		var err error
+		if input != nil {
+			err = input(out, opts)
+		} else if c.Bool(journal.Name) {
+			if len(filenames) > 0 {
+				fatalf(c, "can't use %q along with files", journal.Name)
+			}
+			args := journalctlArgs(journalUnits, c.String(journalPriority.Name), c.String(journalBoot.Name))
+			log.Printf("running journalctl %s...", strings.Join(args, " "))
+			err = readCommand(out, opts, "journalctl", args...)
+		} else if brokers := c.String(kafkaBrokers.Name); brokers != "" {
+			if len(filenames) > 0 {
+				fatalf(c, "can't use %q along with files", kafkaBrokers.Name)
+			}
+			topic := c.String(kafkaTopic.Name)
+			if topic == "" {
+				fatalf(c, "%q needs %q", kafkaBrokers.Name, kafkaTopic.Name)
+			}
+			kcat, kerr := kcatPath()
+			if kerr != nil {
+				log.Fatal(kerr)
+			}
+			args := kcatArgs(brokers, topic, c.String(kafkaGroup.Name))
+			log.Printf("running %s %s...", kcat, strings.Join(args, " "))
+			err = readCommand(out, opts, kcat, args...)
+		} else if c.Bool(strings.Split(follow.Name, ",")[0]) {
+			if len(filenames) == 0 {
+				fatalf(c, "%q needs files to follow", follow.Name)
+			}
+			var cp *humanlog.Checkpoint
+			if state := c.String(checkpoint.Name); state != "" {
+				if cp, err = humanlog.OpenCheckpoint(state); err != nil {
+					log.Fatalf("can't read the checkpoint: %v", err)
+				}
+				saveCheckpoint := func() {
+					// the lines read are only done with once written
+					// out, rather than held to be
+					flushed.Flush()
+					if err := cp.Save(); err != nil {
+						log.Printf("can't save the checkpoint: %v", err)
+					}
+				}
+				defer saveCheckpoint()
+				onInterrupt(saveCheckpoint)
+				go func() {
+					for range time.Tick(checkpointInterval) {
+						saveCheckpoint()
+					}
+				}()
+			}
+			srcs := make([]io.Reader, 0, len(filenames))
+			for _, filename := range filenames {
+				var fl *humanlog.Follower
+				if cp != nil {
+					fl, err = cp.Follow(filename, 10)
+				} else {
+					fl, err = humanlog.Follow(filename, 10)
+				}
+				if err != nil {
+					log.Fatalf("can't open file: %v", err)
+				}
+				defer fl.Close()
+				srcs = append(srcs, fl)
+			}
+			log.Printf("following %d files...", len(srcs))
+			err = humanlog.ScannerInterleave(srcs, out, opts)
+		} else if len(filenames) > 0 {
+			srcs := make([]io.Reader, 0, len(filenames))
+			for _, filename := range filenames {
+				f, err := os.Open(filename)
+				if err != nil {
+					log.Fatalf("can't open file: %v", err)
+				}
+				defer f.Close()
+				src, err := humanlog.Decompress(f)
+				if err != nil {
+					log.Fatalf("can't read %s: %v", filename, err)
+				}
+				srcs = append(srcs, src)
+			}
+			if workers := c.Int(parallel.Name); workers != 0 && len(srcs) == 1 {
+				err = humanlog.ScannerParallel(srcs[0], out, opts, workers)
+			} else {
+				log.Printf("merging %d files...", len(srcs))
+				err = humanlog.ScannerMerge(srcs, out, opts)
+			}
+		} else {
+			log.Print("reading stdin...")
+			var src io.Reader
+			src, err = humanlog.Decompress(os.Stdin)
+			if err != nil {
+				log.Fatalf("can't read stdin: %v", err)
+			}
+			if workers := c.Int(parallel.Name); workers != 0 {
+				err = humanlog.ScannerParallel(src, out, opts, workers)
+			} else {
+				err = humanlog.Scanner(src, out, opts)
+			}
+		}
+		if viewer != nil {
+			viewer.wait(err)
+			log.SetOutput(colorable.NewColorableStderr())
+		}
+		if err != nil {
+			flushed.Flush()
 			log.Fatalf("scanning caught an error: %v", err)
 		}
+		if n := atomic.LoadUint64(&unparsed); n > 0 {
+			flushed.Flush()
+			log.Fatalf("no handler could parse %d of the lines", n)
+		}
 		return nil
 	}
+	app.Action = action
+
+	syslogAddr := cli.StringFlag{
+		Name:  "syslog",
+		Usage: "receive RFC5424 and RFC3164 syslog messages on this address, like :5514, over both UDP and TCP",
+	}
+	httpAddr := cli.StringFlag{
+		Name:  "http",
+		Usage: "receive logs POSTed to any path of this address, like :8080",
+	}
+	kubeSelector := cli.StringFlag{
+		Name:  "selector, l",
+		Usage: "stream the logs of the pods matching this label selector, like app=foo",
+	}
+	kubeNamespace := cli.StringFlag{
+		Name:  "namespace, n",
+		Usage: "look for pods in this namespace rather than in the one of the current context",
+	}
+	kubeContainer := cli.StringFlag{
+		Name:  "container, c",
+		Usage: "stream the logs of the containers of this name, rather than of the first container of each pod",
+	}
+	kubeAllContainers := cli.BoolFlag{
+		Name:  "all-containers",
+		Usage: "stream the logs of all the containers of each pod",
+	}
+	app.Commands = []cli.Command{
+		{
+			Name:      "listen",
+			Usage:     "prettify the logs received over the network rather than those of stdin, with the options given before the command",
+			ArgsUsage: " ",
+			Flags:     []cli.Flag{syslogAddr},
+			Action: func(c *cli.Context) error {
+				addr := c.String(syslogAddr.Name)
+				if addr == "" {
+					fatalf(c, "%q needs an address to listen on", syslogAddr.Name)
+				}
+				input = func(out io.Writer, opts *humanlog.HandlerOptions) error {
+					log.Printf("listening for syslog messages on %s...", addr)
+					return humanlog.ListenSyslog(addr, out, opts)
+				}
+				return action(c.Parent())
+			},
+		},
+		{
+			Name:      "serve",
+			Usage:     "prettify the logs POSTed over HTTP, as NDJSON, plain text or a JSON array, rather than those of stdin, with the options given before the command",
+			ArgsUsage: " ",
+			Flags:     []cli.Flag{httpAddr},
+			Action: func(c *cli.Context) error {
+				addr := c.String(httpAddr.Name)
+				if addr == "" {
+					fatalf(c, "%q needs an address to listen on", httpAddr.Name)
+				}
+				input = func(out io.Writer, opts *humanlog.HandlerOptions) error {
+					log.Printf("listening for logs on http://%s...", addr)
+					return http.ListenAndServe(addr, humanlog.NewHTTPHandler(out, opts))
+				}
+				return action(c.Parent())
+			},
+		},
+		{
+			Name:      "k8s",
+			Usage:     "prettify the logs of the pods of a Kubernetes cluster, by driving kubectl, each line labelled with its pod and container, with the options given before the command",
+			ArgsUsage: " ",
+			Flags:     []cli.Flag{kubeSelector, kubeNamespace, kubeContainer, kubeAllContainers},
+			Action: func(c *cli.Context) error {
+				namespace := c.String(strings.Split(kubeNamespace.Name, ",")[0])
+				kcs, err := kubeContainers(
+					namespace,
+					c.String(strings.Split(kubeSelector.Name, ",")[0]),
+					c.String(strings.Split(kubeContainer.Name, ",")[0]),
+					c.Bool(kubeAllContainers.Name),
+				)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if len(kcs) == 0 {
+					log.Fatal("no pod matches")
+				}
+				input = func(out io.Writer, opts *humanlog.HandlerOptions) error {
+					return streamKubeLogs(out, opts, namespace, kcs)
+				}
+				return action(c.Parent())
+			},
+		},
+		{
+			Name:      "docker",
+			Usage:     "prettify the logs of Docker containers, read from the Docker API of DOCKER_HOST or of the local daemon, with the options given before the command",
+			ArgsUsage: "container...",
+			Action: func(c *cli.Context) error {
+				if c.NArg() == 0 {
+					fatalf(c, "no container to read the logs of")
+				}
+				containers := []string(c.Args())
+				input = func(out io.Writer, opts *humanlog.HandlerOptions) error {
+					return streamDockerLogs(out, opts, containers)
+				}
+				return action(c.Parent())
+			},
+		},
+	}
 	return app
 }
+
+// journalctlArgs are the arguments of journalctl for following the journal
+// as JSON, only for the given units, priority and boot if set.
+func journalctlArgs(units []string, priority, boot string) []string {
+	args := []string{"--output=json", "--follow"}
+	for _, unit := range units {
+		args = append(args, "--unit="+unit)
+	}
+	if priority != "" {
+		args = append(args, "--priority="+priority)
+	}
+	if boot != "" {
+		args = append(args, "--boot="+boot)
+	}
+	return args
+}
+
+// kcatPath finds kcat, which used to be called kafkacat.
+func kcatPath() (string, error) {
+	for _, name := range []string{"kcat", "kafkacat"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("can't find kcat to consume Kafka topics with")
+}
+
+// kcatArgs are the arguments of kcat for consuming the records of topic as
+// JSON, as a member of group if set, or else from the end of the topic.
+func kcatArgs(brokers, topic, group string) []string {
+	args := []string{"-b", brokers, "-J", "-u", "-q"}
+	if group != "" {
+		return append(args, "-G", group, topic)
+	}
+	return append(args, "-C", "-t", topic, "-o", "end")
+}
+
+// readCommand prettifies what the command of name outputs, until it exits.
+func readCommand(out io.Writer, opts *humanlog.HandlerOptions, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := humanlog.Scanner(stdout, out, opts); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if opts.MaxLines > 0 {
+		// the command keeps following what it reads once enough lines
+		// were, and only the ones it ended on its own can fail
+		cmd.Process.Kill()
+		if err := cmd.Wait(); err != nil && cmd.ProcessState.ExitCode() != -1 {
+			return err
+		}
+		return nil
+	}
+	return cmd.Wait()
+}
+
+// expandGlobs expands the glob patterns among args, for shells that don't.
+// Arguments that match nothing are kept as they are, for opening them to
+// tell what is wrong with them.
+func expandGlobs(args []string) ([]string, error) {
+	var filenames []string
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			matches = []string{arg}
+		}
+		filenames = append(filenames, matches...)
+	}
+	return filenames, nil
+}
+
+// defaultOutputFlushInterval is how long the output is held at most when it
+// isn't a terminal, so that it comes out in chunks without lagging behind.
+const defaultOutputFlushInterval = 100 * time.Millisecond
+
+// checkpointInterval is how often the checkpoint is saved while following,
+// besides when done or interrupted.
+const checkpointInterval = time.Second
+
+// isTerminal reports whether f is a terminal, which gets its lines as they
+// come.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// columnsEnv is the width of the terminal as COLUMNS tells, or zero.
+func columnsEnv() int {
+	n, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// linesEnv is the height of the terminal as LINES tells, or zero.
+func linesEnv() int {
+	n, err := strconv.Atoi(os.Getenv("LINES"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// notifyInterruptStats writes the summary of stats to stderr when
+// interrupted, and then exits, unless interrupts are ignored.
+func notifyInterruptStats(stats *humanlog.Stats) {
+	onInterrupt(func() { stats.WriteTo(os.Stderr) })
+}
+
+var (
+	interruptMu    sync.Mutex
+	interruptHooks []func()
+)
+
+// onInterrupt calls f when interrupted, along with the others asked for,
+// before exiting, unless interrupts are ignored.
+func onInterrupt(f func()) {
+	if signal.Ignored(os.Interrupt) {
+		return
+	}
+	interruptMu.Lock()
+	defer interruptMu.Unlock()
+	interruptHooks = append(interruptHooks, f)
+	if len(interruptHooks) > 1 {
+		return
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		interruptMu.Lock()
+		for _, f := range interruptHooks {
+			f()
+		}
+		os.Exit(130)
+	}()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jigish/humanlog"
+)
+
+func TestReadCommandMaxLines(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh to run")
+	}
+	opts := *humanlog.DefaultOptions
+	opts.MaxLines = 2
+	out := bytes.NewBuffer(nil)
+	errc := make(chan error, 1)
+	go func() {
+		// like journalctl --follow, it never ends on its own
+		errc <- readCommand(out, &opts, "sh", "-c", `while :; do echo 'level=info msg="following"'; sleep 0.01; done`)
+	}()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the command stopped once enough lines were read")
+	}
+	if got := strings.Count(out.String(), "following"); got != 2 {
+		t.Fatalf("want 2 lines, got %d in %q", got, out.String())
+	}
+}
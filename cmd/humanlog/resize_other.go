@@ -0,0 +1,8 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+// onResize does nothing, as there is no SIGWINCH to tell when the terminal
+// is resized on this platform.
+func onResize(f func()) {}
@@ -0,0 +1,12 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import "github.com/jigish/humanlog"
+
+// notifyStats writes the summary of stats to stderr on interrupts before
+// exiting, unless they are ignored, as there is no SIGUSR1 to ask for it.
+func notifyStats(stats *humanlog.Stats) {
+	notifyInterruptStats(stats)
+}
@@ -0,0 +1,25 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jigish/humanlog"
+)
+
+// notifyStats writes the summary of stats to stderr on SIGUSR1, and on
+// interrupts before exiting, unless they are ignored.
+func notifyStats(stats *humanlog.Stats) {
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	go func() {
+		for range usr1 {
+			stats.WriteTo(os.Stderr)
+		}
+	}()
+	notifyInterruptStats(stats)
+}
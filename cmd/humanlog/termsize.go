@@ -0,0 +1,30 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth is how many columns the terminal f is, or else the width
+// COLUMNS tells, or zero if neither is known.
+func terminalWidth(f *os.File) int {
+	cols, _ := terminalSize(f)
+	return cols
+}
+
+// terminalSize is how many columns and rows the terminal f is, or else
+// what COLUMNS and LINES tell, or zero for what isn't known.
+func terminalSize(f *os.File) (cols, rows int) {
+	var ws struct {
+		rows, cols, xpixel, ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	if errno == 0 && ws.cols > 0 && ws.rows > 0 {
+		return int(ws.cols), int(ws.rows)
+	}
+	return columnsEnv(), linesEnv()
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import "os"
+
+// terminalWidth is the width COLUMNS tells, as the size of the terminal
+// isn't looked up on this platform, or zero.
+func terminalWidth(f *os.File) int {
+	return columnsEnv()
+}
+
+// terminalSize is the size COLUMNS and LINES tell, or zero for what they
+// don't.
+func terminalSize(f *os.File) (cols, rows int) {
+	return columnsEnv(), linesEnv()
+}
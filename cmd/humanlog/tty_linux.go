@@ -0,0 +1,9 @@
+package main
+
+import "syscall"
+
+// the requests to get and set the attributes of a terminal
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
@@ -0,0 +1,20 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// rawTerminal can't put terminals in raw mode on this platform.
+func rawTerminal(f *os.File) (restore func(), err error) {
+	return nil, errors.New("terminals can't be put in raw mode on this platform")
+}
+
+// keysTerminal can't have terminals pass keys as they are pressed on this
+// platform.
+func keysTerminal(f *os.File) (restore func(), err error) {
+	return nil, errors.New("terminals can't pass keys as they are pressed on this platform")
+}
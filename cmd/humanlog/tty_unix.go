@@ -0,0 +1,47 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// rawTerminal puts the terminal f in raw mode, where keys are read as they
+// are pressed, without being echoed or acted on, until restored.
+func rawTerminal(f *os.File) (restore func(), err error) {
+	return setTerminal(f, func(t *syscall.Termios) {
+		t.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+		t.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	})
+}
+
+// keysTerminal has the terminal f pass keys as they are pressed, without
+// echoing them, until restored. Unlike in raw mode, ctrl-c still
+// interrupts, and lines written still start at the left.
+func keysTerminal(f *os.File) (restore func(), err error) {
+	return setTerminal(f, func(t *syscall.Termios) {
+		t.Lflag &^= syscall.ECHO | syscall.ICANON
+	})
+}
+
+// setTerminal changes the attributes of the terminal f with set, until
+// restored, having keys read one at a time.
+func setTerminal(f *os.File, set func(*syscall.Termios)) (restore func(), err error) {
+	var old syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlGetTermios, uintptr(unsafe.Pointer(&old))); errno != 0 {
+		return nil, errno
+	}
+	t := old
+	set(&t)
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlSetTermios, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return nil, errno
+	}
+	return func() {
+		syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), ioctlSetTermios, uintptr(unsafe.Pointer(&old)))
+	}, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jigish/humanlog"
+)
+
+// viewerRedrawInterval is how often the viewer is drawn again, at most,
+// while lines come in.
+const viewerRedrawInterval = 100 * time.Millisecond
+
+// viewerSession runs a humanlog.Viewer full screen. Keys are read from and
+// the screen drawn on /dev/tty, so that the logs can come from stdin.
+type viewerSession struct {
+	viewer  *humanlog.Viewer
+	tty     *os.File
+	restore func()
+
+	keyed    chan struct{}
+	quit     chan struct{}
+	done     chan struct{}
+	finished chan struct{}
+}
+
+// startViewer takes over the terminal until the viewer is quit, and exits
+// then if the input hasn't ended.
+func startViewer() (*viewerSession, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	restore, err := rawTerminal(tty)
+	if err != nil {
+		tty.Close()
+		return nil, err
+	}
+	s := &viewerSession{
+		viewer:   humanlog.NewViewer(0),
+		tty:      tty,
+		restore:  restore,
+		keyed:    make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	// the alternate screen, without a cursor
+	tty.WriteString("\x1b[?1049h\x1b[?25l")
+	go s.readKeys()
+	go s.draw()
+	// drawn again right away at the new size
+	onResize(func() {
+		select {
+		case s.keyed <- struct{}{}:
+		default:
+		}
+	})
+	go func() {
+		<-s.done
+		select {
+		case <-s.finished:
+		default:
+			os.Exit(0)
+		}
+	}()
+	return s, nil
+}
+
+func (s *viewerSession) readKeys() {
+	buf := make([]byte, 256)
+	for {
+		n, err := s.tty.Read(buf)
+		if err != nil {
+			close(s.quit)
+			return
+		}
+		for _, key := range decodeKeys(buf[:n]) {
+			if s.viewer.Key(key) {
+				close(s.quit)
+				return
+			}
+		}
+		select {
+		case s.keyed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// draw draws the viewer right after keys are pressed, and every now and
+// then as lines come in, until it is quit.
+func (s *viewerSession) draw() {
+	tick := time.NewTicker(viewerRedrawInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.quit:
+			s.tty.WriteString("\x1b[?25h\x1b[?1049l")
+			s.restore()
+			s.tty.Close()
+			close(s.done)
+			return
+		case <-s.keyed:
+		case <-tick.C:
+			if !s.viewer.Changed() {
+				continue
+			}
+		}
+		cols, rows := terminalSize(s.tty)
+		s.viewer.Draw(s.tty, cols, rows)
+	}
+}
+
+// wait tells the viewer that the input ended, because of err if not nil,
+// and waits for it to be quit.
+func (s *viewerSession) wait(err error) {
+	s.viewer.End(err)
+	close(s.finished)
+	<-s.done
+}
+
+// escapeKeys are the escape sequences of the keys that send them, as
+// terminals in both normal and application cursor modes do.
+var escapeKeys = []struct{ seq, key string }{
+	{"\x1b[A", "up"}, {"\x1bOA", "up"},
+	{"\x1b[B", "down"}, {"\x1bOB", "down"},
+	{"\x1b[5~", "pgup"}, {"\x1b[6~", "pgdn"},
+	{"\x1b[H", "home"}, {"\x1bOH", "home"}, {"\x1b[1~", "home"},
+	{"\x1b[F", "end"}, {"\x1bOF", "end"}, {"\x1b[4~", "end"},
+}
+
+// decodeKeys names the keys read from a terminal in raw mode, the way
+// humanlog.Viewer.Key takes them.
+func decodeKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			key, n := decodeEscape(b)
+			if key != "" {
+				keys = append(keys, key)
+			}
+			b = b[n:]
+			continue
+		case c == '\r' || c == '\n':
+			keys = append(keys, "enter")
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, "backspace")
+		case c < 0x20:
+			keys = append(keys, "ctrl-"+string(rune('a'+c-1)))
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// decodeEscape names the key of the escape sequence b starts with, if it
+// is known, along with its length. A lone escape is the esc key.
+func decodeEscape(b []byte) (string, int) {
+	for _, k := range escapeKeys {
+		if bytes.HasPrefix(b, []byte(k.seq)) {
+			return k.key, len(k.seq)
+		}
+	}
+	if len(b) < 2 || (b[1] != '[' && b[1] != 'O') {
+		return "esc", 1
+	}
+	// the unknown sequences end with a byte from @ to ~
+	for i := 2; i < len(b); i++ {
+		if b[i] >= '@' && b[i] <= '~' {
+			return "", i + 1
+		}
+	}
+	return "", len(b)
+}
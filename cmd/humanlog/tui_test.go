@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jigish/humanlog"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"q", []string{"q"}},
+		{"/é\r", []string{"/", "é", "enter"}},
+		{"\x1b[A\x1bOB\x1b[5~\x1b[6~", []string{"up", "down", "pgup", "pgdn"}},
+		{"\x1b", []string{"esc"}},
+		{"\x1bx", []string{"esc", "x"}},
+		{"\x1b[1;5Cj", []string{"j"}},
+		{"\x7f\x03\x06", []string{"backspace", "ctrl-c", "ctrl-f"}},
+	}
+	for _, test := range tests {
+		if got := decodeKeys([]byte(test.in)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("decodeKeys(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestControlKey(t *testing.T) {
+	controls := humanlog.NewControls(humanlog.DefaultOptions)
+	for _, test := range []struct{ key, want string }{
+		{"s", "skip-unchanged on"},
+		{"c", "colors off"},
+		{"c", "colors on"},
+		{"l", "lines from level debug"},
+		{"l", "lines from level info"},
+		{"x", ""},
+	} {
+		if got := controlKey(controls, test.key); got != test.want {
+			t.Errorf("controlKey(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/jigish/humanlog"
+)
+
+// footerColor sets the values watched apart from the lines above them.
+var footerColor = color.New(color.ReverseVideo)
+
+// footerWriter keeps the values of a humanlog.Watch on the last line of
+// the terminal, below the lines written to it. The footer is erased before
+// lines are written, and drawn again after them.
+type footerWriter struct {
+	mu    sync.Mutex
+	out   io.Writer
+	watch *humanlog.Watch
+	// how many columns the terminal is
+	width func() int
+	drawn bool
+}
+
+func (f *footerWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.drawn {
+		io.WriteString(f.out, "\r\x1b[K")
+		f.drawn = false
+	}
+	n, err := f.out.Write(p)
+	if err != nil || !bytes.HasSuffix(p, []byte("\n")) {
+		// the footer would end up in the middle of a line
+		return n, err
+	}
+	if line := f.watch.Line(f.width()); line != "" {
+		io.WriteString(f.out, footerColor.Sprint(line))
+		f.drawn = true
+	}
+	return n, nil
+}
+
+// Close leaves the footer as it was last drawn, on a line of its own.
+func (f *footerWriter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.drawn {
+		io.WriteString(f.out, "\n")
+		f.drawn = false
+	}
+	return nil
+}
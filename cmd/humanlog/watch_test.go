@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/jigish/humanlog"
+)
+
+func TestFooterWriter(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	opts := *humanlog.DefaultOptions
+	opts.Watch = humanlog.NewWatch([]string{"shard"})
+	out := bytes.NewBuffer(nil)
+	footer := &footerWriter{out: out, watch: opts.Watch, width: func() int { return 80 }}
+
+	w := humanlog.NewWriter(footer, &opts)
+	w.Write([]byte(`{"time":"2024-01-02T03:04:05Z","level":"info","msg":"first","shard":"1"}` + "\n"))
+	w.Write([]byte(`{"time":"2024-01-02T03:04:06Z","level":"info","msg":"second","shard":"2"}` + "\n"))
+	w.Close()
+	footer.Close()
+
+	got := out.String()
+	if !strings.Contains(got, "shard=1\r\x1b[K") {
+		t.Fatalf("want the footer erased before the next line, got %q", got)
+	}
+	if !strings.HasSuffix(got, "\nshard=2 (1 change)\n") {
+		t.Fatalf("want the last footer left below the lines, got %q", got)
+	}
+}
@@ -0,0 +1,176 @@
+package humanlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// Colors16 paints with the 16 ANSI colors only, which any color
+	// terminal has.
+	Colors16 = 16
+	// Colors256 paints with the 256 colors of xterm.
+	Colors256 = 256
+	// ColorsTrue paints with 24-bit colors.
+	ColorsTrue = 1 << 24
+)
+
+// ColorDepthFromEnv tells how many colors a terminal has from its TERM and
+// COLORTERM environment variables.
+func ColorDepthFromEnv(term, colorterm string) int {
+	switch {
+	case colorterm == "truecolor" || colorterm == "24bit" || strings.HasSuffix(term, "-direct"):
+		return ColorsTrue
+	case strings.Contains(term, "256color"):
+		return Colors256
+	default:
+		return Colors16
+	}
+}
+
+// ansi16 are the usual values of the 16 ANSI colors, in order, to find the
+// closest one to a color a terminal doesn't have.
+var ansi16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the values of each component of the 6x6x6 color cube of
+// the 256 colors.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// parseColor parses colors joined by `+`, like `bold+bgred`. Each is one
+// of templateColors, a color of the 256 by its number, like `208`, or a
+// 24-bit one, like `#ff8700`, and is a background color when prefixed
+// with `bg`. Colors the ColorDepth doesn't have are painted with the
+// closest it has.
+func (h *HandlerOptions) parseColor(spec string) (*color.Color, error) {
+	if spec == "none" {
+		return noColor(), nil
+	}
+	var attrs []color.Attribute
+	for _, name := range strings.Split(strings.ToLower(spec), "+") {
+		if attr, ok := templateColors[name]; ok {
+			attrs = append(attrs, attr)
+			continue
+		}
+		bg := strings.HasPrefix(name, "bg")
+		name = strings.TrimPrefix(name, "bg")
+		var a []color.Attribute
+		if attr, ok := templateColors[name]; ok && bg && attr >= color.FgBlack {
+			// background colors are 10 past their foreground ones
+			a = []color.Attribute{attr + 10}
+		} else if rgb, ok := parseRGB(name); ok {
+			a = h.rgbAttrs(rgb, bg)
+		} else if n, err := strconv.Atoi(name); err == nil && n >= 0 && n < 256 {
+			a = h.indexAttrs(n, bg)
+		} else {
+			return nil, fmt.Errorf("unknown color %q", name)
+		}
+		attrs = append(attrs, a...)
+	}
+	return color.New(attrs...), nil
+}
+
+// parseRGB parses a 24-bit color like `#ff8700`.
+func parseRGB(s string) ([3]int, bool) {
+	if len(s) != 7 || s[0] != '#' {
+		return [3]int{}, false
+	}
+	n, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return [3]int{}, false
+	}
+	return [3]int{int(n >> 16), int(n >> 8 & 0xff), int(n & 0xff)}, true
+}
+
+// rgbAttrs paints with a 24-bit color, or the closest of ColorDepth.
+func (h *HandlerOptions) rgbAttrs(rgb [3]int, bg bool) []color.Attribute {
+	switch h.ColorDepth {
+	case ColorsTrue:
+		return []color.Attribute{extendedAttr(bg), 2, color.Attribute(rgb[0]), color.Attribute(rgb[1]), color.Attribute(rgb[2])}
+	case Colors256:
+		return h.indexAttrs(closestIndex(rgb), bg)
+	default:
+		return ansiAttrs(closestANSI(rgb), bg)
+	}
+}
+
+// indexAttrs paints with one of the 256 colors, or the closest of
+// ColorDepth.
+func (h *HandlerOptions) indexAttrs(n int, bg bool) []color.Attribute {
+	switch {
+	case n < 16:
+		return ansiAttrs(n, bg)
+	case h.ColorDepth >= Colors256:
+		return []color.Attribute{extendedAttr(bg), 5, color.Attribute(n)}
+	default:
+		return ansiAttrs(closestANSI(indexRGB(n)), bg)
+	}
+}
+
+func extendedAttr(bg bool) color.Attribute {
+	if bg {
+		return 48
+	}
+	return 38
+}
+
+// ansiAttrs paints with the nth of the 16 ANSI colors.
+func ansiAttrs(n int, bg bool) []color.Attribute {
+	attr := color.FgBlack + color.Attribute(n)
+	if n >= 8 {
+		attr = color.FgHiBlack + color.Attribute(n-8)
+	}
+	if bg {
+		attr += 10
+	}
+	return []color.Attribute{attr}
+}
+
+// indexRGB is the 24-bit color of the nth of the 256 colors, past the 16
+// ANSI ones: a 6x6x6 cube, then 24 grays.
+func indexRGB(n int) [3]int {
+	if n >= 232 {
+		gray := 8 + (n-232)*10
+		return [3]int{gray, gray, gray}
+	}
+	n -= 16
+	return [3]int{cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]}
+}
+
+// closestIndex finds the closest of the 256 colors to rgb, among the cube
+// and the grays, whose values don't depend on the terminal.
+func closestIndex(rgb [3]int) int {
+	best, bestDist := 16, -1
+	for n := 16; n < 256; n++ {
+		if d := distance(rgb, indexRGB(n)); bestDist < 0 || d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+// closestANSI finds the closest of the 16 ANSI colors to rgb.
+func closestANSI(rgb [3]int) int {
+	best, bestDist := 0, -1
+	for n, c := range ansi16 {
+		if d := distance(rgb, c); bestDist < 0 || d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+func distance(a, b [3]int) int {
+	d := 0
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return d
+}
@@ -0,0 +1,64 @@
+package humanlog
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestColorDepthFromEnv(t *testing.T) {
+	tests := []struct {
+		term, colorterm string
+		want            int
+	}{
+		{"xterm", "", Colors16},
+		{"xterm-256color", "", Colors256},
+		{"screen-256color", "truecolor", ColorsTrue},
+		{"xterm-direct", "", ColorsTrue},
+		{"", "24bit", ColorsTrue},
+	}
+	for _, test := range tests {
+		if got := ColorDepthFromEnv(test.term, test.colorterm); got != test.want {
+			t.Errorf("TERM=%q COLORTERM=%q: want %d, got %d", test.term, test.colorterm, test.want, got)
+		}
+	}
+}
+
+func TestParseColorDepth(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	tests := []struct {
+		spec  string
+		depth int
+		want  string
+	}{
+		{"bold+red", Colors16, "\x1b[1;31mx\x1b[0m"},
+		{"bghiblue", Colors16, "\x1b[104mx\x1b[0m"},
+		{"#ff8700", ColorsTrue, "\x1b[38;2;255;135;0mx\x1b[0m"},
+		{"bg#ff8700", ColorsTrue, "\x1b[48;2;255;135;0mx\x1b[0m"},
+		{"#ff8700", Colors256, "\x1b[38;5;208mx\x1b[0m"},
+		{"#ff8700", Colors16, "\x1b[33mx\x1b[0m"},
+		{"208", ColorsTrue, "\x1b[38;5;208mx\x1b[0m"},
+		{"bg208", Colors16, "\x1b[43mx\x1b[0m"},
+		{"244", Colors16, "\x1b[90mx\x1b[0m"},
+		{"9", Colors256, "\x1b[91mx\x1b[0m"},
+	}
+	for _, test := range tests {
+		opts := HandlerOptions{ColorDepth: test.depth}
+		c, err := opts.parseColor(test.spec)
+		if err != nil {
+			t.Errorf("%s: %v", test.spec, err)
+			continue
+		}
+		if got := c.Sprint("x"); got != test.want {
+			t.Errorf("%s at %d colors: want %q, got %q", test.spec, test.depth, test.want, got)
+		}
+	}
+
+	for _, bad := range []string{"reddish", "256", "#ff87", "bgbold"} {
+		if _, err := DefaultOptions.parseColor(bad); err == nil {
+			t.Errorf("%q: want an error", bad)
+		}
+	}
+}
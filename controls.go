@@ -0,0 +1,86 @@
+package humanlog
+
+import "sync"
+
+// Controls changes some options while lines are being scanned, like when
+// keys are pressed or the terminal is resized, without restarting the
+// stream. The changes apply from the next line on. It is safe for
+// concurrent use.
+type Controls struct {
+	mu            sync.Mutex
+	changed       bool
+	width         int
+	skipUnchanged bool
+	disableColors bool
+	minLevel      string
+}
+
+// NewControls starts from the options opts has.
+func NewControls(opts *HandlerOptions) *Controls {
+	return &Controls{
+		width:         opts.Width,
+		skipUnchanged: opts.SkipUnchanged,
+		disableColors: opts.DisableColors,
+		minLevel:      opts.MinLevel,
+	}
+}
+
+// SetWidth sets how wide lines can be, as the Width option does.
+func (c *Controls) SetWidth(width int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.width = width
+	c.changed = true
+}
+
+// ToggleSkipUnchanged turns SkipUnchanged on or off, and tells which.
+func (c *Controls) ToggleSkipUnchanged() (on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.skipUnchanged = !c.skipUnchanged
+	c.changed = true
+	return c.skipUnchanged
+}
+
+// ToggleColors turns colors on or off, through DisableColors, and tells
+// which.
+func (c *Controls) ToggleColors() (on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disableColors = !c.disableColors
+	c.changed = true
+	return !c.disableColors
+}
+
+// CycleMinLevel moves MinLevel to the next of the levels a Viewer cycles
+// through, from none to debug, info, warn, error and back to none, and
+// tells which it is now.
+func (c *Controls) CycleMinLevel() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	next := viewerLevels[0]
+	for i, level := range viewerLevels {
+		if level == c.minLevel {
+			next = viewerLevels[(i+1)%len(viewerLevels)]
+			break
+		}
+	}
+	c.minLevel = next
+	c.changed = true
+	return next
+}
+
+// apply sets the options changed since the last call on opts. It is called
+// by the goroutine handling the lines, before each of them.
+func (c *Controls) apply(opts *HandlerOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.changed {
+		return
+	}
+	opts.Width = c.width
+	opts.SkipUnchanged = c.skipUnchanged
+	opts.DisableColors = c.disableColors
+	opts.MinLevel = c.minLevel
+	c.changed = false
+}
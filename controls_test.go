@@ -0,0 +1,91 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// steppedReader hands out one line per read, calling before with the
+// index of the line right before handing it out.
+type steppedReader struct {
+	lines  []string
+	before func(i int)
+	i      int
+}
+
+func (r *steppedReader) Read(p []byte) (int, error) {
+	if r.i == len(r.lines) {
+		return 0, io.EOF
+	}
+	r.before(r.i)
+	n := copy(p, r.lines[r.i]+"\n")
+	r.i++
+	return n, nil
+}
+
+func TestControls(t *testing.T) {
+	lines := []string{
+		`{"time":"2024-01-02T03:04:05Z","level":"debug","msg":"first"}`,
+		`{"time":"2024-01-02T03:04:06Z","level":"debug","msg":"second"}`,
+		`{"time":"2024-01-02T03:04:07Z","level":"info","msg":"third"}`,
+		`{"time":"2024-01-02T03:04:08Z","level":"debug","msg":"fourth"}`,
+	}
+	opts := *DefaultOptions
+	opts.DisableColors = true
+	opts.TimeFormat = "15:04:05"
+	opts.Controls = NewControls(&opts)
+	src := &steppedReader{lines: lines, before: func(i int) {
+		switch i {
+		case 1:
+			// none, then debug, then info
+			opts.Controls.CycleMinLevel()
+			if level := opts.Controls.CycleMinLevel(); level != InfoLevel {
+				t.Errorf("want level %q, got %q", InfoLevel, level)
+			}
+		case 3:
+			opts.Controls.CycleMinLevel()
+			opts.Controls.CycleMinLevel()
+			if level := opts.Controls.CycleMinLevel(); level != "" {
+				t.Errorf("want no level, got %q", level)
+			}
+		}
+	}}
+	out := bytes.NewBuffer(nil)
+	if err := Scanner(src, out, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, msg := range []string{"first", "third", "fourth"} {
+		if !strings.Contains(got, msg) {
+			t.Errorf("want %q written, got %q", msg, got)
+		}
+	}
+	if strings.Contains(got, "second") {
+		t.Errorf("want the second line filtered out, got %q", got)
+	}
+}
+
+func TestControlsToggles(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Width = 80
+	c := NewControls(&opts)
+	if !c.ToggleSkipUnchanged() {
+		t.Error("want skip-unchanged on")
+	}
+	if c.ToggleColors() {
+		t.Error("want colors off")
+	}
+	c.SetWidth(120)
+	c.apply(&opts)
+	if !opts.SkipUnchanged || !opts.DisableColors || opts.Width != 120 {
+		t.Fatalf("want the changes applied, got SkipUnchanged=%v DisableColors=%v Width=%d", opts.SkipUnchanged, opts.DisableColors, opts.Width)
+	}
+	// applied once
+	opts.Width = 100
+	c.apply(&opts)
+	if opts.Width != 100 {
+		t.Fatalf("want the options left alone, got width %d", opts.Width)
+	}
+}
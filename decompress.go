@@ -0,0 +1,48 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/jigish/humanlog/internal/zstd"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decompress reads r decompressed if it starts like gzip, bzip2 or zstd
+// data does, and as is otherwise, so that compressed logs can be read
+// without decompressing them first. Concatenated gzip members and zstd
+// frames are read one after the other, like zcat does.
+func Decompress(r io.Reader) (io.Reader, error) {
+	in := bufio.NewReader(r)
+	// only wait for as many bytes as it takes to tell, so that a short
+	// first line of a live stream isn't held back
+	switch {
+	case hasPrefix(in, gzipMagic):
+		return gzip.NewReader(in)
+	case hasPrefix(in, bzip2Magic):
+		return bzip2.NewReader(in), nil
+	case hasPrefix(in, zstdMagic):
+		return zstd.NewReader(in), nil
+	}
+	return in, nil
+}
+
+// hasPrefix reports whether what in holds starts with prefix, reading no
+// more than it takes to tell.
+func hasPrefix(in *bufio.Reader, prefix []byte) bool {
+	for n := 1; n <= len(prefix); n++ {
+		peeked, _ := in.Peek(n)
+		if len(peeked) < n || !bytes.Equal(peeked, prefix[:n]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package humanlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDecompress(t *testing.T) {
+	const text = "level=info msg=one\nlevel=info msg=two\n"
+
+	// concatenated members, as `gzip -c a >> b` makes
+	var gz bytes.Buffer
+	for _, part := range []string{"level=info msg=one\n", "level=info msg=two\n"} {
+		w := gzip.NewWriter(&gz)
+		w.Write([]byte(part))
+		w.Close()
+	}
+	// `printf 'level=info msg=one\nlevel=info msg=two\n' | bzip2 | base64`
+	bz, err := base64.StdEncoding.DecodeString("QlpoOTFBWSZTWRHhmiUAAArZgAAQQAAAAgOnjYAgACEqaBhMQpgACNoMKRRoySOKMOP4uRaEYv4u5IpwoSAjwzRK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// `printf 'level=info msg=one\nlevel=info msg=two\n' | zstd | base64`
+	zst, err := base64.StdEncoding.DecodeString("KLUv/QRY7QAAuGxldmVsPWluZm8gbXNnPW9uZQp0d28KAQCtm2E50p3B")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]byte{
+		"plain": []byte(text),
+		"gzip":  gz.Bytes(),
+		"bzip2": bz,
+		"zstd":  zst,
+	}
+	for name, data := range tests {
+		r, err := Decompress(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(got) != text {
+			t.Fatalf("%s: want %q, got %q", name, text, got)
+		}
+	}
+
+	for _, short := range []string{"", "B", "BZ", "\x1f"} {
+		r, err := Decompress(strings.NewReader(short))
+		if err != nil {
+			t.Fatalf("%q: %v", short, err)
+		}
+		if got, _ := ioutil.ReadAll(r); string(got) != short {
+			t.Fatalf("want %q read as is, got %q", short, got)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package humanlog
+
+// detectConfidence is the share of the lines sampled a handler must have
+// recognized for the stream to be taken to be in its format.
+const detectConfidence = 0.9
+
+// detected tells if the stream was detected to be in the format of the
+// handler named name.
+func (lh *lineHandler) detected(name string) bool {
+	return lh.dominant >= 0 && lh.handlers[lh.dominant].name == name
+}
+
+// detect notes which handler recognized the line just matched. Once
+// DetectLines lines were recognized, the stream is taken to be in the
+// format of the handler that recognized the most of them, if it recognized
+// enough of them. Lines are sampled again all along, for streams that
+// change format to be followed.
+func (lh *lineHandler) detect() {
+	n := lh.opts.DetectLines
+	if n <= 0 || lh.matched < 0 {
+		return
+	}
+	if lh.detectHits == nil {
+		lh.detectHits = make([]int, len(lh.handlers))
+	}
+	lh.detectHits[lh.matched]++
+	lh.detectLines++
+	if lh.detectLines < n {
+		return
+	}
+	best := 0
+	for i, hits := range lh.detectHits {
+		// ties go to the handler tried first otherwise
+		if hits > lh.detectHits[best] {
+			best = i
+		}
+	}
+	lh.dominant = -1
+	if float64(lh.detectHits[best]) >= detectConfidence*float64(lh.detectLines) {
+		lh.dominant = best
+	}
+	for i := range lh.detectHits {
+		lh.detectHits[i] = 0
+	}
+	lh.detectLines = 0
+}
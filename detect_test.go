@@ -0,0 +1,68 @@
+package humanlog
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	jsonLine := `{"time":"2024-01-02T03:04:05Z","level":"info","msg":"served","status":200}`
+	tests := []struct {
+		name        string
+		detectLines int
+		sample      []string
+		line        string
+		want        string
+	}{
+		{
+			name:        "JSON without a time in a stream of JSON logs",
+			detectLines: 3,
+			sample:      []string{jsonLine, jsonLine, jsonLine},
+			line:        `{"level":"info","msg":"level=warn time=\"2024-01-02T03:04:05Z\" msg=\"retrying\""}`,
+			want:        "json",
+		},
+		{
+			name:        "JSON without a time before detecting",
+			detectLines: 0,
+			sample:      []string{jsonLine, jsonLine, jsonLine},
+			line:        `{"level":"info","msg":"level=warn time=\"2024-01-02T03:04:05Z\" msg=\"retrying\""}`,
+			want:        rawFormat,
+		},
+		{
+			name:        "a bunyan line in a stream of JSON logs",
+			detectLines: 3,
+			sample:      []string{jsonLine, jsonLine, jsonLine},
+			line:        `{"name":"myapp","hostname":"box","pid":4242,"level":40,"msg":"slow request","time":"2012-02-03T18:12:48.123Z","v":0}`,
+			want:        "bunyan",
+		},
+		{
+			name:        "falls back on the other handlers",
+			detectLines: 3,
+			sample:      []string{jsonLine, jsonLine, jsonLine},
+			line:        `time="2024-01-02T03:04:05Z" level=info msg="served"`,
+			want:        "logrus",
+		},
+		{
+			name:        "no format detected in a mixed stream",
+			detectLines: 3,
+			sample:      []string{jsonLine, `time="2024-01-02T03:04:05Z" level=info msg="served"`, jsonLine},
+			line:        `{"name":"myapp","hostname":"box","pid":4242,"level":40,"msg":"slow request","time":"2012-02-03T18:12:48.123Z","v":0}`,
+			want:        "bunyan",
+		},
+		{
+			name:        "unrecognized lines aren't sampled",
+			detectLines: 3,
+			sample:      []string{jsonLine, "just text", jsonLine, "more text", jsonLine},
+			line:        `{"level":"info","msg":"no time"}`,
+			want:        "json",
+		},
+	}
+	for _, test := range tests {
+		opts := *DefaultOptions
+		opts.DetectLines = test.detectLines
+		lh := newLineHandler(&opts)
+		for _, line := range test.sample {
+			lh.match([]byte(line))
+		}
+		if got := lh.match([]byte(test.line)); got != test.want {
+			t.Errorf("%s: want format %q, got %q", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// dockerStreams names the streams of the frames of a multiplexed docker
+// log stream, by the first byte of their header.
+var dockerStreams = [...]string{"stdin", "stdout", "stderr"}
+
+// NewDockerLogReader reads the logs of a container as served by the
+// Docker API with `timestamps=1`, and turns them into the lines of docker's
+// json-file format, so that their time and stream are used for the lines
+// that lack them:
+//
+//	{"log":"the line\n","stream":"stdout","time":"2018-10-24T08:19:50.466951Z"}
+//
+// Unless the container has a TTY, the stream is multiplexed: each message
+// comes in a frame with an 8 bytes header telling its stream and length.
+func NewDockerLogReader(src io.Reader, tty bool) io.Reader {
+	return &dockerLogReader{src: bufio.NewReader(src), tty: tty}
+}
+
+type dockerLogReader struct {
+	src *bufio.Reader
+	tty bool
+	out bytes.Buffer
+	err error
+}
+
+func (d *dockerLogReader) Read(p []byte) (int, error) {
+	for d.out.Len() == 0 && d.err == nil {
+		d.err = d.next()
+	}
+	if d.out.Len() > 0 {
+		return d.out.Read(p)
+	}
+	return 0, d.err
+}
+
+// next reads the next message of the stream into out.
+func (d *dockerLogReader) next() error {
+	if d.tty {
+		msg, err := d.src.ReadBytes('\n')
+		if len(msg) > 0 {
+			d.write("stdout", msg)
+		}
+		return err
+	}
+
+	var header [8]byte
+	if _, err := io.ReadFull(d.src, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return err
+	}
+	stream := "stdout"
+	if int(header[0]) < len(dockerStreams) {
+		stream = dockerStreams[header[0]]
+	}
+	msg := make([]byte, binary.BigEndian.Uint32(header[4:]))
+	if _, err := io.ReadFull(d.src, msg); err != nil {
+		return err
+	}
+	d.write(stream, msg)
+	return nil
+}
+
+// write writes the message out as a json-file line, or as is if it doesn't
+// start with a timestamp.
+func (d *dockerLogReader) write(stream string, msg []byte) {
+	msg = bytes.TrimRight(msg, "\r\n")
+	i := bytes.IndexByte(msg, ' ')
+	if i < 0 {
+		i = len(msg)
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(msg[:i]))
+	if err != nil {
+		d.out.Write(msg)
+		d.out.WriteByte('\n')
+		return
+	}
+	if i < len(msg) {
+		i++
+	}
+	line, _ := marshalJSON(struct {
+		Log    string `json:"log"`
+		Stream string `json:"stream"`
+		Time   string `json:"time"`
+	}{
+		Log:    string(msg[i:]) + "\n",
+		Stream: stream,
+		Time:   t.Format(time.RFC3339Nano),
+	}, "")
+	d.out.WriteString(line)
+	d.out.WriteByte('\n')
+}
@@ -0,0 +1,59 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func dockerFrame(stream byte, msg string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(msg)))
+	return append(header, msg...)
+}
+
+func TestDockerLogReader(t *testing.T) {
+	var src bytes.Buffer
+	src.Write(dockerFrame(1, "2018-10-24T08:19:50.466951Z level=info msg=\"<started>\"\n"))
+	src.Write(dockerFrame(2, "2018-10-24T08:19:51Z oops\n"))
+	src.Write(dockerFrame(1, "no timestamp\n"))
+
+	got, err := ioutil.ReadAll(NewDockerLogReader(&src, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"log":"level=info msg=\"<started>\"\n","stream":"stdout","time":"2018-10-24T08:19:50.466951Z"}` + "\n" +
+		`{"log":"oops\n","stream":"stderr","time":"2018-10-24T08:19:51Z"}` + "\n" +
+		"no timestamp\n"
+	if string(got) != want {
+		t.Fatalf("want\n%s\ngot\n%s", want, got)
+	}
+
+	tty := strings.NewReader("2018-10-24T08:19:50Z hello\r\n2018-10-24T08:19:51Z world")
+	got, err = ioutil.ReadAll(NewDockerLogReader(tty, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `{"log":"hello\n","stream":"stdout","time":"2018-10-24T08:19:50Z"}` + "\n" +
+		`{"log":"world\n","stream":"stdout","time":"2018-10-24T08:19:51Z"}` + "\n"
+	if string(got) != want {
+		t.Fatalf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestDockerLogReaderPrettified(t *testing.T) {
+	src := bytes.NewBuffer(dockerFrame(1, "2018-10-24T08:19:50Z plain text\n"))
+
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(NewDockerLogReader(src, false), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.String(); !strings.HasPrefix(got, "[docker]") || !strings.Contains(got, "Oct 24 08:19:50") || !strings.Contains(got, "plain text") {
+		t.Fatalf("want the line unwrapped with its time, got %q", got)
+	}
+}
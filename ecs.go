@@ -0,0 +1,36 @@
+package humanlog
+
+// unwrapECS reshapes an Elastic Common Schema document into the usual keys:
+// `log.level` becomes the level and the ECS version is hidden. Nested
+// objects are flattened into the dotted keys ECS is usually written with.
+// It reports whether raw was such a document.
+func unwrapECS(raw map[string]interface{}) bool {
+	if _, ok := raw["ecs.version"]; ok {
+		delete(raw, "ecs.version")
+	} else if ecs, ok := raw["ecs"].(map[string]interface{}); ok && ecs["version"] != nil {
+		delete(raw, "ecs")
+	} else {
+		return false
+	}
+
+	for key, val := range raw {
+		if obj, ok := val.(map[string]interface{}); ok {
+			delete(raw, key)
+			flattenValue(raw, key, obj)
+		}
+	}
+
+	if level, ok := raw["log.level"].(string); ok {
+		delete(raw, "log.level")
+		if _, ok := raw["level"]; !ok {
+			raw["level"] = level
+		}
+	}
+	if stack, ok := raw["error.stack_trace"].(string); ok {
+		delete(raw, "error.stack_trace")
+		if _, ok := raw["stacktrace"]; !ok {
+			raw["stacktrace"] = stack
+		}
+	}
+	return true
+}
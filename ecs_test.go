@@ -0,0 +1,44 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnwrapECS(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := JSONHandler{Opts: &opts}
+
+	ev := []byte(`{"@timestamp":"2023-11-30T10:00:00.123Z","log.level":"warn","message":"cache miss","ecs.version":"1.6.0","service.name":"api","log":{"logger":"cache"}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Date(2023, 11, 30, 10, 0, 0, 123000000, time.UTC); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	out := string(h.Prettify(false))
+	for _, want := range []string{"|WARN| cache miss", `service.name="api"`, `log.logger="cache"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+	for _, hidden := range []string{"ecs.version", "@timestamp", "log.level"} {
+		if strings.Contains(out, hidden) {
+			t.Fatalf("want %s hidden, got %q", hidden, out)
+		}
+	}
+
+	ev = []byte(`{"@timestamp":"2023-11-30T10:00:00.123Z","log":{"level":"error"},"message":"boom","ecs":{"version":"8.0.0"},"error":{"type":"IOError","stack_trace":"at a\nat b"}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	out = string(h.Prettify(false))
+	if !strings.Contains(out, "|ERRO| boom") || !strings.Contains(out, `error.type="IOError"`) {
+		t.Fatalf("want nested ECS objects flattened, got %q", out)
+	}
+	if !strings.HasSuffix(out, "\n    at a\n    at b") {
+		t.Fatalf("want the stack trace below the line, got %q", out)
+	}
+}
@@ -0,0 +1,76 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// embeddedObject parses v as a JSON object, if it is one, whether quoted
+// or not. The values of logfmt lines are kept escaped, as in
+// `{\"a\":1}`.
+func embeddedObject(v string) (map[string]interface{}, bool) {
+	if unquoted, err := strconv.Unquote(v); err == nil {
+		v = unquoted
+	} else if unescaped, err := strconv.Unquote(`"` + v + `"`); err == nil {
+		v = unescaped
+	}
+	v = strings.TrimSpace(v)
+	if !strings.HasPrefix(v, "{") || !strings.HasSuffix(v, "}") {
+		return nil, false
+	}
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(v), &obj); err != nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+// parseEmbeddedFields does for the fields of ev what parseEmbeddedJSON does
+// for JSON lines, whatever their format: the values that are JSON objects
+// are spread into fields with dotted keys, or written as indented JSON
+// below the line with NestedObjectsExpand. The fields of ev are copied
+// before any is changed.
+func (h *HandlerOptions) parseEmbeddedFields(ev Event) Event {
+	var fields, nested map[string]string
+	for key, v := range ev.Fields {
+		obj, ok := embeddedObject(v)
+		if !ok {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]string, len(ev.Fields))
+			for k, v := range ev.Fields {
+				fields[k] = v
+			}
+		}
+		delete(fields, key)
+		if h.NestedObjects == NestedObjectsExpand {
+			str, err := marshalJSON(obj, "  ")
+			if err != nil {
+				fields[key] = v
+				continue
+			}
+			if nested == nil {
+				nested = make(map[string]string, len(ev.nested)+1)
+				for k, v := range ev.nested {
+					nested[k] = v
+				}
+			}
+			nested[key] = str
+			continue
+		}
+		flat := make(map[string]interface{})
+		flattenValue(flat, key, obj)
+		for k, v := range flat {
+			fields[k] = h.formatValue(v)
+		}
+	}
+	if fields != nil {
+		ev.Fields = fields
+	}
+	if nested != nil {
+		ev.nested = nested
+	}
+	return ev
+}
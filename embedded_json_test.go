@@ -0,0 +1,47 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseEmbeddedFields(t *testing.T) {
+	src := `time="2024-01-02T03:04:05Z" level=info msg=received payload="{\"a\":1,\"user\":{\"id\":\"u1\"}}" size=3`
+
+	opts := *DefaultOptions
+	opts.ParseEmbeddedJSON = true
+	opts.Truncates = false
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	out := dst.String()
+	for _, want := range []string{"payload.a=1", `payload.user.id="u1"`, "size=3"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+	if strings.Contains(out, `\"`) {
+		t.Fatalf("want no escaped JSON left, got %q", out)
+	}
+
+	opts.NestedObjects = NestedObjectsExpand
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	out = dst.String()
+	if !strings.Contains(out, "\n      \"a\": 1,") || strings.Contains(out, "payload.a") {
+		t.Fatalf("want payload pretty-printed below the line, got %q", out)
+	}
+
+	opts.ParseEmbeddedJSON = false
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dst.String(), `payload={\"a\":1`) {
+		t.Fatalf("want embedded JSON left alone by default, got %q", dst.String())
+	}
+}
@@ -0,0 +1,352 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// envelope is what wrapped a line in a container runtime's log file, like
+// docker's json-file or the CRI format. The line itself is run through the
+// handlers, the envelope only filling in what the line lacks.
+type envelope struct {
+	// format shown for lines no handler recognized
+	format string
+	time   time.Time
+	fields map[string]string
+	// normalized level, given to lines that don't tell theirs
+	level string
+	// also give the fields to the lines handlers recognized, for those
+	// that don't have them already
+	fieldsAlways bool
+	// give the time to the lines handlers recognized too, over their own
+	timeAlways bool
+	// the line along with its envelope, run through the handlers instead
+	// when none recognized the line it wrapped
+	original []byte
+}
+
+// unwrapDocker takes the line out of docker's json-file envelope:
+//
+//	{"log":"the line\n","stream":"stdout","time":"2018-10-24T08:19:50.466951Z"}
+func unwrapDocker(line []byte) ([]byte, *envelope, bool) {
+	if !bytes.HasPrefix(line, []byte(`{"log":`)) {
+		return nil, nil, false
+	}
+	var entry struct {
+		Log    *string `json:"log"`
+		Stream string  `json:"stream"`
+		Time   string  `json:"time"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil || entry.Log == nil {
+		return nil, nil, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, entry.Time)
+	if err != nil {
+		return nil, nil, false
+	}
+	env := &envelope{
+		format:       "docker",
+		time:         t,
+		fields:       map[string]string{},
+		timeAlways:   true,
+		fieldsAlways: true,
+	}
+	if entry.Stream != "" {
+		env.fields["stream"] = entry.Stream
+	}
+	inner := bytes.TrimRight([]byte(*entry.Log), "\r\n")
+	return inner, env, true
+}
+
+// unwrapCRI takes the line out of the prefix containerd and CRI-O put in
+// front of it:
+//
+//	2024-01-02T03:04:05.123456789Z stdout F the line
+//
+// Lines split by the runtime, tagged P rather than F, are unwrapped too,
+// each of their parts on its own.
+func unwrapCRI(line []byte) ([]byte, *envelope, bool) {
+	if !startsWithDigit(line) {
+		return nil, nil, false
+	}
+	parts := bytes.SplitN(line, []byte(" "), 4)
+	if len(parts) < 3 {
+		return nil, nil, false
+	}
+	stream := string(parts[1])
+	if stream != "stdout" && stream != "stderr" {
+		return nil, nil, false
+	}
+	if tag := parts[2]; len(tag) == 0 || (tag[0] != 'F' && tag[0] != 'P') {
+		return nil, nil, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(parts[0]))
+	if err != nil {
+		return nil, nil, false
+	}
+	var inner []byte
+	if len(parts) == 4 {
+		inner = parts[3]
+	}
+	return inner, &envelope{
+		format: "cri",
+		time:   t,
+		fields: map[string]string{"stream": stream},
+	}, true
+}
+
+var lambdaLogStream = regexp.MustCompile(`^\d{4}/\d{2}/\d{2}/\[[^\]]+\]\w+$`)
+
+// unwrapAWSTail takes the line out of what `aws logs tail` puts in front of
+// the lines of Lambda functions:
+//
+//	2024-01-02T03:04:05.123000+00:00 2024/01/02/[$LATEST]0123abcd the line
+func unwrapAWSTail(line []byte) ([]byte, *envelope, bool) {
+	if !startsWithDigit(line) {
+		return nil, nil, false
+	}
+	parts := bytes.SplitN(line, []byte(" "), 3)
+	if len(parts) < 2 || !lambdaLogStream.Match(parts[1]) {
+		return nil, nil, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(parts[0]))
+	if err != nil {
+		return nil, nil, false
+	}
+	var inner []byte
+	if len(parts) == 3 {
+		inner = parts[2]
+	}
+	return inner, &envelope{
+		format: "aws",
+		time:   t,
+		fields: map[string]string{"logStream": string(parts[1])},
+	}, true
+}
+
+// startsWithDigit reports whether line may start with a timestamp, to spare
+// the lines that can't the cost of parsing one.
+func startsWithDigit(line []byte) bool {
+	return len(line) > 0 && line[0] >= '0' && line[0] <= '9'
+}
+
+var herokuTailPrefix = regexp.MustCompile(`^(\S+) ([^\s\[\]]+)\[([^\]\s]+)\]: `)
+
+// unwrapHerokuTail takes the line out of what `heroku logs` puts in front of
+// it:
+//
+//	2024-01-02T03:04:05.123456+00:00 app[web.1]: the line
+//
+// The lines of Heroku's router tell their level with `at`, and are info
+// otherwise.
+func unwrapHerokuTail(line []byte) ([]byte, *envelope, bool) {
+	if !startsWithDigit(line) {
+		return nil, nil, false
+	}
+	m := herokuTailPrefix.FindSubmatchIndex(line)
+	if m == nil {
+		return nil, nil, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(line[m[2]:m[3]]))
+	if err != nil {
+		return nil, nil, false
+	}
+	inner := line[m[1]:]
+	app, proc := string(line[m[4]:m[5]]), string(line[m[6]:m[7]])
+	env := &envelope{
+		format:       "logplex",
+		time:         t,
+		fields:       map[string]string{"app": app, "proc": proc},
+		fieldsAlways: true,
+	}
+	if app == "heroku" && proc == "router" {
+		env.level = InfoLevel
+		if bytes.HasPrefix(inner, []byte("at=")) {
+			at := bytes.Fields(inner[len("at="):])
+			if len(at) > 0 && normalizeLevel(string(at[0])) != UnknownLevel {
+				env.level = normalizeLevel(string(at[0]))
+			}
+		}
+	}
+	return inner, env, true
+}
+
+var actionsTimestamp = regexp.MustCompile(`^\x{feff}?(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{7}Z) `)
+
+// unwrapActions takes the line out of the timestamp GitHub Actions puts in
+// front of every line of the logs of a job, to the tenth of a microsecond:
+//
+//	2024-01-02T03:04:05.1234567Z the line
+//
+// The first line of a downloaded log starts with a byte order mark.
+func unwrapActions(line []byte) ([]byte, *envelope, bool) {
+	if len(line) < 29 {
+		return nil, nil, false
+	}
+	m := actionsTimestamp.FindSubmatchIndex(line)
+	if m == nil {
+		return nil, nil, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(line[m[2]:m[3]]))
+	if err != nil {
+		return nil, nil, false
+	}
+	return line[m[1]:], &envelope{
+		format: "actions",
+		time:   t,
+		fields: map[string]string{},
+	}, true
+}
+
+// unwrapSystemd takes the line out of the priority prefix that programs
+// running under systemd put in front of it, as described in sd-daemon(3):
+//
+//	<6>the line
+//
+// Syslog lines, whose priority is followed by a header, are left as is.
+func unwrapSystemd(line []byte) ([]byte, *envelope, bool) {
+	if len(line) < 3 || line[0] != '<' || line[1] < '0' || line[1] > '7' || line[2] != '>' {
+		return nil, nil, false
+	}
+	inner := line[3:]
+	if bytes.HasPrefix(inner, []byte("1 ")) || bsdSyslogHeader.Match(inner) {
+		return nil, nil, false
+	}
+	return inner, &envelope{
+		format: "systemd",
+		fields: map[string]string{},
+		level:  normalizeSyslogLevel(string(line[1])),
+	}, true
+}
+
+// unwrapKafka takes the value out of a Kafka record, as consumed with
+// `kcat -J`:
+//
+//	{"topic":"logs","partition":0,"offset":42,"tstype":"create","ts":1540369190466,"headers":["k","v"],"key":null,"payload":"the line"}
+//
+// Its partition, offset and headers are given as fields when KafkaMetadata
+// is set.
+func (h *HandlerOptions) unwrapKafka(line []byte) ([]byte, *envelope, bool) {
+	if !bytes.HasPrefix(line, []byte(`{"topic":`)) {
+		return nil, nil, false
+	}
+	var record struct {
+		Topic     string   `json:"topic"`
+		Partition *int     `json:"partition"`
+		Offset    int64    `json:"offset"`
+		TS        int64    `json:"ts"`
+		Headers   []string `json:"headers"`
+		Payload   *string  `json:"payload"`
+	}
+	if err := json.Unmarshal(line, &record); err != nil || record.Partition == nil || record.Payload == nil {
+		return nil, nil, false
+	}
+	env := &envelope{
+		format:       "kafka",
+		fields:       map[string]string{},
+		fieldsAlways: true,
+	}
+	if record.TS > 0 {
+		env.time = time.Unix(0, record.TS*int64(time.Millisecond)).UTC()
+	}
+	if h.KafkaMetadata {
+		env.fields["partition"] = strconv.Itoa(*record.Partition)
+		env.fields["offset"] = strconv.FormatInt(record.Offset, 10)
+		for i := 0; i+1 < len(record.Headers); i += 2 {
+			env.fields["header."+record.Headers[i]] = record.Headers[i+1]
+		}
+	}
+	inner := bytes.TrimRight([]byte(*record.Payload), "\r\n")
+	return inner, env, true
+}
+
+// journalPayload matches the messages of the journal that are lines of
+// their own, as JSON objects or logfmt, like those of the services that log
+// to stdout in containers.
+var journalPayload = regexp.MustCompile(`^(?:\{.*\}|[\w.-]+=\S*(?: [\w.-]+=.*)?)$`)
+
+// unwrapJournal takes the message out of an entry of the journal, as
+// produced by `journalctl -o json`, when it is a JSON object or logfmt:
+//
+//	{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"level=info msg=\"the line\"","_SYSTEMD_UNIT":"app.service"}
+//
+// The metadata of the entry is given as fields, all of them with
+// JournalVerbose, or otherwise in short as the `journal` field, like
+// `app.service[1234]@web1`. Entries whose message no handler recognizes are
+// left to the journal handler.
+func (h *HandlerOptions) unwrapJournal(line []byte) ([]byte, *envelope, bool) {
+	if !bytes.HasPrefix(line, []byte("{")) || !hasJournalTime(line) {
+		return nil, nil, false
+	}
+	raw, err := decodeObject(line)
+	if err != nil {
+		return nil, nil, false
+	}
+	defer releaseObject(raw)
+	msg, ok := raw["MESSAGE"].(string)
+	if !ok || !journalPayload.MatchString(msg) {
+		return nil, nil, false
+	}
+	entry := JournalJSONHandler{Opts: h}
+	if err := entry.UnmarshalJournalEntry(raw); err != nil {
+		return nil, nil, false
+	}
+	env := &envelope{
+		format:       "journal",
+		time:         entry.Time,
+		fields:       map[string]string{},
+		fieldsAlways: true,
+		original:     line,
+	}
+	if entry.Level != "" {
+		env.level = normalizeSyslogLevel(entry.Level)
+	}
+	if h.JournalVerbose {
+		env.fields = entry.Fields
+	} else if origin := journalOrigin(entry.Fields); origin != "" {
+		env.fields["journal"] = origin
+	}
+	return []byte(msg), env, true
+}
+
+// unwrap takes the line held by lh out of its envelope, if it has one.
+func (lh *lineHandler) unwrap() *envelope {
+	for _, unwrap := range []func([]byte) ([]byte, *envelope, bool){
+		unwrapDocker,
+		unwrapCRI,
+		unwrapAWSTail,
+		unwrapHerokuTail,
+		unwrapActions,
+		unwrapSystemd,
+		lh.opts.unwrapKafka,
+		lh.opts.unwrapJournal,
+	} {
+		if inner, env, ok := unwrap(lh.lineData); ok {
+			lh.lineData = inner
+			return env
+		}
+	}
+	return nil
+}
+
+// applyEnvelope fills in what the line held by lh lacks from env. Lines no
+// handler recognized get the time and fields of the envelope around them.
+func (lh *lineHandler) applyEnvelope(env *envelope) {
+	if lh.format == rawFormat {
+		lh.ev = Event{Time: env.time, Level: UnknownLevel, Msg: string(lh.lineData), untimed: true}
+		lh.setMissingLevel(env.level)
+		lh.setMissingFields(env.fields)
+		lh.format = env.format
+		return
+	}
+	if _, ok := lh.time(); (!ok || env.timeAlways) && !env.time.IsZero() {
+		lh.ev.Time = env.time
+	}
+	lh.setMissingLevel(env.level)
+	if env.fieldsAlways {
+		lh.setMissingFields(env.fields)
+	}
+}
@@ -0,0 +1,220 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnwrapDocker(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+
+	for _, tt := range []struct {
+		line   string
+		format string
+		want   []string
+	}{
+		{
+			line:   `{"log":"{\"time\":\"2018-10-24T08:19:51Z\",\"level\":\"warn\",\"msg\":\"inner json\"}\n","stream":"stdout","time":"2018-10-24T08:19:50.466951Z"}`,
+			format: "[json]",
+			want:   []string{"Oct 24 08:19:50", "|WARN| inner json", "stream=stdout"},
+		},
+		{
+			line:   `{"log":"time=\"2018-10-24T08:19:51Z\" level=error msg=\"inner logfmt\"\n","stream":"stderr","time":"2018-10-24T08:19:50.466951Z"}`,
+			format: "[logrus]",
+			want:   []string{"Oct 24 08:19:50", "|ERRO| inner logfmt", "stream=stderr"},
+		},
+		{
+			line:   `{"log":"Listening on :8080\n","stream":"stdout","time":"2018-10-24T08:19:50.466951Z"}`,
+			format: "[docker]",
+			want:   []string{"Oct 24 08:19:50", "Listening on :8080", `stream=stdout`},
+		},
+		{
+			line:   `{"log":"<13>1 - host app - - - started\n","stream":"stdout","time":"2018-10-24T08:19:50.466951Z"}`,
+			format: "[syslog]",
+			want:   []string{"Oct 24 08:19:50", "started"},
+		},
+	} {
+		out, ok := Prettify([]byte(tt.line), &opts)
+		if !ok {
+			t.Fatalf("want %q handled", tt.line)
+		}
+		got := string(out)
+		if !strings.HasPrefix(got, tt.format) {
+			t.Fatalf("want %s, got %q", tt.format, got)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Fatalf("want %q in %q", want, got)
+			}
+		}
+		if strings.Contains(got, `\n`) {
+			t.Fatalf("want the trailing newline of the payload trimmed, got %q", got)
+		}
+	}
+}
+
+func TestUnwrapCRI(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+
+	out, ok := Prettify([]byte(`2024-01-02T03:04:05.123456789Z stdout F {"time":"2024-01-02T03:04:06Z","level":"info","msg":"inner json"}`), &opts)
+	if !ok || !strings.HasPrefix(string(out), "[json]") || !strings.Contains(string(out), "Jan  2 03:04:06 |INFO| inner json") {
+		t.Fatalf("want the inner JSON prettified, got %q", out)
+	}
+
+	out, ok = Prettify([]byte(`2024-01-02T03:04:05.123Z stderr F panic: oops`), &opts)
+	if !ok || !strings.HasPrefix(string(out), "[cri]") {
+		t.Fatalf("want a plain line wrapped in CRI handled, got %q", out)
+	}
+	for _, want := range []string{"Jan  2 03:04:05", "panic: oops", "stream=stderr"} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+
+	for _, line := range []string{
+		`2024-01-02T03:04:05.123Z stdin F nope`,
+		`2024-01-02T03:04:05.123Z stdout X nope`,
+		`yesterday stdout F nope`,
+	} {
+		if _, ok := Prettify([]byte(line), &opts); ok {
+			t.Fatalf("want %q not handled", line)
+		}
+	}
+}
+
+func TestUnwrapSystemd(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+
+	out, ok := Prettify([]byte(`<4>disk almost full`), &opts)
+	if !ok || !strings.HasPrefix(string(out), "[systemd]") || !strings.Contains(string(out), "|WARN| disk almost full") {
+		t.Fatalf("want the priority used as level, got %q", out)
+	}
+
+	out, ok = Prettify([]byte("<3>time:2024-01-02T03:04:05Z\tmsg:no level here"), &opts)
+	if !ok || !strings.HasPrefix(string(out), "[ltsv]") || !strings.Contains(string(out), "|ERRO| no level here") {
+		t.Fatalf("want the inner line parsed with the priority as level, got %q", out)
+	}
+
+	out, ok = Prettify([]byte(`<6>{"time":"2024-01-02T03:04:05Z","level":"debug","msg":"has its own"}`), &opts)
+	if !ok || !strings.HasPrefix(string(out), "[json]") || !strings.Contains(string(out), "|DEBU| has its own") {
+		t.Fatalf("want the level of the inner line kept, got %q", out)
+	}
+
+	for _, line := range []string{
+		`<6>1 2024-01-02T03:04:05Z host app - - - hello`,
+		`<6>Oct 11 22:14:15 host app[42]: hello`,
+	} {
+		out, _ := Prettify([]byte(line), &opts)
+		if !strings.HasPrefix(string(out), "[syslog]") {
+			t.Fatalf("want %q left to the syslog handler, got %q", line, out)
+		}
+	}
+}
+
+func TestUnwrapKafka(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+	opts.KafkaMetadata = true
+
+	for _, tt := range []struct {
+		line   string
+		format string
+		want   []string
+	}{
+		{
+			line:   `{"topic":"logs","partition":2,"offset":42,"tstype":"create","ts":1540369190466,"broker":1,"headers":["trace","abc"],"key":null,"payload":"{\"time\":\"2018-10-24T08:19:51Z\",\"level\":\"warn\",\"msg\":\"inner json\"}"}`,
+			format: "[json]",
+			want:   []string{"Oct 24 08:19:51", "|WARN| inner json", "partition=2", "offset=42", "header.trace=abc"},
+		},
+		{
+			// no time in the line, so the record's is used
+			line:   `{"topic":"logs","partition":0,"offset":7,"tstype":"create","ts":1540369190466,"broker":1,"key":"k","payload":"plain text\n"}`,
+			format: "[kafka]",
+			want:   []string{"Oct 24 08:19:50", "plain text", "partition=0", "offset=7"},
+		},
+	} {
+		out, ok := Prettify([]byte(tt.line), &opts)
+		if !ok {
+			t.Fatalf("want %q handled", tt.line)
+		}
+		got := string(out)
+		if !strings.HasPrefix(got, tt.format) {
+			t.Fatalf("want %s, got %q", tt.format, got)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Fatalf("want %q in %q", want, got)
+			}
+		}
+	}
+
+	opts.KafkaMetadata = false
+	line := `{"topic":"logs","partition":2,"offset":42,"ts":1540369190466,"payload":"time=\"2018-10-24T08:19:51Z\" level=info msg=hello"}`
+	out, _ := Prettify([]byte(line), &opts)
+	if got := string(out); !strings.Contains(got, "hello") || strings.Contains(got, "offset") {
+		t.Fatalf("want the record's metadata left out, got %q", got)
+	}
+}
+
+func TestUnwrapJournal(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+	opts.Truncates = false
+
+	for _, tt := range []struct {
+		line   string
+		format string
+		want   []string
+	}{
+		{
+			line:   `{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"{\"time\":\"2018-10-24T08:19:51Z\",\"msg\":\"inner json\",\"user\":\"bob\"}","_SYSTEMD_UNIT":"app.service","_PID":"42","_HOSTNAME":"web1"}`,
+			format: "[json]",
+			want:   []string{"Oct 24 08:19:51", "|INFO| inner json", `user="bob"`, "journal=app.service[42]@web1"},
+		},
+		{
+			line:   `{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"time=\"2018-10-24T08:19:51Z\" level=error msg=\"inner logfmt\" code=7","SYSLOG_IDENTIFIER":"app"}`,
+			format: "[logrus]",
+			want:   []string{"|ERRO| inner logfmt", "code=7", "journal=app"},
+		},
+		{
+			// not a line of its own
+			line:   `{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"Started app.service.","SYSLOG_IDENTIFIER":"systemd"}`,
+			format: "[journal]",
+			want:   []string{"|INFO| systemd Started app.service."},
+		},
+		{
+			// when the time it was logged isn't known, that it was received
+			line:   `{"__REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"time=\"2018-10-24T08:19:51Z\" level=warn msg=\"no source time\"","SYSLOG_IDENTIFIER":"app"}`,
+			format: "[logrus]",
+			want:   []string{"|WARN| no source time", "journal=app"},
+		},
+		{
+			line:   `{"__REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"Started app.service.","SYSLOG_IDENTIFIER":"systemd"}`,
+			format: "[journal]",
+			want:   []string{"|INFO| systemd Started app.service."},
+		},
+		{
+			// no handler recognizes the payload
+			line:   `{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"{not json}","SYSLOG_IDENTIFIER":"app"}`,
+			format: "[journal]",
+			want:   []string{"|INFO| app {not json}"},
+		},
+	} {
+		out, ok := Prettify([]byte(tt.line), &opts)
+		if !ok {
+			t.Fatalf("want %q handled", tt.line)
+		}
+		got := string(out)
+		if !strings.HasPrefix(got, tt.format) {
+			t.Fatalf("want %s, got %q", tt.format, got)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Fatalf("want %q in %q", want, got)
+			}
+		}
+	}
+}
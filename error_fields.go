@@ -0,0 +1,36 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// errorFields renders the error fields of ev that joinKVs set apart, in
+// the order of ErrorKeys and in the color of errors, to follow the
+// message. The lines past the first of multi-line errors, like stack
+// traces, are returned apart, to be written below the line.
+func (r *Renderer) errorFields(ev Event) (inline string, below []string) {
+	opts := r.Opts
+	if len(r.errKeys) == 0 {
+		return "", nil
+	}
+	c := opts.ErrorLevelColor
+	var b strings.Builder
+	for _, key := range opts.ErrorKeys {
+		if indexOf(r.errKeys, key) < 0 {
+			continue
+		}
+		v := ev.Fields[key]
+		if unquoted, err := strconv.Unquote(v); err == nil && strings.Contains(unquoted, "\n") {
+			lines := strings.SplitN(strings.TrimRight(unquoted, "\n"), "\n", 2)
+			v = lines[0]
+			if len(lines) > 1 {
+				below = append(below, lines[1])
+			}
+		}
+		b.WriteByte(' ')
+		b.WriteString(opts.paint(c, opts.displayKey(key)+"="))
+		b.WriteString(opts.paintHighlighted(c, v))
+	}
+	return b.String(), below
+}
@@ -0,0 +1,44 @@
+package humanlog
+
+import (
+	"time"
+)
+
+// Handler can recognize its log lines and parse them into events.
+type Handler interface {
+	// TryHandle parses line into an event, if it is in the format of the
+	// handler.
+	TryHandle(line []byte) (Event, bool)
+}
+
+// Event is what a line is made of once parsed, whatever its format. Its
+// Level is normalized (see DebugLevel, InfoLevel, etc), and the values of
+// its Fields are as they were logged, the strings of JSON lines still
+// being quoted. Time is zero if the line had none.
+type Event struct {
+	Time   time.Time
+	Level  string
+	Msg    string
+	Fields map[string]string
+
+	// Format names the format the line was in, like "json", "logrus" or
+	// "docker", when it was told apart from others by ParseLine or Scanner.
+	Format string
+
+	// label is the level label to use when Level is unknown
+	label string
+	// nested objects and stack trace to write below the line
+	nested     map[string]string
+	stacktrace string
+	// whether keys starting with an underscore are noise rather than fields
+	// of their own, as with the journal's `_PID` or `_CMDLINE`
+	skipUnderscored bool
+	// whether the `status` field is an HTTP status, colored by its class
+	httpStatus bool
+	// whether the line is an entry of the journal whose metadata is shown
+	// in short, before its message
+	journal bool
+	// whether the line may have no time, like the JSON objects loose
+	// detection lets through, blanks being shown in place of it then
+	untimed bool
+}
@@ -0,0 +1,217 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+var (
+	_ Handler = (*JSONHandler)(nil)
+	_ Handler = (*LogrusHandler)(nil)
+	_ Handler = (*BunyanHandler)(nil)
+	_ Handler = (*GELFHandler)(nil)
+	_ Handler = (*JournalJSONHandler)(nil)
+	_ Handler = (*KlogHandler)(nil)
+	_ Handler = (*AccessLogHandler)(nil)
+	_ Handler = (*SyslogHandler)(nil)
+)
+
+func TestHandlerEvent(t *testing.T) {
+	for _, tt := range []struct {
+		h    Handler
+		line string
+		want Event
+	}{
+		{
+			h:    &JSONHandler{Opts: DefaultOptions},
+			line: `{"time":"2018-10-24T08:19:50Z","level":"warning","msg":"hello","user":"bob"}`,
+			want: Event{Time: time.Date(2018, 10, 24, 8, 19, 50, 0, time.UTC), Level: WarnLevel, Msg: "hello", Fields: map[string]string{"user": `"bob"`}},
+		},
+		{
+			h:    &LogrusHandler{Opts: DefaultOptions},
+			line: `time="2018-10-24T08:19:50Z" level=error msg="hello" user=bob`,
+			want: Event{Time: time.Date(2018, 10, 24, 8, 19, 50, 0, time.UTC), Level: ErrorLevel, Msg: "hello", Fields: map[string]string{"user": "bob"}},
+		},
+		{
+			h:    &BunyanHandler{Opts: DefaultOptions},
+			line: `{"name":"app","hostname":"h","pid":1,"level":30,"msg":"hello","time":"2018-10-24T08:19:50Z","v":0}`,
+			want: Event{Time: time.Date(2018, 10, 24, 8, 19, 50, 0, time.UTC), Level: InfoLevel, Msg: "hello"},
+		},
+	} {
+		ev, ok := tt.h.TryHandle([]byte(tt.line))
+		if !ok {
+			t.Fatalf("want %q handled", tt.line)
+		}
+		if !ev.Time.Equal(tt.want.Time) || ev.Level != tt.want.Level || ev.Msg != tt.want.Msg {
+			t.Fatalf("want %q parsed as %+v, got %+v", tt.line, tt.want, ev)
+		}
+		for key, want := range tt.want.Fields {
+			if got := ev.Fields[key]; got != want {
+				t.Fatalf("want %s=%s in %q, got %q", key, want, tt.line, got)
+			}
+		}
+	}
+
+	if _, ok := (&JSONHandler{Opts: DefaultOptions}).TryHandle([]byte("not json")); ok {
+		t.Fatal("want plain text rejected")
+	}
+}
+
+func TestRendererSkipsUnchanged(t *testing.T) {
+	r := NewRenderer(DefaultOptions)
+	ev := Event{Level: InfoLevel, Msg: "hello", Fields: map[string]string{"a": "1", "b": "2"}}
+	r.Render(ev, true)
+	ev.Fields = map[string]string{"a": "1", "b": "3"}
+	out := r.Render(ev, true)
+	if bytes.Contains(out, []byte("a=1")) || !bytes.Contains(out, []byte("b=3")) {
+		t.Fatalf("want only the changed field, got %q", out)
+	}
+}
+
+func TestRendererBlanksMissingTime(t *testing.T) {
+	opts := *DefaultOptions
+	opts.JSONDetection = JSONDetectionLoose
+	h := JSONHandler{Opts: &opts}
+	ev, ok := h.TryHandle([]byte(`{"msg":"hello"}`))
+	if !ok {
+		t.Fatal("want a JSON object handled with loose detection")
+	}
+	if out := string(NewRenderer(&opts).Render(ev, false)); !strings.HasPrefix(out, strings.Repeat(" ", len(time.Stamp))) {
+		t.Fatalf("want blanks in place of the time, got %q", out)
+	}
+
+	// the other handlers' lines show the zero time, as they always did
+	out := string(NewRenderer(&opts).Render(Event{Level: InfoLevel, Msg: "hello"}, false))
+	if !strings.HasPrefix(out, "Jan  1 00:00:00") {
+		t.Fatalf("want the zero time shown, got %q", out)
+	}
+}
+
+func TestRendererAlignColumns(t *testing.T) {
+	opts := *DefaultOptions
+	opts.AlignColumns = true
+	r := NewRenderer(&opts)
+
+	var lines []string
+	for _, ev := range []Event{
+		{Level: InfoLevel, Msg: "short", Fields: map[string]string{"a": "1", "bb": "2"}},
+		{Level: InfoLevel, Msg: "a longer message", Fields: map[string]string{"a": "12345", "bb": "2"}},
+		{Level: InfoLevel, Msg: "mid", Fields: map[string]string{"a": "1", "bb": "2"}},
+	} {
+		lines = append(lines, string(r.Render(ev, false)))
+	}
+	a, bb := strings.Index(lines[2], "a=1"), strings.Index(lines[2], "bb=2")
+	if a != strings.Index(lines[1], "a=12345") || bb != strings.Index(lines[1], "bb=2") {
+		t.Fatalf("want the columns lined up, got\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestRendererOverflow(t *testing.T) {
+	ev := Event{Level: InfoLevel, Msg: "a message", Fields: map[string]string{"alpha": "1111", "beta": "2222", "gamma": "3333"}}
+
+	opts := *DefaultOptions
+	opts.Width, opts.Overflow = 40, OverflowTruncate
+	out := string(NewRenderer(&opts).Render(ev, false))
+	if visibleWidth(out) != 40 || !strings.HasSuffix(out, "…") {
+		t.Fatalf("want the line cut at 40 characters, got %q", out)
+	}
+
+	opts.Overflow = OverflowWrap
+	out = string(NewRenderer(&opts).Render(ev, false))
+	lines := strings.Split(out, "\n")
+	if len(lines) < 2 {
+		t.Fatalf("want the fields wrapped, got %q", out)
+	}
+	for _, line := range lines {
+		if visibleWidth(line) > 40 {
+			t.Fatalf("want lines of at most 40 characters, got %q", out)
+		}
+	}
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(line, wrapIndent) {
+			t.Fatalf("want wrapped fields indented, got %q", out)
+		}
+	}
+	if strings.Count(out, "=") != 3 {
+		t.Fatalf("want every field kept, got %q", out)
+	}
+}
+
+func TestRendererEmphasize(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	opts.EmphasizeLevel = ErrorLevel
+	r := NewRenderer(&opts)
+
+	bold := strings.TrimSuffix(color.New(color.Bold).Sprint(""), ansiReset)
+	out := string(r.Render(Event{Level: InfoLevel, Msg: "fine", Fields: map[string]string{"a": "1"}}, false))
+	if strings.Contains(out, bold) {
+		t.Fatalf("want info lines left alone, got %q", out)
+	}
+	out = string(r.Render(Event{Level: FatalLevel, Msg: "boom", Fields: map[string]string{"a": "1"}}, false))
+	if !strings.HasPrefix(out, bold) {
+		t.Fatalf("want fatal lines in bold, got %q", out)
+	}
+	if n := strings.Count(out, ansiReset); strings.Count(out, ansiReset+bold) != n-1 {
+		t.Fatalf("want bold to carry on after every color, got %q", out)
+	}
+}
+
+func TestRendererErrorFields(t *testing.T) {
+	opts := *DefaultOptions
+	opts.DisableColors = true
+	opts.Truncates = true
+	opts.TruncateLength = 5
+	r := NewRenderer(&opts)
+
+	ev := Event{Level: ErrorLevel, Msg: "failed", Fields: map[string]string{
+		"path":      `"/checkout"`,
+		"exception": `"NullPointerException: boom\n\tat Cart.total(Cart.java:12)\n\tat Cart.pay(Cart.java:40)\n"`,
+		"err":       `"a long error that isn't truncated"`,
+	}}
+	out := string(r.Render(ev, false))
+	want := `|ERRO| failed err="a long error that isn't truncated" exception=NullPointerException: boom path="/che...` +
+		"\n        at Cart.total(Cart.java:12)\n        at Cart.pay(Cart.java:40)"
+	if !strings.HasSuffix(out, want) {
+		t.Fatalf("want suffix %q, got %q", want, out)
+	}
+
+	// errors are repeated even if unchanged
+	out = string(r.Render(ev, true))
+	if !strings.Contains(out, `err="a long error`) || strings.Contains(out, "path=") {
+		t.Fatalf("want errors kept and the rest skipped, got %q", out)
+	}
+}
+
+func TestRendererTruncateKeys(t *testing.T) {
+	opts := *DefaultOptions
+	opts.DisableColors = true
+	opts.Truncates = true
+	opts.TruncateLength = 5
+	opts.TruncateKeys = map[string]int{"sql": 8, "stack": 0}
+	opts.ExpandKeys = []string{"query"}
+	r := NewRenderer(&opts)
+
+	ev := Event{Level: InfoLevel, Msg: "done", Fields: map[string]string{
+		"path":  `"/checkout"`,
+		"sql":   `"SELECT * FROM carts"`,
+		"stack": `"main.go:12 main.go:40"`,
+		"query": `"SELECT *\n\tFROM orders\n\tWHERE id = 1"`,
+	}}
+	out := string(r.Render(ev, false))
+	for _, want := range []string{`path="/che...`, `sql="SELECT ...`, `stack="main.go:12 main.go:40"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+	want := "\n    query:\n        SELECT *\n            FROM orders\n            WHERE id = 1"
+	if !strings.HasSuffix(out, want) || strings.Contains(out, "query=") {
+		t.Fatalf("want query expanded below the line, got %q", out)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package humanlog
+
+import "os"
+
+// fileID is the path of a file, as inodes aren't looked up on this
+// platform.
+func fileID(path string, info os.FileInfo) string {
+	return path
+}
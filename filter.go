@@ -0,0 +1,77 @@
+package humanlog
+
+// keep tells if the line held since the last call to match passes the
+// filters of the options.
+func (lh *lineHandler) keep() bool {
+	opts := lh.opts
+	if !lh.inTimeRange() {
+		return false
+	}
+	// with context lines, lines around those that pass the other filters
+	// are kept too, which writeContext takes care of
+	if opts.filterContext() {
+		return true
+	}
+	if !lh.matchLevel() || len(opts.Where) > 0 && !lh.matchWhere() {
+		return false
+	}
+	if opts.grepping() && opts.GrepContext == 0 && !lh.matchGrep() {
+		return false
+	}
+	return lh.sample()
+}
+
+// matchFilters tells if the line held since the last call to match passes
+// the filters that ContextBefore and ContextAfter write lines around.
+func (lh *lineHandler) matchFilters() bool {
+	opts := lh.opts
+	if !lh.matchLevel() || len(opts.Where) > 0 && !lh.matchWhere() {
+		return false
+	}
+	if opts.grepping() && !lh.matchGrep() {
+		return false
+	}
+	return lh.sample()
+}
+
+// inTimeRange tells if the line held since the last call to match is
+// between Since and Until.
+func (lh *lineHandler) inTimeRange() bool {
+	opts := lh.opts
+	if opts.Since.IsZero() && opts.Until.IsZero() {
+		return true
+	}
+	t, ok := lh.time()
+	switch {
+	case !ok:
+		// can't tell if it's in the range
+		return !opts.StrictTimeRange
+	case !opts.Since.IsZero() && t.Before(opts.Since):
+		return false
+	case !opts.Until.IsZero() && t.After(opts.Until):
+		return false
+	}
+	return true
+}
+
+// matchLevel tells if the line held since the last call to match is at
+// least as severe as MinLevel.
+func (lh *lineHandler) matchLevel() bool {
+	opts := lh.opts
+	if opts.MinLevel == "" {
+		return true
+	}
+	level := lh.level()
+	if level == UnknownLevel {
+		// can't tell if it's severe enough
+		return !opts.StrictLevel
+	}
+	return levelSeverity(level) >= levelSeverity(opts.MinLevel)
+}
+
+// drop forgets the line held since the last call to match. It doesn't
+// become the last event as far as SkipUnchanged goes, since only the lines
+// rendered do.
+func (lh *lineHandler) drop() {
+	lh.ev = Event{}
+}
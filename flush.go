@@ -0,0 +1,77 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// FlushWriter buffers what is written to it, so that dst isn't written to
+// a piece of line at a time, and writes it out every so many lines, or
+// once the oldest of what it holds has waited for an interval, whichever
+// comes first. It is safe for concurrent use.
+type FlushWriter struct {
+	every    int
+	interval time.Duration
+
+	mu    sync.Mutex
+	w     *bufio.Writer
+	lines int
+	timer *time.Timer
+	err   error
+}
+
+// NewFlushWriter buffers what is written to dst, writing it out every
+// `every` lines, or `interval` after it was written. An `every` of one is
+// line buffering, and zero only writes out when the buffer is full, Flush
+// is called, or interval elapses if it isn't zero.
+func NewFlushWriter(dst io.Writer, every int, interval time.Duration) *FlushWriter {
+	return &FlushWriter{every: every, interval: interval, w: bufio.NewWriter(dst)}
+}
+
+// Write buffers p, writing out what is buffered if p completes enough
+// lines.
+func (fw *FlushWriter) Write(p []byte) (int, error) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.err != nil {
+		return 0, fw.err
+	}
+	n, err := fw.w.Write(p)
+	if err != nil {
+		fw.err = err
+		return n, err
+	}
+	if fw.every > 0 {
+		fw.lines += bytes.Count(p, eol[:])
+		if fw.lines >= fw.every {
+			return n, fw.flush()
+		}
+	}
+	if fw.interval > 0 && fw.timer == nil && fw.w.Buffered() > 0 {
+		fw.timer = time.AfterFunc(fw.interval, func() { fw.Flush() })
+	}
+	return n, nil
+}
+
+// Flush writes out what is buffered.
+func (fw *FlushWriter) Flush() error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return fw.flush()
+}
+
+func (fw *FlushWriter) flush() error {
+	if fw.timer != nil {
+		fw.timer.Stop()
+		fw.timer = nil
+	}
+	fw.lines = 0
+	if fw.err != nil {
+		return fw.err
+	}
+	fw.err = fw.w.Flush()
+	return fw.err
+}
@@ -0,0 +1,41 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlushWriterEvery(t *testing.T) {
+	dst := &lockedBuffer{}
+	fw := NewFlushWriter(dst, 2, 0)
+
+	fw.Write([]byte("one"))
+	fw.Write([]byte("\n"))
+	if got := dst.String(); got != "" {
+		t.Fatalf("want nothing written before the second line, got %q", got)
+	}
+	fw.Write([]byte("two\nthree"))
+	if want, got := "one\ntwo\nthree", dst.String(); want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if err := fw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlushWriterInterval(t *testing.T) {
+	dst := &lockedBuffer{}
+	fw := NewFlushWriter(dst, 0, 10*time.Millisecond)
+
+	fw.Write([]byte("one\n"))
+	if got := dst.String(); got != "" {
+		t.Fatalf("want nothing written right away, got %q", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for dst.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if want, got := "one\n", dst.String(); want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
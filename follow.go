@@ -0,0 +1,214 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// followInterval is how often a followed file is checked for new lines
+// once all of it was read.
+const followInterval = 250 * time.Millisecond
+
+// Follower reads a file like `tail -F` does: once at its end, it waits for
+// more to be appended rather than reporting io.EOF. When the file is
+// truncated it starts over from its beginning, and when it is rotated, as
+// in renamed and created again, the new file is read from its beginning
+// once the old one was read in full.
+type Follower struct {
+	path     string
+	interval time.Duration
+
+	mu     sync.Mutex
+	f      *os.File
+	offset int64
+	// id tells the file being read apart from the ones that had its path
+	// before or will after, see fileID
+	id string
+	// lineOffset is where the last line read in full ends, and consumed
+	// where it ended when reading was last called for, by which time the
+	// lines before were handled
+	lineOffset, consumed int64
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Follow opens the file at path, to be read from the start of its last
+// `lines` lines.
+func Follow(path string, lines int) (*Follower, error) {
+	return follow(path, func(f *os.File) (int64, error) {
+		return tailOffset(f, lines)
+	})
+}
+
+// follow opens the file at path, to be read from the offset start tells.
+func follow(path string, start func(f *os.File) (int64, error)) (*Follower, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := start(f)
+	if err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	var info os.FileInfo
+	if err == nil {
+		info, err = f.Stat()
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Follower{
+		path:       path,
+		interval:   followInterval,
+		f:          f,
+		offset:     offset,
+		id:         fileID(path, info),
+		lineOffset: offset,
+		consumed:   offset,
+		closed:     make(chan struct{}),
+	}, nil
+}
+
+// Read blocks until there is more of the file to read, or the follower is
+// closed, in which case it reports io.EOF.
+func (fl *Follower) Read(p []byte) (int, error) {
+	for {
+		n, more, err := fl.read(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+		if more {
+			continue
+		}
+		select {
+		case <-fl.closed:
+			return 0, io.EOF
+		case <-time.After(fl.interval):
+		}
+	}
+}
+
+// read reads what is there to read, if anything, reporting whether there
+// may be more right away otherwise.
+func (fl *Follower) read(p []byte) (int, bool, error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	select {
+	case <-fl.closed:
+		return 0, false, io.EOF
+	default:
+	}
+	fl.consumed = fl.lineOffset
+	n, err := fl.f.Read(p)
+	if i := bytes.LastIndexByte(p[:n], '\n'); i >= 0 {
+		fl.lineOffset = fl.offset + int64(i) + 1
+	}
+	fl.offset += int64(n)
+	if n > 0 {
+		return n, false, nil
+	}
+	if err != nil && err != io.EOF {
+		return 0, false, err
+	}
+	return 0, fl.reopen(), nil
+}
+
+// reopen checks whether the file was rotated or truncated, reporting
+// whether there may be more to read right away.
+func (fl *Follower) reopen() bool {
+	info, err := os.Stat(fl.path)
+	if err != nil {
+		// rotated but not created again yet
+		return false
+	}
+	current, err := fl.f.Stat()
+	if err != nil {
+		return false
+	}
+	switch {
+	case !os.SameFile(info, current):
+		f, err := os.Open(fl.path)
+		if err != nil {
+			return false
+		}
+		fl.f.Close()
+		fl.f, fl.id = f, fileID(fl.path, info)
+		fl.offset, fl.lineOffset, fl.consumed = 0, 0, 0
+		return true
+	case info.Size() < fl.offset:
+		if _, err := fl.f.Seek(0, io.SeekStart); err != nil {
+			return false
+		}
+		fl.offset, fl.lineOffset, fl.consumed = 0, 0, 0
+		return true
+	}
+	return false
+}
+
+// position is the identity of the file being read, and where the lines
+// handled of it end.
+func (fl *Follower) position() (id string, offset int64) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	return fl.id, fl.consumed
+}
+
+// Close stops following the file, pending reads reporting io.EOF.
+func (fl *Follower) Close() error {
+	var err error
+	fl.closeOnce.Do(func() {
+		fl.mu.Lock()
+		defer fl.mu.Unlock()
+		close(fl.closed)
+		err = fl.f.Close()
+	})
+	return err
+}
+
+// tailOffset is where the last n lines of f start.
+func tailOffset(f *os.File, n int) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if n <= 0 || size == 0 {
+		return size, nil
+	}
+
+	buf := make([]byte, 32*1024)
+	end := size
+	// the newline ending the last line doesn't start another one
+	skipLast := true
+	for end > 0 {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' {
+				skipLast = false
+				continue
+			}
+			if skipLast {
+				skipLast = false
+				continue
+			}
+			n--
+			if n == 0 {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
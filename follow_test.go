@@ -0,0 +1,113 @@
+package humanlog
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	appendTo := func(text string) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendTo("one\ntwo\nthree\n")
+
+	fl, err := Follow(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fl.Close()
+	fl.interval = time.Millisecond
+
+	lines := make(chan string)
+	go func() {
+		in := bufio.NewScanner(fl)
+		for in.Scan() {
+			lines <- in.Text()
+		}
+		close(lines)
+	}()
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("want %q, got nothing", want)
+		}
+	}
+
+	// the last lines of the file to begin with
+	expect("two")
+	expect("three")
+
+	appendTo("four\n")
+	expect("four")
+
+	// truncated
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	appendTo("five\n")
+	expect("five")
+
+	// rotated
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	appendTo("six\n")
+	expect("six")
+
+	fl.Close()
+	select {
+	case _, ok := <-lines:
+		if ok {
+			t.Fatal("want nothing more once closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want reads to end once closed")
+	}
+}
+
+func TestTailOffset(t *testing.T) {
+	f, err := ioutil.TempFile("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.WriteString("a\nbb\nccc\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	for n, want := range map[int]int64{0: 9, 1: 5, 2: 2, 3: 0, 10: 0} {
+		got, err := tailOffset(f, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("last %d lines: want offset %d, got %d", n, want, got)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package humanlog
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// gapRuleWidth is how wide the rule written by GapThreshold is when Width
+// isn't set.
+const gapRuleWidth = 60
+
+// writeGap writes a rule telling how long it has been since the line
+// before, if it has been longer than GapThreshold. The time of a line is
+// the one it was logged at, t, if it has one. Lines without one are left
+// out once one had, and otherwise go by the time they were read at, for
+// streams without times. The times logged and read are never compared.
+func (lh *lineHandler) writeGap(dst io.Writer, t time.Time) {
+	opts := lh.opts
+	if opts.GapThreshold <= 0 {
+		return
+	}
+	logged := !t.IsZero()
+	if !logged {
+		if lh.lastTimeLogged {
+			return
+		}
+		t = time.Now()
+	}
+	prev, prevLogged := lh.lastTime, lh.lastTimeLogged
+	lh.lastTime, lh.lastTimeLogged = t, logged
+	if prev.IsZero() || logged != prevLogged || t.Sub(prev) <= opts.GapThreshold {
+		return
+	}
+
+	label := "── " + formatElapsed(t.Sub(prev)) + " "
+	width := gapRuleWidth
+	if opts.Width > 0 {
+		width = opts.Width
+	}
+	rule := label
+	if n := width - visibleWidth(label); n > 0 {
+		rule += strings.Repeat("─", n)
+	}
+	dst.Write([]byte(opts.paint(opts.RawColor, rule)))
+	dst.Write(eol[:])
+}
@@ -0,0 +1,51 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScannerGaps(t *testing.T) {
+	opts := *DefaultOptions
+	opts.GapThreshold = 5 * time.Second
+
+	src := strings.NewReader(strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"one"}`,
+		`{"time":"2018-10-24T08:19:52Z","level":"info","msg":"two"}`,
+		`{"time":"2018-10-24T08:20:22Z","level":"info","msg":"three"}`,
+	}, "\n"))
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want a rule between the last two lines, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[2], "── 30s ──") || visibleWidth(lines[2]) != gapRuleWidth {
+		t.Fatalf("want a rule telling the gap, got %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "three") {
+		t.Fatalf("want the rule before the line after the gap, got %q", lines)
+	}
+}
+
+func TestScannerGapsSkipLinesWithoutTime(t *testing.T) {
+	opts := *DefaultOptions
+	opts.GapThreshold = 5 * time.Second
+
+	src := strings.NewReader(strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"one"}`,
+		`plain`,
+		`{"time":"2018-10-24T08:19:52Z","level":"info","msg":"two"}`,
+	}, "\n"))
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(dst.String(), "──") {
+		t.Fatalf("want no rule between lines 2s apart, got %q", dst.String())
+	}
+}
@@ -0,0 +1,65 @@
+package humanlog
+
+import (
+	"strings"
+	"time"
+)
+
+// unwrapGCP reshapes a Google Cloud Logging entry into the usual keys: its
+// jsonPayload is flattened into raw, textPayload becomes the message and
+// severity the level. Traces are shortened to their ID. It reports whether
+// raw was such an entry.
+func unwrapGCP(raw map[string]interface{}) bool {
+	severity, ok := raw["severity"].(string)
+	if !ok {
+		return false
+	}
+	_, hasTimestamp := raw["timestamp"]
+	_, hasJSON := raw["jsonPayload"]
+	_, hasText := raw["textPayload"]
+	_, hasTrace := raw["logging.googleapis.com/trace"]
+	if !hasTimestamp && !hasJSON && !hasText && !hasTrace {
+		return false
+	}
+
+	delete(raw, "severity")
+	if _, ok := raw["level"]; !ok {
+		raw["level"] = severity
+	}
+
+	switch ts := raw["timestamp"].(type) {
+	case string:
+		delete(raw, "timestamp")
+		raw["time"] = ts
+	case map[string]interface{}:
+		// a protobuf Timestamp
+		secs, _ := ts["seconds"].(float64)
+		nanos, _ := ts["nanos"].(float64)
+		delete(raw, "timestamp")
+		raw["time"] = time.Unix(int64(secs), int64(nanos)).UTC().Format(time.RFC3339Nano)
+	}
+
+	if payload, ok := raw["jsonPayload"].(map[string]interface{}); ok {
+		delete(raw, "jsonPayload")
+		mergeObject(raw, "jsonPayload", payload)
+	}
+	if text, ok := raw["textPayload"].(string); ok {
+		delete(raw, "textPayload")
+		if _, ok := raw["message"]; !ok {
+			raw["message"] = text
+		}
+	}
+
+	for _, key := range []string{"trace", "logging.googleapis.com/trace"} {
+		if trace, ok := raw[key].(string); ok {
+			delete(raw, key)
+			// projects/PROJECT_ID/traces/TRACE_ID
+			raw["trace"] = trace[strings.LastIndexByte(trace, '/')+1:]
+		}
+	}
+	if span, ok := raw["logging.googleapis.com/spanId"]; ok {
+		delete(raw, "logging.googleapis.com/spanId")
+		raw["spanId"] = span
+	}
+	return true
+}
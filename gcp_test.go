@@ -0,0 +1,55 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnwrapGCP(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := JSONHandler{Opts: &opts}
+
+	ev := []byte(`{"severity":"WARNING","timestamp":{"seconds":1540369190,"nanos":466951000},"jsonPayload":{"message":"quota low","remaining":3},"trace":"projects/my-proj/traces/0123abcd","insertId":"x1"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Unix(1540369190, 466951000); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	if h.Message != "quota low" {
+		t.Fatalf("want the jsonPayload message, got %q", h.Message)
+	}
+	for k, want := range map[string]string{"remaining": "3", "trace": `"0123abcd"`, "insertId": `"x1"`} {
+		if h.Fields[k] != want {
+			t.Fatalf("want %s=%s, got %v", k, want, h.Fields)
+		}
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|WARN| quota low") {
+		t.Fatalf("want the severity as level, got %q", out)
+	}
+
+	ev = []byte(`{"severity":"CRITICAL","timestamp":"2018-10-24T08:19:50.466951Z","textPayload":"disk gone"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Message != "disk gone" || h.Time.IsZero() {
+		t.Fatalf("want the textPayload and timestamp, got %q at %v", h.Message, h.Time)
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|FATA| disk gone") {
+		t.Fatalf("want CRITICAL shown as fatal, got %q", out)
+	}
+
+	// structured logs written to stdout on GKE or Cloud Run
+	ev = []byte(`{"severity":"NOTICE","time":"2018-10-24T08:19:50Z","message":"hi","logging.googleapis.com/trace":"projects/p/traces/abc"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Fields["trace"] != `"abc"` {
+		t.Fatalf("want the trace shortened, got %v", h.Fields)
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|INFO| hi") {
+		t.Fatalf("want NOTICE shown as info, got %q", out)
+	}
+}
@@ -0,0 +1,136 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// GELFHandler can handle logs emmited in the Graylog Extended Log Format.
+type GELFHandler struct {
+	renderer *Renderer
+
+	Opts *HandlerOptions
+
+	Level   string
+	Time    time.Time
+	Message string
+	Fields  map[string]string
+
+	// FullMessage is printed below the line, when it says more than the
+	// short message.
+	FullMessage string
+
+	// Nested holds the nested objects expanded below the line, as
+	// indented JSON by key, when NestedObjects is NestedObjectsExpand.
+	Nested map[string]string
+}
+
+func (h *GELFHandler) clear() {
+	h.Level = ""
+	h.Time = time.Time{}
+	h.Message = ""
+	h.FullMessage = ""
+	h.Nested = nil
+	h.Fields = make(map[string]string)
+}
+
+// parse tells if d was handled by this handler, which then holds it until
+// the next call to Prettify.
+func (h *GELFHandler) parse(d []byte) bool {
+	if !bytes.Contains(d, []byte(`"short_message"`)) || !bytes.Contains(d, []byte(`"version"`)) {
+		return false
+	}
+	err := h.UnmarshalJSON(d)
+	if err != nil {
+		h.clear()
+		return false
+	}
+	return true
+}
+
+// UnmarshalJSON sets the fields of the handler.
+func (h *GELFHandler) UnmarshalJSON(data []byte) error {
+	raw, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	defer releaseObject(raw)
+	if _, ok := raw["version"]; !ok {
+		return fmt.Errorf("not a GELF message, missing version")
+	}
+	delete(raw, "version")
+
+	if h.Message, _ = raw["short_message"].(string); h.Message == "" {
+		return fmt.Errorf("not a GELF message, missing short_message")
+	}
+	delete(raw, "short_message")
+
+	if full, ok := raw["full_message"].(string); ok {
+		delete(raw, "full_message")
+		if full != h.Message {
+			h.FullMessage = full
+		}
+	}
+
+	if timestamp, ok := raw["timestamp"]; ok {
+		delete(raw, "timestamp")
+		h.Time, ok = tryParseTime(timestamp)
+		if !ok {
+			return fmt.Errorf("field timestamp is not a known timestamp: %v", timestamp)
+		}
+	}
+
+	switch lvl := raw["level"].(type) {
+	case float64:
+		h.Level = fmt.Sprintf("%d", int(lvl))
+		delete(raw, "level")
+	case string:
+		h.Level = lvl
+		delete(raw, "level")
+	}
+
+	if h.Fields == nil {
+		h.Fields = make(map[string]string)
+	}
+
+	h.Nested = h.Opts.setFields(h.Fields, raw)
+
+	return nil
+}
+
+// TryHandle parses d into an event, if it is in the format of the handler.
+// The handler holds the line until the next call to Prettify.
+func (h *GELFHandler) TryHandle(d []byte) (Event, bool) {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if !h.parse(d) {
+		return Event{}, false
+	}
+	return h.event(), true
+}
+
+// Prettify the output in a logrus like fashion.
+func (h *GELFHandler) Prettify(skipUnchanged bool) []byte {
+	defer h.clear()
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if h.renderer == nil {
+		h.renderer = NewRenderer(h.Opts)
+	}
+	return h.renderer.Render(h.event(), skipUnchanged)
+}
+
+func (h *GELFHandler) event() Event {
+	return Event{
+		Time:   h.Time,
+		Level:  h.Opts.mapLevel(h.Level, normalizeSyslogLevel),
+		Msg:    h.Message,
+		Fields: h.Fields,
+
+		nested:     h.Nested,
+		stacktrace: h.FullMessage,
+	}
+}
@@ -0,0 +1,51 @@
+package humanlog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestGELFHandler(t *testing.T) {
+	line := []byte(`{"version":"1.1","host":"example.org","short_message":"A short message","full_message":"Backtrace here\n\nmore stuff","timestamp":1385053862.3072,"level":3,"_user_id":9001,"_some_info":"foo"}`)
+
+	opts := *DefaultOptions
+	opts.TimeFormat = time.RFC3339Nano
+	h := GELFHandler{Opts: &opts}
+	if _, ok := h.TryHandle(line); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	if want := time.Unix(1385053862, 307200000); h.Time.Sub(want) > time.Millisecond || want.Sub(h.Time) > time.Millisecond {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	out := h.Prettify(false)
+	for _, want := range []string{"|ERRO|", "A short message", "_user_id=9001", `host="example.org"`} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+	if bytes.Contains(out, []byte("version")) {
+		t.Fatalf("want version to be hidden, got %q", out)
+	}
+	lines := bytes.Split(out, []byte("\n"))
+	if len(lines) != 4 || bytes.Contains(lines[0], []byte("full_message")) {
+		t.Fatalf("want the full message below the line, got %q", out)
+	}
+	if string(lines[1]) != "    Backtrace here" || string(lines[3]) != "    more stuff" {
+		t.Fatalf("want the full message indented, got %q", lines[1:])
+	}
+
+	if _, ok := h.TryHandle([]byte(`{"version":"1.1","host":"h","short_message":"same","full_message":"same","level":6}`)); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	if out := h.Prettify(false); bytes.Contains(out, []byte("\n")) {
+		t.Fatalf("want a full message that adds nothing left out, got %q", out)
+	}
+}
+
+func TestGELFHandlerRejectsPlainJSON(t *testing.T) {
+	h := GELFHandler{Opts: DefaultOptions}
+	if _, ok := h.TryHandle([]byte(`{"time":"2018-10-24T08:19:50Z","msg":"version","short_message":1}`)); ok {
+		t.Fatal("expected line not to be handled")
+	}
+}
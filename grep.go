@@ -0,0 +1,107 @@
+package humanlog
+
+import (
+	"io"
+
+	"github.com/fatih/color"
+)
+
+// contextSeparator is written between groups of lines that aren't
+// contiguous when showing context lines, as grep does.
+const contextSeparator = "--"
+
+// contextColor dims the lines written as ContextBefore and ContextAfter,
+// for those that passed the filters to stand out.
+var contextColor = color.New(color.Faint)
+
+// filterContext tells if lines are written around those that pass the
+// filters, by ContextBefore and ContextAfter.
+func (opts *HandlerOptions) filterContext() bool {
+	return opts.ContextBefore > 0 || opts.ContextAfter > 0
+}
+
+// grepping tells if lines are filtered by Grep or GrepInvert.
+func (opts *HandlerOptions) grepping() bool {
+	return len(opts.Grep) > 0 || len(opts.GrepInvert) > 0
+}
+
+// matchGrep tells if the line held since the last call to match is one of
+// Grep and not one of GrepInvert. A regexp matches a line when it matches
+// either the raw line or its message, which might read differently once
+// unescaped.
+func (lh *lineHandler) matchGrep() bool {
+	opts := lh.opts
+	_, msg := lh.fields()
+	// a raw line is its own message
+	if lh.format == rawFormat {
+		msg = ""
+	}
+	if len(opts.Grep) > 0 {
+		found := false
+		for _, re := range opts.Grep {
+			if re.Match(lh.rawData) || msg != "" && re.MatchString(msg) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, re := range opts.GrepInvert {
+		if re.Match(lh.rawData) || msg != "" && re.MatchString(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeContext handles the line held since the last call to match when
+// lines are written around the ones that matched, be it with GrepContext
+// or ContextBefore and ContextAfter. Lines that didn't match are held back
+// in case one that matches comes within `before` lines, and are written if
+// one matched within `after` lines before them, dimmed if asked to. It
+// reports whether a handler was used.
+func (lh *lineHandler) writeContext(dst io.Writer, rawData []byte, matched bool, before, after int, dim bool) bool {
+	opts := lh.opts
+	if !matched {
+		if lh.after > 0 {
+			lh.after--
+			lh.dim = dim
+			defer func() { lh.dim = false }()
+			return lh.write(dst)
+		}
+		lh.drop()
+		lh.fold = foldDiscard
+		if before == 0 {
+			lh.contextGap = true
+			return false
+		}
+		if len(lh.before) == before {
+			lh.before = lh.before[1:]
+			lh.contextGap = true
+		}
+		lh.before = append(lh.before, append([]byte(nil), rawData...))
+		return false
+	}
+
+	if lh.contextGap && lh.printed > 0 {
+		dst.Write([]byte(opts.paint(opts.RawColor, contextSeparator)))
+		dst.Write(eol[:])
+	}
+	lh.contextGap = false
+	if len(lh.before) > 0 {
+		// the line held is replaced by each of those before it, and
+		// matched again once they are written
+		lh.dim = dim
+		for _, before := range lh.before {
+			lh.match(before)
+			lh.write(dst)
+		}
+		lh.dim = false
+		lh.before = lh.before[:0]
+		lh.match(rawData)
+	}
+	lh.after = after
+	return lh.write(dst)
+}
@@ -0,0 +1,99 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestScannerGrep(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"info","msg":"said \"hello\""}`,
+		`{"time":"2018-10-24T08:00:01Z","level":"info","msg":"timeout talking to db"}`,
+		`time="2018-10-24T08:00:02Z" level=info msg="timeout talking to cache"`,
+		`plain timeout`,
+		`plain hello`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Grep = []*regexp.Regexp{regexp.MustCompile(`timeout`), regexp.MustCompile(`said "hello"`)}
+	opts.GrepInvert = []*regexp.Regexp{regexp.MustCompile(`cache`)}
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	// the first line only matches once its message is unescaped
+	for _, want := range []string{`said "hello"`, "talking to db", "plain timeout"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q printed, got %q", want, got)
+		}
+	}
+	if strings.Count(got, "\n") != 3 {
+		t.Fatalf("want 3 lines, got %q", got)
+	}
+}
+
+func TestScannerGrepContext(t *testing.T) {
+	var lines []string
+	for _, msg := range []string{"a", "b", "c", "match1", "d", "e", "f", "g", "match2", "h", "match3"} {
+		lines = append(lines, `time="2018-10-24T08:00:00Z" level=info msg=`+msg)
+	}
+
+	opts := *DefaultOptions
+	opts.Grep = []*regexp.Regexp{regexp.MustCompile(`match`)}
+	opts.GrepContext = 1
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(strings.Join(lines, "\n")), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n") {
+		fields := strings.Fields(line)
+		got = append(got, fields[len(fields)-1])
+	}
+	want := []string{"c", "match1", "d", "--", "g", "match2", "h", "match3"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestScannerFilterContext(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	var lines []string
+	for _, line := range []string{"info a", "info b", "info c", "error boom", "info d", "info e", "info f", "warn g", "error bang"} {
+		level, msg := line[:strings.Index(line, " ")], line[strings.Index(line, " ")+1:]
+		lines = append(lines, `time="2018-10-24T08:00:00Z" level=`+level+` msg=`+msg)
+	}
+
+	opts := *DefaultOptions
+	opts.MinLevel = ErrorLevel
+	opts.ContextBefore = 2
+	opts.ContextAfter = 1
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(strings.Join(lines, "\n")), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var got, dimmed []string
+	for _, line := range strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n") {
+		fields := strings.Fields(plainText(line))
+		got = append(got, fields[len(fields)-1])
+		if strings.HasPrefix(line, "\x1b[2m") && fields[0] != contextSeparator {
+			dimmed = append(dimmed, fields[len(fields)-1])
+		}
+	}
+	want := []string{"b", "c", "boom", "d", "--", "f", "g", "bang"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if want := []string{"b", "c", "d", "f", "g"}; strings.Join(dimmed, " ") != strings.Join(want, " ") {
+		t.Fatalf("want the context lines dimmed, %q, got %q", want, dimmed)
+	}
+}
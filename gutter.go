@@ -0,0 +1,16 @@
+package humanlog
+
+import "strings"
+
+// gutterMark is written before the lines of a group, in its color.
+const gutterMark = "┃ "
+
+// gutter renders the mark of the line held, in the color of its value of
+// GutterKey, or blanks if it has none, so that lines stay aligned.
+func (lh *lineHandler) gutter() []byte {
+	v := unquoteValue(lh.ev.Fields[lh.opts.GutterKey])
+	if v == "" || v == "null" {
+		return []byte(strings.Repeat(" ", visibleWidth(gutterMark)))
+	}
+	return []byte(lh.opts.paint(traceColor(v), gutterMark))
+}
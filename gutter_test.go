@@ -0,0 +1,48 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestScannerGutter(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	opts.GutterKey = "request_id"
+
+	src := strings.NewReader(strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"one","request_id":"a1"}`,
+		`{"time":"2018-10-24T08:19:51Z","level":"info","msg":"two","request_id":"b2"}`,
+		`{"time":"2018-10-24T08:19:52Z","level":"info","msg":"three","request_id":"a1"}`,
+		`{"time":"2018-10-24T08:19:53Z","level":"info","msg":"four"}`,
+	}, "\n"))
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want 4 lines, got %q", lines)
+	}
+	mark := func(line string) string {
+		i := strings.Index(line, gutterMark)
+		if i < 0 {
+			t.Fatalf("want a gutter mark, got %q", line)
+		}
+		return line[:i]
+	}
+	if mark(lines[0]) != mark(lines[2]) {
+		t.Errorf("want the same color for the same request, got %q and %q", lines[0], lines[2])
+	}
+	if mark(lines[0]) == mark(lines[1]) {
+		t.Errorf("want another color for another request, got %q and %q", lines[0], lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "  ") || strings.Contains(lines[3], gutterMark) {
+		t.Errorf("want a blank gutter without the key, got %q", lines[3])
+	}
+}
@@ -1,19 +1,18 @@
 package humanlog
 
 import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
-	"github.com/kr/logfmt"
 )
 
-// Handler can recognize it's log lines, parse them and prettify them.
-type Handler interface {
-	CanHandle(line []byte) bool
-	Prettify(skipUnchanged bool) []byte
-	logfmt.Handler
-}
-
 var DefaultOptions = &HandlerOptions{
 	SortLongest:    true,
 	SkipUnchanged:  false,
@@ -21,9 +20,27 @@ var DefaultOptions = &HandlerOptions{
 	LightBg:        false,
 	TruncateLength: 15,
 	TimeFormat:     time.Stamp,
+	DetectLines:    100,
+
+	AutoSkipUnderscore: true,
+	ErrorKeys:          []string{"err", "error", "error.message", "exception"},
+	CompactCaller:      true,
+	HumanizeDurations:  true,
+	ShortenTraceIDs:    true,
+	LevelStyle:         LevelStyleBars,
+	NestedObjects:      NestedObjectsInline,
+	BinaryLines:        BinaryLinesEscape,
+	Overflow:           OverflowNone,
+	TimeMode:           TimeModeAbsolute,
+	Output:             OutputPretty,
+	Theme:              DefaultTheme,
 
 	KeyColor:              color.New(color.FgGreen),
 	ValColor:              color.New(color.FgHiWhite),
+	BoolColor:             color.New(color.FgHiYellow),
+	NumberColor:           color.New(color.FgHiCyan),
+	StringColor:           color.New(color.FgHiWhite),
+	NullColor:             color.New(color.FgHiBlack),
 	TimeLightBgColor:      color.New(color.FgBlack),
 	TimeDarkBgColor:       color.New(color.FgWhite),
 	MsgLightBgColor:       color.New(color.FgBlack),
@@ -37,9 +54,18 @@ var DefaultOptions = &HandlerOptions{
 	PanicLevelColor:       color.New(color.BgRed),
 	FatalLevelColor:       color.New(color.BgHiRed, color.FgHiWhite),
 	UnknownLevelColor:     color.New(color.FgMagenta),
+	RawColor:              color.New(color.Faint),
+	StatusSuccessColor:    color.New(color.FgGreen),
+	StatusClientErrColor:  color.New(color.FgYellow),
+	StatusServerErrColor:  color.New(color.FgRed),
+	EmphasisColor:         color.New(color.Bold),
 }
 
 type HandlerOptions struct {
+	// Skip and Keep hold keys, glob patterns of keys as understood by
+	// path.Match, or regexps of keys between slashes like `/^kube/`. They
+	// are looked at once per scan, use SetSkip and SetKeep to change them
+	// afterwards.
 	Skip           map[string]struct{}
 	Keep           map[string]struct{}
 	SortLongest    bool
@@ -49,8 +75,376 @@ type HandlerOptions struct {
 	TruncateLength int
 	TimeFormat     string
 
+	// TruncateKeys overrides Truncates and TruncateLength for some keys,
+	// the length their values are truncated at, or zero for them never to
+	// be.
+	TruncateKeys map[string]int
+
+	// ExpandKeys are written in full below the line, each on lines of its
+	// own, rather than among the other fields. Long SQL queries or stack
+	// traces read better that way.
+	ExpandKeys []string
+
+	// Select shows these keys only, whatever Skip and Keep are, so that
+	// big lines are projected down to a few fields. Paths like
+	// `http.status` reach into the nested objects of JSON lines.
+	Select []string
+
+	// TimeMode is how times are rendered, one of TimeModeAbsolute (the
+	// default), TimeModeRelative or TimeModeDelta. Relative times follow
+	// the order lines are written in.
+	TimeMode string
+
+	// Location is the zone times are converted to before being formatted,
+	// such as time.UTC or time.Local. When nil, times are rendered in the
+	// zone they were logged in.
+	Location *time.Location
+
+	// AutoSkipUnderscore hides the keys of the journal's entries starting
+	// with an underscore unless they are explicitly kept, such as `_PID` or
+	// `_CMDLINE`. Those of other formats are always shown.
+	AutoSkipUnderscore bool
+
+	// StripInputANSI removes ANSI escape sequences found in messages and
+	// values before they are colored, such as JSON escaped ones, and from
+	// the lines written as they are. Lines are always parsed without the
+	// escape sequences they hold, so that colored output is recognized.
+	StripInputANSI bool
+
+	// UnquoteSimpleStrings renders string values without surrounding quotes
+	// when they don't need them, that is when they aren't empty and hold no
+	// whitespace, `=`, quotes or control characters, and wouldn't be
+	// mistaken for a boolean or null.
+	UnquoteSimpleStrings bool
+
+	// ParseEmbeddedJSON explodes string values that are themselves JSON
+	// objects into fields, as double-encoded logs are common, whatever the
+	// format of the line. With NestedObjectsExpand, they are written as
+	// indented JSON below the line instead. A message of a JSON line that
+	// is a JSON object is parsed as if it was the line itself.
+	ParseEmbeddedJSON bool
+
+	// PrefixKeys are rendered as a `[prod/api]` prefix of the message,
+	// in this order, rather than along the other fields.
+	PrefixKeys []string
+
+	// FirstKeys are rendered before the other fields, in this order, so
+	// that they are always found at the same place.
+	FirstKeys []string
+
+	// MessageWidth pads or truncates messages to that many characters, so
+	// that the fields that follow them are aligned from line to line.
+	MessageWidth int
+
+	// AlignColumns pads the message and each field of a line to the
+	// widest they were among the last lines, so that the fields of
+	// consecutive lines line up like the columns of a table. Fields are
+	// then sorted by key only, whatever SortLongest is.
+	AlignColumns bool
+
+	// Width is how many characters fit on a line of the terminal, and
+	// Overflow what to do with the lines wider than that: one of
+	// OverflowNone (the default), OverflowTruncate or OverflowWrap. Zero
+	// means no limit.
+	Width    int
+	Overflow string
+
+	// FoldMultiline attaches the lines that continue the one before them,
+	// like the frames of Java exceptions or Go panics, to that line. They
+	// are written indented under it in the error color, and are filtered
+	// out or skipped along with it.
+	FoldMultiline bool
+
+	// NestedObjects is how the nested objects of JSON lines are rendered,
+	// one of NestedObjectsInline (the default), NestedObjectsFlatten or
+	// NestedObjectsExpand.
+	NestedObjects string
+
+	// BinaryLines is how the lines no handler recognized are written when
+	// they hold control characters or aren't UTF-8, which would mess up a
+	// terminal: one of BinaryLinesEscape (the default), BinaryLinesHex or
+	// BinaryLinesRaw.
+	BinaryLines string
+
+	// MaxArrayElements is how many elements of an array are shown before
+	// the rest are only counted. Zero means no limit.
+	MaxArrayElements int
+
+	// Output is how lines are written, one of OutputPretty (the default),
+	// OutputJSON, OutputLogfmt or OutputRaw. ShowHandler and AppendRaw only
+	// apply to pretty lines.
+	Output string
+	// Template renders lines as an Event in place of Output when set, see
+	// ParseTemplate.
+	Template *template.Template
+
+	// AppendRaw writes the original input line after each prettified line.
+	AppendRaw bool
+
+	// RawCopy gets a copy of every line read, untouched, including the ones
+	// that are filtered out, so that prettifying doesn't cost the original
+	// logs. With JSONArrayInput, JournalExportInput, MySQLSlowLogInput and
+	// CSVInput, it gets each entry as a JSON line.
+	RawCopy io.Writer
+
+	// Unparsed, when set, is called with each line that no handler
+	// recognized, blank ones aside, and with its number, counted from one
+	// in each source, so that they can be reported.
+	Unparsed func(n uint64, line []byte)
+
+	// Written, when set, is called with each line written, as an Event,
+	// as it was written and as it was read, so that a Viewer can keep
+	// them.
+	Written func(ev Event, out, raw []byte)
+
+	// GutterKey, if set, marks the lines in front with a bar in a color of
+	// their value of this key, like `request_id`, so that the lines of
+	// concurrent requests can be told apart in a stream.
+	GutterKey string
+
+	// SourceNames label the lines of each of the sources of ScannerMerge
+	// and ScannerInterleave, in the same order, each with its own color.
+	// They only apply to pretty lines.
+	SourceNames []string
+
+	// SortWindow holds the lines of ScannerInterleave for that long, to
+	// write them in the order of their time rather than as they are read.
+	SortWindow time.Duration
+
+	// KafkaMetadata gives the partition, offset and headers of Kafka
+	// records, as consumed with `kcat -J`, as fields of their lines.
+	KafkaMetadata bool
+
+	// ShowHandler prefixes each line with the name of the format that
+	// recognized it, such as `[json]` or `[raw]`.
+	ShowHandler bool
+
+	// MaxLineLength is how long lines can be before they are truncated,
+	// and marked as such, rather than stopping the scan. Zero means
+	// DefaultMaxLineLength.
+	MaxLineLength int
+
+	// FlushInterval is how long Scanner waits on an idle input before
+	// writing out the partial line it holds. Zero means never.
+	FlushInterval time.Duration
+
+	// TimeFields are keys holding the time of a line, in addition to the
+	// usual ones like `time` and `ts`.
+	TimeFields []string
+	// TimeLayouts are tried before the usual layouts when parsing times,
+	// times without a zone being in local time. See the time package for
+	// how they are written.
+	TimeLayouts []string
+
+	// MessageFields and LevelFields are keys holding the message and the
+	// level of a line, tried after the usual ones like `msg` and `level`.
+	MessageFields []string
+	LevelFields   []string
+
+	// ErrorKeys are keys holding errors, which are written right after the
+	// message, in the color of errors and never truncated, the lines past
+	// the first of multi-line ones, like stack traces, below the line.
+	ErrorKeys []string
+
+	// CompactCaller writes where lines were logged from, found in fields
+	// like `caller`, `source`, or `file` and `func`, at the end of lines in
+	// RawColor, trimmed to the file and its directory, or past the first of
+	// CallerTrimPrefixes they start with, like a module path.
+	CompactCaller      bool
+	CallerTrimPrefixes []string
+
+	// ShortenTraceIDs writes trace and span IDs, found in keys like
+	// `trace_id`, `spanId` or `traceparent`, shortened, and trace IDs in
+	// a color of their own. TraceURL, if set, links them to a trace
+	// viewer like Jaeger or Tempo on terminals, `{trace_id}` and
+	// `{span_id}` being replaced with the full IDs, like
+	// `http://localhost:16686/trace/{trace_id}`.
+	ShortenTraceIDs bool
+	TraceURL        string
+
+	// AutoDetectTime makes the JSON handler look for an RFC3339 or ISO8601
+	// timestamp in other fields when there is no `time` or `ts` field.
+	AutoDetectTime bool
+
+	// JSONDetection is how strictly the JSON handler tells lines to be
+	// logs, one of JSONDetectionTime (the default), JSONDetectionStrict or
+	// JSONDetectionLoose.
+	JSONDetection string
+
+	// DisabledHandlers are the names of the handlers lines aren't tried
+	// against, built-in or registered, see HandlerNames, for when one of
+	// them takes lines for what they aren't.
+	DisabledHandlers []string
+	// DetectLines is how many lines are sampled at a time to detect the
+	// format of a stream, when a handler recognized nine in ten of them or
+	// more. In a stream of JSON logs, the objects without a time are then
+	// taken for logs too, like those with a message that looks like logfmt,
+	// unless JSONDetection is JSONDetectionStrict. The handlers are still
+	// tried in order. Zero never detects it.
+	DetectLines int
+
+	// DisableColors renders everything as plain text, whatever the colors
+	// are set to and whether the output is a terminal or not.
+	DisableColors bool
+
+	// JSONArrayInput makes Scanner expect a single JSON array of entries
+	// rather than one entry per line. It isn't detected automatically since
+	// plain text lines often start with a `[` too.
+	JSONArrayInput bool
+
+	// JournalExportInput makes Scanner expect the export format of journald,
+	// as produced by `journalctl -o export`, rather than lines.
+	JournalExportInput bool
+
+	// JournalVerbose shows every field of the entries of the journal.
+	// Otherwise, the metadata journald adds to them is left out, but for
+	// their unit, process and host, written in short before the message.
+	JournalVerbose bool
+
+	// MySQLSlowLogInput makes Scanner expect the slow query log of MySQL,
+	// whose entries span several lines, rather than lines.
+	MySQLSlowLogInput bool
+
+	// CSVInput makes Scanner expect CSV records rather than lines, with
+	// CSVComma between their values, a comma if zero. Their columns are
+	// named by CSVColumns, or by the first record if it is empty.
+	CSVInput   bool
+	CSVComma   rune
+	CSVColumns []string
+
+	// Since and Until drop the lines timestamped outside of their range,
+	// bounds included. A zero time leaves its side of the range open.
+	Since time.Time
+	Until time.Time
+	// StrictTimeRange also drops the lines that have no timestamp when
+	// Since or Until is set, instead of keeping them.
+	StrictTimeRange bool
+
+	// MinLevel drops the lines less severe than this normalized level name
+	// (see DebugLevel, InfoLevel, etc). Empty means no minimum.
+	MinLevel string
+	// StrictLevel also drops the lines whose level can't be told when
+	// MinLevel is set, like the ones no handler recognized, instead of
+	// keeping them.
+	StrictLevel bool
+
+	// Where drops the lines that don't satisfy all of these predicates.
+	Where []Predicate
+
+	// Grep drops the lines that match none of these regexps, and GrepInvert
+	// those that match any of them. They are matched against the raw line
+	// as well as its message. GrepContext also writes that many lines
+	// before and after each line that passes them, like `grep -C`, groups
+	// of lines being separated by `--`.
+	Grep        []*regexp.Regexp
+	GrepInvert  []*regexp.Regexp
+	GrepContext int
+
+	// ContextBefore and ContextAfter write that many of the lines left out
+	// by MinLevel, Where, Grep, GrepInvert or SampleRate before and after
+	// each line that passes them, dimmed, so that what led to an error and
+	// what came of it can be read along with it. GrepContext is ignored
+	// when either is set.
+	ContextBefore int
+	ContextAfter  int
+
+	// SampleRate keeps only one in this many of the lines that pass the
+	// other filters, to get the gist of a stream too busy to read. Lines
+	// at least as severe as SampleKeepLevel are kept regardless. Zero or
+	// one keeps them all.
+	SampleRate      uint64
+	SampleKeepLevel string
+
+	// SkipLines is how many lines to skip before printing any.
+	SkipLines uint64
+	// MaxLines is how many lines to print before stopping. Zero means no
+	// limit.
+	MaxLines uint64
+
+	// HumanizeKeys renders the numeric values of the given keys in a human
+	// friendly way, according to their unit: one of UnitNanoseconds,
+	// UnitMicroseconds, UnitMilliseconds, UnitSeconds, UnitBytes (powers of
+	// 1024) or UnitBytesSI (powers of 1000).
+	HumanizeKeys map[string]string
+	// HumanizeDurations renders the values of keys named like they hold
+	// durations, like `latency` or `elapsed_ms`, and the values written
+	// like Go durations, in human units, like 1.24s. Those at least as
+	// long as SlowDuration, if set, are in the color of errors.
+	HumanizeDurations bool
+	SlowDuration      time.Duration
+	// HumanizeSizes renders the values of keys named like they hold sizes,
+	// like `bytes_sent` or `content_length`, in powers of 1024, like
+	// 1.4MiB. The JSON and logfmt outputs keep them as they were.
+	HumanizeSizes bool
+
+	// EmphasizeLevel paints whole lines at least this severe, like error,
+	// in EmphasisColor, so that they stand out of a stream. Empty means
+	// none.
+	EmphasizeLevel string
+	EmphasisColor  *color.Color
+
+	// GapThreshold writes a rule telling how long it has been between two
+	// lines when it has been longer than this, so that a lull in a stream
+	// stands out. Zero means never.
+	GapThreshold time.Duration
+
+	// Stats, when set, counts the lines that pass the filters.
+	Stats *Stats
+
+	// Watch, when set, follows the values of some keys among the lines
+	// that pass the filters.
+	Watch *Watch
+	// Controls, when set, changes some of these options as lines are
+	// scanned, like Width when the terminal is resized. ScannerParallel
+	// ignores it.
+	Controls *Controls
+	// Metrics, when set, counts all the lines read, filtered or not.
+	Metrics *Metrics
+
+	// CollapseRepeats writes the lines that repeat the one before them, but
+	// for their time, as a count of repeats once another line comes.
+	CollapseRepeats bool
+
+	// Highlights paint their matches in the messages and values of lines
+	// in their own colors.
+	Highlights []Highlight
+
+	// RenameKeys shows the keys of the map under the names they map to, so
+	// that long keys like `kubernetes.pod_name` read as `pod`. Keys are
+	// still matched by their original name everywhere else.
+	RenameKeys map[string]string
+
+	// LevelMapping maps levels as they are logged, like `notice` or `30`,
+	// onto normalized level names (see DebugLevel, InfoLevel, etc). Levels
+	// are looked up as is, then lowercased. It takes precedence over how
+	// each format usually names its levels.
+	LevelMapping map[string]string
+
+	// LevelLabels overrides the text of the level labels, keyed by
+	// normalized level name (see DebugLevel, InfoLevel, etc).
+	LevelLabels map[string]string
+
+	// LevelStyle is the decoration around level labels, one of
+	// LevelStyleBars (the default), LevelStyleBracket or LevelStylePlain.
+	LevelStyle string
+
+	// MessageColorByLevel overrides the color of messages of the given
+	// normalized levels.
+	MessageColorByLevel map[string]*color.Color
+
+	// Theme is the name of the last theme applied with ApplyTheme.
+	Theme string
+	// ColorDepth is how many colors the terminal has, one of Colors16,
+	// Colors256 and ColorsTrue, which SetColor and templates paint with,
+	// using the closest colors it has for the others. Zero is Colors16.
+	ColorDepth int
+
 	KeyColor              *color.Color
 	ValColor              *color.Color
+	BoolColor             *color.Color
+	NumberColor           *color.Color
+	StringColor           *color.Color
+	NullColor             *color.Color
 	TimeLightBgColor      *color.Color
 	TimeDarkBgColor       *color.Color
 	MsgLightBgColor       *color.Color
@@ -64,25 +458,122 @@ type HandlerOptions struct {
 	PanicLevelColor       *color.Color
 	FatalLevelColor       *color.Color
 	UnknownLevelColor     *color.Color
+	RawColor              *color.Color
+	StatusSuccessColor    *color.Color
+	StatusClientErrColor  *color.Color
+	StatusServerErrColor  *color.Color
+
+	// the patterns found in Skip and Keep
+	patterns *keyPatterns
+	// the times relative ones are computed from
+	clock *timeClock
 }
 
 func (h *HandlerOptions) shouldShowKey(key string) bool {
-	if len(h.Keep) != 0 {
-		if _, keep := h.Keep[key]; keep {
-			return true
-		}
+	return h.showKey(key, false)
+}
+
+func (h *HandlerOptions) showKey(key string, autoSkipUnderscore bool) bool {
+	if len(h.Select) > 0 {
+		return h.selectsKey(key)
 	}
-	if len(h.Skip) != 0 {
-		if _, skip := h.Skip[key]; skip {
-			return false
-		}
+	// exact keys take precedence over patterns
+	if hasKey(h.Keep, key) {
+		return true
+	}
+	if hasKey(h.Skip, key) {
+		return false
+	}
+	if h.keyPatterns().keep.matches(key) {
+		return true
 	}
+	if h.keyPatterns().skip.matches(key) {
+		return false
+	}
+
+	// definitely not a keep -- autoskip underscored
+	if autoSkipUnderscore && strings.HasPrefix(key, "_") {
+		return false
+	}
+
 	return true
 }
 
 func (h *HandlerOptions) shouldShowUnchanged(key string) bool {
-	if len(h.Keep) != 0 {
-		if _, keep := h.Keep[key]; keep {
+	return hasKey(h.Keep, key) || h.keyPatterns().keep.matches(key)
+}
+
+// paint renders s in color c, unless colors are disabled.
+func (h *HandlerOptions) paint(c *color.Color, s string) string {
+	if h.DisableColors || c == nil || color.NoColor {
+		// spare the allocation of Sprint, which wouldn't color it either
+		return s
+	}
+	return c.Sprint(s)
+}
+
+// valColor is the color of a rendered value, by the type it looks like:
+// booleans, numbers and quoted strings each have their own, while nulls
+// and empty strings are dimmed. Those without a color of their own, like
+// when the options weren't built from a theme, are in ValColor.
+func (h *HandlerOptions) valColor(v string) *color.Color {
+	var c *color.Color
+	switch {
+	case v == "true" || v == "false":
+		c = h.BoolColor
+	case v == "null" || v == `""` || v == "":
+		c = h.NullColor
+	case len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"':
+		c = h.StringColor
+	case isNumber(v):
+		c = h.NumberColor
+	}
+	if c == nil {
+		return h.ValColor
+	}
+	return c
+}
+
+// isNumber tells if v is written like a JSON number, leaving out what
+// strconv would parse as a float besides, like Inf or 0x1p-2.
+func isNumber(v string) bool {
+	if v == "" || !(v[0] == '-' || v[0] >= '0' && v[0] <= '9') {
+		return false
+	}
+	if strings.ContainsAny(v, "xXpP_") {
+		return false
+	}
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b[@-Z\\-_]`)
+
+// sanitize cleans up a value coming from the input before it is rendered.
+func (h *HandlerOptions) sanitize(s string) string {
+	if h != nil && h.StripInputANSI {
+		s = ansiEscape.ReplaceAllString(s, "")
+	}
+	return s
+}
+
+// quote renders a string value coming from the input.
+func (h *HandlerOptions) quote(s string) string {
+	s = h.sanitize(s)
+	if h != nil && h.UnquoteSimpleStrings && !needsQuotes(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+func needsQuotes(s string) bool {
+	switch s {
+	case "", "true", "false", "null":
+		// would be mistaken for an empty or a non string value
+		return true
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || r == '\\' || unicode.IsSpace(r) || unicode.IsControl(r) || r == utf8.RuneError {
 			return true
 		}
 	}
@@ -96,6 +587,7 @@ func (h *HandlerOptions) SetSkip(skip []string) {
 	for _, key := range skip {
 		h.Skip[key] = struct{}{}
 	}
+	h.patterns = nil
 }
 
 func (h *HandlerOptions) SetKeep(keep []string) {
@@ -105,4 +597,5 @@ func (h *HandlerOptions) SetKeep(keep []string) {
 	for _, key := range keep {
 		h.Keep[key] = struct{}{}
 	}
+	h.patterns = nil
 }
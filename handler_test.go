@@ -0,0 +1,241 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestAutoSkipUnderscore(t *testing.T) {
+	line := []byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello","_internal_id":"abc123"}`)
+	entry := []byte(`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"hello","_PID":"1234"}`)
+
+	t.Run("journal hidden by default", func(t *testing.T) {
+		opts := *DefaultOptions
+		opts.JournalVerbose = true
+		out, ok := Prettify(entry, &opts)
+		if !ok {
+			t.Fatal("expected entry to be handled")
+		}
+		if bytes.Contains(out, []byte("_PID")) {
+			t.Fatalf("expected underscored key to be hidden, got %q", out)
+		}
+	})
+	t.Run("journal shown when disabled", func(t *testing.T) {
+		opts := *DefaultOptions
+		opts.JournalVerbose = true
+		opts.AutoSkipUnderscore = false
+		out, ok := Prettify(entry, &opts)
+		if !ok {
+			t.Fatal("expected entry to be handled")
+		}
+		if !bytes.Contains(out, []byte("_PID")) {
+			t.Fatalf("expected underscored key to be shown, got %q", out)
+		}
+	})
+	t.Run("other formats always shown", func(t *testing.T) {
+		opts := *DefaultOptions
+		h := JSONHandler{Opts: &opts}
+		if _, ok := h.TryHandle(line); !ok {
+			t.Fatal("expected line to be handled")
+		}
+		out := h.Prettify(false)
+		if !bytes.Contains(out, []byte("_internal_id")) {
+			t.Fatalf("expected underscored key to be shown, got %q", out)
+		}
+	})
+}
+
+func TestStripInputANSI(t *testing.T) {
+	line := []byte(`{"time":"2018-10-24T08:19:50Z","level":"error","msg":"\u001b[31mboom\u001b[0m","who":"\u001b[1mme\u001b[0m"}`)
+
+	opts := *DefaultOptions
+	opts.StripInputANSI = true
+	h := JSONHandler{Opts: &opts}
+	if _, ok := h.TryHandle(line); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	out := h.Prettify(false)
+	if bytes.Contains(out, []byte("\x1b")) || bytes.Contains(out, []byte(`\x1b`)) {
+		t.Fatalf("expected escape codes to be removed, got %q", out)
+	}
+	if !bytes.Contains(out, []byte("boom")) || !bytes.Contains(out, []byte(`who="me"`)) {
+		t.Fatalf("expected message and value to survive, got %q", out)
+	}
+}
+
+func TestBoolAndNullValues(t *testing.T) {
+	line := []byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello","ok":true,"retried":false,"error":null,"str":"true"}`)
+
+	opts := *DefaultOptions
+	h := JSONHandler{Opts: &opts}
+	if _, ok := h.TryHandle(line); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	out := h.Prettify(false)
+	for _, want := range []string{"ok=true", "retried=false", "error=null", `str="true"`} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+
+	for v, want := range map[string]*color.Color{
+		"true":   opts.BoolColor,
+		"false":  opts.BoolColor,
+		"null":   opts.NullColor,
+		`"true"`: opts.StringColor,
+		`""`:     opts.NullColor,
+		"42":     opts.NumberColor,
+		"-1.5e3": opts.NumberColor,
+		"Inf":    opts.ValColor,
+		"12ms":   opts.ValColor,
+		"[1,2]":  opts.ValColor,
+	} {
+		if got := opts.valColor(v); got != want {
+			t.Fatalf("wrong color for %s", v)
+		}
+	}
+
+	plain := HandlerOptions{ValColor: opts.ValColor}
+	if got := plain.valColor("42"); got != opts.ValColor {
+		t.Fatalf("want ValColor for types without a color")
+	}
+}
+
+func TestUnquoteSimpleStrings(t *testing.T) {
+	opts := *DefaultOptions
+	opts.UnquoteSimpleStrings = true
+	h := JSONHandler{Opts: &opts}
+	ev := []byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hi","simple":"bar","spaced":"has spaces","eq":"a=b","empty":"","boolish":"true"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	want := map[string]string{
+		"simple":  `bar`,
+		"spaced":  `"has spaces"`,
+		"eq":      `"a=b"`,
+		"empty":   `""`,
+		"boolish": `"true"`,
+	}
+	for k, v := range want {
+		if h.Fields[k] != v {
+			t.Fatalf("want %s=%s, got %s", k, v, h.Fields[k])
+		}
+	}
+
+	opts.UnquoteSimpleStrings = false
+	h = JSONHandler{Opts: &opts}
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Fields["simple"] != `"bar"` {
+		t.Fatalf("want strings quoted by default, got %s", h.Fields["simple"])
+	}
+}
+
+func TestKeyPatterns(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Skip, opts.Keep = nil, nil
+	opts.SetSkip([]string{"http.*", "_SYSTEMD_*"})
+	opts.SetKeep([]string{"http.status"})
+
+	for key, want := range map[string]bool{
+		"http.method":     false,
+		"http.path":       false,
+		"http.status":     true,
+		"_SYSTEMD_UNIT":   false,
+		"_systemd_slice":  true,
+		"httpx":           true,
+		"user":            true,
+		"http.status.sub": false,
+	} {
+		if got := opts.shouldShowKey(key); got != want {
+			t.Fatalf("key %q: want shown=%v, got %v", key, want, got)
+		}
+	}
+
+	opts.SetKeep([]string{"_SYSTEMD_UNIT"})
+	if !opts.shouldShowKey("_SYSTEMD_UNIT") {
+		t.Fatal("want an exact keep to override a skip pattern")
+	}
+}
+
+func TestKeyRegexps(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Skip, opts.Keep = nil, nil
+	opts.SetSkip([]string{`/^kube(rnetes)?\./`, "/[/"})
+	opts.SetKeep([]string{"/_id$/"})
+
+	for key, want := range map[string]bool{
+		"kubernetes.pod":    false,
+		"kube.namespace":    false,
+		"Kubernetes.Host":   false,
+		"kubernetes.pod_id": true,
+		"kubelet":           true,
+		"/[/":               false,
+		"user":              true,
+		"_id":               true,
+	} {
+		if got := opts.shouldShowKey(key); got != want {
+			t.Fatalf("key %q: want shown=%v, got %v", key, want, got)
+		}
+	}
+}
+
+func TestRenameKeys(t *testing.T) {
+	opts := *DefaultOptions
+	opts.NestedObjects = NestedObjectsFlatten
+	opts.RenameKeys = map[string]string{"request_id": "rid", "kubernetes.pod_name": "pod"}
+	opts.SetSkip([]string{"kubernetes.namespace"})
+	h := JSONHandler{Opts: &opts}
+	ev := []byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello","request_id":"abc","kubernetes":{"pod_name":"api-1","namespace":"prod"}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	got := string(h.Prettify(false))
+	for _, want := range []string{`rid="abc"`, `pod="api-1"`} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q in the output, got %q", want, got)
+		}
+	}
+	if strings.Contains(got, "request_id") || strings.Contains(got, "kubernetes") {
+		t.Fatalf("want the original keys renamed or skipped, got %q", got)
+	}
+}
+
+func TestFirstKeys(t *testing.T) {
+	opts := *DefaultOptions
+	opts.FirstKeys = []string{"err", "status", "duration"}
+	h := JSONHandler{Opts: &opts}
+	ev := []byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello","a":"1","duration":"3ms","status":200,"zzz":"last"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	got := string(h.Prettify(false))
+	status, duration, a := strings.Index(got, "status="), strings.Index(got, "duration="), strings.Index(got, "a=")
+	if status < 0 || duration < 0 || a < 0 || !(status < duration && duration < a) {
+		t.Fatalf("want status, duration and then the other keys, got %q", got)
+	}
+}
+
+func TestPrefixKeys(t *testing.T) {
+	opts := *DefaultOptions
+	opts.PrefixKeys = []string{"env", "service", "region"}
+	h := JSONHandler{Opts: &opts}
+	ev := []byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello","service":"api","env":"prod","user":"bob"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	got := string(h.Prettify(false))
+	if !strings.Contains(got, "|INFO| [prod/api] hello") {
+		t.Fatalf("want the bracketed prefix after the level, got %q", got)
+	}
+	if strings.Contains(got, "service=") || strings.Contains(got, "env=") {
+		t.Fatalf("want the prefix keys out of the fields, got %q", got)
+	}
+	if !strings.Contains(got, `user="bob"`) {
+		t.Fatalf("want the other fields kept, got %q", got)
+	}
+}
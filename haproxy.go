@@ -0,0 +1,81 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// haproxyHTTPLine matches the HTTP log format of HAProxy, like
+// `10.0.1.2:33317 [06/Feb/2009:12:14:14.655] http-in static/srv1 10/0/30/69/109 200 2750 - - ---- 1/1/1/1/0 0/0 "GET /index.html HTTP/1.1"`,
+// which usually comes after the header of syslog.
+var haproxyHTTPLine = regexp.MustCompile(`(?:^|: )(\S+):(\d+) \[(\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2}\.\d{3})\] (\S+) ([^/\s]+)/(\S+) (-?\d+)/(-?\d+)/(-?\d+)/(-?\d+)/\+?(-?\d+) (-?\d+) \+?(\d+) \S+ \S+ (\S{4}) (\d+)/(\d+)/(\d+)/(\d+)/\+?(\d+) (\d+)/(\d+)(?: \{[^}]*\}){0,2} "([^"]*)"$`)
+
+// haproxyTimeLayout is the layout of the time HAProxy accepted the
+// connection at, in local time.
+const haproxyTimeLayout = "02/Jan/2006:15:04:05.000"
+
+// haproxyTimers are the keys of the timers of a request, in the order they
+// are logged. They are in milliseconds, which their keys tell, so that they
+// are humanized as durations.
+var haproxyTimers = []string{"tq_ms", "tw_ms", "tc_ms", "tr_ms", "tt_ms"}
+
+// matchHAProxy tells if the line held by lh is a request logged by HAProxy
+// in its HTTP log format, in which case the logrus handler gets its parts.
+// Timers of steps that weren't reached, logged as -1, are left out. The
+// level is derived from the class of the status code, requests aborted
+// before it was known being warnings.
+func (lh *lineHandler) matchHAProxy() bool {
+	// the regexp is costly, and tried on every line
+	if !bytes.HasSuffix(lh.lineData, []byte(`"`)) || !bytes.Contains(lh.lineData, []byte("] ")) {
+		return false
+	}
+	m := haproxyHTTPLine.FindSubmatch(lh.lineData)
+	if m == nil {
+		return false
+	}
+	t, err := time.ParseInLocation(haproxyTimeLayout, string(m[3]), time.Local)
+	if err != nil {
+		return false
+	}
+	h := &lh.logrusEntry
+	h.Time = t
+	h.setField([]byte("client_ip"), m[1])
+	h.setField([]byte("client_port"), m[2])
+	h.setField([]byte("frontend"), m[4])
+	h.setField([]byte("backend"), m[5])
+	h.setField([]byte("server"), m[6])
+	for i, key := range haproxyTimers {
+		if timer := m[7+i]; !bytes.Equal(timer, []byte("-1")) {
+			h.setField([]byte(key), timer)
+		}
+	}
+	status := m[12]
+	h.setField([]byte("status"), status)
+	h.setField([]byte("bytes"), m[13])
+	h.setField([]byte("termination_state"), m[14])
+	for i, key := range []string{"actconn", "feconn", "beconn", "srv_conn", "retries", "srv_queue", "backend_queue"} {
+		h.setField([]byte(key), m[15+i])
+	}
+
+	request := strings.Fields(string(m[22]))
+	if len(request) == 3 {
+		h.Message = request[0] + " " + request[1]
+		h.Fields["method"] = request[0]
+		h.Fields["path"] = request[1]
+		h.Fields["proto"] = request[2]
+	} else {
+		h.Message = string(m[22])
+	}
+
+	switch status[0] {
+	case '5':
+		h.Level = ErrorLevel
+	case '4', '-':
+		h.Level = WarnLevel
+	default:
+		h.Level = InfoLevel
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHAProxyLine(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	lh := newLineHandler(&opts)
+
+	line := `Feb  6 12:14:14 localhost haproxy[14389]: 10.0.1.2:33317 [06/Feb/2009:12:14:14.655] http-in static/srv1 10/0/30/1500/1540 503 2750 - - SC-- 1/1/1/1/0 0/0 {1wt.eu} {} "GET /index.html HTTP/1.1"`
+	if format := lh.match([]byte(line)); format != "haproxy" {
+		t.Fatalf("want haproxy format, got %q", format)
+	}
+	if want := time.Date(2009, 2, 6, 12, 14, 14, 655000000, time.Local); !lh.ev.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, lh.ev.Time)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{
+		"|ERRO| GET /index.html", "client_ip=10.0.1.2", "frontend=http-in", "backend=static", "server=srv1",
+		"tq_ms=10ms", "tc_ms=30ms", "tr_ms=1.5s", "tt_ms=1.54s",
+		"status=503", "termination_state=SC--", "srv_queue=0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestHAProxyAborted(t *testing.T) {
+	lh := newLineHandler(DefaultOptions)
+	line := `10.0.1.2:33317 [06/Feb/2009:12:14:14.655] http-in static/<NOSRV> 5000/-1/-1/-1/5001 -1 0 - - CR-- 1/1/0/0/0 0/0 "<BADREQ>"`
+	if format := lh.match([]byte(line)); format != "haproxy" {
+		t.Fatalf("want haproxy format, got %q", format)
+	}
+	if lh.ev.Level != WarnLevel {
+		t.Fatalf("want an aborted request to be a warning, got %q", lh.ev.Level)
+	}
+	if _, ok := lh.ev.Fields["tw_ms"]; ok {
+		t.Fatalf("want timers not reached left out, got %v", lh.ev.Fields)
+	}
+}
@@ -0,0 +1,54 @@
+package humanlog
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/jigish/humanlog/parser/logfmt"
+)
+
+// herokuPrefix matches what Heroku's syslog drains put in front of logfmt
+// lines, like `<158>2012-10-11T03:47:20+00:00 host heroku[router]: `.
+var herokuPrefix = regexp.MustCompile(`^<\d{1,3}>(?:1 )?(\S+) \S+ ([^\s\[\]]+)\[([^\]\s]+)\]: `)
+
+// matchHeroku tells if the line held by lh is behind a Heroku drain prefix,
+// in which case the logrus handler gets what follows it: its logfmt pairs,
+// or else the text itself as the message.
+func (lh *lineHandler) matchHeroku() bool {
+	m := herokuPrefix.FindSubmatchIndex(lh.lineData)
+	if m == nil {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(lh.lineData[m[2]:m[3]]))
+	if err != nil {
+		return false
+	}
+	app := lh.lineData[m[4]:m[5]]
+	proc := lh.lineData[m[6]:m[7]]
+
+	h := &lh.logrusEntry
+	rest := lh.lineData[m[1]:]
+	pairs := 0
+	logfmt.Parse(rest, true, true, func(key, val []byte) bool {
+		pairs++
+		return h.visit(key, val)
+	})
+	if pairs == 0 {
+		h.setMessage(rest)
+	}
+	if h.Time.IsZero() {
+		h.Time = t
+	}
+	// router lines tell their level with `at`
+	if at, ok := h.Fields["at"]; ok && h.Level == "" {
+		h.Level = at
+		delete(h.Fields, "at")
+	}
+	if _, ok := h.Fields["app"]; !ok {
+		h.setField([]byte("app"), app)
+	}
+	if _, ok := h.Fields["proc"]; !ok {
+		h.setField([]byte("proc"), proc)
+	}
+	return true
+}
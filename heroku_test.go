@@ -0,0 +1,100 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHerokuRouterLine(t *testing.T) {
+	line := `<158>2012-10-11T03:47:20+00:00 d.f12f2e1f-6dd5-4c0b-8b3e-3a8f6e5a0f3b heroku[router]: at=info method=GET path="/" host=myapp.herokuapp.com request_id=8601b555-6a83-4c12-8269-97c8e32cdb22 fwd="204.204.204.204" dyno=web.1 connect=1ms service=18ms status=200 bytes=13`
+
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+	lh := newLineHandler(&opts)
+	if format := lh.match([]byte(line)); format != "heroku" {
+		t.Fatalf("want heroku format, got %q", format)
+	}
+	if want := time.Date(2012, 10, 11, 3, 47, 20, 0, time.UTC); !lh.ev.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, lh.ev.Time)
+	}
+
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{"[heroku]", "|INFO|", "method=GET", "status=200", "app=heroku", "proc=router", "dyno=web.1"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestHerokuTextLine(t *testing.T) {
+	line := `<190>2012-10-11T03:47:21+00:00 d.f12f2e1f-6dd5-4c0b-8b3e-3a8f6e5a0f3b app[web.1]: Starting process with command bundle exec puma`
+
+	opts := *DefaultOptions
+	opts.Truncates = false
+	lh := newLineHandler(&opts)
+	if format := lh.match([]byte(line)); format != "heroku" {
+		t.Fatalf("want heroku format, got %q", format)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{"Starting process with command bundle exec puma", "app=app", "proc=web.1"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestHerokuPrefixLeavesOtherLinesAlone(t *testing.T) {
+	lh := newLineHandler(DefaultOptions)
+	for line, want := range map[string]string{
+		`time="2018-10-24T08:19:50Z" level=info msg="hello" at=home`: "logrus",
+		`<14>1 2018-10-24T08:19:50Z host app - - - hello`:            "syslog",
+		`<6>just a systemd prefix`:                                   "systemd",
+	} {
+		if got := lh.match([]byte(line)); got != want {
+			t.Fatalf("want %q to be %s, got %s", line, want, got)
+		}
+	}
+}
+
+func TestUnwrapHerokuTail(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+
+	out, ok := Prettify([]byte(`2024-01-02T03:04:05.123456+00:00 app[web.1]: {"time":"2024-01-02T03:04:06Z","level":"warn","msg":"slow"}`), &opts)
+	if !ok || !strings.HasPrefix(string(out), "[json]") || !strings.Contains(string(out), "|WARN| slow") {
+		t.Fatalf("want the inner JSON prettified, got %q", out)
+	}
+	for _, want := range []string{"app=app", "proc=web.1"} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+
+	for line, want := range map[string]string{
+		`2024-01-02T03:04:05.123456+00:00 heroku[router]: at=info method=GET path="/" status=200`:   "|INFO|",
+		`2024-01-02T03:04:05.123456+00:00 heroku[router]: at=error code=H12 desc="Request timeout"`: "|ERRO|",
+		`2024-01-02T03:04:05.123456+00:00 heroku[router]: sock=client at=warning`:                   "|INFO|",
+	} {
+		out, ok = Prettify([]byte(line), &opts)
+		if !ok || !strings.Contains(string(out), want) || !strings.Contains(string(out), "proc=router") {
+			t.Fatalf("want %s in the router line, got %q", want, out)
+		}
+	}
+
+	out, ok = Prettify([]byte(`2024-01-02T03:04:05.123456+00:00 app[web.1]: Listening on 3000`), &opts)
+	if !ok || !strings.HasPrefix(string(out), "[logplex]") {
+		t.Fatalf("want a plain message handled, got %q", out)
+	}
+	for _, want := range []string{"Jan  2 03:04:05", "Listening on 3000", "app=app", "proc=web.1"} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package humanlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Highlight paints the matches of a regexp in the messages and values of
+// lines, so that an ID or an error can be followed along a stream.
+type Highlight struct {
+	Regexp *regexp.Regexp
+	Color  *color.Color
+}
+
+// defaultHighlightColor is the color of highlights that don't name one.
+var defaultHighlightColor = color.New(color.BgYellow, color.FgBlack)
+
+// ParseHighlight parses a highlight written as `pattern[:color]`, like
+// `req-[0-9a-f]+:hired`. The color is one of those templates know, and
+// black on yellow when left out.
+func ParseHighlight(s string) (Highlight, error) {
+	pattern, c := s, defaultHighlightColor
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		if attr, ok := templateColors[s[i+1:]]; ok {
+			pattern, c = s[:i], color.New(attr)
+		}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Highlight{}, err
+	}
+	if re.MatchString("") {
+		return Highlight{}, fmt.Errorf("%q matches empty strings", pattern)
+	}
+	return Highlight{Regexp: re, Color: c}, nil
+}
+
+// paintHighlighted renders s in color c, but for the matches of Highlights
+// which are in their own colors. The first highlight to match a part of s
+// wins.
+func (h *HandlerOptions) paintHighlighted(c *color.Color, s string) string {
+	if len(h.Highlights) == 0 || h.DisableColors || color.NoColor {
+		return h.paint(c, s)
+	}
+	// the highlight each byte of s falls in, if any
+	var marks []int
+	for i, hl := range h.Highlights {
+		for _, loc := range hl.Regexp.FindAllStringIndex(s, -1) {
+			if marks == nil {
+				marks = make([]int, len(s))
+			}
+			for j := loc[0]; j < loc[1]; j++ {
+				if marks[j] == 0 {
+					marks[j] = i + 1
+				}
+			}
+		}
+	}
+	if marks == nil {
+		return h.paint(c, s)
+	}
+	var out strings.Builder
+	for start := 0; start < len(s); {
+		end := start
+		for end < len(s) && marks[end] == marks[start] {
+			end++
+		}
+		if mark := marks[start]; mark > 0 {
+			out.WriteString(h.paint(h.Highlights[mark-1].Color, s[start:end]))
+		} else {
+			out.WriteString(h.paint(c, s[start:end]))
+		}
+		start = end
+	}
+	return out.String()
+}
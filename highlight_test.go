@@ -0,0 +1,52 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseHighlight(t *testing.T) {
+	hl, err := ParseHighlight("req-[0-9]+:hired")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hl.Regexp.String() != "req-[0-9]+" || !hl.Color.Equals(color.New(color.FgHiRed)) {
+		t.Fatalf("want the pattern and its color, got %v %v", hl.Regexp, hl.Color)
+	}
+
+	// a colon that isn't followed by a color is part of the pattern
+	hl, err = ParseHighlight("at 12:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hl.Regexp.String() != "at 12:30" || hl.Color != defaultHighlightColor {
+		t.Fatalf("want the whole pattern in the default color, got %v %v", hl.Regexp, hl.Color)
+	}
+
+	if _, err := ParseHighlight("a*"); err == nil {
+		t.Fatal("want patterns matching empty strings rejected")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	hl, err := ParseHighlight("req-42:hired")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := *DefaultOptions
+	opts.Highlights = []Highlight{hl}
+	h := JSONHandler{Opts: &opts}
+	if _, ok := h.TryHandle([]byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"handling req-42","id":"req-42"}`)); !ok {
+		t.Fatal("should handle the line")
+	}
+	out := string(h.Prettify(false))
+	painted := hl.Color.Sprint("req-42")
+	if strings.Count(out, painted) != 2 {
+		t.Fatalf("want req-42 highlighted in the message and the value, got %q", out)
+	}
+}
@@ -0,0 +1,266 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ansiPalette are the CSS colors of the 16 basic ANSI colors, the bright
+// ones last.
+var ansiPalette = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>humanlog</title>
+<style>
+body { margin: 0; background: %s; color: %s; }
+pre { margin: 0; padding: 1em; font-family: Menlo, Consolas, "DejaVu Sans Mono", monospace; font-size: 13px; line-height: 1.4; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<pre>`
+
+const htmlFooter = `</pre>
+</body>
+</html>
+`
+
+// sgrStyle is the state of the Select Graphic Rendition escape sequences
+// seen so far.
+type sgrStyle struct {
+	fg, bg                         string
+	bold, faint, italic, underline bool
+}
+
+func (s sgrStyle) css() string {
+	var css []string
+	if s.fg != "" {
+		css = append(css, "color: "+s.fg)
+	}
+	if s.bg != "" {
+		css = append(css, "background: "+s.bg)
+	}
+	if s.bold {
+		css = append(css, "font-weight: bold")
+	}
+	if s.faint {
+		css = append(css, "opacity: 0.6")
+	}
+	if s.italic {
+		css = append(css, "font-style: italic")
+	}
+	if s.underline {
+		css = append(css, "text-decoration: underline")
+	}
+	return strings.Join(css, "; ")
+}
+
+// HTMLWriter turns the ANSI colored text written to it into a standalone
+// HTML page, colors becoming styled spans. Escape sequences other than
+// colors are dropped. Close must be called to end the page.
+type HTMLWriter struct {
+	w       io.Writer
+	lightBg bool
+
+	started bool
+	style   sgrStyle
+	open    bool
+	// an escape sequence cut between two writes
+	pending []byte
+}
+
+// NewHTMLWriter writes HTML onto w, for a page with a light background
+// if lightBg is set, and a dark one otherwise.
+func NewHTMLWriter(w io.Writer, lightBg bool) *HTMLWriter {
+	return &HTMLWriter{w: w, lightBg: lightBg}
+}
+
+func (hw *HTMLWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	var out strings.Builder
+	if !hw.started {
+		hw.started = true
+		bg, fg := "#1e1e1e", "#d4d4d4"
+		if hw.lightBg {
+			bg, fg = "#ffffff", "#1e1e1e"
+		}
+		fmt.Fprintf(&out, htmlHeader, bg, fg)
+	}
+	if len(hw.pending) > 0 {
+		p = append(hw.pending, p...)
+		hw.pending = nil
+	}
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\x1b')
+		if i < 0 {
+			out.WriteString(html.EscapeString(string(p)))
+			break
+		}
+		out.WriteString(html.EscapeString(string(p[:i])))
+		p = p[i:]
+
+		end := escapeEnd(p)
+		if end < 0 {
+			hw.pending = append([]byte(nil), p...)
+			break
+		}
+		if len(p) > 2 && p[1] == '[' && p[end-1] == 'm' {
+			hw.applySGR(&out, string(p[2:end-1]))
+		}
+		p = p[end:]
+	}
+	if _, err := io.WriteString(hw.w, out.String()); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Close ends the page, without closing the underlying writer.
+func (hw *HTMLWriter) Close() error {
+	if !hw.started {
+		if _, err := hw.Write(nil); err != nil {
+			return err
+		}
+	}
+	var out strings.Builder
+	if hw.open {
+		out.WriteString("</span>")
+		hw.open = false
+	}
+	out.WriteString(htmlFooter)
+	_, err := io.WriteString(hw.w, out.String())
+	return err
+}
+
+// escapeEnd is the length of the escape sequence p starts with, or -1 if
+// p ends before it does.
+func escapeEnd(p []byte) int {
+	if len(p) < 2 {
+		return -1
+	}
+	switch p[1] {
+	case '[':
+	case ']':
+		// operating system commands end with BEL or ST
+		for i := 2; i < len(p); i++ {
+			switch {
+			case p[i] == '\a':
+				return i + 1
+			case p[i] == '\x1b' && i+1 < len(p) && p[i+1] == '\\':
+				return i + 2
+			}
+		}
+		return -1
+	default:
+		return 2
+	}
+	for i := 2; i < len(p); i++ {
+		if p[i] >= '@' && p[i] <= '~' {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func (hw *HTMLWriter) applySGR(out *strings.Builder, params string) {
+	style := hw.style
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil && codes[i] != "" {
+			continue
+		}
+		switch {
+		case code == 0:
+			style = sgrStyle{}
+		case code == 1:
+			style.bold = true
+		case code == 2:
+			style.faint = true
+		case code == 3:
+			style.italic = true
+		case code == 4:
+			style.underline = true
+		case code == 22:
+			style.bold, style.faint = false, false
+		case code == 23:
+			style.italic = false
+		case code == 24:
+			style.underline = false
+		case code >= 30 && code <= 37:
+			style.fg = ansiPalette[code-30]
+		case code >= 90 && code <= 97:
+			style.fg = ansiPalette[code-90+8]
+		case code == 39:
+			style.fg = ""
+		case code >= 40 && code <= 47:
+			style.bg = ansiPalette[code-40]
+		case code >= 100 && code <= 107:
+			style.bg = ansiPalette[code-100+8]
+		case code == 49:
+			style.bg = ""
+		case code == 38 || code == 48:
+			css, n := extendedColor(codes[i+1:])
+			i += n
+			if code == 38 {
+				style.fg = css
+			} else {
+				style.bg = css
+			}
+		}
+	}
+	if style == hw.style {
+		return
+	}
+	hw.style = style
+	if hw.open {
+		out.WriteString("</span>")
+		hw.open = false
+	}
+	if css := style.css(); css != "" {
+		out.WriteString(`<span style="` + css + `">`)
+		hw.open = true
+	}
+}
+
+// extendedColor reads the CSS color of 256 colors or true colors codes,
+// like `5;208` or `2;255;128;0`, and how many codes it was made of.
+func extendedColor(codes []string) (string, int) {
+	atoi := func(i int) int {
+		if i >= len(codes) {
+			return 0
+		}
+		n, _ := strconv.Atoi(codes[i])
+		return n
+	}
+	switch atoi(0) {
+	case 5:
+		n := atoi(1)
+		switch {
+		case n < 0:
+			return "", 2
+		case n < 16:
+			return ansiPalette[n], 2
+		case n < 232:
+			n -= 16
+			levels := [6]int{0, 95, 135, 175, 215, 255}
+			return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6]), 2
+		case n < 256:
+			gray := 8 + (n-232)*10
+			return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray), 2
+		}
+		return "", 2
+	case 2:
+		return fmt.Sprintf("#%02x%02x%02x", atoi(1)&0xff, atoi(2)&0xff, atoi(3)&0xff), 4
+	}
+	return "", 0
+}
@@ -0,0 +1,43 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTMLWriter(t *testing.T) {
+	dst := bytes.NewBuffer(nil)
+	hw := NewHTMLWriter(dst, false)
+	// escape sequences may be cut between writes
+	for _, chunk := range []string{
+		"plain <b> & \x1b[3",
+		"1mred\x1b[0m \x1b[1;38;5;208mbold orange\x1b[22m orange\x1b[0m",
+		" \x1b[38;2;1;2;3mtrue\x1b[39m \x1b]8;;http://x\x07link\x1b]8;;\x1b\\\n",
+	} {
+		if _, err := hw.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := hw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"background: #1e1e1e",
+		"plain &lt;b&gt; &amp; ",
+		`<span style="color: #cd3131">red</span> `,
+		`<span style="color: #ff8700; font-weight: bold">bold orange</span>`,
+		`<span style="color: #ff8700"> orange</span>`,
+		"<span style=\"color: #010203\">true</span> link\n",
+		"</pre>\n</body>\n</html>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\x1b") {
+		t.Fatalf("want no escape sequence left, got\n%s", got)
+	}
+}
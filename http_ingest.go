@@ -0,0 +1,88 @@
+package humanlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// NewHTTPHandler prettifies onto dst the logs POSTed or PUT to it, so that
+// webhooks or log shippers like Vector or Fluent Bit can send their logs
+// to a terminal. Bodies are either lines, like NDJSON or plain text, or a
+// JSON array of entries when sent as `application/json`, and may be gzip
+// compressed. Lines of concurrent requests are written whole, never mixed
+// with one another.
+func NewHTTPHandler(dst io.Writer, opts *HandlerOptions) http.Handler {
+	return &httpIngest{w: &lineWriter{dst: dst, lh: newLineHandler(opts)}}
+}
+
+type httpIngest struct {
+	w *lineWriter
+}
+
+func (h *httpIngest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.Header().Set("Allow", "POST, PUT")
+		http.Error(w, "logs must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	in := bufio.NewReader(body)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" && startsWithArray(in) {
+		err := eachJSONArrayEntry(in, func(line []byte) bool {
+			h.w.writeLine(line)
+			return true
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	lines := h.w.lh.opts.lineReader(in)
+	for lines.Scan() {
+		if len(lines.Bytes()) > 0 {
+			h.w.writeLine(lines.Raw())
+		}
+	}
+	if err := lines.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// startsWithArray reports whether the first byte of in, past white space,
+// opens a JSON array.
+func startsWithArray(in *bufio.Reader) bool {
+	for n := 1; ; n++ {
+		peeked, err := in.Peek(n)
+		if err != nil {
+			return false
+		}
+		switch peeked[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		}
+		return false
+	}
+}
@@ -0,0 +1,76 @@
+package humanlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	dst := &lockedBuffer{}
+	srv := httptest.NewServer(NewHTTPHandler(dst, DefaultOptions))
+	defer srv.Close()
+
+	post := func(contentType, encoding string, body []byte) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/logs", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", encoding)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	ndjson := `{"time":"2018-10-24T08:00:00Z","level":"info","msg":"first"}` + "\n" +
+		`{"time":"2018-10-24T08:00:01Z","level":"warn","msg":"second"}` + "\n"
+	if code := post("application/x-ndjson", "", []byte(ndjson)); code != http.StatusNoContent {
+		t.Fatalf("want NDJSON accepted, got %d", code)
+	}
+
+	array := ` [{"time":"2018-10-24T08:00:02Z","level":"info","msg":"third"}, {"time":"2018-10-24T08:00:03Z","level":"info","msg":"fourth"}]`
+	if code := post("application/json; charset=utf-8", "", []byte(array)); code != http.StatusNoContent {
+		t.Fatalf("want a JSON array accepted, got %d", code)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("[plain] fifth\r\n"))
+	zw.Close()
+	if code := post("text/plain", "gzip", gz.Bytes()); code != http.StatusNoContent {
+		t.Fatalf("want gzipped text accepted, got %d", code)
+	}
+
+	if code := post("application/json", "", []byte(`[{"msg":`)); code != http.StatusBadRequest {
+		t.Fatalf("want a broken array rejected, got %d", code)
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("want GET rejected, got %d", resp.StatusCode)
+	}
+
+	got := dst.String()
+	for _, want := range []string{"first", "second", "third", "fourth", "[plain] fifth\n"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q written, got %q", want, got)
+		}
+	}
+	if strings.Contains(got, `"msg"`) {
+		t.Fatalf("want entries prettified, got %q", got)
+	}
+}
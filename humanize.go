@@ -0,0 +1,228 @@
+package humanlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unit hints understood by HandlerOptions.HumanizeKeys.
+const (
+	UnitNanoseconds  = "ns"
+	UnitMicroseconds = "us"
+	UnitMilliseconds = "ms"
+	UnitSeconds      = "s"
+	UnitBytes        = "bytes"
+	UnitBytesSI      = "bytes_si"
+)
+
+var durationUnits = map[string]time.Duration{
+	UnitNanoseconds:  time.Nanosecond,
+	UnitMicroseconds: time.Microsecond,
+	UnitMilliseconds: time.Millisecond,
+	UnitSeconds:      time.Second,
+}
+
+// durationKeySuffixes tell the unit of the values of keys ending with them,
+// like `elapsed_ms` or `tookNs`.
+var durationKeySuffixes = []struct {
+	suffix, unit string
+}{
+	{"ns", UnitNanoseconds}, {"nanos", UnitNanoseconds},
+	{"us", UnitMicroseconds}, {"micros", UnitMicroseconds},
+	{"ms", UnitMilliseconds}, {"millis", UnitMilliseconds},
+	{"sec", UnitSeconds}, {"secs", UnitSeconds}, {"seconds", UnitSeconds},
+}
+
+// durationKeyWords are found in the names of keys holding durations.
+var durationKeyWords = []string{"duration", "latency", "elapsed", "took"}
+
+// sizeKeyWords are found in the names of keys holding sizes in bytes, once
+// lowered and without separators.
+var sizeKeyWords = []string{"bytes", "size", "contentlength"}
+
+// isSizeKey tells if key is named like it holds a size in bytes, like
+// `bytes_sent`, `size` or `Content-Length`.
+func isSizeKey(key string) bool {
+	key = strings.ToLower(key)
+	key = strings.NewReplacer("_", "", "-", "", ".", "").Replace(key)
+	for _, word := range sizeKeyWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// humanize renders the numeric value of key in the unit configured for it
+// in HumanizeKeys, or as a duration if it looks like one with
+// HumanizeDurations. Other values are returned unchanged.
+func (h *HandlerOptions) humanize(key, val string) string {
+	if d, ok := h.duration(key, val); ok {
+		return humanizeDuration(d)
+	}
+	return h.humanizeUnit(key, val)
+}
+
+// humanizeUnit renders the numeric value of key in the unit configured for
+// it in HumanizeKeys, but for durations, or as a size in bytes if it is
+// named like one with HumanizeSizes.
+func (h *HandlerOptions) humanizeUnit(key, val string) string {
+	unit, ok := h.HumanizeKeys[key]
+	if !ok && h.HumanizeSizes && isSizeKey(key) {
+		unit, ok = UnitBytes, true
+	}
+	if !ok {
+		return val
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return val
+	}
+	switch unit {
+	case UnitBytes:
+		return humanizeBytes(f, 1024, "KMGTPE", "iB")
+	case UnitBytesSI:
+		return humanizeBytes(f, 1000, "kMGTPE", "B")
+	default:
+		return val
+	}
+}
+
+func humanizeBytes(n, base float64, prefixes, suffix string) string {
+	if n < base && n > -base {
+		return fmt.Sprintf("%gB", n)
+	}
+	i := -1
+	for (n >= base || n <= -base) && i < len(prefixes)-1 {
+		n /= base
+		i++
+	}
+	return fmt.Sprintf("%.1f%c%s", n, prefixes[i], suffix)
+}
+
+// duration tells the duration val stands for, going by the unit given to
+// key in HumanizeKeys, or with HumanizeDurations, by the name of key or by
+// val being written like a Go duration, like `1.5s`. Numbers whose unit
+// the name of key doesn't tell are taken as seconds if they have a
+// fraction, like zap writes them, and as milliseconds otherwise.
+func (h *HandlerOptions) duration(key, val string) (time.Duration, bool) {
+	unit, ok := h.HumanizeKeys[key]
+	if !ok && !h.HumanizeDurations {
+		return 0, false
+	}
+	if !ok {
+		if endsLikeDuration(val) {
+			if d, err := time.ParseDuration(unquoteValue(val)); err == nil {
+				return d, true
+			}
+		}
+		// the number is cheaper to tell than the name of the key
+		if !isNumber(val) {
+			return 0, false
+		}
+		if unit, ok = durationKeyUnit(key); !ok {
+			return 0, false
+		}
+		if unit == "" {
+			unit = UnitMilliseconds
+			if strings.ContainsAny(val, ".eE") {
+				unit = UnitSeconds
+			}
+		}
+	}
+	scale, ok := durationUnits[unit]
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(f * float64(scale)), true
+}
+
+// endsLikeDuration tells if val, quoted or not, ends with the unit of a Go
+// duration, to only try parsing the values that may be ones.
+func endsLikeDuration(val string) bool {
+	val = strings.TrimSuffix(val, `"`)
+	if val == "" {
+		return false
+	}
+	switch val[len(val)-1] {
+	case 's', 'm', 'h':
+		return true
+	}
+	return false
+}
+
+// durationKeyUnit tells if key is named like it holds a duration, and in
+// which unit if its name tells.
+func durationKeyUnit(key string) (string, bool) {
+	lower := strings.ToLower(key)
+	for _, s := range durationKeySuffixes {
+		if len(key) > len(s.suffix) && (hasSeparatedSuffix(lower, s.suffix) || hasTitleSuffix(key, s.suffix)) {
+			return s.unit, true
+		}
+	}
+	for _, word := range durationKeyWords {
+		if strings.Contains(lower, word) {
+			if strings.HasSuffix(lower, "_s") {
+				return UnitSeconds, true
+			}
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// hasSeparatedSuffix tells if s ends with `_` and suffix, without building
+// the string, as it's checked for every field.
+func hasSeparatedSuffix(s, suffix string) bool {
+	return len(s) > len(suffix) && strings.HasSuffix(s, suffix) && s[len(s)-len(suffix)-1] == '_'
+}
+
+// hasTitleSuffix tells if s ends with the lowercase ASCII suffix, with its
+// first letter in uppercase, like `tookMs` does with `ms`.
+func hasTitleSuffix(s, suffix string) bool {
+	if len(s) < len(suffix) || len(suffix) == 0 {
+		return false
+	}
+	end := s[len(s)-len(suffix):]
+	return end[0] == suffix[0]-'a'+'A' && end[1:] == suffix[1:]
+}
+
+// humanizeDuration renders d with three significant digits at most, like
+// 1.24s or 83ms, or to the second past a minute, like 1m30s.
+func humanizeDuration(d time.Duration) string {
+	if d >= time.Minute || d <= -time.Minute {
+		return d.Round(time.Second).String()
+	}
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+	unit, name := time.Nanosecond, "ns"
+	switch {
+	case abs >= time.Second:
+		unit, name = time.Second, "s"
+	case abs >= time.Millisecond:
+		unit, name = time.Millisecond, "ms"
+	case abs >= time.Microsecond:
+		unit, name = time.Microsecond, "µs"
+	}
+	v := float64(d) / float64(unit)
+	decimals := 0
+	switch {
+	case abs < 10*unit:
+		decimals = 2
+	case abs < 100*unit:
+		decimals = 1
+	}
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if decimals > 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s + name
+}
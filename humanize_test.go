@@ -0,0 +1,127 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestHumanize(t *testing.T) {
+	opts := *DefaultOptions
+	opts.HumanizeKeys = map[string]string{
+		"latency_ms": UnitMilliseconds,
+		"took":       UnitNanoseconds,
+		"bytes":      UnitBytes,
+		"size":       UnitBytesSI,
+		"name":       UnitBytes,
+	}
+
+	for _, tt := range []struct {
+		key, val, want string
+	}{
+		{"latency_ms", "1500", "1.5s"},
+		{"latency_ms", "0.25", "250µs"},
+		{"took", "1200", "1.2µs"},
+		{"bytes", "1048576", "1.0MiB"},
+		{"bytes", "1536", "1.5KiB"},
+		{"bytes", "512", "512B"},
+		{"size", "1000000", "1.0MB"},
+		{"size", "1500", "1.5kB"},
+		{"name", `"bob"`, `"bob"`},
+		{"other", "1500", "1500"},
+	} {
+		if got := opts.humanize(tt.key, tt.val); got != tt.want {
+			t.Errorf("humanize(%q, %q): want %q, got %q", tt.key, tt.val, tt.want, got)
+		}
+	}
+
+	h := JSONHandler{Opts: &opts}
+	if _, ok := h.TryHandle([]byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"done","latency_ms":1500,"bytes":1048576}`)); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	if h.Fields["latency_ms"] != "1500" {
+		t.Fatalf("want parsed value to be left alone, got %q", h.Fields["latency_ms"])
+	}
+	out := h.Prettify(false)
+	for _, want := range []string{"latency_ms=1.5s", "bytes=1.0MiB"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestHumanizeDurations(t *testing.T) {
+	opts := *DefaultOptions
+	opts.HumanizeDurations = true
+	for _, tt := range []struct {
+		key, val, want string
+	}{
+		{"elapsed_ms", "1240", "1.24s"},
+		{"tookNs", "83000000", "83ms"},
+		{"latency", "83", "83ms"},
+		{"latency", "0.0012", "1.2ms"},
+		{"duration_s", "90", "1m30s"},
+		{"db_micros", "512", "512µs"},
+		{"upstream", `"1.234567s"`, "1.23s"},
+		{"wait", "250ms", "250ms"},
+		{"latency", `"slow"`, `"slow"`},
+		{"status", "200", "200"},
+		{"columns", "12", "12"},
+		{"retries", "0", "0"},
+	} {
+		if got := opts.humanize(tt.key, tt.val); got != tt.want {
+			t.Errorf("humanize(%q, %q): want %q, got %q", tt.key, tt.val, tt.want, got)
+		}
+	}
+
+	opts.HumanizeDurations = false
+	if got := opts.humanize("latency", "83"); got != "83" {
+		t.Errorf("want durations left alone, got %q", got)
+	}
+}
+
+func TestRendererSlowDuration(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	opts.SlowDuration = time.Second
+	r := NewRenderer(&opts)
+	slow := opts.ErrorLevelColor.Sprint("1.5s")
+	if out := string(r.Render(Event{Msg: "a", Fields: map[string]string{"latency_ms": "1500"}}, false)); !strings.Contains(out, slow) {
+		t.Fatalf("want %q in %q", slow, out)
+	}
+	if out := string(r.Render(Event{Msg: "a", Fields: map[string]string{"latency_ms": "15"}}, false)); strings.Contains(out, opts.ErrorLevelColor.Sprint("15ms")) {
+		t.Fatalf("want fast durations in the usual color, got %q", out)
+	}
+}
+
+func TestHumanizeSizes(t *testing.T) {
+	opts := *DefaultOptions
+	opts.HumanizeSizes = true
+	for _, tt := range []struct {
+		key, val, want string
+	}{
+		{"bytes_sent", "1468006", "1.4MiB"},
+		{"size", "2048", "2.0KiB"},
+		{"Content-Length", "512", "512B"},
+		{"contentLength", "1536", "1.5KiB"},
+		{"size", `"large"`, `"large"`},
+		{"count", "2048", "2048"},
+	} {
+		if got := opts.humanize(tt.key, tt.val); got != tt.want {
+			t.Errorf("humanize(%q, %q): want %q, got %q", tt.key, tt.val, tt.want, got)
+		}
+	}
+
+	opts.Output = OutputJSON
+	lh := newLineHandler(&opts)
+	dst := bytes.NewBuffer(nil)
+	lh.handle(dst, []byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"sent","bytes":1468006}`))
+	if !strings.Contains(dst.String(), `"bytes":1468006`) {
+		t.Fatalf("want the raw size in JSON output, got %q", dst.String())
+	}
+}
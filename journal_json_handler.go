@@ -2,23 +2,14 @@ package humanlog
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
-	"math"
-	"sort"
 	"strconv"
-	"strings"
-	"text/tabwriter"
 	"time"
-
-	"github.com/fatih/color"
 )
 
 // JournalJSONHandler can handle logs emmited by logrus.TextFormatter loggers.
 type JournalJSONHandler struct {
-	buf     *bytes.Buffer
-	out     *tabwriter.Writer
-	truncKV int
+	renderer *Renderer
 
 	Opts *HandlerOptions
 
@@ -26,24 +17,38 @@ type JournalJSONHandler struct {
 	Time    time.Time
 	Message string
 	Fields  map[string]string
-
-	last map[string]string
 }
 
 func (h *JournalJSONHandler) clear() {
 	h.Level = ""
 	h.Time = time.Time{}
 	h.Message = ""
-	h.last = h.Fields
 	h.Fields = make(map[string]string)
-	if h.buf != nil {
-		h.buf.Reset()
+}
+
+// journalTimeKeys are the fields of the time of the entries of the journal:
+// when the message was logged, which not all of them have, or else when the
+// journal received it.
+var journalTimeKeys = []string{"_SOURCE_REALTIME_TIMESTAMP", "__REALTIME_TIMESTAMP"}
+
+// hasJournalTime tells if d has one of journalTimeKeys.
+func hasJournalTime(d []byte) bool {
+	// both end alike, which rules most lines out at once
+	if !bytes.Contains(d, []byte(`REALTIME_TIMESTAMP"`)) {
+		return false
 	}
+	for _, key := range journalTimeKeys {
+		if bytes.Contains(d, []byte(`"`+key+`"`)) {
+			return true
+		}
+	}
+	return false
 }
 
-// TryHandle tells if this line was handled by this handler.
-func (h *JournalJSONHandler) TryHandle(d []byte) bool {
-	if !bytes.Contains(d, []byte(`"_SOURCE_REALTIME_TIMESTAMP"`)) {
+// parse tells if d was handled by this handler, which then holds it until
+// the next call to Prettify.
+func (h *JournalJSONHandler) parse(d []byte) bool {
+	if !hasJournalTime(d) {
 		return false
 	}
 	err := h.UnmarshalJournalJSON(d)
@@ -56,7 +61,7 @@ func (h *JournalJSONHandler) TryHandle(d []byte) bool {
 
 // TryHandleMap tells if this line was handled by this handler.
 func (h *JournalJSONHandler) TryHandleEntry(entry map[string]interface{}) bool {
-	if _, exists := entry["_SOURCE_REALTIME_TIMESTAMP"]; !exists {
+	if journalTimeKey(entry) == "" {
 		return false
 	}
 	err := h.UnmarshalJournalEntry(entry)
@@ -67,13 +72,24 @@ func (h *JournalJSONHandler) TryHandleEntry(entry map[string]interface{}) bool {
 	return true
 }
 
+// journalTimeKey is the first of journalTimeKeys raw has, if any.
+func journalTimeKey(raw map[string]interface{}) string {
+	for _, key := range journalTimeKeys {
+		if _, ok := raw[key]; ok {
+			return key
+		}
+	}
+	return ""
+}
+
 func (h *JournalJSONHandler) UnmarshalJournalEntry(raw map[string]interface{}) error {
-	timestamp, ok := raw["_SOURCE_REALTIME_TIMESTAMP"]
-	if ok {
-		delete(raw, "_SOURCE_REALTIME_TIMESTAMP")
+	var ok bool
+	if key := journalTimeKey(raw); key != "" {
+		timestamp := raw[key]
+		delete(raw, key)
 		timeString, ok := timestamp.(string)
 		if !ok {
-			return fmt.Errorf("_SOURCE_REALTIME_TIMESTAMP %v is not type string", timestamp)
+			return fmt.Errorf("%s %v is not type string", key, timestamp)
 		}
 		timeMicros, err := strconv.ParseInt(timeString, 10, 64)
 		if err != nil {
@@ -81,8 +97,16 @@ func (h *JournalJSONHandler) UnmarshalJournalEntry(raw map[string]interface{}) e
 		}
 		h.Time = time.Unix(timeMicros/int64(1e6), (timeMicros%int64(1e6))*int64(1e3))
 	}
-	if h.Message, ok = raw["MESSAGE"].(string); ok {
+	switch msg := raw["MESSAGE"].(type) {
+	case string:
+		h.Message = msg
 		delete(raw, "MESSAGE")
+	case []interface{}:
+		// journald gives binary messages as arrays of bytes
+		if b, ok := journalBytes(msg); ok {
+			h.Message = string(b)
+			delete(raw, "MESSAGE")
+		}
 	}
 
 	if h.Level, ok = raw["PRIORITY"].(string); ok {
@@ -94,19 +118,7 @@ func (h *JournalJSONHandler) UnmarshalJournalEntry(raw map[string]interface{}) e
 	}
 
 	for key, val := range raw {
-		switch v := val.(type) {
-		case float64:
-			if v-math.Floor(v) < 0.000001 && v < 1e9 {
-				// looks like an integer that's not too large
-				h.Fields[key] = fmt.Sprintf("%d", int(v))
-			} else {
-				h.Fields[key] = fmt.Sprintf("%g", v)
-			}
-		case string:
-			h.Fields[key] = fmt.Sprintf("%q", v)
-		default:
-			h.Fields[key] = fmt.Sprintf("%v", v)
-		}
+		h.Fields[key] = h.Opts.formatValue(val)
 	}
 
 	return nil
@@ -114,152 +126,61 @@ func (h *JournalJSONHandler) UnmarshalJournalEntry(raw map[string]interface{}) e
 
 // UnmarshalJournalJSON sets the fields of the handler.
 func (h *JournalJSONHandler) UnmarshalJournalJSON(data []byte) error {
-	raw := make(map[string]interface{})
-	err := json.Unmarshal(data, &raw)
+	raw, err := decodeObject(data)
 	if err != nil {
 		return err
 	}
+	defer releaseObject(raw)
 
 	return h.UnmarshalJournalEntry(raw)
 }
 
-// Prettify the output in a logrus like fashion.
-func (h *JournalJSONHandler) Prettify(skipUnchanged bool) []byte {
-	defer h.clear()
-	if h.out == nil {
-		if h.Opts == nil {
-			h.Opts = DefaultOptions
-		}
-		h.buf = bytes.NewBuffer(nil)
-		h.out = tabwriter.NewWriter(h.buf, 0, 1, 0, '\t', 0)
-	}
-
-	var (
-		msgColor       *color.Color
-		msgAbsentColor *color.Color
-	)
-	if h.Opts.LightBg {
-		msgColor = h.Opts.MsgLightBgColor
-		msgAbsentColor = h.Opts.MsgAbsentLightBgColor
-	} else {
-		msgColor = h.Opts.MsgDarkBgColor
-		msgAbsentColor = h.Opts.MsgAbsentDarkBgColor
+// TryHandle parses d into an event, if it is in the format of the handler.
+// The handler holds the line until the next call to Prettify.
+func (h *JournalJSONHandler) TryHandle(d []byte) (Event, bool) {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
 	}
-	msgColor = color.New(color.FgHiWhite)
-	msgAbsentColor = color.New(color.FgHiWhite)
-
-	var msg string
-	if h.Message == "" {
-		msg = msgAbsentColor.Sprint("<no msg>")
-	} else {
-		msg = msgColor.Sprint(h.Message)
+	if !h.parse(d) {
+		return Event{}, false
 	}
-
-	var level string
-	switch h.Level {
-	case "7":
-		level = h.Opts.DebugLevelColor.Sprint("DEBU")
-	case "5", "6":
-		level = h.Opts.InfoLevelColor.Sprint("INFO")
-	case "4":
-		level = h.Opts.WarnLevelColor.Sprint("WARN")
-	case "3":
-		level = h.Opts.ErrorLevelColor.Sprint("ERRO")
-	case "2", "1", "0":
-		level = h.Opts.FatalLevelColor.Sprint("FATA")
-	default:
-		level = h.Opts.UnknownLevelColor.Sprint("UNKN")
-	}
-
-	var timeColor *color.Color
-	if h.Opts.LightBg {
-		timeColor = h.Opts.TimeLightBgColor
-	} else {
-		timeColor = h.Opts.TimeDarkBgColor
-	}
-	_, _ = fmt.Fprintf(h.out, "%s |%s| %s\t %s",
-		timeColor.Sprint(h.Time.Format(h.Opts.TimeFormat)),
-		level,
-		msg,
-		strings.Join(h.joinKVs(skipUnchanged, "="), "\t "),
-	)
-
-	_ = h.out.Flush()
-
-	return h.buf.Bytes()
+	return h.event(), true
 }
 
-func (h *JournalJSONHandler) shouldShowKey(key string) bool {
-	if len(h.Opts.Keep) != 0 {
-		if _, keep := h.Opts.Keep[key]; keep {
-			return true
-		}
-		if _, keep := h.Opts.Keep[strings.ToLower(key)]; keep {
-			return true
-		}
-	}
-
-	if len(h.Opts.Skip) != 0 {
-		if _, skip := h.Opts.Skip[key]; skip {
-			return false
-		}
-		if _, skip := h.Opts.Skip[strings.ToLower(key)]; skip {
-			return false
-		}
+// Prettify the output in a logrus like fashion.
+func (h *JournalJSONHandler) Prettify(skipUnchanged bool) []byte {
+	defer h.clear()
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
 	}
-
-	// definitely not a keep -- autoskip underscored
-	if strings.HasPrefix(key, "_") {
-		return false
+	if h.renderer == nil {
+		h.renderer = NewRenderer(h.Opts)
 	}
-
-	return true
+	return h.renderer.Render(h.event(), skipUnchanged)
 }
 
-func (h *JournalJSONHandler) shouldShowUnchanged(key string) bool {
-	if len(h.Opts.Keep) != 0 {
-		if _, keep := h.Opts.Keep[key]; keep {
-			return true
-		}
-	}
-	if len(h.Opts.Keep) != 0 {
-		if _, keep := h.Opts.Keep[strings.ToLower(key)]; keep {
-			return true
-		}
+func (h *JournalJSONHandler) event() Event {
+	return Event{
+		Time:   h.Time,
+		Level:  h.Opts.mapLevel(h.Level, normalizeSyslogLevel),
+		Msg:    h.Message,
+		Fields: h.Fields,
+
+		journal:         !h.Opts.JournalVerbose,
+		skipUnderscored: true,
 	}
-	return false
 }
 
-func (h *JournalJSONHandler) joinKVs(skipUnchanged bool, sep string) []string {
-
-	kv := make([]string, 0, len(h.Fields))
-	for k, v := range h.Fields {
-		if !h.shouldShowKey(k) {
-			continue
+// journalBytes decodes a journald field that was exported as an array of
+// bytes, since it wasn't valid UTF-8 or had control characters.
+func journalBytes(arr []interface{}) ([]byte, bool) {
+	b := make([]byte, 0, len(arr))
+	for _, elem := range arr {
+		n, ok := elem.(float64)
+		if !ok || n < 0 || n > 255 || n != float64(int(n)) {
+			return nil, false
 		}
-
-		if skipUnchanged {
-			if lastV, ok := h.last[k]; ok && lastV == v && !h.shouldShowUnchanged(k) {
-				continue
-			}
-		}
-		kstr := h.Opts.KeyColor.Sprint(k)
-
-		var vstr string
-		if h.Opts.Truncates && len(v) > h.Opts.TruncateLength {
-			vstr = v[:h.Opts.TruncateLength] + "..."
-		} else {
-			vstr = v
-		}
-		vstr = h.Opts.ValColor.Sprint(vstr)
-		kv = append(kv, kstr+sep+vstr)
+		b = append(b, byte(n))
 	}
-
-	sort.Strings(kv)
-
-	if h.Opts.SortLongest {
-		sort.Stable(byLongest(kv))
-	}
-
-	return kv
+	return b, true
 }
@@ -0,0 +1,108 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestJournalJSONHandlerMessageColors(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	opts.MsgDarkBgColor = color.New(color.FgHiMagenta, color.Underline)
+	opts.MsgAbsentDarkBgColor = color.New(color.FgHiBlue, color.Italic)
+
+	h := JournalJSONHandler{Opts: &opts}
+	if _, ok := h.TryHandle([]byte(`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"hello"}`)); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	out := h.Prettify(false)
+	if want := opts.MsgDarkBgColor.Sprint("hello"); !bytes.Contains(out, []byte(want)) {
+		t.Fatalf("want %q in output, got %q", want, out)
+	}
+
+	if _, ok := h.TryHandle([]byte(`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6"}`)); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	out = h.Prettify(false)
+	if want := opts.MsgAbsentDarkBgColor.Sprint("<no msg>"); !bytes.Contains(out, []byte(want)) {
+		t.Fatalf("want %q in output, got %q", want, out)
+	}
+}
+
+func TestJournalJSONHandlerBinaryMessage(t *testing.T) {
+	h := JournalJSONHandler{Opts: DefaultOptions}
+	// "hello\x01world"
+	if _, ok := h.TryHandle([]byte(`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":[104,101,108,108,111,1,119,111,114,108,100]}`)); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	if h.Message != "hello\x01world" {
+		t.Fatalf("want the message decoded from its bytes, got %q", h.Message)
+	}
+	if _, ok := h.Fields["MESSAGE"]; ok {
+		t.Fatal("want MESSAGE out of the fields")
+	}
+
+	if _, ok := h.TryHandle([]byte(`{"__REALTIME_TIMESTAMP":"1540369190466951","MESSAGE":"received"}`)); !ok {
+		t.Fatal("want an entry with only the time it was received handled")
+	}
+	if want := time.Unix(1540369190, 466951000); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	if _, ok := h.Fields["__REALTIME_TIMESTAMP"]; ok {
+		t.Fatal("want __REALTIME_TIMESTAMP out of the fields")
+	}
+
+	if _, ok := h.TryHandle([]byte(`{"_SOURCE_REALTIME_TIMESTAMP":1540369190466951,"MESSAGE":"hi"}`)); ok {
+		t.Fatal("want a numeric timestamp rejected")
+	}
+}
+
+func TestJournalJSONHandlerOrigin(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	line := []byte(`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"4","MESSAGE":"disk almost full","SYSLOG_IDENTIFIER":"nginx","_SYSTEMD_UNIT":"nginx.service","_PID":"1234","_HOSTNAME":"web1","__CURSOR":"s=1","_BOOT_ID":"abc","CODE_FILE":"main.c"}`)
+
+	h := JournalJSONHandler{Opts: &opts}
+	if _, ok := h.TryHandle(line); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	out := string(h.Prettify(false))
+	if !strings.Contains(out, "|WARN| nginx.service[1234]@web1 disk almost full") || !strings.Contains(out, `CODE_FILE="main.c"`) {
+		t.Fatalf("want the origin before the message, got %q", out)
+	}
+	for _, key := range []string{"_PID", "__CURSOR", "_BOOT_ID", "SYSLOG_IDENTIFIER"} {
+		if strings.Contains(out, key+"=") {
+			t.Fatalf("want %s left out, got %q", key, out)
+		}
+	}
+
+	opts.JournalVerbose = true
+	if _, ok := h.TryHandle(line); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	out = string(h.Prettify(false))
+	if !strings.Contains(out, "|WARN| disk almost full") || !strings.Contains(out, `SYSLOG_IDENTIFIER="nginx"`) {
+		t.Fatalf("want every field with JournalVerbose, got %q", out)
+	}
+}
+
+func TestJournalOrigin(t *testing.T) {
+	for _, test := range []struct {
+		fields map[string]string
+		want   string
+	}{
+		{map[string]string{"SYSLOG_IDENTIFIER": `"sshd"`, "_SYSTEMD_UNIT": `"session-3.scope"`, "_PID": `"42"`}, "sshd[42]"},
+		{map[string]string{"_COMM": `"cron"`, "SYSLOG_PID": `"7"`, "_HOSTNAME": `"box"`}, "cron[7]@box"},
+		{map[string]string{}, ""},
+	} {
+		if got := journalOrigin(test.fields); got != test.want {
+			t.Fatalf("want origin %q of %v, got %q", test.want, test.fields, got)
+		}
+	}
+}
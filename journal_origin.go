@@ -0,0 +1,47 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// isJournalMetadata tells if key is one of the fields journald adds to the
+// entries it stores, like `_PID` or `__CURSOR`, or that syslog clients
+// set, like `SYSLOG_IDENTIFIER`, rather than one the program logged.
+func isJournalMetadata(key string) bool {
+	return strings.HasPrefix(key, "_") || strings.HasPrefix(key, "SYSLOG_")
+}
+
+// journalOrigin tells where an entry of the journal comes from, in short,
+// like `nginx.service[1234]@web1`: its unit, or its syslog identifier for
+// the units that aren't services of their own like sessions, its process
+// and its host.
+func journalOrigin(fields map[string]string) string {
+	field := func(keys ...string) string {
+		for _, key := range keys {
+			v, ok := fields[key]
+			if !ok {
+				continue
+			}
+			if unquoted, err := strconv.Unquote(v); err == nil {
+				v = unquoted
+			}
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	name := field("_SYSTEMD_UNIT")
+	if name == "" || strings.HasSuffix(name, ".scope") || strings.HasSuffix(name, ".slice") {
+		name = field("SYSLOG_IDENTIFIER", "_COMM", "_SYSTEMD_UNIT")
+	}
+	origin := name
+	if pid := field("_PID", "SYSLOG_PID"); pid != "" {
+		origin += "[" + pid + "]"
+	}
+	if host := field("_HOSTNAME"); host != "" {
+		origin += "@" + host
+	}
+	return origin
+}
@@ -0,0 +1,240 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// rawPool holds the maps lines are decoded into, which are only needed
+// until the handlers took their fields out of them.
+var rawPool = sync.Pool{
+	New: func() interface{} { return make(map[string]interface{}) },
+}
+
+// maxPooledRaw is the number of keys past which a map isn't pooled, so
+// that a few huge lines don't keep their maps around.
+const maxPooledRaw = 64
+
+// keysPool holds the keys decoded before, by themselves, so that the keys
+// lines have in common are only allocated once.
+var keysPool = sync.Pool{
+	New: func() interface{} { return make(map[string]string) },
+}
+
+// maxKeys is the number of keys past which no more are added to those of
+// keysPool, as lines with keys of their own, like IDs, would have them
+// grow forever.
+const maxKeys = 1024
+
+// decodeObject decodes the JSON object data into a map taken from rawPool,
+// to be given back with releaseObject once done with. It does what
+// json.Unmarshal does, without going through reflection for the objects
+// that only hold strings, numbers, booleans and nulls, as most lines do.
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	raw := rawPool.Get().(map[string]interface{})
+	keys := keysPool.Get().(map[string]string)
+	ok := decodeFlatObject(data, raw, keys)
+	keysPool.Put(keys)
+	if ok {
+		return raw, nil
+	}
+	for k := range raw {
+		delete(raw, k)
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		releaseObject(raw)
+		return nil, err
+	}
+	return raw, nil
+}
+
+// releaseObject gives a map decodeObject returned back to rawPool. Nothing
+// from it must be used afterwards.
+func releaseObject(raw map[string]interface{}) {
+	if raw == nil || len(raw) > maxPooledRaw {
+		return
+	}
+	for k := range raw {
+		delete(raw, k)
+	}
+	rawPool.Put(raw)
+}
+
+// decodeFlatObject decodes data into raw if it is an object whose values
+// are all strings, numbers, booleans or nulls, and tells if it did. When it
+// didn't, raw holds whatever was decoded before it gave up. The keys found
+// in keys are taken from there.
+func decodeFlatObject(data []byte, raw map[string]interface{}, keys map[string]string) bool {
+	i := skipJSONSpace(data, 0)
+	if i == len(data) || data[i] != '{' {
+		return false
+	}
+	i = skipJSONSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return skipJSONSpace(data, i+1) == len(data)
+	}
+	for {
+		key, next, ok := decodeJSONKey(data, i, keys)
+		if !ok {
+			return false
+		}
+		i = skipJSONSpace(data, next)
+		if i == len(data) || data[i] != ':' {
+			return false
+		}
+		i = skipJSONSpace(data, i+1)
+		if i == len(data) {
+			return false
+		}
+		var val interface{}
+		switch c := data[i]; {
+		case c == '"':
+			val, i, ok = decodeJSONString(data, i)
+		case c == '-' || c >= '0' && c <= '9':
+			val, i, ok = decodeJSONNumber(data, i)
+		case hasLiteral(data[i:], "true"):
+			val, i, ok = true, i+4, true
+		case hasLiteral(data[i:], "false"):
+			val, i, ok = false, i+5, true
+		case hasLiteral(data[i:], "null"):
+			val, i, ok = nil, i+4, true
+		default:
+			// nested objects and arrays, left to encoding/json
+			return false
+		}
+		if !ok {
+			return false
+		}
+		raw[key] = val
+		i = skipJSONSpace(data, i)
+		if i == len(data) {
+			return false
+		}
+		switch data[i] {
+		case ',':
+			i = skipJSONSpace(data, i+1)
+		case '}':
+			return skipJSONSpace(data, i+1) == len(data)
+		default:
+			return false
+		}
+	}
+}
+
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func hasLiteral(data []byte, lit string) bool {
+	return len(data) >= len(lit) && string(data[:len(lit)]) == lit
+}
+
+// decodeJSONKey decodes the string starting at data[i] like
+// decodeJSONString, taking it from keys when it's there.
+func decodeJSONKey(data []byte, i int, keys map[string]string) (string, int, bool) {
+	if i < len(data) && data[i] == '"' {
+		// only keys without escapes are kept, so the first quote ends
+		// those found, and looking them up doesn't allocate
+		if end := bytes.IndexByte(data[i+1:], '"'); end >= 0 {
+			if key, ok := keys[string(data[i+1:i+1+end])]; ok {
+				return key, i + end + 2, true
+			}
+		}
+	}
+	key, next, ok := decodeJSONString(data, i)
+	if ok && len(keys) < maxKeys && string(data[i+1:next-1]) == key {
+		keys[key] = key
+	}
+	return key, next, ok
+}
+
+// decodeJSONString decodes the string starting at data[i], and tells where
+// it ends. Escaped strings are decoded by encoding/json.
+func decodeJSONString(data []byte, i int) (string, int, bool) {
+	if i == len(data) || data[i] != '"' {
+		return "", i, false
+	}
+	start := i + 1
+	escaped, ascii := false, true
+	for j := start; j < len(data); j++ {
+		switch c := data[j]; {
+		case c == '"':
+			if escaped {
+				var s string
+				if err := json.Unmarshal(data[i:j+1], &s); err != nil {
+					return "", j, false
+				}
+				return s, j + 1, true
+			}
+			// invalid UTF-8 is replaced by encoding/json
+			if !ascii && !utf8.Valid(data[start:j]) {
+				return "", j, false
+			}
+			return string(data[start:j]), j + 1, true
+		case c == '\\':
+			escaped = true
+			j++
+		case c < 0x20:
+			return "", j, false
+		case c >= utf8.RuneSelf:
+			ascii = false
+		}
+	}
+	return "", len(data), false
+}
+
+// decodeJSONNumber decodes the number starting at data[i] as a float64, as
+// encoding/json does, and tells where it ends.
+func decodeJSONNumber(data []byte, i int) (float64, int, bool) {
+	start := i
+	if data[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(data) && data[i] == '0':
+		i++
+	case i < len(data) && data[i] >= '1' && data[i] <= '9':
+		i = skipJSONDigits(data, i)
+	default:
+		return 0, i, false
+	}
+	if i < len(data) && data[i] == '.' {
+		if i+1 == len(data) || data[i+1] < '0' || data[i+1] > '9' {
+			return 0, i, false
+		}
+		i = skipJSONDigits(data, i+1)
+	}
+	if i < len(data) && (data[i] == 'e' || data[i] == 'E') {
+		i++
+		if i < len(data) && (data[i] == '+' || data[i] == '-') {
+			i++
+		}
+		if i == len(data) || data[i] < '0' || data[i] > '9' {
+			return 0, i, false
+		}
+		i = skipJSONDigits(data, i)
+	}
+	f, err := strconv.ParseFloat(string(data[start:i]), 64)
+	if err != nil {
+		return 0, i, false
+	}
+	return f, i, true
+}
+
+func skipJSONDigits(data []byte, i int) int {
+	for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+		i++
+	}
+	return i
+}
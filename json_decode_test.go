@@ -0,0 +1,44 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeObject(t *testing.T) {
+	for _, data := range []string{
+		`{}`,
+		` { "a" : "b" , "n":-1.5e3,"t":true,"f":false,"z":null } `,
+		`{"msg":"say \"hi\"\n","é":"😀"}`,
+		`{"a":1,"a":2}`,
+		`{"nested":{"a":[1,2]},"s":"x"}`,
+		`{"invalid":"` + "\xff" + `"}`,
+		`{"big":1e400}`,
+		`{"lead":01}`,
+		`{"dot":1.}`,
+		`{"ctl":"` + "\t" + `"}`,
+		`{"a":"b"`,
+		`{"a":"b",}`,
+		`{"a" "b"}`,
+		`{"a":tru}`,
+		`{"a":"b"} x`,
+		`["a"]`,
+		`null`,
+		``,
+	} {
+		want := map[string]interface{}{}
+		wantErr := json.Unmarshal([]byte(data), &want)
+		// twice, the second with keys already known
+		for i := 0; i < 2; i++ {
+			got, err := decodeObject([]byte(data))
+			if (err == nil) != (wantErr == nil) {
+				t.Fatalf("%q: want error %v, got %v", data, wantErr, err)
+			}
+			if err == nil && !reflect.DeepEqual(got, want) {
+				t.Fatalf("%q: want %#v, got %#v", data, want, got)
+			}
+			releaseObject(got)
+		}
+	}
+}
@@ -2,22 +2,29 @@ package humanlog
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
-	"math"
 	"sort"
 	"strings"
-	"text/tabwriter"
 	"time"
+)
 
-	"github.com/fatih/color"
+// How strictly lines are told to be JSON logs, see
+// HandlerOptions.JSONDetection.
+const (
+	// JSONDetectionTime takes JSON objects with a time for logs, the
+	// default.
+	JSONDetectionTime = "time"
+	// JSONDetectionStrict also wants them to have a level or a message,
+	// so that JSON data that happens to have a time is left as is.
+	JSONDetectionStrict = "strict"
+	// JSONDetectionLoose takes any JSON object for a log, with or without
+	// a time.
+	JSONDetectionLoose = "loose"
 )
 
 // JSONHandler can handle logs emmited by logrus.TextFormatter loggers.
 type JSONHandler struct {
-	buf     *bytes.Buffer
-	out     *tabwriter.Writer
-	truncKV int
+	renderer *Renderer
 
 	Opts *HandlerOptions
 
@@ -26,27 +33,67 @@ type JSONHandler struct {
 	Message string
 	Fields  map[string]string
 
-	last map[string]string
+	// Stacktrace is printed below the line, like zap's `stacktrace`.
+	Stacktrace string
+
+	// Nested holds the nested objects expanded below the line, as
+	// indented JSON by key, when NestedObjects is NestedObjectsExpand.
+	Nested map[string]string
 }
 
 func (h *JSONHandler) clear() {
 	h.Level = ""
 	h.Time = time.Time{}
 	h.Message = ""
-	h.last = h.Fields
+	h.Stacktrace = ""
+	h.Nested = nil
 	h.Fields = make(map[string]string)
-	if h.buf != nil {
-		h.buf.Reset()
-	}
 }
 
-// TryHandle tells if this line was handled by this handler.
-func (h *JSONHandler) TryHandle(d []byte) bool {
-	if !bytes.Contains(d, []byte(`"time":`)) && !bytes.Contains(d, []byte(`"ts":`)) {
+// parse tells if d was handled by this handler, which then holds it until
+// the next call to Prettify.
+func (h *JSONHandler) parse(d []byte) bool {
+	if h.Opts != nil && h.Opts.JSONDetection == JSONDetectionLoose {
+		return h.parseObject(d)
+	}
+	hasTimeKey := bytes.Contains(d, []byte(`"time":`)) || bytes.Contains(d, []byte(`"ts":`)) ||
+		// Google Cloud Logging, among others
+		bytes.Contains(d, []byte(`"timestamp":`)) ||
+		// CloudWatch Logs Insights, or Elastic Common Schema
+		bytes.Contains(d, []byte(`"@timestamp":`)) ||
+		// OpenTelemetry
+		bytes.Contains(d, []byte(`UnixNano":`)) ||
+		// MongoDB
+		bytes.Contains(d, []byte(`"$date":`)) ||
+		// log4j2's JSONLayout
+		bytes.Contains(d, []byte(`"instant":`)) || bytes.Contains(d, []byte(`"timeMillis":`))
+	if !hasTimeKey && h.Opts != nil {
+		for _, field := range h.Opts.TimeFields {
+			if bytes.Contains(d, []byte(`"`+field+`":`)) {
+				hasTimeKey = true
+				break
+			}
+		}
+	}
+	if !hasTimeKey && (h.Opts == nil || !h.Opts.AutoDetectTime || !bytes.HasPrefix(d, []byte("{"))) {
 		return false
 	}
 	err := h.UnmarshalJSON(d)
-	if err != nil {
+	if err != nil || (!hasTimeKey && h.Time.IsZero()) {
+		h.clear()
+		return false
+	}
+	if h.Opts != nil && h.Opts.JSONDetection == JSONDetectionStrict && h.Message == "" && h.Level == "???" {
+		h.clear()
+		return false
+	}
+	return true
+}
+
+// parseObject tells if d is a JSON object, with or without a time, which
+// the handler then holds until the next call to Prettify.
+func (h *JSONHandler) parseObject(d []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(d), []byte("{")) || h.UnmarshalJSON(d) != nil {
 		h.clear()
 		return false
 	}
@@ -55,164 +102,164 @@ func (h *JSONHandler) TryHandle(d []byte) bool {
 
 // UnmarshalJSON sets the fields of the handler.
 func (h *JSONHandler) UnmarshalJSON(data []byte) error {
-	raw := make(map[string]interface{})
-	err := json.Unmarshal(data, &raw)
+	raw, err := decodeObject(data)
 	if err != nil {
 		return err
 	}
+	defer releaseObject(raw)
+	lambda := unwrapLambda(raw)
+	unwrapGCP(raw)
+	unwrapInsights(raw)
+	unwrapLogstash(raw)
+	unwrapECS(raw)
+	unwrapOTLP(raw)
+	unwrapMongoDB(raw)
+	unwrapLog4j(raw)
+	if h.Opts != nil && h.Opts.ParseEmbeddedJSON {
+		parseEmbeddedJSON(raw, h.Opts.NestedObjects == NestedObjectsExpand)
+	}
+	if _, ok := raw["level"]; lambda && !ok {
+		raw["level"] = "info"
+	}
 
-	time, ok := raw["time"]
-	if ok {
-		delete(raw, "time")
-	} else {
-		time, ok = raw["ts"]
-		if ok {
-			delete(raw, "ts")
+	var time interface{}
+	var ok bool
+	keys := []string{"time", "ts", "timestamp"}
+	if h.Opts != nil {
+		keys = append(keys, h.Opts.TimeFields...)
+	}
+	for _, key := range keys {
+		if time, ok = raw[key]; ok {
+			delete(raw, key)
+			break
 		}
 	}
 	if ok {
-		h.Time, ok = tryParseTime(time)
+		h.Time, ok = h.Opts.parseTime(time)
 		if !ok {
 			return fmt.Errorf("field time is not a known timestamp: %v", time)
 		}
+	} else if h.Opts != nil && h.Opts.AutoDetectTime {
+		h.Time, _ = detectTime(raw)
 	}
-	if h.Message, ok = raw["msg"].(string); ok {
-		delete(raw, "msg")
-	} else if h.Message, ok = raw["message"].(string); ok {
-		delete(raw, "message")
+	for _, key := range h.Opts.messageKeys() {
+		if h.Message, ok = raw[key].(string); ok {
+			delete(raw, key)
+			break
+		}
 	}
 
-	h.Level, ok = raw["level"].(string)
-	if !ok {
-		h.Level, ok = raw["lvl"].(string)
-		delete(raw, "lvl")
-		if !ok {
-			h.Level = "???"
+	h.Level = "???"
+	for _, key := range h.Opts.levelKeys() {
+		if level, ok := raw[key].(string); ok {
+			h.Level = level
+			delete(raw, key)
+			break
 		}
-	} else {
-		delete(raw, "level")
 	}
 
 	if h.Fields == nil {
 		h.Fields = make(map[string]string)
 	}
 
-	for key, val := range raw {
-		switch v := val.(type) {
-		case float64:
-			if v-math.Floor(v) < 0.000001 && v < 1e9 {
-				// looks like an integer that's not too large
-				h.Fields[key] = fmt.Sprintf("%d", int(v))
-			} else {
-				h.Fields[key] = fmt.Sprintf("%g", v)
-			}
-		case string:
-			h.Fields[key] = fmt.Sprintf("%q", v)
-		default:
-			h.Fields[key] = fmt.Sprintf("%v", v)
+	if stack, ok := raw["stacktrace"].(string); ok {
+		h.Stacktrace = stack
+		delete(raw, "stacktrace")
+	}
+	// callers like `app/main.go:42` don't need quoting
+	if caller, ok := raw["caller"].(string); ok {
+		h.Fields["caller"] = h.Opts.sanitize(caller)
+		delete(raw, "caller")
+	}
+	// log/slog's source, as file:line
+	if src, ok := raw["source"].(map[string]interface{}); ok {
+		file, hasFile := src["file"].(string)
+		line, hasLine := src["line"].(float64)
+		if hasFile && hasLine {
+			h.Fields["source"] = h.Opts.sanitize(file) + ":" + formatNumber(line)
+			delete(raw, "source")
 		}
 	}
 
+	h.Nested = h.Opts.setFields(h.Fields, raw)
+
 	return nil
 }
 
-// Prettify the output in a logrus like fashion.
-func (h *JSONHandler) Prettify(skipUnchanged bool) []byte {
-	defer h.clear()
-	if h.out == nil {
-		if h.Opts == nil {
-			h.Opts = DefaultOptions
-		}
-		h.buf = bytes.NewBuffer(nil)
-		h.out = tabwriter.NewWriter(h.buf, 0, 1, 0, '\t', 0)
-	}
-
+// detectTime looks for a value that is an RFC3339 or ISO8601 timestamp, and
+// consumes it from raw. To avoid picking up any odd date, the value must
+// either have a key that looks like a time, or be the only timestamp around.
+func detectTime(raw map[string]interface{}) (time.Time, bool) {
 	var (
-		msgColor       *color.Color
-		msgAbsentColor *color.Color
+		candidates []string
+		named      []string
+		times      = make(map[string]time.Time)
 	)
-	if h.Opts.LightBg {
-		msgColor = h.Opts.MsgLightBgColor
-		msgAbsentColor = h.Opts.MsgAbsentLightBgColor
-	} else {
-		msgColor = h.Opts.MsgDarkBgColor
-		msgAbsentColor = h.Opts.MsgAbsentDarkBgColor
-	}
-	msgColor = color.New(color.FgHiWhite)
-	msgAbsentColor = color.New(color.FgHiWhite)
-
-	var msg string
-	if h.Message == "" {
-		msg = msgAbsentColor.Sprint("<no msg>")
-	} else {
-		msg = msgColor.Sprint(h.Message)
-	}
-
-	lvl := strings.ToUpper(h.Level)[:imin(4, len(h.Level))]
-	var level string
-	switch h.Level {
-	case "debug":
-		level = h.Opts.DebugLevelColor.Sprint(lvl)
-	case "info":
-		level = h.Opts.InfoLevelColor.Sprint(lvl)
-	case "warn", "warning":
-		level = h.Opts.WarnLevelColor.Sprint(lvl)
-	case "error":
-		level = h.Opts.ErrorLevelColor.Sprint(lvl)
-	case "fatal", "panic":
-		level = h.Opts.FatalLevelColor.Sprint(lvl)
+	for key, val := range raw {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		t, ok := tryParseISO8601(str)
+		if !ok {
+			continue
+		}
+		times[key] = t
+		candidates = append(candidates, key)
+		lkey := strings.ToLower(key)
+		if strings.Contains(lkey, "time") || strings.Contains(lkey, "date") || strings.Contains(lkey, "ts") {
+			named = append(named, key)
+		}
+	}
+	var key string
+	switch {
+	case len(named) > 0:
+		// be deterministic when many keys look like times
+		sort.Strings(named)
+		key = named[0]
+	case len(candidates) == 1:
+		key = candidates[0]
 	default:
-		level = h.Opts.UnknownLevelColor.Sprint(lvl)
+		return time.Time{}, false
 	}
+	delete(raw, key)
+	return times[key], true
+}
 
-	var timeColor *color.Color
-	if h.Opts.LightBg {
-		timeColor = h.Opts.TimeLightBgColor
-	} else {
-		timeColor = h.Opts.TimeDarkBgColor
+// TryHandle parses d into an event, if it is in the format of the handler.
+// The handler holds the line until the next call to Prettify.
+func (h *JSONHandler) TryHandle(d []byte) (Event, bool) {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
 	}
-	_, _ = fmt.Fprintf(h.out, "%s |%s| %s\t %s",
-		timeColor.Sprint(h.Time.Format(h.Opts.TimeFormat)),
-		level,
-		msg,
-		strings.Join(h.joinKVs(skipUnchanged, "="), "\t "),
-	)
-
-	_ = h.out.Flush()
-
-	return h.buf.Bytes()
+	if !h.parse(d) {
+		return Event{}, false
+	}
+	return h.event(), true
 }
 
-func (h *JSONHandler) joinKVs(skipUnchanged bool, sep string) []string {
-
-	kv := make([]string, 0, len(h.Fields))
-	for k, v := range h.Fields {
-		if !h.Opts.shouldShowKey(k) {
-			continue
-		}
-
-		if skipUnchanged {
-			if lastV, ok := h.last[k]; ok && lastV == v && !h.Opts.shouldShowUnchanged(k) {
-				continue
-			}
-		}
-		kstr := h.Opts.KeyColor.Sprint(k)
-
-		var vstr string
-		if h.Opts.Truncates && len(v) > h.Opts.TruncateLength {
-			vstr = v[:h.Opts.TruncateLength] + "..."
-		} else {
-			vstr = v
-		}
-		vstr = h.Opts.ValColor.Sprint(vstr)
-		kv = append(kv, kstr+sep+vstr)
+// Prettify the output in a logrus like fashion.
+func (h *JSONHandler) Prettify(skipUnchanged bool) []byte {
+	defer h.clear()
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
 	}
+	if h.renderer == nil {
+		h.renderer = NewRenderer(h.Opts)
+	}
+	return h.renderer.Render(h.event(), skipUnchanged)
+}
 
-	sort.Strings(kv)
+func (h *JSONHandler) event() Event {
+	return Event{
+		Time:   h.Time,
+		Level:  h.Opts.mapLevel(h.Level, normalizeLevel),
+		Msg:    h.Message,
+		Fields: h.Fields,
 
-	if h.Opts.SortLongest {
-		sort.Stable(byLongest(kv))
+		label:      strings.ToUpper(h.Level)[:imin(4, len(h.Level))],
+		nested:     h.Nested,
+		stacktrace: h.Stacktrace,
+		untimed:    true,
 	}
-
-	return kv
 }
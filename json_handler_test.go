@@ -0,0 +1,151 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONHandlerAutoDetectTime(t *testing.T) {
+	opts := *DefaultOptions
+	opts.TimeFormat = time.RFC3339Nano
+	opts.AutoDetectTime = true
+
+	t.Run("time-like key", func(t *testing.T) {
+		h := JSONHandler{Opts: &opts}
+		line := []byte(`{"level":"info","msg":"hello","event_date":"2021-03-04T05:06:07.123Z","other":"2000-01-01T00:00:00Z"}`)
+		if _, ok := h.TryHandle(line); !ok {
+			t.Fatal("expected line to be handled")
+		}
+		out := h.Prettify(false)
+		if !bytes.HasPrefix(out, []byte("2021-03-04T05:06:07.123Z ")) {
+			t.Fatalf("want detected time to be rendered, got %q", out)
+		}
+		if bytes.Contains(out, []byte("event_date")) {
+			t.Fatalf("want detected time to be consumed, got %q", out)
+		}
+		if !bytes.Contains(out, []byte("other=")) {
+			t.Fatalf("want other fields to be left alone, got %q", out)
+		}
+	})
+	t.Run("only timestamp", func(t *testing.T) {
+		h := JSONHandler{Opts: &opts}
+		line := []byte(`{"level":"info","msg":"hello","when":"2021-03-04T05:06:07Z"}`)
+		if _, ok := h.TryHandle(line); !ok {
+			t.Fatal("expected line to be handled")
+		}
+		if want := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC); !h.Time.Equal(want) {
+			t.Fatalf("want time %v, got %v", want, h.Time)
+		}
+	})
+	t.Run("ambiguous", func(t *testing.T) {
+		h := JSONHandler{Opts: &opts}
+		line := []byte(`{"level":"info","msg":"hello","from":"2021-03-04T05:06:07Z","to":"2021-03-05T05:06:07Z"}`)
+		if _, ok := h.TryHandle(line); ok {
+			t.Fatal("expected line not to be handled")
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		h := JSONHandler{Opts: DefaultOptions}
+		line := []byte(`{"level":"info","msg":"hello","createdAt":"2021-03-04T05:06:07.123Z"}`)
+		if _, ok := h.TryHandle(line); ok {
+			t.Fatal("expected line not to be handled")
+		}
+	})
+}
+
+func TestJSONHandlerZap(t *testing.T) {
+	h := JSONHandler{Opts: DefaultOptions}
+	ev := []byte(`{"level":"error","ts":1540369190.466951,"logger":"api","caller":"app/main.go:42","msg":"boom","stacktrace":"main.main\n\t/app/main.go:42\nruntime.main\n\t/usr/local/go/src/runtime/proc.go:250"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Unix(1540369190, 466951000); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	if h.Fields["caller"] != "app/main.go:42" {
+		t.Fatalf("want the caller unquoted, got %q", h.Fields["caller"])
+	}
+	out := string(h.Prettify(false))
+	lines := strings.Split(out, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("want the line and 4 lines of stacktrace, got %q", lines)
+	}
+	if !strings.Contains(lines[0], "|ERRO| boom") || strings.Contains(lines[0], "stacktrace") {
+		t.Fatalf("want the stacktrace out of the fields, got %q", lines[0])
+	}
+	if lines[1] != "    main.main" || lines[2] != "        /app/main.go:42" {
+		t.Fatalf("want the stacktrace indented below the line, got %q", lines[1:])
+	}
+
+	if _, ok := h.TryHandle([]byte(`{"level":"dpanic","ts":1540369190,"msg":"oops"}`)); !ok {
+		t.Fatal("should handle the line")
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|PANI|") {
+		t.Fatalf("want dpanic shown as a panic, got %q", out)
+	}
+}
+
+func TestJSONHandlerSlog(t *testing.T) {
+	h := JSONHandler{Opts: DefaultOptions}
+	ev := []byte(`{"time":"2023-11-30T10:00:00.000Z","level":"INFO+2","source":{"function":"main.main","file":"/app/main.go","line":42},"msg":"hello"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Fields["source"] != "/app/main.go:42" {
+		t.Fatalf("want the source as file:line, got %q", h.Fields["source"])
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|INFO| hello") {
+		t.Fatalf("want INFO+2 shown as an info level, got %q", out)
+	}
+}
+
+func TestJSONHandlerZerolog(t *testing.T) {
+	h := JSONHandler{Opts: DefaultOptions}
+	ev := []byte(`{"level":"trace","time":1540369190,"caller":"/app/main.go:42","message":"polling"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Unix(1540369190, 0); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	out := string(h.Prettify(false))
+	for _, want := range []string{"|TRAC| polling", " app/main.go:42"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+
+	// milliseconds, with zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs
+	if _, ok := h.TryHandle([]byte(`{"level":"fatal","time":1540369190466,"message":"bye"}`)); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Unix(1540369190, 466000000); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+}
+
+func TestJSONDetection(t *testing.T) {
+	tests := []struct {
+		detection string
+		line      string
+		want      bool
+	}{
+		{JSONDetectionTime, `{"time":"2018-10-24T08:19:50Z","id":1}`, true},
+		{JSONDetectionTime, `{"id":1,"name":"a"}`, false},
+		{JSONDetectionStrict, `{"time":"2018-10-24T08:19:50Z","id":1}`, false},
+		{JSONDetectionStrict, `{"time":"2018-10-24T08:19:50Z","msg":"hello"}`, true},
+		{JSONDetectionStrict, `{"time":"2018-10-24T08:19:50Z","level":"info"}`, true},
+		{JSONDetectionLoose, `{"id":1,"name":"a"}`, true},
+		{JSONDetectionLoose, `[1, 2]`, false},
+	}
+	for _, test := range tests {
+		opts := *DefaultOptions
+		opts.JSONDetection = test.detection
+		h := JSONHandler{Opts: &opts}
+		if got := h.parse([]byte(test.line)); got != test.want {
+			t.Errorf("%s: parse(%s) = %v, want %v", test.detection, test.line, got, test.want)
+		}
+	}
+}
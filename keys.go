@@ -0,0 +1,168 @@
+package humanlog
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// keyPatterns are the entries of HandlerOptions.Keep and Skip that are
+// patterns, like the glob `http.*` or the regexp `/^kube(rnetes)?\./`,
+// rather than exact keys.
+type keyPatterns struct {
+	keep keyMatcher
+	skip keyMatcher
+}
+
+// keyMatcher tells if keys match glob patterns or regexps.
+type keyMatcher struct {
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+// compileKeyPatterns picks out the patterns of Keep and Skip once, so that
+// they aren't looked for on every key of every line. It must be called
+// before lines are handled concurrently.
+func (h *HandlerOptions) compileKeyPatterns() {
+	if h.patterns == nil {
+		h.patterns = h.findKeyPatterns()
+	}
+}
+
+func (h *HandlerOptions) keyPatterns() *keyPatterns {
+	if h.patterns != nil {
+		return h.patterns
+	}
+	return h.findKeyPatterns()
+}
+
+func (h *HandlerOptions) findKeyPatterns() *keyPatterns {
+	return &keyPatterns{
+		keep: newKeyMatcher(h.Keep),
+		skip: newKeyMatcher(h.Skip),
+	}
+}
+
+func newKeyMatcher(keys map[string]struct{}) keyMatcher {
+	var m keyMatcher
+	for key := range keys {
+		if re, ok := keyRegexp(key); ok {
+			m.regexps = append(m.regexps, re)
+			continue
+		}
+		if !strings.ContainsAny(key, `*?[\`) {
+			continue
+		}
+		if _, err := path.Match(key, ""); err != nil {
+			// not a valid pattern, so only ever matched exactly
+			continue
+		}
+		m.globs = append(m.globs, key)
+	}
+	return m
+}
+
+// keyRegexp compiles key if it is a regexp between slashes, like /^http_/.
+// Invalid regexps are only ever matched exactly, like invalid globs.
+func keyRegexp(key string) (*regexp.Regexp, bool) {
+	if len(key) < 3 || key[0] != '/' || key[len(key)-1] != '/' {
+		return nil, false
+	}
+	re, err := regexp.Compile(key[1 : len(key)-1])
+	if err != nil {
+		return nil, false
+	}
+	return re, true
+}
+
+// hasKey tells if key, or its lowercased form, is in keys.
+func hasKey(keys map[string]struct{}, key string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	if _, ok := keys[key]; ok {
+		return true
+	}
+	_, ok := keys[strings.ToLower(key)]
+	return ok
+}
+
+// matches tells if key, or its lowercased form, matches one of the
+// patterns.
+func (m keyMatcher) matches(key string) bool {
+	if len(m.globs) == 0 && len(m.regexps) == 0 {
+		return false
+	}
+	lkey := strings.ToLower(key)
+	for _, pattern := range m.globs {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, lkey); ok {
+			return true
+		}
+	}
+	for _, re := range m.regexps {
+		if re.MatchString(key) || re.MatchString(lkey) {
+			return true
+		}
+	}
+	return false
+}
+
+// displayKey is the name key is shown under, as RenameKeys tells.
+func (h *HandlerOptions) displayKey(key string) string {
+	if name, ok := h.RenameKeys[key]; ok {
+		return name
+	}
+	return key
+}
+
+// messageKeys are the keys holding the message of a line, in order of
+// preference.
+func (h *HandlerOptions) messageKeys() []string {
+	keys := []string{"msg", "message"}
+	if h != nil {
+		keys = append(keys, h.MessageFields...)
+	}
+	return keys
+}
+
+// levelKeys are the keys holding the level of a line, in order of
+// preference.
+func (h *HandlerOptions) levelKeys() []string {
+	keys := []string{"level", "lvl"}
+	if h != nil {
+		keys = append(keys, h.LevelFields...)
+	}
+	return keys
+}
+
+// isTimeField tells if key is one of TimeFields.
+func (h *HandlerOptions) isTimeField(key string) bool {
+	return h != nil && containsString(h.TimeFields, key)
+}
+
+// isMessageField tells if key is one of MessageFields.
+func (h *HandlerOptions) isMessageField(key string) bool {
+	return h != nil && containsString(h.MessageFields, key)
+}
+
+// isLevelField tells if key is one of LevelFields.
+func (h *HandlerOptions) isLevelField(key string) bool {
+	return h != nil && containsString(h.LevelFields, key)
+}
+
+func containsString(list []string, s string) bool {
+	return indexOf(list, s) >= 0
+}
+
+// indexOf is the index of s in list, or -1 if it isn't there.
+func indexOf(list []string, s string) int {
+	for i, elem := range list {
+		if elem == s {
+			return i
+		}
+	}
+	return -1
+}
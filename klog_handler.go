@@ -0,0 +1,148 @@
+package humanlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jigish/humanlog/parser/logfmt"
+)
+
+// KlogHandler can handle the lines of klog and glog, as logged by Kubernetes
+// components:
+//
+//	I0225 10:21:15.123456   12345 controller.go:87] message
+type KlogHandler struct {
+	renderer *Renderer
+
+	Opts *HandlerOptions
+
+	Level   string
+	Time    time.Time
+	Message string
+	Fields  map[string]string
+}
+
+func (h *KlogHandler) clear() {
+	h.Level = ""
+	h.Time = time.Time{}
+	h.Message = ""
+	h.Fields = make(map[string]string)
+}
+
+// parse tells if d was handled by this handler, which then holds it until
+// the next call to Prettify.
+func (h *KlogHandler) parse(d []byte) bool {
+	if len(d) == 0 || strings.IndexByte("IWEF", d[0]) < 0 {
+		return false
+	}
+	err := h.UnmarshalKlog(d)
+	if err != nil {
+		h.clear()
+		return false
+	}
+	return true
+}
+
+var klogHeader = regexp.MustCompile(`^([IWEF])(\d{2})(\d{2}) (\d{2}):(\d{2}):(\d{2})\.(\d{6})\s+(\d+) ([^\s:\]]+:\d+)\] ?`)
+
+// UnmarshalKlog sets the fields of the handler from a klog line. The year
+// isn't logged, so the most recent one that doesn't put the line in the
+// future is assumed.
+func (h *KlogHandler) UnmarshalKlog(data []byte) error {
+	m := klogHeader.FindSubmatch(data)
+	if m == nil {
+		return fmt.Errorf("not a klog line")
+	}
+	var nums [6]int
+	for i := range nums {
+		nums[i], _ = strconv.Atoi(string(m[i+2]))
+	}
+	micros, _ := strconv.Atoi(string(m[7]))
+
+	t := time.Date(0, time.Month(nums[0]), nums[1], nums[2], nums[3], nums[4], micros*1000, time.Local)
+	h.Time = inferYear(t, time.Now())
+
+	switch m[1][0] {
+	case 'I':
+		h.Level = InfoLevel
+	case 'W':
+		h.Level = WarnLevel
+	case 'E':
+		h.Level = ErrorLevel
+	case 'F':
+		h.Level = FatalLevel
+	}
+
+	if h.Fields == nil {
+		h.Fields = make(map[string]string)
+	}
+	h.Fields["pid"] = string(m[8])
+	h.Fields["source"] = string(m[9])
+
+	msg := data[len(m[0]):]
+	// structured klog logs a quoted message followed by key=value pairs
+	if len(msg) > 0 && msg[0] == '"' {
+		if end := findClosingQuote(msg); end > 0 {
+			if unquoted, err := strconv.Unquote(string(msg[:end+1])); err == nil {
+				logfmt.Parse(msg[end+1:], false, false, func(key, val []byte) bool {
+					h.Fields[string(key)] = string(val)
+					return true
+				})
+				h.Message = unquoted
+				return nil
+			}
+		}
+	}
+	h.Message = string(msg)
+	return nil
+}
+
+// findClosingQuote returns the index of the quote closing the one that
+// starts s, or -1.
+func findClosingQuote(s []byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// TryHandle parses d into an event, if it is in the format of the handler.
+// The handler holds the line until the next call to Prettify.
+func (h *KlogHandler) TryHandle(d []byte) (Event, bool) {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if !h.parse(d) {
+		return Event{}, false
+	}
+	return h.event(), true
+}
+
+// Prettify the output in a logrus like fashion.
+func (h *KlogHandler) Prettify(skipUnchanged bool) []byte {
+	defer h.clear()
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if h.renderer == nil {
+		h.renderer = NewRenderer(h.Opts)
+	}
+	return h.renderer.Render(h.event(), skipUnchanged)
+}
+
+func (h *KlogHandler) event() Event {
+	return Event{
+		Time:   h.Time,
+		Level:  h.Level,
+		Msg:    h.Message,
+		Fields: h.Fields,
+	}
+}
@@ -0,0 +1,51 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKlogHandler(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := KlogHandler{Opts: &opts}
+	if _, ok := h.TryHandle([]byte(`W0225 10:21:15.123456   12345 controller.go:87] node not ready`)); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Time.Month() != time.February || h.Time.Day() != 25 || h.Time.Hour() != 10 || h.Time.Nanosecond() != 123456000 {
+		t.Fatalf("wrong time %v", h.Time)
+	}
+	if h.Time.After(time.Now().Add(24 * time.Hour)) {
+		t.Fatalf("want a time that isn't in the future, got %v", h.Time)
+	}
+	out := string(h.Prettify(false))
+	for _, want := range []string{"|WARN| node not ready", "pid=12345", " controller.go:87"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+
+	if _, ok := h.TryHandle([]byte(`E0225 10:21:16.000001       1 reconciler.go:12] "Failed to sync" pod="default/web" err="timeout"`)); !ok {
+		t.Fatal("should handle the structured line")
+	}
+	if h.Message != "Failed to sync" {
+		t.Fatalf("want the structured message unquoted, got %q", h.Message)
+	}
+	if h.Fields["pod"] != "default/web" || h.Fields["err"] != "timeout" {
+		t.Fatalf("want the structured fields, got %v", h.Fields)
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|ERRO| Failed to sync") {
+		t.Fatalf("want an error level, got %q", out)
+	}
+
+	for _, line := range []string{
+		"INFO starting",
+		"I0225 starting",
+		"E0225 10:21:16 reconciler.go:12] no micros",
+	} {
+		if _, ok := h.TryHandle([]byte(line)); ok {
+			t.Fatalf("want %q rejected", line)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// unwrapLambda flattens the record of an AWS Lambda telemetry envelope, like
+// `{"time":"...","type":"platform.report","record":{...}}`, into raw. The
+// type becomes the message unless the record has one of its own. It reports
+// whether raw was such an envelope.
+func unwrapLambda(raw map[string]interface{}) bool {
+	typ, ok := raw["type"].(string)
+	if !ok || !(strings.HasPrefix(typ, "platform.") || typ == "function" || typ == "extension") {
+		return false
+	}
+	record, ok := raw["record"]
+	if !ok {
+		return false
+	}
+	delete(raw, "record")
+	delete(raw, "type")
+
+	switch rec := record.(type) {
+	case map[string]interface{}:
+		if status, _ := rec["status"].(string); status != "" && status != "success" {
+			raw["level"] = "error"
+		}
+		mergeObject(raw, "record", rec)
+	case string:
+		raw["message"] = rec
+	default:
+		raw["record"] = record
+	}
+	if _, ok := raw["msg"]; !ok {
+		if _, ok := raw["message"]; !ok {
+			raw["msg"] = typ
+		}
+	}
+	return true
+}
+
+// mergeObject sets the values of obj into raw, nested objects being
+// flattened. Keys already in raw are left alone, the value then goes under
+// `prefix.key` instead.
+func mergeObject(raw map[string]interface{}, prefix string, obj map[string]interface{}) {
+	for key, val := range obj {
+		if _, exists := raw[key]; exists {
+			key = prefix + "." + key
+		}
+		flattenValue(raw, key, val)
+	}
+}
+
+// flattenValue sets val into raw under key, or under keys joined with dots
+// when val is an object.
+func flattenValue(raw map[string]interface{}, key string, val interface{}) {
+	obj, ok := val.(map[string]interface{})
+	if !ok {
+		raw[key] = val
+		return
+	}
+	for k, v := range obj {
+		flattenValue(raw, key+"."+k, v)
+	}
+}
+
+// parseEmbeddedJSON replaces the string values of raw that are JSON objects
+// by their fields, or by the objects themselves when they are to be
+// expanded. The fields of a message that is a JSON object are merged with
+// raw itself, as if the message had been logged without its wrapping.
+func parseEmbeddedJSON(raw map[string]interface{}, expand bool) {
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		str, ok := raw[key].(string)
+		if !ok {
+			continue
+		}
+		str = strings.TrimSpace(str)
+		if !strings.HasPrefix(str, "{") {
+			continue
+		}
+		obj := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(str), &obj); err != nil {
+			continue
+		}
+		delete(raw, key)
+		switch {
+		case key == "msg" || key == "message":
+			mergeObject(raw, key, obj)
+		case expand:
+			raw[key] = obj
+		default:
+			flattenValue(raw, key, obj)
+		}
+	}
+}
+
+// unwrapInsights reshapes a CloudWatch Logs Insights export record, whose
+// keys are prefixed with `@`, into the usual keys. Elastic documents share
+// the `@timestamp` key. It reports whether raw was such a record.
+func unwrapInsights(raw map[string]interface{}) bool {
+	ts, ok := raw["@timestamp"].(string)
+	if !ok {
+		return false
+	}
+	t, ok := tryParseISO8601(ts)
+	if !ok {
+		return false
+	}
+	delete(raw, "@timestamp")
+	raw["time"] = t.Format(time.RFC3339Nano)
+	if msg, ok := raw["@message"]; ok {
+		delete(raw, "@message")
+		raw["message"] = msg
+	}
+	return true
+}
+
+var (
+	lambdaPlatformLine = regexp.MustCompile(`^(START|END|REPORT|INIT_START|INIT_REPORT|RESTORE_START|RESTORE_REPORT|EXTENSION|TELEMETRY) (.*)$`)
+	lambdaPlatformPair = regexp.MustCompile(`([A-Z][A-Za-z ]*?): (\S+(?: ms| MB)?)`)
+	// the Node.js runtime, and the Python one
+	lambdaNodeLine   = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\S+Z)\t(\S+)\t(TRACE|DEBUG|INFO|WARN|ERROR|FATAL)\t(.*)$`)
+	lambdaPythonLine = regexp.MustCompile(`^\[(DEBUG|INFO|WARNING|ERROR|CRITICAL)\]\t(\d{4}-\d{2}-\d{2}T\S+Z)\t(\S+)\t(.*)$`)
+)
+
+// matchLambda tells if the line held by lh was logged by the AWS Lambda
+// runtime, like `REPORT RequestId: ... Duration: 2.45 ms ...`, in which case
+// the logrus handler gets its parts.
+func (lh *lineHandler) matchLambda() bool {
+	h := &lh.logrusEntry
+	line := string(lh.lineData)
+
+	if m := lambdaPlatformLine.FindStringSubmatch(line); m != nil {
+		pairs := lambdaPlatformPair.FindAllStringSubmatch(m[2], -1)
+		if len(pairs) == 0 {
+			return false
+		}
+		h.setMessage([]byte(m[1]))
+		h.setLevel([]byte(InfoLevel))
+		for _, pair := range pairs {
+			key := lambdaKey(pair[1])
+			h.setField([]byte(key), []byte(pair[2]))
+			if key == "status" && pair[2] != "success" {
+				h.setLevel([]byte(ErrorLevel))
+			}
+		}
+		return true
+	}
+
+	var ts, requestID, level, msg string
+	if m := lambdaNodeLine.FindStringSubmatch(line); m != nil {
+		ts, requestID, level, msg = m[1], m[2], m[3], m[4]
+	} else if m := lambdaPythonLine.FindStringSubmatch(line); m != nil {
+		ts, requestID, level, msg = m[2], m[3], m[1], m[4]
+	} else {
+		return false
+	}
+	if !h.setTime([]byte(ts)) {
+		return false
+	}
+	h.setLevel([]byte(level))
+	h.setMessage([]byte(msg))
+	if requestID != "undefined" {
+		h.setField([]byte("requestId"), []byte(requestID))
+	}
+	return true
+}
+
+// lambdaKey turns labels like `Max Memory Used` into keys like
+// `maxMemoryUsed`.
+func lambdaKey(label string) string {
+	var key strings.Builder
+	for i, word := range strings.Fields(label) {
+		if i == 0 {
+			key.WriteString(strings.ToLower(word[:1]) + word[1:])
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		key.WriteString(string(r))
+	}
+	return key.String()
+}
@@ -0,0 +1,145 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLambdaPlatformReport(t *testing.T) {
+	opts := *DefaultOptions
+	h := JSONHandler{Opts: &opts}
+	ev := []byte(`{"time":"2023-11-30T10:00:00.000Z","type":"platform.report","record":{"requestId":"6d68ca91","metrics":{"durationMs":1.52,"billedDurationMs":2},"status":"success"}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Time.IsZero() {
+		t.Fatal("want the envelope time as the timestamp")
+	}
+	if h.Message != "platform.report" {
+		t.Fatalf("want the type as message, got %q", h.Message)
+	}
+	if h.Level != "info" {
+		t.Fatalf("want level info, got %q", h.Level)
+	}
+	want := map[string]string{
+		"requestId":                `"6d68ca91"`,
+		"metrics.durationMs":       "1.52",
+		"metrics.billedDurationMs": "2",
+		"status":                   `"success"`,
+	}
+	for k, v := range want {
+		if h.Fields[k] != v {
+			t.Fatalf("want %s=%s, got %q", k, v, h.Fields[k])
+		}
+	}
+	if _, ok := h.Fields["record"]; ok {
+		t.Fatal("want the record flattened")
+	}
+
+	ev = []byte(`{"time":"2023-11-30T10:00:00.000Z","type":"platform.runtimeDone","record":{"requestId":"6d68ca91","status":"timeout"}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Level != "error" {
+		t.Fatalf("want a failed invocation to be an error, got %q", h.Level)
+	}
+}
+
+func TestParseEmbeddedJSON(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ParseEmbeddedJSON = true
+	h := JSONHandler{Opts: &opts}
+	ev := []byte(`{"time":"2023-11-30T10:00:00.000Z","type":"function","record":"{\"level\":\"WARN\",\"msg\":\"cache miss\",\"key\":\"user:1\"}"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Message != "cache miss" {
+		t.Fatalf("want the embedded message, got %q", h.Message)
+	}
+	if h.Level != "WARN" {
+		t.Fatalf("want the embedded level, got %q", h.Level)
+	}
+	if h.Fields["key"] != `"user:1"` {
+		t.Fatalf("want the embedded fields, got %v", h.Fields)
+	}
+
+	ev = []byte(`{"time":"2023-11-30T10:00:00.000Z","msg":"hi","payload":"{\"id\":42}"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Fields["payload.id"] != "42" {
+		t.Fatalf("want the embedded object flattened, got %v", h.Fields)
+	}
+
+	opts.ParseEmbeddedJSON = false
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if _, ok := h.Fields["payload"]; !ok {
+		t.Fatalf("want embedded JSON left alone by default, got %v", h.Fields)
+	}
+}
+
+func TestLambdaRuntimeLines(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+
+	for _, tt := range []struct {
+		line string
+		want []string
+	}{
+		{
+			line: "START RequestId: 8f507cfc-1b2a-4a1e-9c1d-0123456789ab Version: $LATEST",
+			want: []string{"[lambda]", "|INFO| START", "requestId=8f507cfc-1b2a-4a1e-9c1d-0123456789ab", "version=$LATEST"},
+		},
+		{
+			line: "REPORT RequestId: 8f507cfc-1b2a-4a1e-9c1d-0123456789ab\tDuration: 2.45 ms\tBilled Duration: 3 ms\tMemory Size: 128 MB\tMax Memory Used: 64 MB\tInit Duration: 150.33 ms",
+			want: []string{"|INFO| REPORT", "duration=2.45 ms", "billedDuration=3 ms", "memorySize=128 MB", "maxMemoryUsed=64 MB", "initDuration=150.33 ms"},
+		},
+		{
+			line: "REPORT RequestId: 8f507cfc-1b2a-4a1e-9c1d-0123456789ab\tDuration: 3000.00 ms\tStatus: timeout",
+			want: []string{"|ERRO| REPORT", "status=timeout"},
+		},
+		{
+			line: "2024-01-02T03:04:05.123Z\t8f507cfc-1b2a-4a1e-9c1d-0123456789ab\tWARN\tretrying",
+			want: []string{"Jan  2 03:04:05 |WARN| retrying", "requestId=8f507cfc"},
+		},
+		{
+			line: "[ERROR]\t2024-01-02T03:04:05.123Z\t8f507cfc-1b2a-4a1e-9c1d-0123456789ab\tboom",
+			want: []string{"Jan  2 03:04:05 |ERRO| boom"},
+		},
+		{
+			line: "2024-01-02T03:04:05.123000+00:00 2024/01/02/[$LATEST]0123abcd END RequestId: 8f507cfc-1b2a-4a1e-9c1d-0123456789ab",
+			want: []string{"[lambda]", "Jan  2 03:04:05 |INFO| END"},
+		},
+		{
+			line: "2024-01-02T03:04:05.123000+00:00 2024/01/02/[$LATEST]0123abcd plain output",
+			want: []string{"[aws]", "Jan  2 03:04:05", "plain output", "logStream=2024/01/02/[$LATEST]0123abcd"},
+		},
+	} {
+		out, ok := Prettify([]byte(tt.line), &opts)
+		if !ok {
+			t.Fatalf("want %q handled", tt.line)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(out), want) {
+				t.Fatalf("want %q in %q", want, out)
+			}
+		}
+	}
+
+	if _, ok := Prettify([]byte("START the engines"), &opts); ok {
+		t.Fatal("want a line that only starts like a Lambda one not handled")
+	}
+}
+
+func TestUnwrapInsights(t *testing.T) {
+	h := JSONHandler{Opts: DefaultOptions}
+	if _, ok := h.TryHandle([]byte(`{"@timestamp":"2024-01-02 03:04:05.123","@message":"hello","@logStream":"abc"}`)); !ok {
+		t.Fatal("should handle the line")
+	}
+	if h.Message != "hello" || h.Time.IsZero() || h.Fields["@logStream"] != `"abc"` {
+		t.Fatalf("want the Insights record reshaped, got %q at %v with %v", h.Message, h.Time, h.Fields)
+	}
+}
@@ -0,0 +1,222 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// Normalized level names, used as keys of HandlerOptions.LevelLabels.
+const (
+	TraceLevel   = "trace"
+	DebugLevel   = "debug"
+	InfoLevel    = "info"
+	WarnLevel    = "warn"
+	ErrorLevel   = "error"
+	PanicLevel   = "panic"
+	FatalLevel   = "fatal"
+	UnknownLevel = "unknown"
+)
+
+// Styles of decoration around the level labels.
+const (
+	// LevelStyleBars renders levels as `|INFO|`.
+	LevelStyleBars = "bars"
+	// LevelStyleBracket renders levels as `[INFO]`.
+	LevelStyleBracket = "bracket"
+	// LevelStylePlain renders levels as `INFO`.
+	LevelStylePlain = "plain"
+)
+
+var defaultLevelLabels = map[string]string{
+	TraceLevel:   "TRAC",
+	DebugLevel:   "DEBU",
+	InfoLevel:    "INFO",
+	WarnLevel:    "WARN",
+	ErrorLevel:   "ERRO",
+	PanicLevel:   "PANI",
+	FatalLevel:   "FATA",
+	UnknownLevel: "UNKN",
+}
+
+// levelSeverity orders the normalized level names, from the least to the
+// most severe.
+func levelSeverity(level string) int {
+	switch level {
+	case TraceLevel:
+		return 1
+	case DebugLevel:
+		return 2
+	case InfoLevel:
+		return 3
+	case WarnLevel:
+		return 4
+	case ErrorLevel:
+		return 5
+	case PanicLevel:
+		return 6
+	case FatalLevel:
+		return 7
+	default:
+		return 0
+	}
+}
+
+// IsLevel tells if level is one of the normalized level names.
+func IsLevel(level string) bool {
+	_, ok := defaultLevelLabels[level]
+	return ok
+}
+
+// normalizeLevel maps the usual level names onto one of the normalized
+// level names.
+func normalizeLevel(lvl string) string {
+	// log/slog has levels in between, like `DEBUG-4` or `INFO+2`
+	if i := strings.IndexAny(lvl, "+-"); i > 0 && isDigits(lvl[i+1:]) {
+		lvl = lvl[:i]
+	}
+	switch strings.ToLower(lvl) {
+	case "trace":
+		return TraceLevel
+	case "debug":
+		return DebugLevel
+	case "info", "notice":
+		return InfoLevel
+	case "warn", "warning":
+		return WarnLevel
+	case "error":
+		return ErrorLevel
+	case "panic", "dpanic":
+		return PanicLevel
+	case "fatal", "critical", "alert", "emergency":
+		return FatalLevel
+	default:
+		return UnknownLevel
+	}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeSyslogLevel maps syslog severities onto one of the normalized
+// level names.
+func normalizeSyslogLevel(severity string) string {
+	switch severity {
+	case "7":
+		return DebugLevel
+	case "5", "6":
+		return InfoLevel
+	case "4":
+		return WarnLevel
+	case "3":
+		return ErrorLevel
+	case "2", "1", "0":
+		return FatalLevel
+	default:
+		return UnknownLevel
+	}
+}
+
+// normalizeBunyanLevel maps the numeric levels of node-bunyan and pino onto
+// one of the normalized level names.
+func normalizeBunyanLevel(lvl string) string {
+	n, err := strconv.Atoi(lvl)
+	switch {
+	case err != nil:
+		return UnknownLevel
+	case n <= 10:
+		return TraceLevel
+	case n <= 20:
+		return DebugLevel
+	case n <= 30:
+		return InfoLevel
+	case n <= 40:
+		return WarnLevel
+	case n <= 50:
+		return ErrorLevel
+	default:
+		return FatalLevel
+	}
+}
+
+// mapLevel normalizes a level as it was logged, with LevelMapping if it has
+// the level, or with normalize otherwise.
+func (h *HandlerOptions) mapLevel(lvl string, normalize func(string) string) string {
+	if h != nil && len(h.LevelMapping) > 0 {
+		if level, ok := h.LevelMapping[lvl]; ok {
+			return level
+		}
+		if level, ok := h.LevelMapping[strings.ToLower(lvl)]; ok {
+			return level
+		}
+	}
+	return normalize(lvl)
+}
+
+func (h *HandlerOptions) levelColor(level string) *color.Color {
+	switch level {
+	case TraceLevel, DebugLevel:
+		return h.DebugLevelColor
+	case InfoLevel:
+		return h.InfoLevelColor
+	case WarnLevel:
+		return h.WarnLevelColor
+	case ErrorLevel:
+		return h.ErrorLevelColor
+	case PanicLevel, FatalLevel:
+		return h.FatalLevelColor
+	default:
+		return h.UnknownLevelColor
+	}
+}
+
+// levelLabelWidth is the width of the widest level label, which every
+// label is padded to so that what follows it stays aligned.
+func (h *HandlerOptions) levelLabelWidth() int {
+	width := 4
+	for _, label := range h.LevelLabels {
+		if w := utf8.RuneCountInString(label); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// levelLabel renders the colored and decorated label of a normalized level.
+// If the level is unknown and no label is configured for it, `fallback` is
+// used.
+func (h *HandlerOptions) levelLabel(level, fallback string) string {
+	label, ok := h.LevelLabels[level]
+	if !ok {
+		if level == UnknownLevel && fallback != "" {
+			label = fallback
+		} else {
+			label = defaultLevelLabels[level]
+		}
+	}
+	pad := h.levelLabelWidth() - utf8.RuneCountInString(label)
+	if pad < 0 {
+		pad = 0
+	}
+	label = h.paint(h.levelColor(level), label) + strings.Repeat(" ", pad)
+
+	switch h.LevelStyle {
+	case LevelStyleBracket:
+		return "[" + label + "]"
+	case LevelStylePlain:
+		return label
+	default:
+		return "|" + label + "|"
+	}
+}
@@ -0,0 +1,121 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestLevelLabels(t *testing.T) {
+	opts := *DefaultOptions
+	opts.LevelLabels = map[string]string{
+		WarnLevel:  "WARNING",
+		ErrorLevel: "ERROR",
+	}
+
+	src := strings.NewReader(strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"warning","msg":"careful"}`,
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello"}`,
+		`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"3","MESSAGE":"boom"}`,
+	}, "\n"))
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+
+	for i, want := range []string{"|WARNING| careful", "|INFO   | hello", "|ERROR  | boom"} {
+		if !strings.Contains(lines[i], want) {
+			t.Fatalf("want %q in line %d, got %q", want, i, lines[i])
+		}
+	}
+	col := strings.Index(lines[0], "| ")
+	for i, line := range lines {
+		if got := strings.Index(line, "| "); got != col {
+			t.Fatalf("want message of line %d to start at column %d, got %d", i, col, got)
+		}
+	}
+}
+
+func TestLevelStyle(t *testing.T) {
+	line := `{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello","a":1}`
+	for style, want := range map[string]string{
+		"":                " |INFO| hello",
+		LevelStyleBars:    " |INFO| hello",
+		LevelStyleBracket: " [INFO] hello",
+		LevelStylePlain:   " INFO hello",
+	} {
+		opts := *DefaultOptions
+		opts.LevelStyle = style
+		dst := bytes.NewBuffer(nil)
+		if err := Scanner(strings.NewReader(line), dst, &opts); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(dst.String(), want) {
+			t.Fatalf("style %q: want %q in output, got %q", style, want, dst.String())
+		}
+		if !strings.HasSuffix(dst.String(), "\t a=1\n") && !strings.HasSuffix(dst.String(), " a=1\n") {
+			t.Fatalf("style %q: want fields after the message, got %q", style, dst.String())
+		}
+	}
+}
+
+func TestMessageColorByLevel(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	opts.MessageColorByLevel = map[string]*color.Color{
+		ErrorLevel: color.New(color.FgRed, color.Bold),
+	}
+
+	src := strings.NewReader(strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"error","msg":"boom"}`,
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"fine"}`,
+		`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"3","MESSAGE":"journal boom"}`,
+	}, "\n"))
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	out := dst.String()
+
+	for _, want := range []string{
+		opts.MessageColorByLevel[ErrorLevel].Sprint("boom"),
+		opts.MsgDarkBgColor.Sprint("fine"),
+		opts.MessageColorByLevel[ErrorLevel].Sprint("journal boom"),
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestLevelMapping(t *testing.T) {
+	opts := *DefaultOptions
+	opts.LevelMapping = map[string]string{
+		"note":     InfoLevel,
+		"30":       WarnLevel,
+		"critical": ErrorLevel,
+		"4":        ErrorLevel,
+	}
+
+	for line, want := range map[string]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"note","msg":"json"}`:                       "|INFO| json",
+		`{"time":"2018-10-24T08:19:50Z","level":"CRITICAL","msg":"lowercased"}`:             "|ERRO| lowercased",
+		`{"v":0,"time":"2018-10-24T08:19:50Z","level":30,"msg":"bunyan"}`:                   "|WARN| bunyan",
+		`time="2018-10-24T08:19:50Z" level=note msg="logrus"`:                               "|INFO| logrus",
+		`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"4","MESSAGE":"jrnl"}`: "|ERRO| jrnl",
+		`{"time":"2018-10-24T08:19:50Z","level":"warning","msg":"unmapped"}`:                "|WARN| unmapped",
+	} {
+		out, ok := Prettify([]byte(line), &opts)
+		if !ok {
+			t.Fatalf("should handle %s", line)
+		}
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("want %q in %q", want, out)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxLineLength is the length lines are truncated to when
+// MaxLineLength isn't set.
+const DefaultMaxLineLength = 1 << 20
+
+// maxLineLength is how long a line can be before it is truncated.
+func (h *HandlerOptions) maxLineLength() int {
+	if h.MaxLineLength > 0 {
+		return h.MaxLineLength
+	}
+	return DefaultMaxLineLength
+}
+
+// lineReader splits what it reads into lines, like a bufio.Scanner with
+// bufio.ScanLines would. Lines longer than its maximum are truncated and
+// marked as such, rather than stopping the scan with an error.
+type lineReader struct {
+	in   *bufio.Reader
+	max  int
+	line []byte
+	err  error
+
+	// whether to keep the lines as they were read too, and those lines
+	keepRaw bool
+	raw     []byte
+}
+
+func newLineReader(src io.Reader, max int) *lineReader {
+	return &lineReader{in: bufio.NewReader(src), max: max}
+}
+
+// lineReader reads the lines of src, keeping them as they were read as
+// well when they are copied to RawCopy.
+func (h *HandlerOptions) lineReader(src io.Reader) *lineReader {
+	r := newLineReader(src, h.maxLineLength())
+	r.keepRaw = h.RawCopy != nil
+	return r
+}
+
+// Scan reads the next line, reporting whether there was one.
+func (r *lineReader) Scan() bool {
+	if r.err != nil {
+		return false
+	}
+	r.line = r.line[:0]
+	r.raw = r.raw[:0]
+	dropped := 0
+	for {
+		chunk, err := r.in.ReadSlice('\n')
+		if r.keepRaw {
+			r.raw = append(r.raw, bytes.TrimSuffix(chunk, []byte("\n"))...)
+		}
+		if err == nil {
+			// the newline doesn't count in the length of the line
+			chunk = chunk[:len(chunk)-1]
+		}
+		if room := r.max - len(r.line); room < len(chunk) {
+			r.line = append(r.line, chunk[:room]...)
+			dropped += len(chunk) - room
+		} else {
+			r.line = append(r.line, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			r.err = err
+			if len(r.line) == 0 && dropped == 0 {
+				return false
+			}
+		}
+		break
+	}
+
+	if dropped > 0 {
+		r.line = append(r.line, fmt.Sprintf("… [%d bytes truncated]", dropped)...)
+		return true
+	}
+	r.line = trimCR(r.line)
+	return true
+}
+
+// trimCR removes the carriage returns ending line, so that CRLF line
+// endings don't end up in the last value of a line.
+func trimCR(line []byte) []byte {
+	for len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// Bytes is the line read by the last call to Scan, only valid until the
+// next one.
+func (r *lineReader) Bytes() []byte { return r.line }
+
+// Raw is the line read by the last call to Scan as it was read, neither
+// truncated nor trimmed, when it was kept, and otherwise the same as Bytes.
+// It is only valid until the next call to Scan.
+func (r *lineReader) Raw() []byte {
+	if r.keepRaw {
+		return r.raw
+	}
+	return r.line
+}
+
+// Err is the error that stopped the scan, if it wasn't the end of the
+// input.
+func (r *lineReader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// truncateLine truncates line to max bytes, marking it as such, for lines
+// that weren't read by a lineReader.
+func truncateLine(line []byte, max int) []byte {
+	if len(line) <= max {
+		return line
+	}
+	out := make([]byte, 0, max+32)
+	out = append(out, line[:max]...)
+	return append(out, fmt.Sprintf("… [%d bytes truncated]", len(line)-max)...)
+}
@@ -0,0 +1,55 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	src := "short\r\n" + long + "\n\n" + strings.Repeat("y", 100) + "\nlast"
+
+	in := newLineReader(strings.NewReader(src), 100)
+	var got []string
+	for in.Scan() {
+		got = append(got, string(in.Bytes()))
+	}
+	if err := in.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"short", strings.Repeat("x", 100) + "… [9900 bytes truncated]", "", strings.Repeat("y", 100), "last"}
+	if len(got) != len(want) {
+		t.Fatalf("want %d lines, got %d: %q", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("line %d: want %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestScannerLongLines(t *testing.T) {
+	// longer than what bufio.Scanner can hold by default
+	huge := `{"time":"2018-10-24T08:00:00Z","level":"info","msg":"huge","payload":"` + strings.Repeat("x", 100000) + `"}`
+	src := huge + "\n" + `{"time":"2018-10-24T08:00:01Z","level":"info","msg":"after"}` + "\n"
+
+	opts := *DefaultOptions
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.String(); !strings.Contains(got, "huge") || !strings.Contains(got, "after") {
+		t.Fatalf("want long lines handled, got %q", got)
+	}
+
+	opts.MaxLineLength = 1000
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	if !strings.Contains(got, "bytes truncated]") || !strings.Contains(got, "after") {
+		t.Fatalf("want the long line truncated and the scan going on, got %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+package humanlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// log4jLine matches the usual pattern of log4j and Logback,
+// `%d{ISO8601} [%thread] %-5level %logger - %msg`.
+var log4jLine = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[,.]\d{1,9})?(?:Z|[+-]\d{2}:?\d{2})?) \[([^\]]*)\] (TRACE|DEBUG|INFO|WARN|ERROR|FATAL) +(\S+) +- (.*)$`)
+
+// log4jTimeLayouts are the layouts of ISO8601 dates, once the date and
+// time are joined by a T and the milliseconds put behind a dot rather than
+// a comma. Those without a zone are in local time.
+var log4jTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// matchLog4j tells if the line held by lh was logged with the usual
+// pattern of log4j or Logback, like
+// `2024-01-02 03:04:05,123 [main] INFO  com.example.App - Started`, in which
+// case the logrus handler gets its parts. The stack traces of exceptions
+// logged on the lines after it are folded under it.
+func (lh *lineHandler) matchLog4j() bool {
+	m := log4jLine.FindSubmatch(lh.lineData)
+	if m == nil {
+		return false
+	}
+	stamp := strings.Replace(strings.Replace(string(m[1]), " ", "T", 1), ",", ".", 1)
+	var (
+		t   time.Time
+		err error
+	)
+	for _, layout := range log4jTimeLayouts {
+		if t, err = time.ParseInLocation(layout, stamp, time.Local); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return false
+	}
+	h := &lh.logrusEntry
+	h.Time = t
+	h.setField([]byte("thread"), m[2])
+	h.setLevel(m[3])
+	h.setField([]byte("logger"), m[4])
+	h.setMessage(m[5])
+	return true
+}
+
+// unwrapLog4j moves the parts of an event of log4j2's JSONLayout, like
+//
+//	{"instant":{"epochSecond":1704164645,"nanoOfSecond":123000000},"thread":"main","level":"ERROR","loggerName":"com.example.App","message":"boom","thrown":{"name":"java.lang.IllegalStateException","message":"boom","extendedStackTrace":[...]}}
+//
+// to where the JSON handler looks for them: its time, its logger and the
+// stack trace of the exception it was thrown, to be written below it.
+func unwrapLog4j(raw map[string]interface{}) bool {
+	if _, ok := raw["loggerName"]; !ok {
+		return false
+	}
+	var t time.Time
+	if instant, ok := raw["instant"].(map[string]interface{}); ok {
+		sec, _ := instant["epochSecond"].(float64)
+		nsec, _ := instant["nanoOfSecond"].(float64)
+		t = time.Unix(int64(sec), int64(nsec))
+	} else if ms, ok := raw["timeMillis"].(float64); ok {
+		t = time.Unix(0, int64(ms)*int64(time.Millisecond))
+	} else {
+		return false
+	}
+	delete(raw, "instant")
+	delete(raw, "timeMillis")
+	raw["time"] = t.UTC().Format(time.RFC3339Nano)
+
+	raw["logger"] = raw["loggerName"]
+	delete(raw, "loggerName")
+	// the same for every line
+	delete(raw, "loggerFqcn")
+	delete(raw, "endOfBatch")
+	delete(raw, "threadPriority")
+
+	if thrown, ok := raw["thrown"].(map[string]interface{}); ok {
+		delete(raw, "thrown")
+		var trace strings.Builder
+		writeLog4jThrown(&trace, thrown, "")
+		raw["stacktrace"] = strings.TrimSuffix(trace.String(), "\n")
+	}
+	return true
+}
+
+// writeLog4jThrown writes an exception of JSONLayout the way Java prints
+// them, its causes following it.
+func writeLog4jThrown(trace *strings.Builder, thrown map[string]interface{}, prefix string) {
+	name, _ := thrown["name"].(string)
+	trace.WriteString(prefix + name)
+	if msg, ok := thrown["message"].(string); ok && msg != "" {
+		trace.WriteString(": " + msg)
+	}
+	trace.WriteString("\n")
+	frames, _ := thrown["extendedStackTrace"].([]interface{})
+	if frames == nil {
+		frames, _ = thrown["stackTrace"].([]interface{})
+	}
+	for _, frame := range frames {
+		f, ok := frame.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		class, _ := f["class"].(string)
+		method, _ := f["method"].(string)
+		file, _ := f["file"].(string)
+		if line, ok := f["line"].(float64); ok && line > 0 {
+			file = fmt.Sprintf("%s:%d", file, int(line))
+		}
+		fmt.Fprintf(trace, "\tat %s.%s(%s)\n", class, method, file)
+	}
+	// stacktraceAsString
+	if s, ok := thrown["extendedStackTrace"].(string); ok {
+		trace.WriteString(strings.TrimSuffix(s, "\n") + "\n")
+	}
+	if cause, ok := thrown["cause"].(map[string]interface{}); ok {
+		writeLog4jThrown(trace, cause, "Caused by: ")
+	}
+}
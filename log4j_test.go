@@ -0,0 +1,101 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLog4jLine(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+	lh := newLineHandler(&opts)
+
+	line := `2024-01-02T03:04:05,123+01:00 [http-nio-8080-exec-1] WARN  com.example.App - disk almost full`
+	if format := lh.match([]byte(line)); format != "log4j" {
+		t.Fatalf("want log4j format, got %q", format)
+	}
+	if want := time.Date(2024, 1, 2, 2, 4, 5, 123000000, time.UTC); !lh.ev.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, lh.ev.Time)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{
+		"[log4j]", "|WARN| disk almost full", "thread=http-nio-8080-exec-1", "logger=com.example.App",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestLog4jFoldsThrowable(t *testing.T) {
+	src := strings.Join([]string{
+		`2024-01-02 03:04:05,123 [main] ERROR com.example.App - request failed`,
+		`java.lang.IllegalStateException: boom`,
+		"\tat com.example.Handler.serve(Handler.java:42)",
+		`Caused by: java.io.IOException: broken pipe`,
+		"\t... 2 more",
+		`2024-01-02 03:04:06,000 [main] INFO  com.example.App - next`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	want := []string{
+		"|ERRO| request failed",
+		"    java.lang.IllegalStateException: boom",
+		"        at com.example.Handler.serve(Handler.java:42)",
+		"    Caused by: java.io.IOException: broken pipe",
+		"        ... 2 more",
+		"|INFO| next",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("want %d lines, got %q", len(want), lines)
+	}
+	for i, w := range want {
+		if !strings.Contains(lines[i], w) {
+			t.Fatalf("want line %d to contain %q, got %q", i, w, lines[i])
+		}
+	}
+}
+
+func TestLog4jJSONLayout(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	lh := newLineHandler(&opts)
+
+	line := `{"instant":{"epochSecond":1704164645,"nanoOfSecond":123000000},"thread":"main","level":"ERROR","loggerName":"com.example.App","message":"request failed","endOfBatch":false,"loggerFqcn":"org.apache.logging.log4j.spi.AbstractLogger","threadId":1,"threadPriority":5,` +
+		`"thrown":{"name":"java.lang.IllegalStateException","message":"boom","extendedStackTrace":[{"class":"com.example.Handler","method":"serve","file":"Handler.java","line":42}],` +
+		`"cause":{"name":"java.io.IOException","message":"broken pipe","extendedStackTrace":[]}}}`
+	if format := lh.match([]byte(line)); format != "json" {
+		t.Fatalf("want json format, got %q", format)
+	}
+	if want := time.Unix(1704164645, 123000000); !lh.ev.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, lh.ev.Time)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{
+		"|ERRO| request failed", `logger="com.example.App"`, `thread="main"`,
+		"java.lang.IllegalStateException: boom",
+		"at com.example.Handler.serve(Handler.java:42)",
+		"Caused by: java.io.IOException: broken pipe",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+	for _, noise := range []string{"loggerFqcn", "endOfBatch", "threadPriority", "instant"} {
+		if strings.Contains(out, noise) {
+			t.Fatalf("want %s left out, got %q", noise, out)
+		}
+	}
+}
@@ -2,21 +2,15 @@ package humanlog
 
 import (
 	"bytes"
-	"fmt"
-	"sort"
-	"strconv"
 	"strings"
-	"text/tabwriter"
 	"time"
 
-	"github.com/fatih/color"
+	"github.com/jigish/humanlog/parser/logfmt"
 )
 
 // LogrusHandler can handle logs emmited by logrus.TextFormatter loggers.
 type LogrusHandler struct {
-	buf     *bytes.Buffer
-	out     *tabwriter.Writer
-	truncKV int
+	renderer *Renderer
 
 	Opts *HandlerOptions
 
@@ -24,33 +18,53 @@ type LogrusHandler struct {
 	Time    time.Time
 	Message string
 	Fields  map[string]string
-
-	last map[string]string
 }
 
 func (h *LogrusHandler) clear() {
 	h.Level = ""
 	h.Time = time.Time{}
 	h.Message = ""
-	h.last = h.Fields
 	h.Fields = make(map[string]string)
-	h.buf.Reset()
 }
 
 // CanHandle tells if this line can be handled by this handler.
 func (h *LogrusHandler) CanHandle(d []byte) bool {
-	if !bytes.Contains(d, []byte(`level=`)) {
+	var opts HandlerOptions
+	if h.Opts != nil {
+		opts = *h.Opts
+	}
+	if !bytes.Contains(d, []byte(`level=`)) && !hasAnyKey(d, opts.LevelFields, "=") {
 		return false
 	}
-	if !bytes.Contains(d, []byte(`time="`)) {
+	// log/slog's TextHandler only quotes the values that need it
+	if bytes.HasPrefix(d, []byte(`time=`)) && bytes.Contains(d, []byte(` msg=`)) {
+		return true
+	}
+	if !bytes.Contains(d, []byte(`time="`)) && !hasAnyKey(d, opts.TimeFields, "=") {
 		return false
 	}
-	if !bytes.Contains(d, []byte(`msg="`)) {
+	if !bytes.Contains(d, []byte(`msg="`)) && !hasAnyKey(d, opts.MessageFields, `="`) {
 		return false
 	}
 	return true
 }
 
+// parse tells if d was handled by this handler, which then holds it until
+// the next call to Prettify.
+func (h *LogrusHandler) parse(d []byte) bool {
+	return h.CanHandle(d) && logfmt.Parse(d, true, true, h.visit)
+}
+
+// hasAnyKey tells if d has one of keys, followed by sep.
+func hasAnyKey(d []byte, keys []string, sep string) bool {
+	for _, key := range keys {
+		if bytes.Contains(d, []byte(key+sep)) {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleLogfmt sets the fields of the handler.
 func (h *LogrusHandler) visit(key, val []byte) bool {
 	switch {
@@ -66,86 +80,56 @@ func (h *LogrusHandler) visit(key, val []byte) bool {
 		h.setTime(val)
 	case bytes.Equal(key, []byte("ts")):
 		h.setTime(val)
+	case h.Opts.isTimeField(string(key)) && h.setTime(val):
+	case h.Opts.isMessageField(string(key)) && h.Message == "":
+		h.setMessage(val)
+	case h.Opts.isLevelField(string(key)) && h.Level == "":
+		h.setLevel(val)
 	default:
 		h.setField(key, val)
 	}
 	return true
 }
 
+// TryHandle parses d into an event, if it is in the format of the handler.
+// The handler holds the line until the next call to Prettify.
+func (h *LogrusHandler) TryHandle(d []byte) (Event, bool) {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if !h.parse(d) {
+		return Event{}, false
+	}
+	return h.event(), true
+}
+
 // Prettify the output in a logrus like fashion.
 func (h *LogrusHandler) Prettify(skipUnchanged bool) []byte {
 	defer h.clear()
-	if h.out == nil {
-		if h.Opts == nil {
-			h.Opts = DefaultOptions
-		}
-		h.buf = bytes.NewBuffer(nil)
-		h.out = tabwriter.NewWriter(h.buf, 0, 1, 0, '\t', 0)
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
 	}
-
-	var (
-		msgColor       *color.Color
-		msgAbsentColor *color.Color
-	)
-	if h.Opts.LightBg {
-		msgColor = h.Opts.MsgLightBgColor
-		msgAbsentColor = h.Opts.MsgAbsentLightBgColor
-	} else {
-		msgColor = h.Opts.MsgDarkBgColor
-		msgAbsentColor = h.Opts.MsgAbsentDarkBgColor
-	}
-
-	var msg string
-	if h.Message == "" {
-		msg = msgAbsentColor.Sprint("<no msg>")
-	} else {
-		msg = msgColor.Sprint(h.Message)
+	if h.renderer == nil {
+		h.renderer = NewRenderer(h.Opts)
 	}
+	return h.renderer.Render(h.event(), skipUnchanged)
+}
 
-	lvl := strings.ToUpper(h.Level)[:imin(4, len(h.Level))]
-	var level string
-	switch h.Level {
-	case "debug":
-		level = h.Opts.DebugLevelColor.Sprint(lvl)
-	case "info":
-		level = h.Opts.InfoLevelColor.Sprint(lvl)
-	case "warn", "warning":
-		level = h.Opts.WarnLevelColor.Sprint(lvl)
-	case "error":
-		level = h.Opts.ErrorLevelColor.Sprint(lvl)
-	case "fatal", "panic":
-		level = h.Opts.FatalLevelColor.Sprint(lvl)
-	default:
-		level = h.Opts.UnknownLevelColor.Sprint(lvl)
-	}
+func (h *LogrusHandler) event() Event {
+	return Event{
+		Time:   h.Time,
+		Level:  h.Opts.mapLevel(h.Level, normalizeLevel),
+		Msg:    h.Message,
+		Fields: h.Fields,
 
-	var timeColor *color.Color
-	if h.Opts.LightBg {
-		timeColor = h.Opts.TimeLightBgColor
-	} else {
-		timeColor = h.Opts.TimeDarkBgColor
+		label: strings.ToUpper(h.Level)[:imin(4, len(h.Level))],
 	}
-	_, _ = fmt.Fprintf(h.out, "%s |%s| %s\t %s",
-		timeColor.Sprint(h.Time.Format(h.Opts.TimeFormat)),
-		level,
-		msg,
-		strings.Join(h.joinKVs(skipUnchanged, "="), "\t "),
-	)
-
-	_ = h.out.Flush()
-
-	return h.buf.Bytes()
 }
 
 func (h *LogrusHandler) setLevel(val []byte)   { h.Level = string(val) }
 func (h *LogrusHandler) setMessage(val []byte) { h.Message = string(val) }
 func (h *LogrusHandler) setTime(val []byte) (parsed bool) {
-	valStr := string(val)
-	if valFloat, err := strconv.ParseFloat(valStr, 64); err == nil {
-		h.Time, parsed = tryParseTime(valFloat)
-	} else {
-		h.Time, parsed = tryParseTime(string(val))
-	}
+	h.Time, parsed = h.Opts.parseTime(string(val))
 	return
 }
 
@@ -155,51 +139,3 @@ func (h *LogrusHandler) setField(key, val []byte) {
 	}
 	h.Fields[string(key)] = string(val)
 }
-
-func (h *LogrusHandler) joinKVs(skipUnchanged bool, sep string) []string {
-
-	kv := make([]string, 0, len(h.Fields))
-	for k, v := range h.Fields {
-		if !h.Opts.shouldShowKey(k) {
-			continue
-		}
-
-		if skipUnchanged {
-			if lastV, ok := h.last[k]; ok && lastV == v && !h.Opts.shouldShowUnchanged(k) {
-				continue
-			}
-		}
-
-		kstr := h.Opts.KeyColor.Sprint(k)
-
-		var vstr string
-		if h.Opts.Truncates && len(v) > h.Opts.TruncateLength {
-			vstr = v[:h.Opts.TruncateLength] + "..."
-		} else {
-			vstr = v
-		}
-		vstr = h.Opts.ValColor.Sprint(vstr)
-		kv = append(kv, kstr+sep+vstr)
-	}
-
-	sort.Strings(kv)
-
-	if h.Opts.SortLongest {
-		sort.Stable(byLongest(kv))
-	}
-
-	return kv
-}
-
-type byLongest []string
-
-func (s byLongest) Len() int           { return len(s) }
-func (s byLongest) Less(i, j int) bool { return len(s[i]) < len(s[j]) }
-func (s byLongest) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
-
-func imin(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,75 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlogText(t *testing.T) {
+	line := []byte(`time=2023-11-30T10:00:00.000Z level=WARN source=/app/main.go:42 msg="disk almost full" free=1GB`)
+	out, ok := Prettify(line, DefaultOptions)
+	if !ok {
+		t.Fatal("should handle the line")
+	}
+	got := string(out)
+	for _, want := range []string{"Nov 30 10:00:00", "|WARN| disk almost full", " app/main.go:42", "free=1GB"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q in %q", want, got)
+		}
+	}
+
+	out, ok = Prettify([]byte(`time=2023-11-30T10:00:00.000Z level=DEBUG-4 msg=hi`), DefaultOptions)
+	if !ok {
+		t.Fatal("should handle the line")
+	}
+	if !strings.Contains(string(out), "|DEBU| hi") {
+		t.Fatalf("want DEBUG-4 shown as a debug level, got %q", out)
+	}
+}
+
+func TestCustomTimeFields(t *testing.T) {
+	opts := *DefaultOptions
+	opts.TimeFields = []string{"logged_at"}
+	opts.TimeLayouts = []string{"2006/01/02 15:04:05"}
+
+	for _, line := range []string{
+		`logged_at="2023/11/30 10:00:00" level=info msg="from logfmt"`,
+		`{"logged_at":"2023/11/30 10:00:00","level":"info","msg":"from json"}`,
+	} {
+		out, ok := Prettify([]byte(line), &opts)
+		if !ok {
+			t.Fatalf("should handle %s", line)
+		}
+		if got := string(out); !strings.HasPrefix(got, "Nov 30 10:00:00 |INFO|") || strings.Contains(got, "logged_at") {
+			t.Fatalf("want the custom field used as time, got %q", got)
+		}
+	}
+
+	if _, ok := Prettify([]byte(`logged_at="2023/11/30 10:00:00" level=info msg="hi"`), DefaultOptions); ok {
+		t.Fatal("want custom fields ignored unless configured")
+	}
+}
+
+func TestCustomMessageAndLevelFields(t *testing.T) {
+	opts := *DefaultOptions
+	opts.MessageFields = []string{"log"}
+	opts.LevelFields = []string{"severity", "loglevel"}
+
+	for _, line := range []string{
+		`time="2023-11-30T10:00:00Z" loglevel=warn log="from logfmt"`,
+		`{"time":"2023-11-30T10:00:00Z","severity":"warn","log":"from json"}`,
+	} {
+		out, ok := Prettify([]byte(line), &opts)
+		if !ok {
+			t.Fatalf("should handle %s", line)
+		}
+		if got := string(out); !strings.Contains(got, "|WARN| from ") || strings.Contains(got, "log=") {
+			t.Fatalf("want the custom fields used as message and level, got %q", got)
+		}
+	}
+
+	out, _ := Prettify([]byte(`{"time":"2023-11-30T10:00:00Z","msg":"usual","log":"custom"}`), &opts)
+	if got := string(out); !strings.Contains(got, "usual") || !strings.Contains(got, `log="custom"`) {
+		t.Fatalf("want the usual keys preferred, got %q", got)
+	}
+}
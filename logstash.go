@@ -0,0 +1,31 @@
+package humanlog
+
+// unwrapLogstash reshapes a logstash event into the usual keys. Events of
+// the v0 schema keep their fields under `@fields`, which is flattened into
+// raw, and the other `@` keys lose their prefix. The `@version` of v1
+// events says nothing about what was logged and is dropped. It reports
+// whether raw was such an event.
+func unwrapLogstash(raw map[string]interface{}) bool {
+	_, hasVersion := raw["@version"]
+	fields, hasFields := raw["@fields"].(map[string]interface{})
+	if !hasVersion && !hasFields {
+		return false
+	}
+	delete(raw, "@version")
+	if hasFields {
+		delete(raw, "@fields")
+		mergeObject(raw, "@fields", fields)
+	}
+	for _, key := range []string{"@message", "@source", "@source_host", "@source_path", "@tags", "@type"} {
+		val, ok := raw[key]
+		if !ok {
+			continue
+		}
+		delete(raw, key)
+		if _, exists := raw[key[1:]]; exists {
+			continue
+		}
+		raw[key[1:]] = val
+	}
+	return true
+}
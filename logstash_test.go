@@ -0,0 +1,47 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnwrapLogstash(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := JSONHandler{Opts: &opts}
+
+	ev := []byte(`{"@timestamp":"2014-03-04T12:13:14.123Z","@version":"1","message":"user logged in","level":"INFO","logger_name":"auth","host":"web1"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Date(2014, 3, 4, 12, 13, 14, 123e6, time.UTC); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	if _, ok := h.Fields["@version"]; ok {
+		t.Fatal("want @version hidden")
+	}
+	if got := h.Fields["logger_name"]; got != `"auth"` {
+		t.Fatalf("want logger_name kept, got %q", got)
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|INFO| user logged in") {
+		t.Fatalf("want the message and level shown, got %q", out)
+	}
+
+	ev = []byte(`{"@timestamp":"2014-03-04T12:13:14.123Z","@message":"disk full","@source_host":"db1","@tags":["prod"],"@fields":{"level":"error","disk":{"free":0}}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	for k, want := range map[string]string{
+		"source_host": `"db1"`,
+		"tags":        `[prod]`,
+		"disk.free":   "0",
+	} {
+		if got := h.Fields[k]; got != want {
+			t.Fatalf("want %s=%s, got %s", k, want, got)
+		}
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|ERRO| disk full") {
+		t.Fatalf("want the level of @fields used, got %q", out)
+	}
+}
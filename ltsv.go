@@ -0,0 +1,31 @@
+package humanlog
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/jigish/humanlog/parser/ltsv"
+)
+
+// matchLTSV tells if the line held by lh is made of Labeled Tab-separated
+// Values, in which case the logrus handler gets its pairs.
+func (lh *lineHandler) matchLTSV() bool {
+	if bytes.IndexByte(lh.lineData, '\t') < 0 {
+		return false
+	}
+	h := &lh.logrusEntry
+	return ltsv.Parse(lh.lineData, h.visitLTSV)
+}
+
+func (h *LogrusHandler) visitLTSV(label, val []byte) bool {
+	// nginx and apache write their time in access log fashion,
+	// like `[10/Oct/2000:13:55:36 -0700]`
+	if bytes.Equal(label, []byte("time")) && bytes.HasPrefix(val, []byte("[")) {
+		t, err := time.Parse(accessLogTimeLayout, string(bytes.Trim(val, "[]")))
+		if err == nil {
+			h.Time = t
+			return true
+		}
+	}
+	return h.visit(label, val)
+}
@@ -0,0 +1,56 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLTSVLine(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+	lh := newLineHandler(&opts)
+
+	line := "time:2024-01-02T15:04:05Z\tlevel:warn\tmsg:disk almost full\tfree:10%"
+	if format := lh.match([]byte(line)); format != "ltsv" {
+		t.Fatalf("want ltsv format, got %q", format)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{"[ltsv]", "|WARN| disk almost full", "free=10%"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestLTSVAccessLogTime(t *testing.T) {
+	lh := newLineHandler(DefaultOptions)
+
+	line := "time:[10/Oct/2000:13:55:36 -0700]\thost:127.0.0.1\treq:GET /index.html HTTP/1.1\tstatus:200"
+	if format := lh.match([]byte(line)); format != "ltsv" {
+		t.Fatalf("want ltsv format, got %q", format)
+	}
+	want := time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC)
+	if got := lh.ev.Time; !got.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, got)
+	}
+	if got := lh.ev.Fields["req"]; got != "GET /index.html HTTP/1.1" {
+		t.Fatalf("want the request as a field, got %q", got)
+	}
+}
+
+func TestLTSVLeavesOtherLinesAlone(t *testing.T) {
+	lh := newLineHandler(DefaultOptions)
+	for _, line := range []string{
+		"hello\tworld",
+		"note: something\tfailed",
+	} {
+		if got := lh.match([]byte(line)); got != rawFormat {
+			t.Fatalf("want %q to be raw, got %s", line, got)
+		}
+	}
+}
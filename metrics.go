@@ -0,0 +1,102 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Metrics counts the lines read, before any filter, and serves the counts
+// to Prometheus, so that a long running humanlog, following files or
+// listening for logs, doubles as a probe of their health. It is safe for
+// concurrent use.
+type Metrics struct {
+	mu       sync.Mutex
+	lines    uint64
+	bytes    uint64
+	formats  map[string]uint64
+	failures map[string]uint64
+	levels   map[string]uint64
+}
+
+// NewMetrics starts counting lines from zero.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		formats:  make(map[string]uint64),
+		failures: make(map[string]uint64),
+		levels:   make(map[string]uint64),
+	}
+}
+
+// read counts a line read, before it is matched.
+func (m *Metrics) read(rawData []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lines++
+	// counting the newline read along with it
+	m.bytes += uint64(len(rawData)) + 1
+}
+
+// matched counts the line held by lh under its format and level. A line
+// no handler recognized counts as a failure of the handler it looks meant
+// for, if it can be told.
+func (m *Metrics) matched(lh *lineHandler) {
+	level := ""
+	if lh.format != rawFormat {
+		level = lh.level()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.formats[lh.format]++
+	if lh.format == rawFormat {
+		m.failures[meantFormat(lh.lineData)]++
+		return
+	}
+	m.levels[level]++
+}
+
+// meantFormat guesses the format of a line no handler recognized: json
+// for an object, which is most likely malformed or cut short, and unknown
+// otherwise.
+func meantFormat(line []byte) string {
+	if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 && trimmed[0] == '{' {
+		return "json"
+	}
+	return "unknown"
+}
+
+// ServeHTTP writes the counts in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	var b strings.Builder
+	writeMetric(&b, "humanlog_lines_read_total", "Lines read, before any filter.", "", map[string]uint64{"": m.lines})
+	writeMetric(&b, "humanlog_bytes_read_total", "Bytes read, newlines included.", "", map[string]uint64{"": m.bytes})
+	writeMetric(&b, "humanlog_lines_total", "Lines read by the format they were recognized as, raw for those no handler recognized.", "format", m.formats)
+	writeMetric(&b, "humanlog_parse_failures_total", "Lines no handler recognized, by the format they look meant to be in.", "format", m.failures)
+	writeMetric(&b, "humanlog_events_total", "Lines recognized by a handler, by level.", "level", m.levels)
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// writeMetric writes a counter, with a sample for each of the values of
+// label, or a single sample if label is empty.
+func writeMetric(b *strings.Builder, name, help, label string, counts map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	if label == "" {
+		fmt.Fprintf(b, "%s %d\n", name, counts[""])
+		return
+	}
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		fmt.Fprintf(b, "%s{%s=%q} %d\n", name, label, value, counts[value])
+	}
+}
@@ -0,0 +1,40 @@
+package humanlog
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Metrics = NewMetrics()
+	opts.MinLevel = ErrorLevel
+	src := strings.NewReader(`{"time":"2020-01-01T00:00:00Z","level":"info","msg":"one"}
+{"time":"2020-01-01T00:00:01Z","level":"error","msg":"two"}
+{"time":"2020-01-01T00:00:02Z","level":
+not structured
+`)
+	if err := Scanner(src, bytes.NewBuffer(nil), &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	opts.Metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	got := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE humanlog_lines_read_total counter\nhumanlog_lines_read_total 4\n",
+		"humanlog_bytes_read_total 174\n",
+		`humanlog_lines_total{format="json"} 2`,
+		`humanlog_lines_total{format="raw"} 2`,
+		`humanlog_parse_failures_total{format="json"} 1`,
+		`humanlog_parse_failures_total{format="unknown"} 1`,
+		`humanlog_events_total{level="info"} 1`,
+		`humanlog_events_total{level="error"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in:\n%s", want, got)
+		}
+	}
+}
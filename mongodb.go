@@ -0,0 +1,71 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unwrapMongoDB reshapes a line of the structured log of mongod 4.4 and
+// later into the usual keys: `t.$date` becomes the time, the severity
+// letter `s` the level, `c` the component and attr is flattened into raw.
+// It reports whether raw was such a line.
+func unwrapMongoDB(raw map[string]interface{}) bool {
+	t, ok := raw["t"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	severity, ok := raw["s"].(string)
+	if !ok {
+		return false
+	}
+	switch date := t["$date"].(type) {
+	case string:
+		raw["time"] = date
+	case map[string]interface{}:
+		// canonical extended JSON, milliseconds since the epoch
+		ms, ok := date["$numberLong"].(string)
+		if !ok {
+			return false
+		}
+		n, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil {
+			return false
+		}
+		raw["time"] = time.Unix(0, n*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano)
+	default:
+		return false
+	}
+	delete(raw, "t")
+
+	delete(raw, "s")
+	raw["level"] = mongoDBLevel(severity)
+	if component, ok := raw["c"]; ok {
+		delete(raw, "c")
+		raw["component"] = component
+	}
+	if attr, ok := raw["attr"].(map[string]interface{}); ok {
+		delete(raw, "attr")
+		mergeObject(raw, "attr", attr)
+	}
+	return true
+}
+
+// mongoDBLevel maps the severity letters of mongod onto one of the
+// normalized level names.
+func mongoDBLevel(severity string) string {
+	switch {
+	case severity == "F":
+		return FatalLevel
+	case severity == "E":
+		return ErrorLevel
+	case severity == "W":
+		return WarnLevel
+	case severity == "I":
+		return InfoLevel
+	case strings.HasPrefix(severity, "D"):
+		return DebugLevel
+	default:
+		return UnknownLevel
+	}
+}
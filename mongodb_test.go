@@ -0,0 +1,46 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnwrapMongoDB(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := JSONHandler{Opts: &opts}
+
+	ev := []byte(`{"t":{"$date":"2020-05-01T15:16:17.180+00:00"},"s":"W","c":"NETWORK","id":22943,"ctx":"listener","msg":"Connection accepted","attr":{"remote":"127.0.0.1:52148","connectionCount":2,"client":{"driver":"mongo-go"}}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Date(2020, 5, 1, 15, 16, 17, 180e6, time.UTC); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	for k, want := range map[string]string{
+		"component":     `"NETWORK"`,
+		"ctx":           `"listener"`,
+		"id":            "22943",
+		"remote":        `"127.0.0.1:52148"`,
+		"client.driver": `"mongo-go"`,
+	} {
+		if got := h.Fields[k]; got != want {
+			t.Fatalf("want %s=%s, got %s", k, want, got)
+		}
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|WARN| Connection accepted") {
+		t.Fatalf("want severity W shown as a warning, got %q", out)
+	}
+
+	ev = []byte(`{"t":{"$date":{"$numberLong":"1588346177180"}},"s":"D2","c":"STORAGE","id":1,"ctx":"main","msg":"checkpoint"}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Date(2020, 5, 1, 15, 16, 17, 180e6, time.UTC); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|DEBU| checkpoint") {
+		t.Fatalf("want severity D2 shown as debug, got %q", out)
+	}
+}
@@ -0,0 +1,66 @@
+package humanlog
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Where the continuation lines of the last line go when folding.
+const (
+	foldNone = iota
+	foldWrite
+	foldDiscard
+)
+
+var (
+	goroutineHeader = regexp.MustCompile(`^goroutine \d+ \[[^\]]*\]:$`)
+	// a call in a Go stack trace, which is followed by its file indented
+	goFrame = regexp.MustCompile(`^(?:created by )?[\w./*()\[\]-]+\(.*\)(?: in goroutine \d+)?$`)
+	// `java.lang.IllegalStateException: boom`, as logged after the message
+	// of the line it belongs to
+	javaException = regexp.MustCompile(`^(?:[a-zA-Z_$][\w$]*\.)+[A-Z][\w$]*(?:Exception|Error|Throwable)(?::.*)?$`)
+)
+
+// continues tells if rawData continues the line before it, like the frames
+// of a stack trace or a Go panic, in which case it was written under that
+// line already, or dropped along with it.
+func (lh *lineHandler) continues(dst io.Writer, rawData []byte) bool {
+	if lh.fold == foldNone {
+		return false
+	}
+	line := string(rawData)
+	switch {
+	case strings.TrimSpace(line) == "":
+		// a blank line doesn't end a trace, but isn't worth printing
+		return lh.goTrace
+	case goroutineHeader.MatchString(line):
+		lh.goTrace = true
+	case line[0] == ' ' || line[0] == '\t',
+		strings.HasPrefix(line, "at "),
+		strings.HasPrefix(line, "Caused by: "),
+		strings.HasPrefix(line, "Suppressed: "),
+		javaException.MatchString(line),
+		lh.goTrace && goFrame.MatchString(line):
+	default:
+		lh.goTrace = false
+		return false
+	}
+	if lh.fold == foldDiscard {
+		return true
+	}
+
+	opts := lh.opts
+	if opts.Output == OutputRaw {
+		dst.Write(rawData)
+		dst.Write(eol[:])
+		return true
+	}
+	if opts.ShowHandler {
+		dst.Write([]byte(strings.Repeat(" ", formatTagWidth)))
+	}
+	line = "    " + strings.Replace(opts.sanitize(line), "\t", "    ", -1)
+	dst.Write([]byte(opts.paint(opts.ErrorLevelColor, line)))
+	dst.Write(eol[:])
+	return true
+}
@@ -0,0 +1,103 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFoldMultilineJava(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2024-01-02T03:04:05Z","level":"error","msg":"request failed"}`,
+		`java.lang.IllegalStateException: boom`,
+		"\tat com.example.Handler.serve(Handler.java:42)",
+		"\tat com.example.Server.run(Server.java:7)",
+		`Caused by: java.io.IOException: broken pipe`,
+		"\t... 2 more",
+		`{"time":"2024-01-02T03:04:06Z","level":"info","msg":"next"}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.FoldMultiline = true
+	opts.MaxLines = 2
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	want := []string{
+		"|ERRO| request failed",
+		"    java.lang.IllegalStateException: boom",
+		"        at com.example.Handler.serve(Handler.java:42)",
+		"        at com.example.Server.run(Server.java:7)",
+		"    Caused by: java.io.IOException: broken pipe",
+		"        ... 2 more",
+		"|INFO| next",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("want %d lines, got %q", len(want), lines)
+	}
+	for i, w := range want {
+		if !strings.Contains(lines[i], w) {
+			t.Fatalf("want line %d to contain %q, got %q", i, w, lines[i])
+		}
+	}
+}
+
+func TestFoldMultilineGoPanic(t *testing.T) {
+	src := strings.Join([]string{
+		`panic: runtime error: index out of range [3] with length 3`,
+		``,
+		`goroutine 1 [running]:`,
+		`main.main()`,
+		"\t/app/main.go:12 +0x1d",
+		`exit status 2`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.FoldMultiline = true
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	want := "panic: runtime error: index out of range [3] with length 3\n" +
+		"\n" +
+		"    goroutine 1 [running]:\n" +
+		"    main.main()\n" +
+		"        /app/main.go:12 +0x1d\n" +
+		"exit status 2\n"
+	if got := dst.String(); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestFoldMultilineFollowsFilters(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2024-01-02T03:04:05Z","level":"error","msg":"too early"}`,
+		"\tat com.example.Old.run(Old.java:1)",
+		`{"time":"2024-01-02T04:04:05Z","level":"error","msg":"in range"}`,
+		"\tat com.example.New.run(New.java:1)",
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.FoldMultiline = true
+	opts.Since = time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	if strings.Contains(got, "Old") || !strings.Contains(got, "at com.example.New.run") {
+		t.Fatalf("want the trace of the dropped line dropped too, got %q", got)
+	}
+
+	opts.FoldMultiline = false
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.String(); !strings.Contains(got, "\tat com.example.Old.run") {
+		t.Fatalf("want continuation lines left alone when not folding, got %q", got)
+	}
+}
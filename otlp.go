@@ -0,0 +1,145 @@
+package humanlog
+
+import (
+	"strconv"
+	"time"
+)
+
+// unwrapOTLP reshapes an OpenTelemetry log record, as exported in JSON by
+// collectors, into the usual keys. Its attributes are flattened and the
+// trace context is shortened. It reports whether raw was such a record.
+func unwrapOTLP(raw map[string]interface{}) bool {
+	nanos, ok := raw["timeUnixNano"]
+	if !ok {
+		if nanos, ok = raw["observedTimeUnixNano"]; !ok {
+			return false
+		}
+	}
+	var ns int64
+	switch v := nanos.(type) {
+	case string:
+		var err error
+		if ns, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return false
+		}
+	case float64:
+		ns = int64(v)
+	default:
+		return false
+	}
+	delete(raw, "timeUnixNano")
+	delete(raw, "observedTimeUnixNano")
+	if ns != 0 {
+		raw["time"] = time.Unix(0, ns).UTC().Format(time.RFC3339Nano)
+	}
+
+	if text, ok := raw["severityText"].(string); ok && text != "" {
+		raw["level"] = text
+	} else if num, ok := raw["severityNumber"].(float64); ok {
+		raw["level"] = otlpSeverity(int(num))
+	}
+	delete(raw, "severityText")
+	delete(raw, "severityNumber")
+
+	if body, ok := raw["body"].(map[string]interface{}); ok {
+		delete(raw, "body")
+		switch v := otlpValue(body).(type) {
+		case string:
+			raw["message"] = v
+		case map[string]interface{}:
+			flattenValue(raw, "body", v)
+		default:
+			raw["body"] = v
+		}
+	}
+
+	if attrs, ok := raw["attributes"].([]interface{}); ok {
+		delete(raw, "attributes")
+		for key, val := range otlpKeyValues(attrs) {
+			if _, exists := raw[key]; exists {
+				key = "attributes." + key
+			}
+			flattenValue(raw, key, val)
+		}
+	}
+
+	for _, key := range []string{"traceId", "spanId"} {
+		if id, ok := raw[key].(string); ok && id == "" {
+			delete(raw, key)
+		}
+	}
+	delete(raw, "flags")
+	delete(raw, "droppedAttributesCount")
+	return true
+}
+
+// otlpSeverity names the ranges of OpenTelemetry severity numbers.
+func otlpSeverity(num int) string {
+	switch {
+	case num <= 0:
+		return UnknownLevel
+	case num <= 4:
+		return TraceLevel
+	case num <= 8:
+		return DebugLevel
+	case num <= 12:
+		return InfoLevel
+	case num <= 16:
+		return WarnLevel
+	case num <= 20:
+		return ErrorLevel
+	default:
+		return FatalLevel
+	}
+}
+
+// otlpValue decodes an OpenTelemetry AnyValue, like `{"stringValue":"a"}`.
+func otlpValue(v map[string]interface{}) interface{} {
+	for kind, val := range v {
+		switch kind {
+		case "stringValue", "boolValue", "doubleValue", "bytesValue":
+			return val
+		case "intValue":
+			// 64 bits integers are strings in JSON
+			if s, ok := val.(string); ok {
+				if n, err := strconv.ParseFloat(s, 64); err == nil {
+					return n
+				}
+			}
+			return val
+		case "arrayValue":
+			arr, _ := val.(map[string]interface{})
+			values, _ := arr["values"].([]interface{})
+			out := make([]interface{}, 0, len(values))
+			for _, elem := range values {
+				if m, ok := elem.(map[string]interface{}); ok {
+					out = append(out, otlpValue(m))
+				}
+			}
+			return out
+		case "kvlistValue":
+			kvs, _ := val.(map[string]interface{})
+			values, _ := kvs["values"].([]interface{})
+			return otlpKeyValues(values)
+		}
+	}
+	return nil
+}
+
+// otlpKeyValues decodes a list of OpenTelemetry KeyValues.
+func otlpKeyValues(kvs []interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(kvs))
+	for _, elem := range kvs {
+		kv, ok := elem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, ok := kv["key"].(string)
+		if !ok {
+			continue
+		}
+		val, _ := kv["value"].(map[string]interface{})
+		out[key] = otlpValue(val)
+	}
+	return out
+}
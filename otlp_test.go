@@ -0,0 +1,49 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnwrapOTLP(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := JSONHandler{Opts: &opts}
+
+	ev := []byte(`{"timeUnixNano":"1700000000123456789","severityNumber":13,"body":{"stringValue":"retrying"},"attributes":[{"key":"http.method","value":{"stringValue":"GET"}},{"key":"attempt","value":{"intValue":"3"}},{"key":"tags","value":{"arrayValue":{"values":[{"stringValue":"a"},{"stringValue":"b"}]}}}],"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174","flags":1}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if want := time.Unix(0, 1700000000123456789); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	if h.Message != "retrying" {
+		t.Fatalf("want the body as message, got %q", h.Message)
+	}
+	for k, want := range map[string]string{
+		"http.method": `"GET"`,
+		"attempt":     "3",
+		"tags":        `[a,b]`,
+		"traceId":     `"5b8efff798038103d269b633813fc60c"`,
+		"spanId":      `"eee19b7ec3c1b174"`,
+	} {
+		if got := h.Fields[k]; got != want {
+			t.Fatalf("want %s=%s, got %s", k, want, got)
+		}
+	}
+	if _, ok := h.Fields["flags"]; ok {
+		t.Fatal("want flags hidden")
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|WARN| retrying") || !strings.Contains(out, "traceId=5b8efff7 ") {
+		t.Fatalf("want severity number 13 shown as a warning, and the trace ID shortened, got %q", out)
+	}
+
+	ev = []byte(`{"timeUnixNano":"1700000000000000000","severityText":"ERROR","severityNumber":17,"body":{"stringValue":"boom"}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if out := string(h.Prettify(false)); !strings.Contains(out, "|ERRO| boom") {
+		t.Fatalf("want the severity text as level, got %q", out)
+	}
+}
@@ -0,0 +1,135 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ways of writing out lines.
+const (
+	// OutputPretty writes lines prettified, in color.
+	OutputPretty = "pretty"
+	// OutputJSON writes each line as a JSON object of its time, level,
+	// message and fields, whatever its format was.
+	OutputJSON = "json"
+	// OutputLogfmt writes each line as logfmt, its time, level and message
+	// first and its fields in order.
+	OutputLogfmt = "logfmt"
+	// OutputRaw writes the lines that aren't filtered out as they were
+	// read, untouched, as grep would.
+	OutputRaw = "raw"
+)
+
+// event is the line held since the last call to match, without the fields
+// that aren't shown.
+func (lh *lineHandler) event() Event {
+	held := lh.ev
+	ev := Event{Time: held.Time, Level: held.Level, Msg: held.Msg}
+	if !ev.Time.IsZero() && lh.opts.Location != nil {
+		ev.Time = ev.Time.In(lh.opts.Location)
+	}
+	for key, val := range held.Fields {
+		if !lh.opts.showKey(key, lh.opts.AutoSkipUnderscore && held.skipUnderscored) {
+			continue
+		}
+		if ev.Fields == nil {
+			ev.Fields = make(map[string]string, len(held.Fields))
+		}
+		ev.Fields[key] = val
+	}
+	return ev
+}
+
+type jsonEvent struct {
+	Time   *time.Time                 `json:"time,omitempty"`
+	Level  string                     `json:"level"`
+	Msg    string                     `json:"msg"`
+	Fields map[string]json.RawMessage `json:"fields,omitempty"`
+}
+
+// marshalEvent renders ev as a JSON object. Values that are JSON, like
+// numbers or quoted strings, are kept as such, the others become strings.
+func marshalEvent(ev Event) []byte {
+	out := jsonEvent{Level: ev.Level, Msg: ev.Msg}
+	if !ev.Time.IsZero() {
+		out.Time = &ev.Time
+	}
+	if len(ev.Fields) > 0 {
+		out.Fields = make(map[string]json.RawMessage, len(ev.Fields))
+		for key, val := range ev.Fields {
+			if !json.Valid([]byte(val)) {
+				str, _ := marshalJSON(val, "")
+				val = str
+			}
+			out.Fields[key] = json.RawMessage(val)
+		}
+	}
+	// can't fail, all the values being valid JSON by now
+	str, _ := marshalJSON(out, "")
+	return []byte(str)
+}
+
+// marshalLogfmt renders ev as a logfmt line. Keys are made valid by
+// replacing what logfmt doesn't allow in them, and values are quoted when
+// they need to be.
+func marshalLogfmt(ev Event) []byte {
+	buf := bytes.NewBuffer(nil)
+	if !ev.Time.IsZero() {
+		writeLogfmtPair(buf, "time", ev.Time.Format(time.RFC3339Nano))
+	}
+	writeLogfmtPair(buf, "level", ev.Level)
+	writeLogfmtPair(buf, "msg", ev.Msg)
+
+	keys := make([]string, 0, len(ev.Fields))
+	for key := range ev.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeLogfmtPair(buf, key, unquoteValue(ev.Fields[key]))
+	}
+	return buf.Bytes()
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, val string) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	if logfmtNeedsQuotes(val) {
+		buf.WriteString(strconv.Quote(val))
+	} else {
+		buf.WriteString(val)
+	}
+}
+
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtNeedsQuotes(val string) bool {
+	if val == "" {
+		return true
+	}
+	for _, r := range val {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputJSON(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"warning","msg":"hello","status":200,"path":"/<a>","ok":true}`,
+		`time="2018-10-24T08:00:01Z" level=error msg="logfmt line" user=bob count=3`,
+		`raw line`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Output = OutputJSON
+	opts.Location = time.UTC
+	opts.SetSkip([]string{"ok"})
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"warn","msg":"hello","fields":{"path":"/<a>","status":200}}`,
+		`{"time":"2018-10-24T08:00:01Z","level":"error","msg":"logfmt line","fields":{"count":3,"user":"bob"}}`,
+		`{"level":"unknown","msg":"raw line"}`,
+	}, "\n") + "\n"
+	if got := dst.String(); got != want {
+		t.Fatalf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestOutputLogfmt(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"info","msg":"hello world","path":"/a b","q":"say \"hi\"","n":1.5,"empty":"","bad key":"x=y"}`,
+		`raw line`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Output = OutputLogfmt
+	opts.Location = time.UTC
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`time=2018-10-24T08:00:00Z level=info msg="hello world" bad_key="x=y" empty="" n=1.5 path="/a b" q="say \"hi\""`,
+		`level=unknown msg="raw line"`,
+	}, "\n") + "\n"
+	if got := dst.String(); got != want {
+		t.Fatalf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestOutputRaw(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z",  "level":"info","msg":"hello"}`,
+		`time="2018-10-24T08:00:01Z" level=error msg="logfmt line" user=bob`,
+		"\tat com.example.Handler.serve(Handler.java:42)",
+		`{"time":"2018-10-24T08:00:02Z","level":"warn","msg":"\u001b[31mcolored\u001b[0m"}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Output = OutputRaw
+	opts.MinLevel = WarnLevel
+	opts.FoldMultiline = true
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`time="2018-10-24T08:00:01Z" level=error msg="logfmt line" user=bob`,
+		"\tat com.example.Handler.serve(Handler.java:42)",
+		`{"time":"2018-10-24T08:00:02Z","level":"warn","msg":"\u001b[31mcolored\u001b[0m"}`,
+	}, "\n") + "\n"
+	if got := dst.String(); got != want {
+		t.Fatalf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestOutputTemplate(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"warning","msg":"hello","user":"bob","n":2}`,
+		`raw line`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Location = time.UTC
+	opts.TimeFormat = time.RFC3339
+	tmpl, err := opts.ParseTemplate(`{{timefmt .Time}}|{{levelcolor .Level (pad 5 .Level)}}|{{trunc 3 .Msg}}|{{.Fields.user}}|{{logfmt .Fields}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Template = tmpl
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`2018-10-24T08:00:00Z|warn |hel|bob|n=2 user=bob`,
+		`|unknown|raw||`,
+	}, "\n") + "\n"
+	if got := dst.String(); got != want {
+		t.Fatalf("want\n%s\ngot\n%s", want, got)
+	}
+
+	if _, err := opts.ParseTemplate(`{{nope .Msg}}`); err == nil {
+		t.Fatal("want unknown functions rejected")
+	}
+}
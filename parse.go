@@ -0,0 +1,26 @@
+package humanlog
+
+import (
+	"errors"
+)
+
+// ErrUnrecognized is returned by ParseLine for lines that no handler
+// recognized.
+var ErrUnrecognized = errors.New("humanlog: line in no known format")
+
+// ParseLine tells apart the format of line and parses it into an event,
+// as Scanner would with DefaultOptions, but without rendering it. The
+// Format of the event names the handler that recognized the line, and its
+// time is as logged.
+func ParseLine(line []byte) (*Event, error) {
+	// handling a line changes the state of the options it is handled with
+	opts := *DefaultOptions
+	opts.patterns, opts.clock = nil, nil
+
+	lh := newLineHandler(&opts)
+	if lh.match(line) == rawFormat {
+		return nil, ErrUnrecognized
+	}
+	ev := lh.ev
+	return &ev, nil
+}
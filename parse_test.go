@@ -0,0 +1,44 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	for line, want := range map[string]Event{
+		`{"time":"2018-10-24T08:19:50Z","level":"warning","msg":"hello","user":"bob"}`: {
+			Format: "json", Level: WarnLevel, Msg: "hello",
+			Time:   time.Date(2018, 10, 24, 8, 19, 50, 0, time.UTC),
+			Fields: map[string]string{"user": `"bob"`},
+		},
+		`time="2018-10-24T08:19:50Z" level=error msg="boom" user=bob`: {
+			Format: "logrus", Level: ErrorLevel, Msg: "boom",
+			Time:   time.Date(2018, 10, 24, 8, 19, 50, 0, time.UTC),
+			Fields: map[string]string{"user": "bob"},
+		},
+		`I1024 08:19:50.123456   1234 main.go:42] started`: {
+			Format: "klog", Level: InfoLevel, Msg: "started",
+		},
+	} {
+		ev, err := ParseLine([]byte(line))
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if ev.Format != want.Format || ev.Level != want.Level || ev.Msg != want.Msg {
+			t.Fatalf("want %q parsed as %+v, got %+v", line, want, *ev)
+		}
+		if !want.Time.IsZero() && !ev.Time.Equal(want.Time) {
+			t.Fatalf("want %q at %v, got %v", line, want.Time, ev.Time)
+		}
+		for key, val := range want.Fields {
+			if ev.Fields[key] != val {
+				t.Fatalf("want %s=%s in %q, got %+v", key, val, line, ev.Fields)
+			}
+		}
+	}
+
+	if _, err := ParseLine([]byte("just some text")); err != ErrUnrecognized {
+		t.Fatalf("want ErrUnrecognized, got %v", err)
+	}
+}
@@ -7,11 +7,19 @@ import (
 )
 
 // Visitor receives key/value pairs as they are parse, returns `false` if
-// it wishes to abort the parsing.
+// it wishes to abort the parsing. The slices point into the parsed line
+// and are only valid until the visitor returns: copy them to keep them.
 type Visitor func(key, val []byte) (more bool)
 
+// garbageKey is the key given to what precedes the first pair, when it
+// is kept.
+var garbageKey = []byte("garbage")
+
 // Parse does a best effort parsing of logfmt entries. If `allowEmptyKey`,
 // it will parse ` =value` as `""=value`, where empty string is a valid key.
+//
+// Parse doesn't allocate: it hands out sub-slices of data, and quoted
+// values keep their escapes.
 func Parse(data []byte, allowEmptyKey, keepGarbage bool, eachPair Visitor) bool {
 	// don't try to parse logfmt if there's no `mykey=` in the
 	// first few bytes
@@ -20,65 +28,81 @@ func Parse(data []byte, allowEmptyKey, keepGarbage bool, eachPair Visitor) bool
 }
 
 func scanAllKeyValue(data []byte, allowEmptyKey, keepGarbage bool, eachPair Visitor) {
-	i := 0
-	firstKV := true
-	more := true
-	for i < len(data) && more {
-		keyStart, keyEnd, valStart, valEnd, found := scanKeyValue(data, i, allowEmptyKey)
-		if !found {
-			return
-		}
-		if firstKV {
-			firstKV = false
-			if keyStart != 0 && keepGarbage {
-				eachPair([]byte("garbage"), data[:keyStart])
-			}
-		}
-
-		if data[valStart] == '"' {
-			more = eachPair(data[keyStart:keyEnd], data[valStart+1:valEnd-1])
-		} else {
-			more = eachPair(data[keyStart:keyEnd], data[valStart:valEnd])
-		}
-		i = valEnd + 1
-	}
-
-}
-
-func scanKeyValue(data []byte, from int, allowEmptyKey bool) (keyStart, keyEnd, valStart, valEnd int, found bool) {
-
-	keyStart, keyEnd, found = findWordFollowedBy('=', data, from, allowEmptyKey)
+	keyStart, keyEnd, found := findWordFollowedBy('=', data, 0, allowEmptyKey)
 	if !found {
 		return
 	}
-	valStart = keyEnd + 1
-	if r, sz := utf8.DecodeRune(data[valStart:]); r == '"' {
-		// find next unescaped `"`
-		valEnd = findUnescaped('"', '\\', data, valStart+sz)
-		found = valEnd != -1
-		valEnd++
-		return
+	// what precedes the first complete pair is garbage
+	garbageEnd := 0
+	if keepGarbage {
+		garbageEnd = keyStart
 	}
+	for {
+		valStart := keyEnd + 1
+		if valStart < len(data) && data[valStart] == '"' {
+			// find next unescaped `"`
+			valEnd := findUnescaped('"', '\\', data, valStart+1)
+			if valEnd == -1 {
+				return
+			}
+			if garbageEnd != 0 {
+				eachPair(garbageKey, data[:garbageEnd])
+				garbageEnd = 0
+			}
+			if !eachPair(data[keyStart:keyEnd], data[valStart+1:valEnd]) {
+				return
+			}
+			if valEnd+2 > len(data) {
+				return
+			}
+			keyStart, keyEnd, found = findWordFollowedBy('=', data, valEnd+2, allowEmptyKey)
+			if !found {
+				return
+			}
+			continue
+		}
 
-	nextKeyStart, _, nextFound := findWordFollowedBy('=', data, keyEnd+1, allowEmptyKey)
-
-	if nextFound {
-		valEnd = nextKeyStart - 1
-	} else {
-		valEnd = len(data)
+		// an unquoted value runs until the next key, which is looked
+		// up once and carried over to the next pair
+		nextStart, nextEnd, nextFound := findWordFollowedBy('=', data, valStart, allowEmptyKey)
+		valEnd := len(data)
+		if nextFound {
+			valEnd = nextStart - 1
+			if valEnd < valStart {
+				valEnd = valStart
+			}
+		}
+		if garbageEnd != 0 {
+			eachPair(garbageKey, data[:garbageEnd])
+			garbageEnd = 0
+		}
+		if !eachPair(data[keyStart:keyEnd], data[valStart:valEnd]) || !nextFound {
+			return
+		}
+		keyStart, keyEnd = nextStart, nextEnd
 	}
-
-	return
 }
 
 func findWordFollowedBy(by rune, data []byte, from int, allowEmptyKey bool) (start int, end int, found bool) {
-	i := bytes.IndexRune(data[from:], by)
+	var i int
+	if by < utf8.RuneSelf {
+		i = bytes.IndexByte(data[from:], byte(by))
+	} else {
+		i = bytes.IndexRune(data[from:], by)
+	}
 	if i == -1 {
 		return i, i, false
 	}
 	i += from
 	// loop for all letters before the `by`, stop at the first space
 	for j := i - 1; j >= from; j-- {
+		if c := data[j]; c < utf8.RuneSelf {
+			if asciiSpace[c] {
+				j++
+				return j, i, allowEmptyKey || j < i
+			}
+			continue
+		}
 		if !utf8.RuneStart(data[j]) {
 			continue
 		}
@@ -91,7 +115,13 @@ func findWordFollowedBy(by rune, data []byte, from int, allowEmptyKey bool) (sta
 	return from, i, allowEmptyKey || from < i
 }
 
+// asciiSpace are the ASCII runes for which unicode.IsSpace is true.
+var asciiSpace = [utf8.RuneSelf]bool{'\t': true, '\n': true, '\v': true, '\f': true, '\r': true, ' ': true}
+
 func findUnescaped(toFind, escape rune, data []byte, from int) int {
+	if toFind < utf8.RuneSelf && escape < utf8.RuneSelf {
+		return findUnescapedByte(byte(toFind), byte(escape), data, from)
+	}
 	for i := from; i < len(data); {
 		r, sz := utf8.DecodeRune(data[i:])
 		i += sz
@@ -105,3 +135,25 @@ func findUnescaped(toFind, escape rune, data []byte, from int) int {
 	}
 	return -1
 }
+
+// findUnescapedByte is findUnescaped for ASCII runes, which never appear
+// inside a multibyte rune: it jumps from one toFind to the next, which
+// is escaped if an odd number of escapes precede it.
+func findUnescapedByte(toFind, escape byte, data []byte, from int) int {
+	for i := from; i < len(data); {
+		idx := bytes.IndexByte(data[i:], toFind)
+		if idx == -1 {
+			return -1
+		}
+		idx += i
+		escapes := 0
+		for j := idx - 1; j >= from && data[j] == escape; j-- {
+			escapes++
+		}
+		if escapes%2 == 0 {
+			return idx
+		}
+		i = idx + 1
+	}
+	return -1
+}
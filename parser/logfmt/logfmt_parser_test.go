@@ -5,6 +5,7 @@ import (
 	"log"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -125,6 +126,26 @@ func TestScanKeyValue(t *testing.T) {
 				{key: []byte("allo"), val: []byte("more crap")},
 			},
 		},
+		{
+			input: `hello=`,
+			want: []kv{
+				{key: []byte("hello"), val: []byte("")},
+			},
+		},
+		{
+			input: `hello=bye=crap`,
+			want: []kv{
+				{key: []byte("hello"), val: []byte("")},
+				{key: []byte("bye"), val: []byte("crap")},
+			},
+		},
+		{
+			input: `hello="bye \\" allo="more \" crap"`,
+			want: []kv{
+				{key: []byte("hello"), val: []byte(`bye \\`)},
+				{key: []byte("allo"), val: []byte(`more \" crap`)},
+			},
+		},
 
 		// sanitized real world input that breaks kr/logfmt
 		// {
@@ -373,3 +394,57 @@ func TestFindUnescaped(t *testing.T) {
 		}
 	}
 }
+
+var benchLines = [][]byte{
+	[]byte(`time="2018-10-24T08:19:50Z" level=info msg="request handled" status=200 path=/v1/things/42 duration=0.042 user=someone`),
+	[]byte(`time=2023-06-01T12:00:00.000Z level=WARN msg="slow query, \"users\" table" db.statement="SELECT * FROM users WHERE id = $1" db.rows=1 elapsed=1.2s`),
+	[]byte(`level=error msg="something went wrong" err="dial tcp 10.0.0.1:5432: connect: connection refused" retry=3 backoff=200ms component=db`),
+}
+
+func discard(key, val []byte) bool { return true }
+
+func TestParseDoesntAllocate(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, line := range benchLines {
+			Parse(line, true, true, discard)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("want no allocation, got %v", allocs)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	var size int64
+	for _, line := range benchLines {
+		size += int64(len(line))
+	}
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range benchLines {
+			Parse(line, true, true, discard)
+		}
+	}
+}
+
+func BenchmarkParseGarbage(b *testing.B) {
+	line := []byte(`2018/10/24 08:19:50 server.go:42: some prefix before the pairs status=200 path=/v1/things/42 user=someone`)
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Parse(line, true, true, discard)
+	}
+}
+
+func BenchmarkParseLongValue(b *testing.B) {
+	line := []byte(`level=info msg="` + strings.Repeat(`a long quoted message with \"escapes\" `, 50) + `" note=` + strings.Repeat("unquoted words ", 50) + `last=1`)
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Parse(line, true, true, discard)
+	}
+}
@@ -0,0 +1,59 @@
+package ltsv
+
+import (
+	"bytes"
+)
+
+// Visitor receives label/value pairs as they are parsed, returns `false` if
+// it wishes to abort the parsing.
+type Visitor func(label, val []byte) (more bool)
+
+// Parse parses a line of Labeled Tab-separated Values, like
+// `time:2024-01-02T15:04:05Z<TAB>level:info<TAB>msg:hello`. It reports
+// whether the whole line was LTSV, which requires at least two fields that
+// all have a valid label. Nothing is visited unless that's the case.
+func Parse(data []byte, eachPair Visitor) bool {
+	if !isLTSV(data) {
+		return false
+	}
+	for _, field := range bytes.Split(data, []byte("\t")) {
+		if len(field) == 0 {
+			continue
+		}
+		colon := bytes.IndexByte(field, ':')
+		if !eachPair(field[:colon], field[colon+1:]) {
+			break
+		}
+	}
+	return true
+}
+
+func isLTSV(data []byte) bool {
+	fields := 0
+	for _, field := range bytes.Split(data, []byte("\t")) {
+		if len(field) == 0 {
+			continue
+		}
+		colon := bytes.IndexByte(field, ':')
+		if colon <= 0 || !isLabel(field[:colon]) {
+			return false
+		}
+		fields++
+	}
+	return fields >= 2
+}
+
+// isLabel tells if b only has the characters the spec allows in labels.
+func isLabel(b []byte) bool {
+	for _, c := range b {
+		switch {
+		case c >= '0' && c <= '9',
+			c >= 'a' && c <= 'z',
+			c >= 'A' && c <= 'Z',
+			c == '_', c == '.', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package ltsv
+
+import (
+	"reflect"
+	"testing"
+)
+
+type kv struct{ label, val string }
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		input string
+		ok    bool
+		want  []kv
+	}{
+		{
+			input: "time:2024-01-02T15:04:05Z\tlevel:info\tmsg:hello world",
+			ok:    true,
+			want: []kv{
+				{"time", "2024-01-02T15:04:05Z"},
+				{"level", "info"},
+				{"msg", "hello world"},
+			},
+		},
+		{
+			input: "host:127.0.0.1\treq:GET /a:b HTTP/1.1\tstatus:200\t",
+			ok:    true,
+			want: []kv{
+				{"host", "127.0.0.1"},
+				{"req", "GET /a:b HTTP/1.1"},
+				{"status", "200"},
+			},
+		},
+		{
+			input: "empty:\tother:x",
+			ok:    true,
+			want: []kv{
+				{"empty", ""},
+				{"other", "x"},
+			},
+		},
+		{input: "just:one"},
+		{input: "note: hello\tthere"},
+		{input: "bad label:x\tlevel:info"},
+		{input: "hello world"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			var got []kv
+			ok := Parse([]byte(test.input), func(label, val []byte) bool {
+				got = append(got, kv{string(label), string(val)})
+				return true
+			})
+			if ok != test.ok {
+				t.Fatalf("want ok=%v, got %v", test.ok, ok)
+			}
+			if !reflect.DeepEqual(test.want, got) {
+				t.Fatalf("want %v, got %v", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// ExecHandler hands lines over to an external command, for formats that
+// humanlog doesn't know about. The command is started once and kept
+// running: each line is written to its standard input, and it must answer
+// with one line on its standard output for each of them, either `null` if
+// it doesn't recognize it, or a JSON object like:
+//
+//	{"time":"2018-10-24T08:00:00Z","level":"warn","msg":"hello","fields":{"user":"bob"}}
+//
+// which is the same as what OutputJSON writes. All its keys are optional,
+// and the time can be in any of the layouts humanlog understands.
+//
+// Register it with a low priority, so that the command only sees the lines
+// no built-in handler recognized. If the command fails, no more lines are
+// handed over to it.
+type ExecHandler struct {
+	Opts *HandlerOptions
+
+	name string
+
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	out   *bufio.Reader
+	err   error
+}
+
+// NewExecHandler starts the command of path, with args.
+func NewExecHandler(opts *HandlerOptions, path string, args ...string) (*ExecHandler, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &ExecHandler{
+		Opts:  opts,
+		name:  filepath.Base(path),
+		cmd:   cmd,
+		stdin: stdin,
+		out:   bufio.NewReader(stdout),
+	}, nil
+}
+
+// Name is the name of the command, naming the format of the lines it
+// recognizes.
+func (h *ExecHandler) Name() string { return h.name }
+
+// TryHandle asks the command to parse d.
+func (h *ExecHandler) TryHandle(d []byte) (Event, bool) {
+	// the command can't tell where lines end otherwise
+	if bytes.IndexByte(d, '\n') >= 0 {
+		return Event{}, false
+	}
+
+	h.mu.Lock()
+	reply, err := h.ask(d)
+	h.mu.Unlock()
+	if err != nil {
+		return Event{}, false
+	}
+
+	var parsed struct {
+		Time   interface{}            `json:"time"`
+		Level  string                 `json:"level"`
+		Msg    string                 `json:"msg"`
+		Fields map[string]interface{} `json:"fields"`
+	}
+	reply = bytes.TrimSpace(reply)
+	if len(reply) == 0 || bytes.Equal(reply, []byte("null")) || json.Unmarshal(reply, &parsed) != nil {
+		return Event{}, false
+	}
+
+	opts := h.Opts
+	if opts == nil {
+		opts = DefaultOptions
+	}
+	ev := Event{Level: parsed.Level, Msg: parsed.Msg}
+	if parsed.Time != nil {
+		ev.Time, _ = opts.parseTime(parsed.Time)
+	}
+	if len(parsed.Fields) > 0 {
+		ev.Fields = make(map[string]string, len(parsed.Fields))
+		ev.nested = opts.setFields(ev.Fields, parsed.Fields)
+	}
+	return ev, true
+}
+
+func (h *ExecHandler) ask(d []byte) ([]byte, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	line := make([]byte, 0, len(d)+1)
+	line = append(append(line, d...), '\n')
+	if _, err := h.stdin.Write(line); err != nil {
+		h.err = err
+		return nil, err
+	}
+	reply, err := h.out.ReadBytes('\n')
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("command exited")
+		}
+		h.err = err
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Close stops the command, waiting for it to exit.
+func (h *ExecHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err == nil {
+		h.err = errors.New("closed")
+	}
+	h.stdin.Close()
+	return h.cmd.Wait()
+}
@@ -0,0 +1,68 @@
+package humanlog
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+const pluginScript = `while IFS= read -r line; do
+	case "$line" in
+	ACME\ *) echo '{"time":"2018-10-24T08:00:00Z","level":"error","msg":"'"${line#ACME }"'","fields":{"n":1,"s":"x"}}' ;;
+	*) echo null ;;
+	esac
+done`
+
+func TestExecHandler(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to run the plugin with")
+	}
+	h, err := NewExecHandler(DefaultOptions, sh, "-c", pluginScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	if h.Name() != "sh" {
+		t.Fatalf("want the format named after the command, got %q", h.Name())
+	}
+	if _, ok := h.TryHandle([]byte("something else")); ok {
+		t.Fatal("want lines the plugin answers null to rejected")
+	}
+	ev, ok := h.TryHandle([]byte("ACME disk full"))
+	if !ok {
+		t.Fatal("want the line handled")
+	}
+	if ev.Msg != "disk full" || ev.Level != "error" || ev.Time.IsZero() || ev.Fields["n"] != "1" || ev.Fields["s"] != `"x"` {
+		t.Fatalf("want the event of the plugin, got %+v", ev)
+	}
+
+	// the plugin's lines go through the usual rendering
+	opts := *DefaultOptions
+	lh := newLineHandler(&opts)
+	lh.handlers = append(lh.handlers, handlerEntry{handler: h, name: h.Name()})
+	dst := bytes.NewBuffer(nil)
+	lh.handle(dst, []byte("ACME disk full"))
+	if got := dst.String(); !strings.Contains(got, "|ERRO| disk full") {
+		t.Fatalf("want the line prettified, got %q", got)
+	}
+}
+
+func TestExecHandlerExited(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to run the plugin with")
+	}
+	h, err := NewExecHandler(DefaultOptions, sh, "-c", "exit 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	for i := 0; i < 2; i++ {
+		if _, ok := h.TryHandle([]byte("ACME disk full")); ok {
+			t.Fatal("want nothing handled once the plugin exited")
+		}
+	}
+}
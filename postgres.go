@@ -0,0 +1,73 @@
+package humanlog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// postgresLine matches lines logged by PostgreSQL with its default
+// log_line_prefix of `%m [%p] `, optionally followed by `user@db `.
+var postgresLine = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)? [A-Za-z][\w+-]*) \[(\d+)\] (?:(\S+)@(\S+) )?(DEBUG[1-5]|LOG|INFO|NOTICE|WARNING|ERROR|FATAL|PANIC|DETAIL|HINT|QUERY|CONTEXT|LOCATION|STATEMENT):  (.*)$`)
+
+// postgresDuration matches what log_min_duration_statement puts in front of
+// the statements it logs.
+var postgresDuration = regexp.MustCompile(`^duration: (\d+(?:\.\d+)?) ms(?:  |$)`)
+
+const postgresTimeLayout = "2006-01-02 15:04:05.999 MST"
+
+// matchPostgres tells if the line held by lh was logged by a PostgreSQL
+// server, like `2024-01-02 03:04:05.123 UTC [1234] LOG:  msg`, in which
+// case the logrus handler gets its parts.
+func (lh *lineHandler) matchPostgres() bool {
+	m := postgresLine.FindSubmatch(lh.lineData)
+	if m == nil {
+		return false
+	}
+	t, err := time.Parse(postgresTimeLayout, string(m[1]))
+	if err != nil {
+		return false
+	}
+	h := &lh.logrusEntry
+	h.Time = t
+	h.setField([]byte("pid"), m[2])
+	if len(m[3]) > 0 {
+		h.setField([]byte("user"), m[3])
+		h.setField([]byte("db"), m[4])
+	}
+
+	severity, msg := string(m[5]), string(m[6])
+	h.setLevel([]byte(postgresLevel(severity)))
+	switch severity {
+	case "DETAIL", "HINT", "QUERY", "CONTEXT", "LOCATION", "STATEMENT":
+		// these continue the report of the line before them
+		msg = severity + ": " + msg
+	}
+	if d := postgresDuration.FindStringSubmatch(msg); d != nil {
+		h.setField([]byte("duration"), []byte(d[1]+"ms"))
+		if rest := strings.TrimPrefix(msg, d[0]); rest != "" {
+			msg = rest
+		}
+	}
+	h.setMessage([]byte(msg))
+	return true
+}
+
+// postgresLevel maps the severities of PostgreSQL onto one of the normalized
+// level names.
+func postgresLevel(severity string) string {
+	switch severity {
+	case "WARNING":
+		return WarnLevel
+	case "ERROR":
+		return ErrorLevel
+	case "FATAL":
+		return FatalLevel
+	case "PANIC":
+		return PanicLevel
+	}
+	if strings.HasPrefix(severity, "DEBUG") {
+		return DebugLevel
+	}
+	return InfoLevel
+}
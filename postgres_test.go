@@ -0,0 +1,54 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostgresLine(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+	lh := newLineHandler(&opts)
+
+	line := "2024-01-02 03:04:05.123 UTC [1234] LOG:  duration: 12.345 ms  statement: SELECT * FROM users"
+	if format := lh.match([]byte(line)); format != "postgres" {
+		t.Fatalf("want postgres format, got %q", format)
+	}
+	want := time.Date(2024, 1, 2, 3, 4, 5, 123e6, time.UTC)
+	if got := lh.ev.Time; !got.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, got)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{"[postgres]", "|INFO| statement: SELECT * FROM users", "pid=1234", "duration=12.3ms"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestPostgresLevels(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	lh := newLineHandler(&opts)
+
+	for line, want := range map[string]string{
+		`2024-01-02 03:04:05.123 UTC [1234] app@shop ERROR:  relation "nope" does not exist at character 15`: `|ERRO| relation "nope" does not exist at character 15`,
+		`2024-01-02 03:04:05.123 UTC [1234] app@shop STATEMENT:  SELECT * FROM nope`:                         "|INFO| STATEMENT: SELECT * FROM nope",
+		`2024-01-02 03:04:05 UTC [99] FATAL:  the database system is shutting down`:                          "|FATA| the database system is shutting down",
+		`2024-01-02 03:04:05.123 UTC [99] DEBUG2:  checkpoint sync`:                                          "|DEBU| checkpoint sync",
+	} {
+		if format := lh.match([]byte(line)); format != "postgres" {
+			t.Fatalf("want %q to be postgres, got %q", line, format)
+		}
+		dst := bytes.NewBuffer(nil)
+		lh.write(dst)
+		if out := dst.String(); !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
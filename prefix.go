@@ -0,0 +1,38 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+)
+
+func (h *HandlerOptions) isPrefixKey(key string) bool {
+	for _, k := range h.PrefixKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// keyPrefix renders the values of the PrefixKeys found in fields as
+// `[prod/api] `, or nothing if there are none.
+func (h *HandlerOptions) keyPrefix(fields map[string]string) string {
+	if len(h.PrefixKeys) == 0 {
+		return ""
+	}
+	vals := make([]string, 0, len(h.PrefixKeys))
+	for _, key := range h.PrefixKeys {
+		v, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(v); err == nil {
+			v = unquoted
+		}
+		vals = append(vals, h.sanitize(v))
+	}
+	if len(vals) == 0 {
+		return ""
+	}
+	return "[" + h.paint(h.KeyColor, strings.Join(vals, "/")) + "] "
+}
@@ -0,0 +1,23 @@
+package humanlog
+
+import "bytes"
+
+// Prettify runs a single line through the handlers, returning it prettified
+// and whether a handler recognized it. Lines no handler recognized are
+// returned as is.
+//
+// Nothing is remembered between calls, so opts.SkipUnchanged and the line
+// limits have no effect here.
+func Prettify(line []byte, opts *HandlerOptions) ([]byte, bool) {
+	lineOpts := *opts
+	lineOpts.SkipUnchanged = false
+	lineOpts.SkipLines, lineOpts.MaxLines = 0, 0
+
+	lh := newLineHandler(&lineOpts)
+	if lh.match(line) == rawFormat {
+		return line, false
+	}
+	out := bytes.NewBuffer(nil)
+	lh.write(out)
+	return bytes.TrimSuffix(out.Bytes(), eol[:]), true
+}
@@ -0,0 +1,36 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrettify(t *testing.T) {
+	opts := *DefaultOptions
+	opts.SkipUnchanged = true
+
+	line := []byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello","user":"bob"}`)
+	for i := 0; i < 2; i++ {
+		out, ok := Prettify(line, &opts)
+		if !ok {
+			t.Fatal("want the JSON line handled")
+		}
+		got := string(out)
+		if !strings.Contains(got, "|INFO| hello") || !strings.Contains(got, `user="bob"`) {
+			t.Fatalf("want the line prettified with all its fields, got %q", got)
+		}
+		if strings.HasSuffix(got, "\n") {
+			t.Fatalf("want no trailing newline, got %q", got)
+		}
+	}
+
+	plain := []byte("just some text")
+	out, ok := Prettify(plain, &opts)
+	if ok {
+		t.Fatal("want the plain line not handled")
+	}
+	if !bytes.Equal(out, plain) {
+		t.Fatalf("want the plain line as is, got %q", out)
+	}
+}
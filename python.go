@@ -0,0 +1,36 @@
+package humanlog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// pythonLine matches the format Python services usually hand to the logging
+// module, `%(asctime)s - %(name)s - %(levelname)s - %(message)s`.
+var pythonLine = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:,\d{3})?) - (\S+) - (NOTSET|DEBUG|INFO|WARNING|WARN|ERROR|CRITICAL|FATAL) - (.*)$`)
+
+// pythonTimeLayout is the layout of asctime, once its milliseconds are put
+// behind a dot rather than a comma.
+const pythonTimeLayout = "2006-01-02 15:04:05.999"
+
+// matchPython tells if the line held by lh was logged by Python's logging
+// module, like `2024-01-02 03:04:05,123 - mymodule - WARNING - msg`, in which
+// case the logrus handler gets its parts.
+func (lh *lineHandler) matchPython() bool {
+	m := pythonLine.FindSubmatch(lh.lineData)
+	if m == nil {
+		return false
+	}
+	// asctime is in local time
+	t, err := time.ParseInLocation(pythonTimeLayout, strings.Replace(string(m[1]), ",", ".", 1), time.Local)
+	if err != nil {
+		return false
+	}
+	h := &lh.logrusEntry
+	h.Time = t
+	h.setField([]byte("logger"), m[2])
+	h.setLevel(m[3])
+	h.setMessage(m[4])
+	return true
+}
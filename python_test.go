@@ -0,0 +1,50 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPythonLoggingLine(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+	lh := newLineHandler(&opts)
+
+	line := "2024-01-02 03:04:05,123 - myapp.db - WARNING - connection pool exhausted"
+	if format := lh.match([]byte(line)); format != "python" {
+		t.Fatalf("want python format, got %q", format)
+	}
+	want := time.Date(2024, 1, 2, 3, 4, 5, 123e6, time.Local)
+	if got := lh.ev.Time; !got.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, got)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{"[python]", "|WARN| connection pool exhausted", "logger=myapp.db"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+
+	line = "2024-01-02 03:04:05,456 - root - CRITICAL - giving up"
+	if format := lh.match([]byte(line)); format != "python" {
+		t.Fatalf("want python format, got %q", format)
+	}
+	dst.Reset()
+	lh.write(dst)
+	if out := dst.String(); !strings.Contains(out, "|FATA| giving up") {
+		t.Fatalf("want critical shown as fatal, got %q", out)
+	}
+}
+
+func TestPythonLeavesOtherLinesAlone(t *testing.T) {
+	lh := newLineHandler(DefaultOptions)
+	line := "2024-01-02 03:04:05,123 - not - a level - hello"
+	if got := lh.match([]byte(line)); got != rawFormat {
+		t.Fatalf("want %q to be raw, got %s", line, got)
+	}
+}
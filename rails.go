@@ -0,0 +1,128 @@
+package humanlog
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// rubyLoggerPrefix matches what Ruby's Logger puts before the message in
+	// production, like `I, [2024-01-02T03:04:05.123456 #1234]  INFO -- : `,
+	// followed by the tags of ActiveSupport::TaggedLogging, if any.
+	rubyLoggerPrefix = regexp.MustCompile(`^[DIWEFUA], \[(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?) #(\d+)\] +(DEBUG|INFO|WARN|ERROR|FATAL|ANY) -- [^:]*: ((?:\[[^\]]*\] )*)`)
+
+	railsStarted    = regexp.MustCompile(`^Started ([A-Z]+) "([^"]*)" for (\S+)(?: at (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} [+-]\d{4}))?$`)
+	railsProcessing = regexp.MustCompile(`^Processing by (\S+)#(\S+) as (\S+)$`)
+	railsCompleted  = regexp.MustCompile(`^Completed (\d{3}) [^(]*?in (\d+(?:\.\d+)?ms)(?: \((.*)\))?$`)
+	// `Views: 3.1ms`, in the breakdown of the time a request took
+	railsTiming = regexp.MustCompile(`^([A-Za-z ]+): (\S+)$`)
+)
+
+const (
+	rubyLoggerTimeLayout = "2006-01-02T15:04:05.999999999"
+	railsStartedLayout   = "2006-01-02 15:04:05 -0700"
+)
+
+// matchRails tells if the line held by lh is one of those Rails logs for
+// every request, like `Started GET "/users" for 127.0.0.1`, `Processing by
+// UsersController#index as HTML` or `Completed 200 OK in 12ms (Views: 3.1ms
+// | ActiveRecord: 1.2ms)`, in which case the logrus handler gets their parts.
+// They may come after the prefix of Ruby's Logger, as in production.
+func (lh *lineHandler) matchRails() bool {
+	line := lh.lineData
+	var (
+		level, pid string
+		t          time.Time
+		tags       []string
+	)
+	if m := rubyLoggerPrefix.FindSubmatch(line); m != nil {
+		// Logger's timestamps are in local time
+		var err error
+		if t, err = time.ParseInLocation(rubyLoggerTimeLayout, string(m[1]), time.Local); err != nil {
+			return false
+		}
+		level, pid = string(m[3]), string(m[2])
+		for _, tag := range strings.SplitAfter(strings.TrimSpace(string(m[4])), "] ") {
+			if tag = strings.Trim(strings.TrimSpace(tag), "[]"); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		line = line[len(m[0]):]
+	}
+
+	h := &lh.logrusEntry
+	switch {
+	case railsStarted.Match(line):
+		m := railsStarted.FindSubmatch(line)
+		if len(m[4]) > 0 {
+			started, err := time.Parse(railsStartedLayout, string(m[4]))
+			if err != nil {
+				return false
+			}
+			t = started
+		}
+		h.Message = "Started " + string(m[1]) + " " + string(m[2])
+		h.setField([]byte("method"), m[1])
+		h.setField([]byte("path"), m[2])
+		h.setField([]byte("remote_addr"), m[3])
+	case railsProcessing.Match(line):
+		m := railsProcessing.FindSubmatch(line)
+		h.Message = "Processing by " + string(m[1]) + "#" + string(m[2])
+		h.setField([]byte("controller"), m[1])
+		h.setField([]byte("action"), m[2])
+		h.setField([]byte("format"), m[3])
+	case railsCompleted.Match(line):
+		m := railsCompleted.FindSubmatch(line)
+		h.Message = strings.TrimSpace(strings.SplitN(string(m[0]), " in ", 2)[0])
+		h.setField([]byte("status"), m[1])
+		h.setField([]byte("duration"), m[2])
+		for _, part := range strings.Split(string(m[3]), " | ") {
+			if tm := railsTiming.FindStringSubmatch(strings.TrimSpace(part)); tm != nil {
+				h.setField([]byte(railsTimingKey(tm[1])), []byte(tm[2]))
+			}
+		}
+		if level == "" || level == "INFO" {
+			switch m[1][0] {
+			case '5':
+				level = ErrorLevel
+			case '4':
+				level = WarnLevel
+			}
+		}
+	default:
+		return false
+	}
+	if level == "" {
+		level = InfoLevel
+	}
+	h.Level = level
+	h.Time = t
+	if pid != "" {
+		h.setField([]byte("pid"), []byte(pid))
+	}
+	if len(tags) > 0 {
+		h.setField([]byte("tags"), []byte(strings.Join(tags, ",")))
+	}
+	return true
+}
+
+// railsTimingKey turns the name of a part of the time a request took, like
+// `ActiveRecord` or `Views`, into the key of its field.
+func railsTimingKey(name string) string {
+	var key strings.Builder
+	for i, r := range strings.TrimSpace(name) {
+		switch {
+		case r == ' ':
+			key.WriteByte('_')
+			continue
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && !strings.HasSuffix(key.String(), "_") {
+				key.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		key.WriteRune(r)
+	}
+	return key.String()
+}
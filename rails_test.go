@@ -0,0 +1,59 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRailsLines(t *testing.T) {
+	for _, test := range []struct {
+		line string
+		want []string
+	}{
+		{
+			`Started GET "/users?page=2" for 127.0.0.1 at 2024-01-02 03:04:05 +0000`,
+			[]string{"|INFO| Started GET /users?page=2", `method=GET`, `path=/users?page=2`, `remote_addr=127.0.0.1`},
+		},
+		{
+			`Processing by UsersController#index as HTML`,
+			[]string{"|INFO| Processing by UsersController#index", `controller=UsersController`, `action=index`, `format=HTML`},
+		},
+		{
+			`Completed 200 OK in 12ms (Views: 3.1ms | ActiveRecord: 1.2ms | Allocations: 4242)`,
+			[]string{"|INFO| Completed 200 OK", `status=200`, `duration=12ms`, `views=3.1ms`, `active_record=1.2ms`, `allocations=4242`},
+		},
+		{
+			`Completed 500 Internal Server Error in 5ms (ActiveRecord: 0.4ms)`,
+			[]string{"|ERRO| Completed 500 Internal Server Error", `status=500`},
+		},
+		{
+			`I, [2024-01-02T03:04:05.123456 #4242]  INFO -- : [0f1e2d3c] Completed 404 Not Found in 3ms`,
+			[]string{"|WARN| Completed 404 Not Found", `pid=4242`, `tags=0f1e2d3c`},
+		},
+	} {
+		opts := *DefaultOptions
+		opts.Truncates = false
+		lh := newLineHandler(&opts)
+		if format := lh.match([]byte(test.line)); format != "rails" {
+			t.Fatalf("want rails format for %q, got %q", test.line, format)
+		}
+		dst := bytes.NewBuffer(nil)
+		lh.write(dst)
+		out := dst.String()
+		for _, want := range test.want {
+			if !strings.Contains(out, want) {
+				t.Fatalf("want %q in output, got %q", want, out)
+			}
+		}
+	}
+}
+
+func TestRailsStartedTime(t *testing.T) {
+	lh := newLineHandler(DefaultOptions)
+	lh.match([]byte(`Started POST "/login" for ::1 at 2024-01-02 03:04:05 +0100`))
+	if want := time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC); !lh.ev.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, lh.ev.Time)
+	}
+}
@@ -0,0 +1,168 @@
+package humanlog
+
+import (
+	"sort"
+	"sync"
+)
+
+// customFormat names the format of the lines of registered handlers that
+// don't tell its name.
+const customFormat = "custom"
+
+// Namer can be implemented by a registered Handler to name the format of
+// its lines, as shown by ShowHandler. The name must differ from those of
+// the built-in formats, like "json" or "logrus".
+type Namer interface {
+	Name() string
+}
+
+// handlerEntry is a handler lines are tried against, either one that was
+// registered or a built-in one.
+type handlerEntry struct {
+	priority int
+
+	handler Handler
+	name    string
+
+	// builtin matches the line held by lh, telling the name of its format
+	// and the handler holding its event, nil if it doesn't match
+	builtin func(lh *lineHandler) (string, builtinEntry)
+}
+
+var registry struct {
+	sync.RWMutex
+	entries []handlerEntry
+}
+
+// RegisterHandler adds h to the handlers lines are tried against, those
+// with a higher priority being tried first. The built-in handlers have
+// priorities from 100 (syslog) to 1400 (journald), so a handler with a
+// priority above 1400 is tried before all of them, and one with a
+// priority below 100 only gets the lines none of them recognized.
+//
+// Lines are read by many goroutines at once with ScannerParallel, h must
+// be safe for concurrent use then. Handlers registered while a Scanner
+// runs are only used by the ones started afterwards.
+func RegisterHandler(h Handler, priority int) {
+	name := customFormat
+	if n, ok := h.(Namer); ok {
+		name = n.Name()
+	}
+	register(handlerEntry{priority: priority, handler: h, name: name})
+}
+
+// registerBuiltin adds a built-in handler, named as it is to be disabled,
+// though the lines it matches can be told to be in other formats.
+func registerBuiltin(priority int, name string, match func(lh *lineHandler) (string, builtinEntry)) {
+	register(handlerEntry{priority: priority, name: name, builtin: match})
+}
+
+func register(entry handlerEntry) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.entries = append(registry.entries, entry)
+	// handlers of the same priority are tried in the order they came in
+	sort.SliceStable(registry.entries, func(i, j int) bool {
+		return registry.entries[i].priority > registry.entries[j].priority
+	})
+}
+
+// registeredHandlers are the handlers lines are tried against, in order,
+// but for the disabled ones.
+func registeredHandlers(disabled []string) []handlerEntry {
+	registry.RLock()
+	defer registry.RUnlock()
+	entries := make([]handlerEntry, 0, len(registry.entries))
+	for _, entry := range registry.entries {
+		if indexOf(disabled, entry.name) < 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// HandlerNames are the names of the handlers lines are tried against, in
+// order, as they can be disabled with DisabledHandlers. The bunyan handler
+// matches pino lines too.
+func HandlerNames() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.entries))
+	for _, entry := range registry.entries {
+		if indexOf(names, entry.name) < 0 {
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+func init() {
+	registerBuiltin(1400, "journal", func(lh *lineHandler) (string, builtinEntry) {
+		return "journal", heldBy(&lh.journalJSONEntry, lh.journalJSONEntry.parse(lh.lineData))
+	})
+	registerBuiltin(1300, "gelf", func(lh *lineHandler) (string, builtinEntry) {
+		return "gelf", heldBy(&lh.gelfEntry, lh.gelfEntry.parse(lh.lineData))
+	})
+	registerBuiltin(1200, "bunyan", func(lh *lineHandler) (string, builtinEntry) {
+		if !lh.bunyanEntry.parse(lh.lineData) {
+			return "", nil
+		}
+		if lh.bunyanEntry.Pino {
+			return "pino", &lh.bunyanEntry
+		}
+		return "bunyan", &lh.bunyanEntry
+	})
+	registerBuiltin(1100, "json", func(lh *lineHandler) (string, builtinEntry) {
+		if lh.jsonEntry.parse(lh.lineData) {
+			return "json", &lh.jsonEntry
+		}
+		// in a stream of JSON logs, the objects without a time are logs
+		// too, rather than lines for the handlers after this one
+		return "json", heldBy(&lh.jsonEntry, lh.detected("json") && lh.opts.JSONDetection != JSONDetectionStrict && lh.jsonEntry.parseObject(lh.lineData))
+	})
+	registerBuiltin(1000, "heroku", func(lh *lineHandler) (string, builtinEntry) {
+		return "heroku", heldBy(&lh.logrusEntry, lh.matchHeroku())
+	})
+	registerBuiltin(900, "lambda", func(lh *lineHandler) (string, builtinEntry) {
+		return "lambda", heldBy(&lh.logrusEntry, lh.matchLambda())
+	})
+	registerBuiltin(800, "ltsv", func(lh *lineHandler) (string, builtinEntry) {
+		return "ltsv", heldBy(&lh.logrusEntry, lh.matchLTSV())
+	})
+	registerBuiltin(700, "python", func(lh *lineHandler) (string, builtinEntry) {
+		return "python", heldBy(&lh.logrusEntry, lh.matchPython())
+	})
+	registerBuiltin(650, "log4j", func(lh *lineHandler) (string, builtinEntry) {
+		return "log4j", heldBy(&lh.logrusEntry, lh.matchLog4j())
+	})
+	registerBuiltin(600, "postgres", func(lh *lineHandler) (string, builtinEntry) {
+		return "postgres", heldBy(&lh.logrusEntry, lh.matchPostgres())
+	})
+	registerBuiltin(550, "winevent", func(lh *lineHandler) (string, builtinEntry) {
+		return "winevent", heldBy(&lh.logrusEntry, lh.matchWindowsEvent())
+	})
+	registerBuiltin(500, "cef", func(lh *lineHandler) (string, builtinEntry) {
+		return "cef", heldBy(&lh.logrusEntry, lh.matchCEF())
+	})
+	registerBuiltin(450, "rails", func(lh *lineHandler) (string, builtinEntry) {
+		return "rails", heldBy(&lh.logrusEntry, lh.matchRails())
+	})
+	registerBuiltin(400, "klog", func(lh *lineHandler) (string, builtinEntry) {
+		return "klog", heldBy(&lh.klogEntry, lh.klogEntry.parse(lh.lineData))
+	})
+	registerBuiltin(350, "haproxy", func(lh *lineHandler) (string, builtinEntry) {
+		return "haproxy", heldBy(&lh.logrusEntry, lh.matchHAProxy())
+	})
+	registerBuiltin(300, "access", func(lh *lineHandler) (string, builtinEntry) {
+		return "access", heldBy(&lh.accessLogEntry, lh.accessLogEntry.parse(lh.lineData))
+	})
+	registerBuiltin(250, "ci", func(lh *lineHandler) (string, builtinEntry) {
+		return "ci", heldBy(&lh.logrusEntry, lh.matchCI())
+	})
+	registerBuiltin(200, "logrus", func(lh *lineHandler) (string, builtinEntry) {
+		return "logrus", heldBy(&lh.logrusEntry, lh.logrusEntry.parse(lh.lineData))
+	})
+	registerBuiltin(100, "syslog", func(lh *lineHandler) (string, builtinEntry) {
+		return "syslog", heldBy(&lh.syslogEntry, lh.syslogEntry.parse(lh.lineData))
+	})
+}
@@ -0,0 +1,82 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pipeHandler handles lines like `PIPE|2018-10-24T08:00:00Z|warn|message|key=value`.
+type pipeHandler struct{}
+
+func (pipeHandler) Name() string { return "pipe" }
+
+func (pipeHandler) TryHandle(line []byte) (Event, bool) {
+	parts := strings.Split(string(line), "|")
+	if len(parts) < 4 || parts[0] != "PIPE" {
+		return Event{}, false
+	}
+	t, err := time.Parse(time.RFC3339, parts[1])
+	if err != nil {
+		return Event{}, false
+	}
+	ev := Event{Time: t, Level: parts[2], Msg: parts[3], Fields: make(map[string]string)}
+	for _, kv := range parts[4:] {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			ev.Fields[kv[:i]] = kv[i+1:]
+		}
+	}
+	return ev, true
+}
+
+func TestRegisterHandler(t *testing.T) {
+	RegisterHandler(pipeHandler{}, 2000)
+
+	src := strings.Join([]string{
+		`PIPE|2018-10-24T08:00:00Z|warning|from a plugin|user=bob`,
+		`{"time":"2018-10-24T08:00:01Z","level":"info","msg":"still json"}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+	opts.MinLevel = WarnLevel
+	opts.StrictLevel = true
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	if !strings.HasPrefix(got, "[pipe]") || !strings.Contains(got, "|WARN| from a plugin") || !strings.Contains(got, "user=bob") {
+		t.Fatalf("want the line prettified by the registered handler, got %q", got)
+	}
+	if strings.Contains(got, "still json") {
+		t.Fatalf("want the info line filtered out, got %q", got)
+	}
+}
+
+func TestRegisteredHandlersOrder(t *testing.T) {
+	entries := registeredHandlers(nil)
+	for i := 1; i < len(entries); i++ {
+		if entries[i-1].priority < entries[i].priority {
+			t.Fatalf("want handlers by decreasing priority, got %d before %d", entries[i-1].priority, entries[i].priority)
+		}
+	}
+}
+
+func TestDisabledHandlers(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+	opts.DisabledHandlers = []string{"json"}
+	dst := bytes.NewBuffer(nil)
+	src := `{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello"}`
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.String(); !strings.HasPrefix(got, "[raw]") || !strings.HasSuffix(got, src+"\n") {
+		t.Fatalf("want the line left as is, got %q", got)
+	}
+	if names := HandlerNames(); indexOf(names, "json") < 0 || indexOf(names, "journal") < 0 {
+		t.Fatalf("want the built-in handlers named, got %q", names)
+	}
+}
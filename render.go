@@ -0,0 +1,340 @@
+package humanlog
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+)
+
+// Renderer prettifies events, the same way whatever format they came in.
+// It remembers the fields of the last event it rendered, to leave out the
+// ones that didn't change.
+type Renderer struct {
+	Opts *HandlerOptions
+
+	buf  *bytes.Buffer
+	out  *tabwriter.Writer
+	last map[string]string
+	// kv is reused from one event to the next, and so are the keys of
+	// the error fields and of the expanded fields joinKVs sets apart
+	kv         []string
+	errKeys    []string
+	expandKeys []string
+	// the keys of the fields the caller was found in, with CompactCaller
+	callerKeys []string
+	// the line given to out, reused as well
+	line []byte
+	// the widths of the columns of the last events, with AlignColumns
+	widths    [alignWindow][]int
+	nextWidth int
+}
+
+// NewRenderer prettifies events as opts tell.
+func NewRenderer(opts *HandlerOptions) *Renderer {
+	buf := bytes.NewBuffer(nil)
+	return &Renderer{
+		Opts: opts,
+		buf:  buf,
+		out:  tabwriter.NewWriter(buf, 0, 1, 0, '\t', 0),
+	}
+}
+
+// Render prettifies ev, leaving out the fields that have the same value as
+// in the event rendered before it when skipUnchanged is set. What it
+// returns is only valid until the next call.
+func (r *Renderer) Render(ev Event, skipUnchanged bool) []byte {
+	opts := r.Opts
+	r.buf.Reset()
+	if opts.ParseEmbeddedJSON {
+		ev = opts.parseEmbeddedFields(ev)
+	}
+
+	var (
+		msgColor       *color.Color
+		msgAbsentColor *color.Color
+	)
+	if opts.LightBg {
+		msgColor = opts.MsgLightBgColor
+		msgAbsentColor = opts.MsgAbsentLightBgColor
+	} else {
+		msgColor = opts.MsgDarkBgColor
+		msgAbsentColor = opts.MsgAbsentDarkBgColor
+	}
+	if c, ok := opts.MessageColorByLevel[ev.Level]; ok {
+		msgColor = c
+	}
+
+	var msg string
+	if ev.Msg == "" {
+		msg = opts.paint(msgAbsentColor, "<no msg>")
+	} else {
+		msg = opts.paintHighlighted(msgColor, opts.sanitize(ev.Msg))
+	}
+
+	level := opts.levelLabel(ev.Level, ev.label)
+
+	var timeColor *color.Color
+	if opts.LightBg {
+		timeColor = opts.TimeLightBgColor
+	} else {
+		timeColor = opts.TimeDarkBgColor
+	}
+	var caller string
+	r.callerKeys = r.callerKeys[:0]
+	if opts.CompactCaller {
+		caller, r.callerKeys = opts.caller(ev)
+	}
+	kvs := r.joinKVs(ev, skipUnchanged, "=")
+	if caller != "" {
+		// at the end of the line, out of the way
+		kvs = append(kvs, opts.paint(opts.RawColor, caller))
+	}
+	errs, errLines := r.errorFields(ev)
+	stamp := opts.formatTime(ev.Time)
+	if ev.untimed && ev.Time.IsZero() {
+		// as wide as a time, for lines without one to line up
+		stamp = strings.Repeat(" ", visibleWidth(stamp))
+	}
+	prefix := opts.keyPrefix(ev.Fields)
+	if ev.journal {
+		if origin := journalOrigin(ev.Fields); origin != "" {
+			prefix = opts.paint(opts.KeyColor, opts.sanitize(origin)) + " " + prefix
+		}
+	}
+	head := opts.paint(timeColor, stamp) + " " + level + " " +
+		opts.fitMessage(prefix+msg) + errs
+	if opts.AlignColumns {
+		head = r.alignColumns(head, kvs)
+	}
+	overflow := opts.Overflow
+	if opts.Width <= 0 {
+		overflow = OverflowNone
+	}
+	switch {
+	case overflow == OverflowWrap:
+		opts.wrapCells(r.buf, head, kvs)
+	case overflow == OverflowTruncate:
+		r.buf.WriteString(opts.fitWidth(head + " " + strings.Join(kvs, " ")))
+	case opts.AlignColumns:
+		r.buf.WriteString(head)
+		for _, kv := range kvs {
+			r.buf.WriteByte(' ')
+			r.buf.WriteString(kv)
+		}
+	default:
+		// appended piece by piece rather than formatted, and written at
+		// once, as this is done for every line
+		r.line = append(r.line[:0], head...)
+		r.line = append(r.line, "\t "...)
+		for i, kv := range kvs {
+			if i > 0 {
+				r.line = append(r.line, "\t "...)
+			}
+			r.line = append(r.line, kv...)
+		}
+		_, _ = r.out.Write(r.line)
+		_ = r.out.Flush()
+	}
+
+	for _, lines := range errLines {
+		writeStacktrace(r.buf, lines)
+	}
+	opts.writeExpanded(r.buf, ev, r.expandKeys)
+	opts.writeNested(r.buf, ev.nested)
+	if ev.stacktrace != "" {
+		writeStacktrace(r.buf, opts.sanitize(ev.stacktrace))
+	}
+
+	if opts.EmphasizeLevel != "" && levelSeverity(ev.Level) >= levelSeverity(opts.EmphasizeLevel) {
+		r.emphasize()
+	}
+
+	r.last = ev.Fields
+	return r.buf.Bytes()
+}
+
+// ansiReset ends the colors of what paint renders.
+const ansiReset = "\x1b[0m"
+
+// emphasize paints every line rendered in EmphasisColor, which carries on
+// after the colors within the lines end.
+func (r *Renderer) emphasize() {
+	opts := r.Opts
+	painted := opts.paint(opts.EmphasisColor, "")
+	if painted == "" {
+		// colors are disabled
+		return
+	}
+	start := strings.TrimSuffix(painted, ansiReset)
+	lines := strings.Split(r.buf.String(), "\n")
+	r.buf.Reset()
+	for i, line := range lines {
+		if i > 0 {
+			r.buf.WriteByte('\n')
+		}
+		r.buf.WriteString(start)
+		r.buf.WriteString(strings.Replace(line, ansiReset, ansiReset+start, -1))
+		r.buf.WriteString(ansiReset)
+	}
+}
+
+// alignWindow is how many events AlignColumns keeps the columns of, so that
+// a wide value only widens its column for a while.
+const alignWindow = 32
+
+// alignColumns pads the message and the fields of an event to the widest
+// each column was among the last events, so that consecutive lines read
+// like a table. The fields are padded in place, and the padded message is
+// returned.
+func (r *Renderer) alignColumns(head string, kvs []string) string {
+	widths := r.widths[r.nextWidth%alignWindow][:0]
+	widths = append(widths, visibleWidth(head))
+	for _, kv := range kvs {
+		widths = append(widths, visibleWidth(kv))
+	}
+	r.widths[r.nextWidth%alignWindow] = widths
+	r.nextWidth++
+
+	pad := func(i int, cell string) string {
+		width := 0
+		for _, w := range r.widths {
+			if i < len(w) && w[i] > width {
+				width = w[i]
+			}
+		}
+		if widths[i] < width {
+			cell += strings.Repeat(" ", width-widths[i])
+		}
+		return cell
+	}
+	if len(kvs) == 0 {
+		return head
+	}
+	head = pad(0, head)
+	for i := range kvs[:len(kvs)-1] {
+		kvs[i] = pad(i+1, kvs[i])
+	}
+	return head
+}
+
+func (r *Renderer) joinKVs(ev Event, skipUnchanged bool, sep string) []string {
+	opts := r.Opts
+
+	kv := r.kv[:0]
+	r.errKeys = r.errKeys[:0]
+	r.expandKeys = r.expandKeys[:0]
+	var first []string
+	if len(opts.FirstKeys) > 0 {
+		first = make([]string, len(opts.FirstKeys))
+	}
+	for k, v := range ev.Fields {
+		if opts.isPrefixKey(k) {
+			continue
+		}
+		if ev.journal && isJournalMetadata(k) {
+			// shown in short before the message
+			continue
+		}
+		if !opts.showKey(k, opts.AutoSkipUnderscore && ev.skipUnderscored) {
+			continue
+		}
+
+		if indexOf(r.callerKeys, k) >= 0 {
+			continue
+		}
+		if indexOf(opts.ErrorKeys, k) >= 0 {
+			// never left out, nor truncated
+			r.errKeys = append(r.errKeys, k)
+			continue
+		}
+		if indexOf(opts.ExpandKeys, k) >= 0 {
+			// written in full below the line
+			r.expandKeys = append(r.expandKeys, k)
+			continue
+		}
+
+		if skipUnchanged {
+			if lastV, ok := r.last[k]; ok && lastV == v && !opts.shouldShowUnchanged(k) {
+				continue
+			}
+		}
+		kstr := opts.paint(opts.KeyColor, opts.displayKey(k))
+
+		v = opts.sanitize(v)
+		d, isDuration := opts.duration(k, v)
+		if isDuration {
+			v = humanizeDuration(d)
+		} else {
+			v = opts.humanizeUnit(k, v)
+		}
+		vstr := v
+		if n, ok := opts.truncateLength(k); ok && len(v) > n {
+			vstr = v[:n] + "..."
+		}
+		traced, isTrace := "", false
+		if opts.ShortenTraceIDs {
+			traced, isTrace = opts.traceValue(k, v)
+		}
+		switch {
+		case isTrace:
+			vstr = traced
+		case ev.httpStatus && k == "status":
+			vstr = opts.paintHighlighted(opts.statusColor(v), vstr)
+		case isDuration && opts.SlowDuration > 0 && d >= opts.SlowDuration:
+			vstr = opts.paintHighlighted(opts.ErrorLevelColor, vstr)
+		default:
+			vstr = opts.paintHighlighted(opts.valColor(v), vstr)
+		}
+		if i := indexOf(opts.FirstKeys, k); i >= 0 {
+			first[i] = kstr + sep + vstr
+			continue
+		}
+		kv = append(kv, kstr+sep+vstr)
+	}
+
+	sort.Strings(kv)
+
+	if opts.SortLongest && !opts.AlignColumns {
+		sort.Stable(byLongest(kv))
+	}
+
+	if first != nil {
+		// the keys found, in front of the others
+		pinned := first[:0]
+		for _, s := range first {
+			if s != "" {
+				pinned = append(pinned, s)
+			}
+		}
+		kv = append(pinned, kv...)
+	}
+
+	r.kv = kv
+	return kv
+}
+
+// writeStacktrace writes the lines of stack below a prettified line,
+// indented so that they stand apart from the lines that follow.
+func writeStacktrace(buf *bytes.Buffer, stack string) {
+	for _, line := range strings.Split(strings.TrimRight(stack, "\n"), "\n") {
+		buf.WriteByte('\n')
+		buf.WriteString("    ")
+		buf.WriteString(strings.Replace(line, "\t", "    ", -1))
+	}
+}
+
+type byLongest []string
+
+func (s byLongest) Len() int           { return len(s) }
+func (s byLongest) Less(i, j int) bool { return len(s[i]) < len(s[j]) }
+func (s byLongest) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
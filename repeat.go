@@ -0,0 +1,94 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// repeats is what CollapseRepeats remembers of the lines written, shared by
+// the line handlers writing to the same output.
+type repeats struct {
+	// the line last written, and how many times it was repeated since
+	last  string
+	count int
+	// the line held, until it is written
+	held string
+}
+
+// repeatKey identifies the line held since the last call to match but for
+// its time, so that a line logged again later reads as a repeat.
+func (lh *lineHandler) repeatKey() string {
+	if lh.format == rawFormat {
+		return rawFormat + "\x00" + string(lh.lineData)
+	}
+	ev := lh.ev
+	keys := make([]string, 0, len(ev.Fields))
+	for k := range ev.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var key strings.Builder
+	key.WriteString(lh.format)
+	key.WriteByte(0)
+	key.WriteString(ev.Level)
+	key.WriteByte(0)
+	key.WriteString(ev.Msg)
+	for _, k := range keys {
+		key.WriteByte(0)
+		key.WriteString(k)
+		key.WriteByte('=')
+		key.WriteString(ev.Fields[k])
+	}
+	return key.String()
+}
+
+// collapse tells if the line held since the last call to match repeats the
+// last one written, with CollapseRepeats, in which case it is counted
+// rather than written along with the lines that continue it.
+func (lh *lineHandler) collapse() bool {
+	if !lh.opts.CollapseRepeats {
+		return false
+	}
+	// the line held is rendered before it is written
+	lh.repeats.held = lh.repeatKey()
+	if lh.repeats.held != lh.repeats.last {
+		return false
+	}
+	lh.repeats.count++
+	lh.fold = foldDiscard
+	return true
+}
+
+// writeRepeats tells how many times the last line written was repeated, if
+// it was, before the line held since the last call to match is written.
+func (lh *lineHandler) writeRepeats(dst io.Writer) {
+	if !lh.opts.CollapseRepeats {
+		return
+	}
+	lh.finish(dst)
+	lh.repeats.last = lh.repeats.held
+}
+
+// finish writes out what is left to write once there are no more lines.
+func (lh *lineHandler) finish(dst io.Writer) {
+	lh.repeats.finish(dst, lh.opts)
+}
+
+// finish tells how many times the last line written was repeated, if it
+// was.
+func (r *repeats) finish(dst io.Writer, opts *HandlerOptions) {
+	if r.count == 0 {
+		return
+	}
+	times := "times"
+	if r.count == 1 {
+		times = "time"
+	}
+	note := fmt.Sprintf("    last line repeated %d more %s", r.count, times)
+	dst.Write([]byte(opts.paint(opts.RawColor, note)))
+	dst.Write(eol[:])
+	r.count = 0
+}
@@ -0,0 +1,57 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCollapseRepeats(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"health check","status":200}`,
+		`{"time":"2018-10-24T08:19:51Z","level":"info","msg":"health check","status":200}`,
+		`{"time":"2018-10-24T08:19:52Z","level":"info","msg":"health check","status":200}`,
+		`{"time":"2018-10-24T08:19:53Z","level":"info","msg":"health check","status":500}`,
+		`plain`,
+		`plain`,
+	}, "\n") + "\n"
+
+	scanners := map[string]func(dst *bytes.Buffer, opts *HandlerOptions) error{
+		"serial": func(dst *bytes.Buffer, opts *HandlerOptions) error {
+			return Scanner(strings.NewReader(src), dst, opts)
+		},
+		"merge": func(dst *bytes.Buffer, opts *HandlerOptions) error {
+			return ScannerMerge([]io.Reader{strings.NewReader(src)}, dst, opts)
+		},
+		"interleave": func(dst *bytes.Buffer, opts *HandlerOptions) error {
+			return ScannerInterleave([]io.Reader{strings.NewReader(src)}, dst, opts)
+		},
+		"writer": func(dst *bytes.Buffer, opts *HandlerOptions) error {
+			w := NewWriter(dst, opts)
+			w.Write([]byte(src))
+			return w.Close()
+		},
+	}
+	for name, scan := range scanners {
+		opts := *DefaultOptions
+		opts.CollapseRepeats = true
+		dst := bytes.NewBuffer(nil)
+		if err := scan(dst, &opts); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+		if len(lines) != 5 {
+			t.Fatalf("%s: want 5 lines, got %q", name, lines)
+		}
+		if !strings.Contains(lines[1], "repeated 2 more times") {
+			t.Fatalf("%s: want the repeats of the first line counted, got %q", name, lines[1])
+		}
+		if !strings.Contains(lines[2], "500") || lines[3] != "plain" {
+			t.Fatalf("%s: want the lines that differ written, got %q", name, lines)
+		}
+		if !strings.Contains(lines[4], "repeated 1 more time") {
+			t.Fatalf("%s: want the repeats counted at the end, got %q", name, lines[4])
+		}
+	}
+}
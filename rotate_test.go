@@ -0,0 +1,82 @@
+package humanlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "humanlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pretty.log")
+
+	w, err := NewRotatingWriter(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n", "ffff\n", "gggg\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"pretty.log":   "gggg\n",
+		"pretty.log.1": "eeee\nffff\n",
+		"pretty.log.2": "cccc\ndddd\n",
+	} {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Fatalf("%s: want %q, got %q", name, want, got)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("want at most 2 rotated files, got %v", err)
+	}
+
+	// appends to what is there already
+	w, err = NewRotatingWriter(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("hhhh\n"))
+	w.Close()
+	if got, _ := ioutil.ReadFile(path); string(got) != "gggg\nhhhh\n" {
+		t.Fatalf("want the file appended to, got %q", got)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"512":    512,
+		"64KB":   64 << 10,
+		"64k":    64 << 10,
+		"100MB":  100 << 20,
+		"1.5GiB": 3 << 29,
+		"2 G":    2 << 30,
+	} {
+		got, err := ParseSize(in)
+		if err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("%q: want %d, got %d", in, want, got)
+		}
+	}
+	for _, in := range []string{"", "MB", "12XB", "-1"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Fatalf("want %q rejected", in)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package humanlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseSampleRate parses a sampling rate like `1/100`, or just `100`, into
+// how many lines one is kept out of.
+func ParseSampleRate(s string) (uint64, error) {
+	n := s
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		if strings.TrimSpace(s[:i]) != "1" {
+			return 0, fmt.Errorf("%q: only one line in so many can be kept, like 1/100", s)
+		}
+		n = s[i+1:]
+	}
+	rate, err := strconv.ParseUint(strings.TrimSpace(n), 10, 64)
+	if err != nil || rate == 0 {
+		return 0, fmt.Errorf("%q: want a rate like 1/100", s)
+	}
+	return rate, nil
+}
+
+// sample tells if the line held is kept by SampleRate: the first of every
+// SampleRate lines is, as well as those at least as severe as
+// SampleKeepLevel, which don't count towards the rate.
+func (lh *lineHandler) sample() bool {
+	if lh.opts.SampleRate <= 1 {
+		return true
+	}
+	return lh.sampleLevel(lh.level())
+}
+
+// sampleLevel is sample for a line of the given level, for the lines that
+// aren't held by lh, like those ScannerParallel puts back in order.
+func (lh *lineHandler) sampleLevel(level string) bool {
+	opts := lh.opts
+	if opts.SampleRate <= 1 {
+		return true
+	}
+	if opts.SampleKeepLevel != "" && level != UnknownLevel && levelSeverity(level) >= levelSeverity(opts.SampleKeepLevel) {
+		return true
+	}
+	keep := lh.sampled%opts.SampleRate == 0
+	lh.sampled++
+	return keep
+}
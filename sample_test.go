@@ -0,0 +1,59 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseSampleRate(t *testing.T) {
+	for in, want := range map[string]uint64{"1/100": 100, "10": 10, "1 / 5": 5} {
+		got, err := ParseSampleRate(in)
+		if err != nil || got != want {
+			t.Errorf("%q: want %d, got %d, %v", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"", "1/0", "2/100", "often"} {
+		if _, err := ParseSampleRate(in); err == nil {
+			t.Errorf("%q: want an error", in)
+		}
+	}
+}
+
+func TestScannerSample(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		level := "info"
+		if i == 5 {
+			level = "error"
+		}
+		lines = append(lines, fmt.Sprintf(`{"time":"2018-10-24T08:00:0%dZ","level":%q,"msg":"line %d"}`, i, level, i))
+	}
+	src := strings.Join(lines, "\n")
+
+	opts := *DefaultOptions
+	opts.SampleRate = 4
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.String(); strings.Count(got, "\n") != 3 || !strings.Contains(got, "line 0") || !strings.Contains(got, "line 4") || !strings.Contains(got, "line 8") {
+		t.Fatalf("want lines 0, 4 and 8, got %q", got)
+	}
+
+	opts.SampleKeepLevel = WarnLevel
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	for _, want := range []string{"line 0", "line 4", "line 5", "line 9"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q kept, got %q", want, got)
+		}
+	}
+	if strings.Count(got, "\n") != 4 {
+		t.Fatalf("want 4 lines, got %q", got)
+	}
+}
@@ -1,69 +1,438 @@
 package humanlog
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"io"
-
-	"github.com/jigish/humanlog/parser/logfmt"
+	"time"
 )
 
 var (
 	eol = [...]byte{'\n'}
 )
 
+// rawFormat is the name of the format of lines no handler recognized.
+const rawFormat = "raw"
+
+// formatTagWidth fits the tag of the longest format name, so that tags
+// don't disturb the alignment of what follows them.
+const formatTagWidth = len("[journal] ")
+
+func formatTag(format string) []byte {
+	tag := make([]byte, 0, formatTagWidth)
+	tag = append(tag, '[')
+	tag = append(tag, format...)
+	tag = append(tag, ']')
+	for len(tag) < formatTagWidth {
+		tag = append(tag, ' ')
+	}
+	return tag
+}
+
 // Scanner reads logfmt'd lines from src and prettify them onto dst.
 // If the lines aren't logfmt, it will simply write them out with no
 // prettification.
 func Scanner(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
-	in := bufio.NewScanner(src)
-	in.Split(bufio.ScanLines)
+	if opts.JSONArrayInput {
+		return scanJSONArray(src, dst, opts)
+	}
+	if opts.JournalExportInput {
+		return scanJournalExport(src, dst, opts)
+	}
+	if opts.MySQLSlowLogInput {
+		return scanMySQLSlowLog(src, dst, opts)
+	}
+	if opts.CSVInput {
+		return scanCSV(src, dst, opts)
+	}
+	if opts.FlushInterval > 0 {
+		return ScannerContext(context.Background(), src, dst, opts)
+	}
 
-	var line uint64
+	in := opts.lineReader(src)
 
-	var lastLogrus bool
-	var lastJSON bool
-	var lastJournalJSON bool
+	var line uint64
 
-	logrusEntry := LogrusHandler{Opts: opts}
-	jsonEntry := JSONHandler{Opts: opts}
-	journalJSONEntry := JournalJSONHandler{Opts: opts}
+	lh := newLineHandler(opts)
 
-	for in.Scan() {
+	for !lh.done() && in.Scan() {
 		line++
-		lineData := in.Bytes()
+		opts.copyRaw(in.Raw())
+		lh.handle(dst, in.Bytes())
+	}
+	lh.finish(dst)
+
+	return in.Err()
+}
 
-		// remove that pesky syslog crap
-		lineData = bytes.TrimPrefix(lineData, []byte("@cee: "))
+// lineHandler runs lines through each known handler, remembering what
+// it needs between lines to skip unchanged values.
+type lineHandler struct {
+	opts *HandlerOptions
 
-		switch {
+	// the built-in handlers, which parse lines into their events
+	logrusEntry      LogrusHandler
+	jsonEntry        JSONHandler
+	journalJSONEntry JournalJSONHandler
+	gelfEntry        GELFHandler
+	bunyanEntry      BunyanHandler
+	klogEntry        KlogHandler
+	accessLogEntry   AccessLogHandler
+	syslogEntry      SyslogHandler
 
-		case journalJSONEntry.TryHandle(lineData):
-			dst.Write(journalJSONEntry.Prettify(opts.SkipUnchanged && lastJournalJSON))
-			lastJournalJSON = true
+	// the handlers lines are tried against, and the event of the line held,
+	// whichever handler recognized it
+	handlers []handlerEntry
+	ev       Event
+	// the index of the handler that recognized the line held, that of the
+	// one of the format the stream was detected to be in, -1 for none, and
+	// how many of the lines sampled each recognized
+	matched     int
+	dominant    int
+	detectHits  []int
+	detectLines int
+	// what renders the events, and the last one it rendered, whose fields
+	// are left out of the next one of the same format with SkipUnchanged
+	renderer  *Renderer
+	lastEvent Event
 
-		case jsonEntry.TryHandle(lineData):
-			dst.Write(jsonEntry.Prettify(opts.SkipUnchanged && lastJSON))
-			lastJSON = true
+	// how many lines were read, to number them
+	lines uint64
+	// how many lines were printed or skipped with SkipLines
+	printed uint64
+	// how many lines were sampled, to keep one in SampleRate
+	sampled uint64
 
-		case logrusEntry.CanHandle(lineData) && logfmt.Parse(lineData, true, true, logrusEntry.visit):
-			dst.Write(logrusEntry.Prettify(opts.SkipUnchanged && lastLogrus))
-			lastLogrus = true
+	// the label of the source of the lines, written before them
+	source []byte
 
-		default:
-			lastLogrus = false
-			lastJSON = false
-			lastJournalJSON = false
-			dst.Write(lineData)
-		}
-		dst.Write(eol[:])
+	// the lines written, to tell repeats of them
+	repeats *repeats
+	// the time of the last line written, to tell gaps, and whether it was
+	// logged rather than read
+	lastTime       time.Time
+	lastTimeLogged bool
+	// the time of the last line written that had one, to order lines by
+	lastLogged time.Time
+	// the time and the level of the last line written, kept for
+	// ScannerParallel to draw gaps and sample lines once back in order
+	keepWritten  bool
+	writtenTime  time.Time
+	writtenLevel string
 
+	// the line held between match and write, and that line with its
+	// escape sequences when they were stripped from lineData
+	rawData  []byte
+	lineData []byte
+	ansiData []byte
+	format   string
+
+	// where continuation lines go when folding them, and whether they are
+	// those of a Go stack trace
+	fold    int
+	goTrace bool
+
+	// the title of the group of the CI log the lines are in
+	ciGroup string
+
+	// with context lines, the lines held back in case one that matches
+	// follows them, how many more lines to write after the last match,
+	// whether lines were left out since the last one written, and whether
+	// the line held is written dimmed as context
+	before     [][]byte
+	after      int
+	contextGap bool
+	dim        bool
+}
+
+func newLineHandler(opts *HandlerOptions) *lineHandler {
+	opts.compileKeyPatterns()
+	opts.resetClock()
+	return &lineHandler{
+		opts:             opts,
+		logrusEntry:      LogrusHandler{Opts: opts},
+		jsonEntry:        JSONHandler{Opts: opts},
+		journalJSONEntry: JournalJSONHandler{Opts: opts},
+		gelfEntry:        GELFHandler{Opts: opts},
+		bunyanEntry:      BunyanHandler{Opts: opts},
+		klogEntry:        KlogHandler{Opts: opts},
+		accessLogEntry:   AccessLogHandler{Opts: opts},
+		syslogEntry:      SyslogHandler{Opts: opts},
+		handlers:         registeredHandlers(opts.DisabledHandlers),
+		renderer:         NewRenderer(opts),
+		matched:          -1,
+		dominant:         -1,
+		repeats:          &repeats{},
+	}
+}
+
+// done tells if as many lines as opts.MaxLines were printed already.
+func (lh *lineHandler) done() bool {
+	return lh.opts.MaxLines > 0 && lh.printed >= lh.opts.SkipLines+lh.opts.MaxLines
+}
+
+// handle writes the prettified rawData to dst, or rawData itself if no
+// handler recognized it. Nothing is written if the line is filtered out.
+// When folding, a line that continues the one before it is written under
+// it instead. It reports whether a handler was used.
+func (lh *lineHandler) handle(dst io.Writer, rawData []byte) bool {
+	if lh.opts.Controls != nil {
+		lh.opts.Controls.apply(lh.opts)
+	}
+	lh.lines++
+	if lh.opts.Metrics != nil {
+		lh.opts.Metrics.read(rawData)
+	}
+	// the stack traces of Java exceptions are always folded under log4j
+	// lines, as they are logged on the lines after them
+	if (lh.opts.FoldMultiline || lh.format == "log4j") && lh.continues(dst, rawData) {
+		return false
+	}
+	lh.match(rawData)
+	if lh.opts.Metrics != nil {
+		lh.opts.Metrics.matched(lh)
+	}
+	if lh.opts.Unparsed != nil && lh.format == rawFormat && len(bytes.TrimSpace(lh.lineData)) > 0 {
+		lh.opts.Unparsed(lh.lines, rawData)
+	}
+	if !lh.keep() {
+		lh.drop()
+		lh.fold = foldDiscard
+		return false
+	}
+	switch opts := lh.opts; {
+	case opts.filterContext():
+		return lh.writeContext(dst, rawData, lh.matchFilters(), opts.ContextBefore, opts.ContextAfter, true)
+	case opts.grepping() && opts.GrepContext > 0:
+		return lh.writeContext(dst, rawData, lh.matchGrep(), opts.GrepContext, opts.GrepContext, false)
+	}
+	return lh.write(dst)
+}
+
+// copyRaw writes rawData out to RawCopy, if set. It is called with the
+// lines as they were read, before they are handled.
+func (h *HandlerOptions) copyRaw(rawData []byte) {
+	if h.RawCopy == nil {
+		return
+	}
+	h.RawCopy.Write(rawData)
+	h.RawCopy.Write(eol[:])
+}
+
+// match finds the handler that recognizes rawData, and holds its event
+// until the next call to write.
+func (lh *lineHandler) match(rawData []byte) string {
+	lh.rawData = rawData
+
+	// remove that pesky syslog crap
+	lh.lineData = bytes.TrimPrefix(rawData, []byte("@cee: "))
+	// the colors of programs that color their output would get in the way
+	// of telling its format
+	lh.ansiData = nil
+	if bytes.IndexByte(lh.lineData, 0x1b) >= 0 {
+		lh.ansiData = lh.lineData
+		lh.lineData = ansiEscape.ReplaceAll(lh.lineData, nil)
+	}
+	env := lh.unwrap()
+	lh.matchHandlers()
+	if env != nil && env.original != nil && lh.format == rawFormat {
+		// the line is left in its envelope after all
+		lh.lineData, env = env.original, nil
+		lh.matchHandlers()
+	}
+	if env != nil {
+		lh.applyEnvelope(env)
+	}
+	lh.ev.Format = lh.format
+	lh.detect()
+	return lh.format
+}
+
+// matchHandlers runs the line held by lh through the handlers, until one
+// recognizes it. They are always tried in order, even once the format of
+// the stream is detected, for the more specific ones, like bunyan, to get
+// their lines before the more general ones, like json.
+func (lh *lineHandler) matchHandlers() {
+	lh.format = rawFormat
+	lh.matched = -1
+	for i := range lh.handlers {
+		if lh.tryHandler(i) {
+			return
+		}
 	}
+	lh.ev = Event{Level: UnknownLevel, Msg: string(lh.lineData)}
+}
+
+// builtinEntry is a built-in handler, which holds the line it parsed until
+// its event is taken.
+type builtinEntry interface {
+	event() Event
+	clear()
+}
 
-	switch err := in.Err(); err {
-	case nil, io.EOF:
+// heldBy is entry if it parsed the line, as ok tells, or nil.
+func heldBy(entry builtinEntry, ok bool) builtinEntry {
+	if !ok {
 		return nil
+	}
+	return entry
+}
+
+// tryHandler runs the line held by lh through the i-th handler, and tells
+// if it recognized it.
+func (lh *lineHandler) tryHandler(i int) bool {
+	entry := lh.handlers[i]
+	if entry.builtin != nil {
+		format, held := entry.builtin(lh)
+		if held == nil {
+			return false
+		}
+		// the handler is cleared at once, for the next line it parses
+		lh.ev = held.event()
+		held.clear()
+		lh.format = format
+	} else {
+		ev, ok := entry.handler.TryHandle(lh.lineData)
+		if !ok {
+			return false
+		}
+		ev.Level = lh.opts.mapLevel(ev.Level, normalizeLevel)
+		lh.ev, lh.format = ev, entry.name
+	}
+	lh.matched = i
+	return true
+}
+
+// time is the time of the line held since the last call to match, if it
+// has one.
+func (lh *lineHandler) time() (time.Time, bool) {
+	return lh.ev.Time, !lh.ev.Time.IsZero()
+}
+
+// fields are the fields and the message of the line held since the last
+// call to match.
+func (lh *lineHandler) fields() (map[string]string, string) {
+	return lh.ev.Fields, lh.ev.Msg
+}
+
+// level is the normalized level of the line held since the last call to
+// match, or UnknownLevel if it can't be told.
+func (lh *lineHandler) level() string {
+	return lh.ev.Level
+}
+
+// setMissingLevel gives the normalized level to the line held, if its own
+// can't be told.
+func (lh *lineHandler) setMissingLevel(level string) {
+	if level != "" && lh.ev.Level == UnknownLevel {
+		lh.ev.Level = level
+	}
+}
+
+// setMissingFields gives the fields to the line held, for those it doesn't
+// have already.
+func (lh *lineHandler) setMissingFields(fields map[string]string) {
+	for k, v := range fields {
+		if lh.ev.Fields == nil {
+			lh.ev.Fields = make(map[string]string, len(fields))
+		}
+		if _, ok := lh.ev.Fields[k]; !ok {
+			lh.ev.Fields[k] = v
+		}
+	}
+}
+
+// write prettifies the line held since the last call to match onto dst,
+// or renders it as Output tells. It reports whether a handler was used.
+func (lh *lineHandler) write(dst io.Writer) bool {
+	opts := lh.opts
+	if opts.Stats != nil {
+		opts.Stats.add(lh.format, lh.ev)
+	}
+	if opts.Watch != nil {
+		opts.Watch.add(lh.ev)
+	}
+	if lh.collapse() {
+		return lh.format != rawFormat
+	}
+	// the line held is rendered before it is written
+	logged, ok := lh.time()
+	if ok {
+		lh.lastLogged = logged
+	}
+	if lh.keepWritten {
+		lh.writtenTime, lh.writtenLevel = logged, lh.level()
+	}
+
+	var out []byte
+	pretty := opts.Template == nil && (opts.Output == "" || opts.Output == OutputPretty)
+	var gutter []byte
+	if pretty && opts.GutterKey != "" {
+		gutter = lh.gutter()
+	}
+	switch {
+	case opts.Template != nil:
+		out = opts.executeTemplate(lh.event())
+	case opts.Output == OutputJSON:
+		out = marshalEvent(lh.event())
+	case opts.Output == OutputLogfmt:
+		out = marshalLogfmt(lh.event())
+	case opts.Output == OutputRaw:
+		out = lh.rawData
 	default:
-		return err
+		out = lh.prettify()
+		if lh.dim {
+			out = []byte(opts.paint(contextColor, plainText(string(out))))
+		}
+	}
+	handled := lh.format != rawFormat
+
+	lh.printed++
+	if lh.printed <= opts.SkipLines {
+		lh.fold = foldDiscard
+		return handled
+	}
+	lh.fold = foldWrite
+	lh.writeRepeats(dst)
+	lh.writeGap(dst, logged)
+
+	dst.Write(gutter)
+	if pretty && lh.source != nil {
+		dst.Write(lh.source)
+	}
+	if pretty && opts.ShowHandler {
+		dst.Write(formatTag(lh.format))
+	}
+	dst.Write(out)
+	dst.Write(eol[:])
+
+	if pretty && handled && opts.AppendRaw {
+		dst.Write([]byte(opts.paint(opts.RawColor, string(lh.rawData))))
+		dst.Write(eol[:])
+	}
+	if opts.Written != nil {
+		line := out
+		if pretty {
+			line = append(append(gutter, lh.source...), out...)
+		}
+		ev := lh.event()
+		ev.Format = lh.format
+		opts.Written(ev, line, lh.rawData)
+	}
+	return handled
+}
+
+// prettify renders the event of the line held since the last call to
+// match, or the line as is if no handler recognized it.
+func (lh *lineHandler) prettify() []byte {
+	opts := lh.opts
+	skipUnchanged := opts.SkipUnchanged && lh.lastEvent.Format == lh.format
+	lh.lastEvent = lh.ev
+	if lh.format != rawFormat {
+		return lh.renderer.Render(lh.ev, skipUnchanged)
+	}
+	if lh.ansiData != nil && !opts.StripInputANSI && !isBinary(lh.lineData) {
+		return lh.ansiData
 	}
+	return opts.binaryLine(lh.lineData)
 }
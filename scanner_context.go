@@ -0,0 +1,127 @@
+package humanlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// flusher is implemented by writers that buffer, like bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// ScannerContext is like Scanner, but stops when ctx is done. When
+// opts.FlushInterval is set and src goes idle for that long, whatever
+// partial line was read so far is written out as is, and dst is flushed
+// if it can be.
+//
+// A read that is blocked on src when ctx is done is abandoned.
+func ScannerContext(ctx context.Context, src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	type chunk struct {
+		data []byte
+		err  error
+	}
+	chunks := make(chan chunk)
+	go func() {
+		for {
+			buf := make([]byte, 32*1024)
+			n, err := src.Read(buf)
+			select {
+			case chunks <- chunk{data: buf[:n], err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var idle <-chan time.Time
+	var timer *time.Timer
+	if opts.FlushInterval > 0 {
+		timer = time.NewTimer(opts.FlushInterval)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
+	lh := newLineHandler(opts)
+
+	var (
+		pending []byte
+		// how much of the pending line was already written out on idle
+		flushed int
+	)
+	writeLine := func(line []byte) {
+		opts.copyRaw(line)
+		line = truncateLine(trimCR(line), opts.maxLineLength())
+		if flushed == 0 {
+			lh.handle(dst, line)
+			return
+		}
+		// the start of this line is already out there, no way to
+		// prettify it anymore
+		if flushed < len(line) {
+			dst.Write(line[flushed:])
+		}
+		dst.Write(eol[:])
+		flushed = 0
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-idle:
+			if len(pending) > flushed {
+				dst.Write(pending[flushed:])
+				flushed = len(pending)
+			}
+			if f, ok := dst.(flusher); ok {
+				_ = f.Flush()
+			}
+			timer.Reset(opts.FlushInterval)
+
+		case c := <-chunks:
+			pending = append(pending, c.data...)
+			for {
+				i := bytes.IndexByte(pending, '\n')
+				if i < 0 {
+					break
+				}
+				writeLine(pending[:i])
+				pending = pending[i+1:]
+				if lh.done() {
+					return nil
+				}
+			}
+			// don't hold on to the memory of long gone lines
+			pending = append([]byte(nil), pending...)
+
+			if timer != nil {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(opts.FlushInterval)
+			}
+
+			switch c.err {
+			case nil:
+			case io.EOF:
+				if len(pending) > 0 {
+					writeLine(pending)
+				}
+				lh.finish(dst)
+				return nil
+			default:
+				return c.err
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package humanlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestScannerContextFlushesIdlePartialLine(t *testing.T) {
+	opts := *DefaultOptions
+	opts.FlushInterval = 10 * time.Millisecond
+
+	pr, pw := io.Pipe()
+	dst := &syncBuffer{}
+	errc := make(chan error, 1)
+	go func() { errc <- ScannerContext(context.Background(), pr, dst, &opts) }()
+
+	_, _ = pw.Write([]byte(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"whole"}` + "\nwaiting for"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.HasSuffix(dst.String(), "waiting for") {
+		if time.Now().After(deadline) {
+			t.Fatalf("partial line never flushed, got %q", dst.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !strings.Contains(dst.String(), "|INFO| whole") {
+		t.Fatalf("want complete line to be prettified, got %q", dst.String())
+	}
+
+	_, _ = pw.Write([]byte(" something\nlast"))
+	_ = pw.Close()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(dst.String(), "\nwaiting for something\nlast\n") {
+		t.Fatalf("want the rest of the lines, got %q", dst.String())
+	}
+}
+
+func TestScannerContextCancel(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- ScannerContext(ctx, pr, &syncBuffer{}, DefaultOptions) }()
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("want %v, got %v", context.Canceled, err)
+	}
+}
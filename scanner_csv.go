@@ -0,0 +1,64 @@
+package humanlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// scanCSV is Scanner for a src made of CSV records, one per entry, like
+// the logs of batch jobs or the exports of databases. Columns are named
+// by CSVColumns, or else by the first record.
+//
+// Every record is given to the JSON handler as an object of its columns,
+// so that TimeFields, MessageFields and LevelFields tell which hold the time,
+// the message and the level of the entry when they aren't named like
+// usual. Empty columns are left out.
+func scanCSV(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	in := csv.NewReader(src)
+	if opts.CSVComma != 0 {
+		in.Comma = opts.CSVComma
+	}
+	if in.Comma == '\t' {
+		// TSV doesn't quote its values
+		in.LazyQuotes = true
+	}
+	in.FieldsPerRecord = -1
+	in.ReuseRecord = true
+
+	lh := newLineHandler(opts)
+	defer lh.finish(dst)
+	columns := opts.CSVColumns
+	for !lh.done() {
+		record, err := in.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if columns == nil {
+			columns = append([]string(nil), record...)
+			continue
+		}
+		entry := make(map[string]string, len(record))
+		for i, val := range record {
+			if val == "" {
+				continue
+			}
+			if i < len(columns) {
+				entry[columns[i]] = val
+			} else {
+				entry[fmt.Sprintf("column%d", i+1)] = val
+			}
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		opts.copyRaw(line)
+		lh.handle(dst, line)
+	}
+	return nil
+}
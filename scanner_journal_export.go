@@ -0,0 +1,83 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// scanJournalExport is Scanner for a src in the export format of journald,
+// as produced by `journalctl -o export`. Entries are made of `KEY=value`
+// lines and end with an empty line. Fields that may hold anything, like
+// binary data or newlines, come as `KEY` on its own line, followed by the
+// length of the value as a little endian uint64, the value and a newline.
+//
+// Every entry is given to the journal handler as if it came from
+// `journalctl -o json`.
+func scanJournalExport(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	in := bufio.NewReader(src)
+	lh := newLineHandler(opts)
+	defer lh.finish(dst)
+	entry := make(map[string]string)
+	flush := func() error {
+		if len(entry) == 0 {
+			return nil
+		}
+		if _, ok := entry["_SOURCE_REALTIME_TIMESTAMP"]; !ok {
+			if ts, ok := entry["__REALTIME_TIMESTAMP"]; ok {
+				entry["_SOURCE_REALTIME_TIMESTAMP"] = ts
+			}
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		opts.copyRaw(line)
+		lh.handle(dst, line)
+		entry = make(map[string]string)
+		return nil
+	}
+
+	for !lh.done() {
+		line, err := in.ReadBytes('\n')
+		switch {
+		case err == io.EOF:
+			if len(line) > 0 {
+				return fmt.Errorf("truncated journal export entry")
+			}
+			return flush()
+		case err != nil:
+			return err
+		}
+		line = line[:len(line)-1]
+
+		if len(line) == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if eq := bytes.IndexByte(line, '='); eq >= 0 {
+			entry[string(line[:eq])] = string(line[eq+1:])
+			continue
+		}
+
+		// a binary safe field
+		var size uint64
+		if err := binary.Read(in, binary.LittleEndian, &size); err != nil {
+			return fmt.Errorf("reading size of journal export field %q: %v", line, err)
+		}
+		val := make([]byte, size+1)
+		if _, err := io.ReadFull(in, val); err != nil {
+			return fmt.Errorf("reading journal export field %q: %v", line, err)
+		}
+		if val[size] != '\n' {
+			return fmt.Errorf("journal export field %q isn't followed by a newline", line)
+		}
+		entry[string(line)] = string(val[:size])
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// scanJSONArray is Scanner for a src that is a single JSON array of log
+// entries, as produced by `jq -s` for instance. The entries are decoded
+// one at a time, so the array is never held in memory all at once.
+func scanJSONArray(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	lh := newLineHandler(opts)
+	defer lh.finish(dst)
+	return eachJSONArrayEntry(src, func(line []byte) bool {
+		opts.copyRaw(line)
+		lh.handle(dst, line)
+		return !lh.done()
+	})
+}
+
+// eachJSONArrayEntry calls fn with each entry of the JSON array of src,
+// compacted on a single line, until it returns false.
+func eachJSONArrayEntry(src io.Reader, fn func(line []byte) bool) error {
+	dec := json.NewDecoder(src)
+	tok, err := dec.Token()
+	switch {
+	case err == io.EOF:
+		return nil
+	case err != nil:
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected a JSON array, got %v", tok)
+	}
+
+	line := bytes.NewBuffer(nil)
+	for dec.More() {
+		var entry json.RawMessage
+		if err := dec.Decode(&entry); err != nil {
+			return err
+		}
+		line.Reset()
+		if err := json.Compact(line, entry); err != nil {
+			return err
+		}
+		if !fn(line.Bytes()) {
+			return nil
+		}
+	}
+	// the closing bracket
+	_, err = dec.Token()
+	return err
+}
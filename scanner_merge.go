@@ -0,0 +1,136 @@
+package humanlog
+
+import (
+	"container/heap"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// ScannerMerge is like Scanner, but reads from many sources at once and
+// interleaves their lines in chronological order onto dst.
+//
+// Each source is expected to be in chronological order. Lines that have
+// no timestamp are written right after the line that preceded them in
+// their source. Lines are labelled with the name of their source when
+// SourceNames are set.
+//
+// GrepContext, ContextBefore and ContextAfter are not available in this
+// mode and are ignored.
+func ScannerMerge(srcs []io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	// every source has its own line handler, so limits are enforced here
+	limitOpts := *opts
+	limitOpts.SkipLines, limitOpts.MaxLines = 0, 0
+	limitOpts.GrepContext = 0
+	limitOpts.ContextBefore, limitOpts.ContextAfter = 0, 0
+	var printed uint64
+	// repeats are those of the lines merged, whatever their source
+	shared := &repeats{}
+
+	sources := make(mergeHeap, 0, len(srcs))
+	for i, src := range srcs {
+		in := opts.lineReader(src)
+		ms := &mergeSource{
+			index: i,
+			in:    in,
+			lh:    newLineHandler(&limitOpts),
+		}
+		ms.lh.source = limitOpts.sourceLabel(i)
+		ms.lh.repeats = shared
+		ok, err := ms.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			sources = append(sources, ms)
+		}
+	}
+	heap.Init(&sources)
+
+	for sources.Len() > 0 {
+		if opts.MaxLines > 0 && printed >= opts.SkipLines+opts.MaxLines {
+			return nil
+		}
+		ms := sources[0]
+		if printed > opts.SkipLines && ms.lh.collapse() {
+			if err := sources.advance(); err != nil {
+				return err
+			}
+			continue
+		}
+		printed++
+		if printed <= opts.SkipLines {
+			ms.lh.write(ioutil.Discard)
+		} else {
+			ms.lh.write(dst)
+		}
+
+		if err := sources.advance(); err != nil {
+			return err
+		}
+	}
+	shared.finish(dst, &limitOpts)
+	return nil
+}
+
+// advance reads the next line of the first source, dropping the source if
+// it has no more.
+func (h *mergeHeap) advance() error {
+	ok, err := (*h)[0].next()
+	if err != nil {
+		return err
+	}
+	if ok {
+		heap.Fix(h, 0)
+	} else {
+		heap.Pop(h)
+	}
+	return nil
+}
+
+type mergeSource struct {
+	index int
+	in    *lineReader
+	lh    *lineHandler
+
+	// time of the pending line, or of the last timestamped line of this
+	// source if the pending one has none
+	time time.Time
+}
+
+// next reads the next line of the source, reporting whether there was one.
+// Lines that are filtered out are skipped.
+func (ms *mergeSource) next() (bool, error) {
+	for ms.in.Scan() {
+		ms.lh.opts.copyRaw(ms.in.Raw())
+		ms.lh.match(ms.in.Bytes())
+		if !ms.lh.keep() {
+			ms.lh.drop()
+			continue
+		}
+		if t, ok := ms.lh.time(); ok {
+			ms.time = t
+		}
+		return true, nil
+	}
+	return false, ms.in.Err()
+}
+
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].time.Equal(h[j].time) {
+		return h[i].index < h[j].index
+	}
+	return h[i].time.Before(h[j].time)
+}
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeSource)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,41 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestScannerMerge(t *testing.T) {
+	a := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:01Z","level":"info","msg":"a1"}`,
+		`no time a1`,
+		`{"time":"2018-10-24T08:00:03Z","level":"info","msg":"a3"}`,
+		`{"time":"2018-10-24T08:00:05Z","level":"info","msg":"a5"}`,
+	}, "\n")
+	b := strings.Join([]string{
+		`no time b0`,
+		`time="2018-10-24T08:00:02Z" level=info msg="b2"`,
+		`time="2018-10-24T08:00:04Z" level=info msg="b4"`,
+		`time="2018-10-24T08:00:06Z" level=info msg="b6"`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	dst := bytes.NewBuffer(nil)
+	err := ScannerMerge([]io.Reader{strings.NewReader(a), strings.NewReader(b)}, dst, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	want := []string{"b0", "a1", "no time a1", "b2", "a3", "b4", "a5", "b6"}
+	if len(lines) != len(want) {
+		t.Fatalf("want %d lines, got %d: %q", len(want), len(lines), lines)
+	}
+	for i, w := range want {
+		if !strings.Contains(lines[i], w) {
+			t.Fatalf("want line %d to contain %q, got %q", i, w, lines[i])
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package humanlog
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// `# User@Host: app[app] @ localhost [127.0.0.1]  Id:    42`
+	mysqlUserHost = regexp.MustCompile(`^# User@Host: (\S*)\[[^\]]*\] @ (\S*) \[([^\]]*)\](?:\s+Id:\s+(\d+))?`)
+	// `Query_time: 1.234567`, in the other comments of an entry
+	mysqlAttribute = regexp.MustCompile(`(\w+): (\S+)`)
+	mysqlTimestamp = regexp.MustCompile(`^SET timestamp=(\d+);$`)
+	mysqlUse       = regexp.MustCompile(`^use (\S+);$`)
+)
+
+// mysqlOldTimeLayout is the layout of `# Time:` before MySQL 5.7, in local
+// time, once the padding of its hour is removed.
+const mysqlOldTimeLayout = "060102 15:04:05"
+
+// scanMySQLSlowLog is Scanner for a src in the format of the slow query
+// log of MySQL. Entries start with comments, like
+//
+//	# Time: 2024-01-02T03:04:05.123456Z
+//	# User@Host: app[app] @ localhost [127.0.0.1]  Id:    42
+//	# Query_time: 1.234567  Lock_time: 0.000123 Rows_sent: 10  Rows_examined: 100000
+//
+// followed by the statements of the query. The lines the server writes when
+// it starts are skipped.
+//
+// Every entry is given to the JSON handler as one line, with its statements
+// as the message and the times and counts of its comments as fields.
+func scanMySQLSlowLog(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	in := newLineReader(src, opts.maxLineLength())
+	lh := newLineHandler(opts)
+	defer lh.finish(dst)
+	var (
+		entry map[string]interface{}
+		sql   []string
+	)
+	flush := func() error {
+		if entry == nil {
+			return nil
+		}
+		entry["msg"] = strings.Join(sql, " ")
+		if _, ok := entry["level"]; !ok {
+			entry["level"] = InfoLevel
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		opts.copyRaw(line)
+		lh.handle(dst, line)
+		entry, sql = nil, nil
+		return nil
+	}
+
+	for !lh.done() && in.Scan() {
+		line := strings.TrimSpace(string(in.Bytes()))
+		if strings.HasPrefix(line, "# ") {
+			// the comments of the next entry
+			if len(sql) > 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if entry == nil {
+				entry = make(map[string]interface{})
+			}
+			parseMySQLComment(entry, line)
+			continue
+		}
+		if entry == nil || line == "" {
+			// the lines written when the server starts
+			continue
+		}
+		if m := mysqlTimestamp.FindStringSubmatch(line); m != nil {
+			if _, ok := entry["time"]; !ok {
+				sec, _ := strconv.ParseInt(m[1], 10, 64)
+				entry["time"] = time.Unix(sec, 0).UTC().Format(time.RFC3339Nano)
+			}
+			continue
+		}
+		if m := mysqlUse.FindStringSubmatch(line); m != nil && len(sql) == 0 {
+			entry["db"] = m[1]
+			continue
+		}
+		sql = append(sql, line)
+	}
+	if err := in.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// parseMySQLComment puts what a comment of the slow query log tells about
+// its entry into entry.
+func parseMySQLComment(entry map[string]interface{}, line string) {
+	switch {
+	case strings.HasPrefix(line, "# Time: "):
+		stamp := strings.TrimSpace(strings.TrimPrefix(line, "# Time: "))
+		if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+			entry["time"] = t.Format(time.RFC3339Nano)
+		} else if t, err := time.ParseInLocation(mysqlOldTimeLayout, strings.Join(strings.Fields(stamp), " "), time.Local); err == nil {
+			entry["time"] = t.Format(time.RFC3339Nano)
+		}
+	case strings.HasPrefix(line, "# User@Host: "):
+		if m := mysqlUserHost.FindStringSubmatch(line); m != nil {
+			entry["user"] = m[1]
+			if m[2] != "" {
+				entry["host"] = m[2]
+			}
+			if m[3] != "" {
+				entry["ip"] = m[3]
+			}
+			if m[4] != "" {
+				entry["thread_id"] = m[4]
+			}
+		}
+	default:
+		for _, m := range mysqlAttribute.FindAllStringSubmatch(line, -1) {
+			// like Query_time and Rows_sent, shown as the numbers they are
+			if f, err := strconv.ParseFloat(m[2], 64); err == nil && isNumber(m[2]) {
+				entry[strings.ToLower(m[1])] = f
+				continue
+			}
+			entry[strings.ToLower(m[1])] = m[2]
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// linesPerBatch is how many lines are handed to a worker at once, to keep
+// the cost of synchronizing low compared to the cost of prettifying.
+const linesPerBatch = 256
+
+// parallelBatch holds lines one after the other in a single buffer, and
+// so does it with their prettified form, so that both buffers are reused
+// along with the batch.
+type parallelBatch struct {
+	seq uint64
+
+	lines    []byte
+	lineEnds []int
+	out      []byte
+	outEnds  []int
+	// the time and the level of the lines of out, for gaps and sampling
+	outTimes  []time.Time
+	outLevels []string
+}
+
+var batchPool = sync.Pool{New: func() interface{} { return new(parallelBatch) }}
+
+func newParallelBatch(seq uint64) *parallelBatch {
+	batch := batchPool.Get().(*parallelBatch)
+	batch.seq = seq
+	batch.lines, batch.lineEnds = batch.lines[:0], batch.lineEnds[:0]
+	batch.out, batch.outEnds = batch.out[:0], batch.outEnds[:0]
+	batch.outTimes, batch.outLevels = batch.outTimes[:0], batch.outLevels[:0]
+	return batch
+}
+
+// ScannerParallel is like Scanner, but prettifies lines on `workers`
+// goroutines while preserving their order on dst. If `workers` is not
+// positive, GOMAXPROCS workers are used.
+//
+// Since lines aren't prettified in sequence, SkipUnchanged, FoldMultiline,
+// Context lines, CollapseRepeats, Controls and relative times are not
+// available in this mode and are ignored. Gaps and sampling are done once
+// the lines are back in order.
+func ScannerParallel(src io.Reader, dst io.Writer, opts *HandlerOptions, workers int) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// lines are counted once put back in order, not by the workers
+	workerOpts := *opts
+	workerOpts.SkipUnchanged = false
+	workerOpts.FoldMultiline = false
+	workerOpts.TimeMode = TimeModeAbsolute
+	workerOpts.GrepContext = 0
+	workerOpts.ContextBefore, workerOpts.ContextAfter = 0, 0
+	workerOpts.SkipLines, workerOpts.MaxLines = 0, 0
+	workerOpts.CollapseRepeats = false
+	workerOpts.Controls = nil
+	// gaps are drawn and lines sampled once back in order
+	workerOpts.GapThreshold = 0
+	workerOpts.SampleRate = 0
+	// lines are copied as they are read, not by the workers
+	workerOpts.RawCopy = nil
+	workerOpts.compileKeyPatterns()
+
+	var (
+		batches = make(chan *parallelBatch, workers)
+		results = make(chan *parallelBatch, workers)
+		errc    = make(chan error, 1)
+		stop    = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	defer close(stop)
+
+	go func() {
+		defer close(batches)
+		in := opts.lineReader(src)
+
+		var seq uint64
+		batch := newParallelBatch(seq)
+		for in.Scan() {
+			opts.copyRaw(in.Raw())
+			batch.lines = append(batch.lines, in.Bytes()...)
+			batch.lineEnds = append(batch.lineEnds, len(batch.lines))
+			if len(batch.lineEnds) == linesPerBatch {
+				select {
+				case batches <- batch:
+				case <-stop:
+					return
+				}
+				seq++
+				batch = newParallelBatch(seq)
+			}
+		}
+		if len(batch.lineEnds) != 0 {
+			select {
+			case batches <- batch:
+			case <-stop:
+				return
+			}
+		}
+		switch err := in.Err(); err {
+		case nil, io.EOF:
+			errc <- nil
+		default:
+			errc <- err
+		}
+	}()
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			lh := newLineHandler(&workerOpts)
+			lh.keepWritten = true
+			buf := bytes.NewBuffer(nil)
+			for batch := range batches {
+				// numbering the lines as if they were all read by lh
+				lh.lines = batch.seq * linesPerBatch
+				start := 0
+				for _, end := range batch.lineEnds {
+					buf.Reset()
+					lh.handle(buf, batch.lines[start:end])
+					start = end
+					if buf.Len() == 0 {
+						// filtered out
+						continue
+					}
+					batch.out = append(batch.out, buf.Bytes()...)
+					batch.outEnds = append(batch.outEnds, len(batch.out))
+					batch.outTimes = append(batch.outTimes, lh.writtenTime)
+					batch.outLevels = append(batch.outLevels, lh.writtenLevel)
+				}
+				select {
+				case results <- batch:
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// reorder the batches as they come back from the workers
+	var (
+		next    uint64
+		printed uint64
+		pending = make(map[uint64]*parallelBatch)
+		// only for the gaps between the lines and sampling them
+		ordered = &lineHandler{opts: opts}
+	)
+	for batch := range results {
+		pending[batch.seq] = batch
+		for {
+			batch, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			start := 0
+			for i, end := range batch.outEnds {
+				if !ordered.sampleLevel(batch.outLevels[i]) {
+					start = end
+					continue
+				}
+				if opts.MaxLines > 0 && printed >= opts.SkipLines+opts.MaxLines {
+					return nil
+				}
+				printed++
+				if printed > opts.SkipLines {
+					ordered.writeGap(dst, batch.outTimes[i])
+					dst.Write(batch.out[start:end])
+				}
+				start = end
+			}
+			batchPool.Put(batch)
+			next++
+		}
+	}
+
+	return <-errc
+}
@@ -0,0 +1,141 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func mixedFixture(lines int) []byte {
+	buf := bytes.NewBuffer(nil)
+	for i := 0; i < lines; i++ {
+		switch i % 4 {
+		case 0:
+			fmt.Fprintf(buf, `{"time":"2018-10-24T08:19:50Z","level":"info","msg":"request %d","status":200,"path":"/v1/things/%d"}`+"\n", i, i)
+		case 1:
+			fmt.Fprintf(buf, `time="2018-10-24T08:19:50Z" level=warn msg="logrus %d" attempt=%d`+"\n", i, i)
+		case 2:
+			fmt.Fprintf(buf, `{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"3","MESSAGE":"journal %d"}`+"\n", i)
+		default:
+			fmt.Fprintf(buf, "plain line %d\n", i)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestScannerParallelPreservesOrder(t *testing.T) {
+	input := mixedFixture(10*linesPerBatch + 7)
+
+	opts := *DefaultOptions
+	want := bytes.NewBuffer(nil)
+	if err := Scanner(bytes.NewReader(input), want, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{1, 3, 8} {
+		got := bytes.NewBuffer(nil)
+		if err := ScannerParallel(bytes.NewReader(input), got, &opts, workers); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want.Bytes(), got.Bytes()) {
+			t.Fatalf("with %d workers, output differs from the serial scanner", workers)
+		}
+	}
+}
+
+func TestScannerParallelGapsAndSampling(t *testing.T) {
+	start := time.Date(2018, 10, 24, 8, 19, 50, 0, time.UTC)
+	buf := bytes.NewBuffer(nil)
+	for i := 0; i < 20*linesPerBatch; i++ {
+		ts := start.Add(time.Duration(i) * time.Second)
+		if i >= 10*linesPerBatch+3 {
+			// a lull of ten minutes
+			ts = ts.Add(10 * time.Minute)
+		}
+		level := "info"
+		if i%97 == 0 {
+			level = "error"
+		}
+		fmt.Fprintf(buf, `{"time":%q,"level":%q,"msg":"request %d"}`+"\n", ts.Format(time.RFC3339), level, i)
+	}
+	input := buf.Bytes()
+
+	for name, set := range map[string]func(*HandlerOptions){
+		"gaps":     func(opts *HandlerOptions) { opts.GapThreshold = time.Minute },
+		"sampling": func(opts *HandlerOptions) { opts.SampleRate, opts.SampleKeepLevel = 10, ErrorLevel },
+		"both": func(opts *HandlerOptions) {
+			opts.GapThreshold = time.Minute
+			opts.SampleRate = 7
+		},
+	} {
+		opts := *DefaultOptions
+		set(&opts)
+		want := bytes.NewBuffer(nil)
+		if err := Scanner(bytes.NewReader(input), want, &opts); err != nil {
+			t.Fatal(err)
+		}
+		if name != "sampling" && bytes.Count(want.Bytes(), []byte("── 10m")) != 1 {
+			t.Fatalf("%s: want a single rule from the serial scanner, got %q", name, want.String())
+		}
+		for _, workers := range []int{1, 3, 8} {
+			got := bytes.NewBuffer(nil)
+			if err := ScannerParallel(bytes.NewReader(input), got, &opts, workers); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(want.Bytes(), got.Bytes()) {
+				t.Fatalf("%s: with %d workers, output differs from the serial scanner", name, workers)
+			}
+		}
+	}
+}
+
+func jsonFixture(lines int) []byte {
+	buf := bytes.NewBuffer(nil)
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(buf, `{"time":"2018-10-24T08:19:50Z","level":"info","msg":"request %d","status":200,"path":"/v1/things/%d","duration":0.%03d,"user":"someone"}`+"\n", i, i, i%1000)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkScanner(b *testing.B) {
+	input := jsonFixture(20000)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Scanner(bytes.NewReader(input), ioutil.Discard, DefaultOptions); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScannerParallel(b *testing.B) {
+	input := jsonFixture(20000)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ScannerParallel(bytes.NewReader(input), ioutil.Discard, DefaultOptions, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func logfmtFixture(lines int) []byte {
+	buf := bytes.NewBuffer(nil)
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(buf, `time="2018-10-24T08:19:50Z" level=info msg="request %d" status=200 path=/v1/things/%d duration=0.%03d user=someone`+"\n", i, i, i%1000)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkScannerLogfmt(b *testing.B) {
+	input := logfmtFixture(20000)
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Scanner(bytes.NewReader(input), ioutil.Discard, DefaultOptions); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,613 @@
+package humanlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+func TestScannerAppendRaw(t *testing.T) {
+	handled := `{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello"}`
+	plain := "just some text"
+
+	opts := *DefaultOptions
+	opts.AppendRaw = true
+
+	dst := bytes.NewBuffer(nil)
+	src := strings.NewReader(handled + "\n" + plain + "\n")
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("want 3 lines, got %d: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "hello") || strings.Contains(lines[0], `"msg"`) {
+		t.Fatalf("want prettified line first, got %q", lines[0])
+	}
+	if lines[1] != handled {
+		t.Fatalf("want raw line after the prettified one, got %q", lines[1])
+	}
+	if lines[2] != plain {
+		t.Fatalf("want unhandled line printed once, got %q", lines[2])
+	}
+}
+
+func TestScannerShowHandler(t *testing.T) {
+	opts := *DefaultOptions
+	opts.ShowHandler = true
+
+	src := strings.NewReader(strings.Join([]string{
+		`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"6","MESSAGE":"from journal"}`,
+		`{"version":"1.1","host":"example.org","short_message":"from gelf","level":6}`,
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"from json"}`,
+		`time="2018-10-24T08:19:50Z" level=info msg="from logrus"`,
+		`<14>1 2018-10-24T08:19:50Z host app - - - from syslog`,
+		`from nothing`,
+	}, "\n"))
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+
+	for i, want := range []string{"[journal] ", "[gelf]    ", "[json]    ", "[logrus]  ", "[syslog]  ", "[raw]     from nothing"} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Fatalf("want line %d to start with %q, got %q", i, want, lines[i])
+		}
+	}
+}
+
+func TestScannerSkipUnchanged(t *testing.T) {
+	opts := *DefaultOptions
+	opts.SkipUnchanged = true
+
+	src := strings.NewReader(strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"first","user":"bob"}`,
+		`{"time":"2018-10-24T08:19:51Z","level":"info","msg":"second","user":"bob"}`,
+		`time="2018-10-24T08:19:52Z" level=info msg=third user=bob`,
+		`time="2018-10-24T08:19:53Z" level=info msg=fourth user=bob`,
+		`from nothing`,
+		`time="2018-10-24T08:19:54Z" level=info msg=fifth user=bob`,
+	}, "\n"))
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(src, dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+
+	// fields are only left out when they are those of the line before, in
+	// the same format
+	for i, want := range []bool{true, false, true, false, false, true} {
+		if got := strings.Contains(lines[i], "user="); got != want {
+			t.Fatalf("want the user of line %d shown: %v, got %q", i, want, lines[i])
+		}
+	}
+}
+
+func TestScannerDisableColors(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	src := strings.Join([]string{
+		`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"3","MESSAGE":"from journal","ok":true}`,
+		`{"version":"1.1","host":"example.org","short_message":"from gelf","level":6}`,
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"from json","nothing":null}`,
+		`{"time":"2018-10-24T08:19:50Z","level":"info"}`,
+		`time="2018-10-24T08:19:50Z" level=info msg="from logrus"`,
+		`<14>1 2018-10-24T08:19:50Z host app - - - from syslog`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.AppendRaw = true
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(dst.Bytes(), []byte("\x1b[")) {
+		t.Fatalf("want colors to be enabled for this test, got %q", dst.String())
+	}
+
+	opts.DisableColors = true
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(dst.Bytes(), []byte("\x1b")) {
+		t.Fatalf("want no ANSI escapes, got %q", dst.String())
+	}
+}
+
+func TestScannerLineLimits(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"line %d"}`, i))
+	}
+	src := strings.Join(lines, "\n")
+
+	scanners := map[string]func(src string, dst *bytes.Buffer, opts *HandlerOptions) error{
+		"serial": func(src string, dst *bytes.Buffer, opts *HandlerOptions) error {
+			return Scanner(strings.NewReader(src), dst, opts)
+		},
+		"context": func(src string, dst *bytes.Buffer, opts *HandlerOptions) error {
+			return ScannerContext(context.Background(), strings.NewReader(src), dst, opts)
+		},
+		"parallel": func(src string, dst *bytes.Buffer, opts *HandlerOptions) error {
+			return ScannerParallel(strings.NewReader(src), dst, opts, 3)
+		},
+		"merge": func(src string, dst *bytes.Buffer, opts *HandlerOptions) error {
+			return ScannerMerge([]io.Reader{strings.NewReader(src)}, dst, opts)
+		},
+	}
+
+	for name, scan := range scanners {
+		for _, tt := range []struct {
+			skip, max uint64
+			want      []string
+		}{
+			{max: 3, want: []string{"line 0", "line 1", "line 2"}},
+			{skip: 8, want: []string{"line 8", "line 9"}},
+			{skip: 4, max: 2, want: []string{"line 4", "line 5"}},
+			{skip: 9, max: 5, want: []string{"line 9"}},
+		} {
+			opts := *DefaultOptions
+			opts.SkipLines, opts.MaxLines = tt.skip, tt.max
+			dst := bytes.NewBuffer(nil)
+			if err := scan(src, dst, &opts); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			got := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+			if len(got) != len(tt.want) {
+				t.Fatalf("%s skip=%d max=%d: want %d lines, got %q", name, tt.skip, tt.max, len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Fatalf("%s skip=%d max=%d: want line %d to be %q, got %q", name, tt.skip, tt.max, i, want, got[i])
+				}
+			}
+		}
+	}
+}
+
+func TestScannerJSONArrayInput(t *testing.T) {
+	src := `[
+  {"time": "2018-10-24T08:19:50Z", "level": "info", "msg": "first"},
+  {"_SOURCE_REALTIME_TIMESTAMP": "1540369190466951", "PRIORITY": "4", "MESSAGE": "second"},
+  {
+    "time": "2018-10-24T08:19:52Z",
+    "level": "error",
+    "msg": "third"
+  }
+]`
+
+	opts := *DefaultOptions
+	opts.JSONArrayInput = true
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	want := []string{"|INFO| first", "|WARN| second", "|ERRO| third"}
+	if len(lines) != len(want) {
+		t.Fatalf("want %d lines, got %q", len(want), lines)
+	}
+	for i, w := range want {
+		if !strings.Contains(lines[i], w) {
+			t.Fatalf("want line %d to contain %q, got %q", i, w, lines[i])
+		}
+	}
+
+	if err := Scanner(strings.NewReader(`{"not":"an array"}`), dst, &opts); err == nil {
+		t.Fatal("want an error when the input isn't an array")
+	}
+}
+
+func TestScannerJournalExportInput(t *testing.T) {
+	binaryMessage := "multi\nline"
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len(binaryMessage)))
+
+	src := "__CURSOR=s=1\n" +
+		"__REALTIME_TIMESTAMP=1540369190466951\n" +
+		"PRIORITY=6\n" +
+		"SYSLOG_IDENTIFIER=app\n" +
+		"MESSAGE=first\n" +
+		"\n" +
+		"__CURSOR=s=2\n" +
+		"__REALTIME_TIMESTAMP=1540369191466951\n" +
+		"PRIORITY=3\n" +
+		"MESSAGE\n" + string(size) + binaryMessage + "\n" +
+		"\n"
+
+	opts := *DefaultOptions
+	opts.JournalExportInput = true
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	for _, want := range []string{"|INFO| app first", "|ERRO| multi"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q in output, got %q", want, got)
+		}
+	}
+	if want := time.Unix(1540369190, 466951000).Format(opts.TimeFormat); !strings.Contains(got, want) {
+		t.Fatalf("want the realtime timestamp %q used, got %q", want, got)
+	}
+
+	truncated := "MESSAGE\n" + string(size) + "multi"
+	if err := Scanner(strings.NewReader(truncated), dst, &opts); err == nil {
+		t.Fatal("want an error when a binary field is truncated")
+	}
+}
+
+func TestScannerMySQLSlowLogInput(t *testing.T) {
+	src := strings.Join([]string{
+		`/usr/sbin/mysqld, Version: 8.0.35 (MySQL Community Server - GPL). started with:`,
+		`Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock`,
+		`Time                 Id Command    Argument`,
+		`# Time: 2024-01-02T03:04:05.123456Z`,
+		`# User@Host: app[app] @ localhost [127.0.0.1]  Id:    42`,
+		`# Query_time: 1.234567  Lock_time: 0.000123 Rows_sent: 10  Rows_examined: 100000`,
+		`use shop;`,
+		`SET timestamp=1704164645;`,
+		`SELECT *`,
+		`  FROM orders WHERE total > 100;`,
+		`# User@Host: app[app] @  [10.0.0.7]  Id:    43`,
+		`# Query_time: 2.5  Lock_time: 0.0 Rows_sent: 0  Rows_examined: 5`,
+		`SET timestamp=1704164650;`,
+		`DELETE FROM carts;`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.MySQLSlowLogInput = true
+	opts.Truncates = false
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want an entry per query, got %q", lines)
+	}
+	for _, want := range []string{
+		"|INFO| SELECT * FROM orders WHERE total > 100;", `db="shop"`, `user="app"`, `host="localhost"`,
+		`ip="127.0.0.1"`, `query_time=1.234567`, `lock_time=0.000123`, `rows_examined=100000`,
+		time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Local().Format(opts.TimeFormat),
+	} {
+		if !strings.Contains(lines[0], want) {
+			t.Fatalf("want %q in %q", want, lines[0])
+		}
+	}
+	for _, want := range []string{
+		"|INFO| DELETE FROM carts;", `ip="10.0.0.7"`, `query_time=2.5`, `lock_time=0 `,
+		time.Unix(1704164650, 0).Format(opts.TimeFormat),
+	} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("want %q in %q", want, lines[1])
+		}
+	}
+}
+
+func TestScannerCSVInput(t *testing.T) {
+	src := strings.Join([]string{
+		`started_at,severity,event,job,rows`,
+		`2024-01-02T03:04:05Z,warning,"slow batch, retrying",import,`,
+		`2024-01-02T03:04:06Z,info,done,import,1200,extra`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.CSVInput = true
+	opts.TimeFields = []string{"started_at"}
+	opts.LevelFields = []string{"severity"}
+	opts.MessageFields = []string{"event"}
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want an entry per record, got %q", lines)
+	}
+	for i, want := range [][]string{
+		{"|WARN| slow batch, retrying", `job="import"`, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Local().Format(opts.TimeFormat)},
+		{"|INFO| done", `rows="1200"`, `column6="extra"`},
+	} {
+		for _, w := range want {
+			if !strings.Contains(lines[i], w) {
+				t.Fatalf("want %q in %q", w, lines[i])
+			}
+		}
+	}
+	if strings.Contains(lines[0], "rows=") {
+		t.Fatalf("want empty columns left out, got %q", lines[0])
+	}
+
+	opts.CSVComma = '\t'
+	opts.CSVColumns = []string{"time", "level", "msg"}
+	dst.Reset()
+	if err := Scanner(strings.NewReader("2024-01-02T03:04:05Z\terror\tsaid \"no\""), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := `|ERRO| said "no"`; !strings.Contains(dst.String(), want) {
+		t.Fatalf("want %q in %q", want, dst.String())
+	}
+}
+
+func TestScannerTimeRange(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"info","msg":"first"}`,
+		`{"time":"2018-10-24T09:00:00Z","level":"info","msg":"second"}`,
+		`no timestamp here`,
+		`{"time":"2018-10-24T10:00:00Z","level":"info","msg":"third"}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Since = time.Date(2018, 10, 24, 9, 0, 0, 0, time.UTC)
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	if strings.Contains(got, "first") {
+		t.Fatalf("want lines before Since dropped, got %q", got)
+	}
+	for _, want := range []string{"second", "no timestamp here", "third"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q kept, got %q", want, got)
+		}
+	}
+
+	opts.Until = time.Date(2018, 10, 24, 9, 30, 0, 0, time.UTC)
+	opts.StrictTimeRange = true
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got = dst.String()
+	if strings.Count(got, "\n") != 1 || !strings.Contains(got, "second") {
+		t.Fatalf("want only the second line, got %q", got)
+	}
+}
+
+func TestScannerMinLevel(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"debug","msg":"json debug"}`,
+		`{"time":"2018-10-24T08:00:01Z","level":"warning","msg":"json warning"}`,
+		`time="2018-10-24T08:00:02Z" level=info msg="logrus info"`,
+		`time="2018-10-24T08:00:03Z" level=error msg="logrus error"`,
+		`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190466951","PRIORITY":"2","MESSAGE":"journal crit"}`,
+		`{"v":0,"time":"2018-10-24T08:00:04Z","level":20,"msg":"bunyan debug"}`,
+		`not parsed`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.MinLevel = WarnLevel
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	for _, want := range []string{"json warning", "logrus error", "journal crit", "not parsed"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("want %q kept, got %q", want, got)
+		}
+	}
+	for _, unwanted := range []string{"json debug", "logrus info", "bunyan debug"} {
+		if strings.Contains(got, unwanted) {
+			t.Fatalf("want %q dropped, got %q", unwanted, got)
+		}
+	}
+
+	opts.StrictLevel = true
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.String(); strings.Contains(got, "not parsed") || !strings.Contains(got, "json warning") {
+		t.Fatalf("want lines without a level dropped, got %q", got)
+	}
+}
+
+func TestScannerMessageWidth(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"short","a":1}`,
+		`{"time":"2018-10-24T08:19:51Z","level":"info","msg":"a much much longer message than the other one","b":2}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.MessageWidth = 20
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %q", lines)
+	}
+	col := func(line, key string) int {
+		visible := ansiEscape.ReplaceAllString(line, "")
+		i := strings.Index(visible, key+"=")
+		if i < 0 {
+			t.Fatalf("want %q in %q", key, visible)
+		}
+		return utf8.RuneCountInString(visible[:i])
+	}
+	if a, b := col(lines[0], "a"), col(lines[1], "b"); a != b {
+		t.Fatalf("want the fields to start on the same column, got %d and %d in %q", a, b, lines)
+	}
+	if !strings.Contains(lines[1], "…") {
+		t.Fatalf("want the long message truncated, got %q", lines[1])
+	}
+}
+
+func TestScannerRawCopy(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"debug","msg":"dropped"}`,
+		`time="2018-10-24T08:00:01Z" level=error msg="kept"`,
+		"not parsed \x1b[31m",
+		"with a carriage return\r",
+		"longer than the maximum " + strings.Repeat("x", 100),
+	}, "\n") + "\n"
+
+	scanners := map[string]func(src io.Reader, dst io.Writer, opts *HandlerOptions) error{
+		"sequential": Scanner,
+		"parallel": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			return ScannerParallel(src, dst, opts, 2)
+		},
+		"merge": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			return ScannerMerge([]io.Reader{src}, dst, opts)
+		},
+		"interleave": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			return ScannerInterleave([]io.Reader{src}, dst, opts)
+		},
+		"context": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			return ScannerContext(context.Background(), src, dst, opts)
+		},
+		"writer": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			w := NewWriter(dst, opts)
+			if _, err := io.Copy(w, src); err != nil {
+				return err
+			}
+			return w.Close()
+		},
+	}
+	for name, scan := range scanners {
+		opts := *DefaultOptions
+		opts.MinLevel = WarnLevel
+		opts.MaxLineLength = 80
+		raw := bytes.NewBuffer(nil)
+		opts.RawCopy = raw
+
+		dst := bytes.NewBuffer(nil)
+		if err := scan(strings.NewReader(src), dst, &opts); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(dst.String(), "dropped") {
+			t.Fatalf("%s: want the debug line filtered out, got %q", name, dst.String())
+		}
+		if got := raw.String(); got != src {
+			t.Fatalf("%s: want every line copied untouched\nwant: %q\n got: %q", name, src, got)
+		}
+	}
+}
+
+func TestScannerFinalLineAndCRLF(t *testing.T) {
+	src := "time=\"2018-10-24T08:00:00Z\" level=info msg=first user=bob\r\n" +
+		"time=\"2018-10-24T08:00:01Z\" level=info msg=last user=alice\r\r"
+
+	scanners := map[string]func(src io.Reader, dst io.Writer, opts *HandlerOptions) error{
+		"sequential": Scanner,
+		"parallel": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			return ScannerParallel(src, dst, opts, 2)
+		},
+		"merge": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			return ScannerMerge([]io.Reader{src}, dst, opts)
+		},
+		"context": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			return ScannerContext(context.Background(), src, dst, opts)
+		},
+		"writer": func(src io.Reader, dst io.Writer, opts *HandlerOptions) error {
+			w := NewWriter(dst, opts)
+			if _, err := io.Copy(w, src); err != nil {
+				return err
+			}
+			return w.Close()
+		},
+	}
+	for name, scan := range scanners {
+		opts := *DefaultOptions
+		opts.Output = OutputJSON
+		dst := bytes.NewBuffer(nil)
+		if err := scan(strings.NewReader(src), dst, &opts); err != nil {
+			t.Fatal(err)
+		}
+		got := dst.String()
+		if strings.Contains(got, `\r`) {
+			t.Fatalf("%s: want carriage returns stripped, got %q", name, got)
+		}
+		if !strings.Contains(got, `"user":"bob"`) || !strings.Contains(got, `"user":"alice"`) {
+			t.Fatalf("%s: want both lines, the last one without a newline, got %q", name, got)
+		}
+	}
+}
+
+func TestScannerColoredInput(t *testing.T) {
+	colored := "\x1b[36mtime\x1b[0m=\"2018-10-24T08:19:50Z\" \x1b[36mlevel\x1b[0m=info \x1b[36mmsg\x1b[0m=\"hello\""
+	plain := "\x1b[31mjust some text\x1b[0m"
+
+	for _, strip := range []bool{false, true} {
+		opts := *DefaultOptions
+		opts.StripInputANSI = strip
+
+		dst := bytes.NewBuffer(nil)
+		if err := Scanner(strings.NewReader(colored+"\n"+plain+"\n"), dst, &opts); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("want 2 lines, got %q", lines)
+		}
+		if !strings.Contains(lines[0], "hello") || strings.Contains(lines[0], "msg") {
+			t.Fatalf("want the colored line prettified, got %q", lines[0])
+		}
+		want := plain
+		if strip {
+			want = "just some text"
+		}
+		if lines[1] != want {
+			t.Fatalf("with strip=%v, want %q, got %q", strip, want, lines[1])
+		}
+	}
+}
+
+func TestScannerUnparsed(t *testing.T) {
+	var lines []string
+	for i := 0; i < 600; i++ {
+		lines = append(lines, `{"time":"2018-10-24T08:00:00Z","level":"info","msg":"fine"}`)
+	}
+	lines[1], lines[2], lines[299], lines[599] = "not structured", "", `{"time":`, "neither"
+	src := strings.Join(lines, "\n")
+
+	for _, workers := range []int{0, 3} {
+		var (
+			mu  sync.Mutex
+			got []uint64
+		)
+		opts := *DefaultOptions
+		opts.Unparsed = func(n uint64, line []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, n)
+		}
+		var err error
+		if workers == 0 {
+			err = Scanner(strings.NewReader(src), ioutil.Discard, &opts)
+		} else {
+			err = ScannerParallel(strings.NewReader(src), ioutil.Discard, &opts, workers)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		if want := []uint64{2, 300, 600}; !reflect.DeepEqual(want, got) {
+			t.Errorf("%d workers: want lines %v reported, got %v", workers, want, got)
+		}
+	}
+}
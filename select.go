@@ -0,0 +1,51 @@
+package humanlog
+
+import (
+	"strings"
+)
+
+// selectsKey tells if key is one of Select, or is within one of them once
+// nested objects are flattened.
+func (h *HandlerOptions) selectsKey(key string) bool {
+	for _, path := range h.Select {
+		if key == path || strings.HasPrefix(key, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPaths adds the paths of Select that reach into the nested objects
+// of raw to fields, as keys of their own.
+func (h *HandlerOptions) selectPaths(fields map[string]string, raw map[string]interface{}) {
+	for _, path := range h.Select {
+		if _, ok := fields[path]; ok || !strings.Contains(path, ".") {
+			continue
+		}
+		if val, ok := lookupPath(raw, path); ok {
+			fields[path] = h.formatValue(val)
+		}
+	}
+}
+
+// lookupPath finds the value at a path like `http.request.method` within
+// the nested objects of raw. Keys holding dots themselves are looked up
+// as is first.
+func lookupPath(raw map[string]interface{}, path string) (interface{}, bool) {
+	if val, ok := raw[path]; ok {
+		return val, true
+	}
+	for i := strings.IndexByte(path, '.'); i > 0; {
+		if obj, ok := raw[path[:i]].(map[string]interface{}); ok {
+			if val, ok := lookupPath(obj, path[i+1:]); ok {
+				return val, true
+			}
+		}
+		next := strings.IndexByte(path[i+1:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil, false
+}
@@ -0,0 +1,60 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestScannerSelect(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"info","msg":"served","status":200,"http":{"method":"GET","path":"/","headers":{"a":"b"}},"user":{"id":1}}`,
+		`time="2018-10-24T08:00:01Z" level=info msg=served status=500 http.method=POST other=x`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.Select = []string{"status", "http.method", "user"}
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %q", dst.String())
+	}
+	for _, want := range []string{"status=200", `http.method="GET"`, "user=", "served"} {
+		if !strings.Contains(lines[0], want) {
+			t.Fatalf("want %q in %q", want, lines[0])
+		}
+	}
+	for _, want := range []string{"status=500", "http.method=POST"} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("want %q in %q", want, lines[1])
+		}
+	}
+	for _, line := range lines {
+		for _, unwanted := range []string{"path", "headers", "other"} {
+			if strings.Contains(line, unwanted) {
+				t.Fatalf("want %q hidden in %q", unwanted, line)
+			}
+		}
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	raw := map[string]interface{}{
+		"a":   map[string]interface{}{"b": map[string]interface{}{"c": 1.0}},
+		"x.y": map[string]interface{}{"z": 2.0},
+	}
+	for path, want := range map[string]interface{}{
+		"a.b.c": 1.0,
+		"x.y.z": 2.0,
+	} {
+		if got, ok := lookupPath(raw, path); !ok || got != want {
+			t.Fatalf("want %v at %q, got %v", want, path, got)
+		}
+	}
+	if _, ok := lookupPath(raw, "a.c"); ok {
+		t.Fatal("want a.c missing")
+	}
+}
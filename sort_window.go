@@ -0,0 +1,77 @@
+package humanlog
+
+import (
+	"container/heap"
+	"io"
+	"time"
+)
+
+// sortWindow holds the lines written by ScannerInterleave for a while, to
+// write them in the order of their time rather than in the order they were
+// read in, as long as they weren't read more than the window apart.
+type sortWindow struct {
+	window time.Duration
+	lines  sortHeap
+	// how many lines were held, to keep lines of the same time in order
+	seq uint64
+}
+
+type sortedLine struct {
+	time time.Time
+	seq  uint64
+	// when the line was held, to write it once the window is over
+	held time.Time
+	line []byte
+}
+
+// hold keeps line, logged at t, until the window is over.
+func (s *sortWindow) hold(t time.Time, line []byte, now time.Time) {
+	s.seq++
+	heap.Push(&s.lines, &sortedLine{
+		time: t,
+		seq:  s.seq,
+		held: now,
+		line: append([]byte(nil), line...),
+	})
+}
+
+// flush writes the earliest lines onto dst, for as long as the window of
+// the earliest is over at now. A zero now writes every line.
+func (s *sortWindow) flush(dst io.Writer, now time.Time) {
+	for s.lines.Len() > 0 {
+		first := s.lines[0]
+		if !now.IsZero() && now.Sub(first.held) < s.window {
+			return
+		}
+		heap.Pop(&s.lines)
+		dst.Write(first.line)
+	}
+}
+
+// sortFlushInterval is how often the lines whose window is over are
+// written, as a fraction of the window.
+func sortFlushInterval(window time.Duration) time.Duration {
+	if every := window / 4; every > 10*time.Millisecond {
+		return every
+	}
+	return 10 * time.Millisecond
+}
+
+type sortHeap []*sortedLine
+
+func (h sortHeap) Len() int { return len(h) }
+func (h sortHeap) Less(i, j int) bool {
+	if h[i].time.Equal(h[j].time) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].time.Before(h[j].time)
+}
+func (h sortHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *sortHeap) Push(x interface{}) { *h = append(*h, x.(*sortedLine)) }
+func (h *sortHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
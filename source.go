@@ -0,0 +1,169 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// sourceColors are given to the labels of sources in turn, so that lines
+// of neighbouring sources stand apart.
+var sourceColors = []*color.Color{
+	color.New(color.FgCyan),
+	color.New(color.FgYellow),
+	color.New(color.FgGreen),
+	color.New(color.FgMagenta),
+	color.New(color.FgBlue),
+	color.New(color.FgRed),
+	color.New(color.FgHiCyan),
+	color.New(color.FgHiYellow),
+	color.New(color.FgHiGreen),
+	color.New(color.FgHiMagenta),
+	color.New(color.FgHiBlue),
+	color.New(color.FgHiRed),
+}
+
+// sourceLabel is the prefix of the lines of the i-th source, padded to the
+// longest of SourceNames, or nil if it has no name.
+func (h *HandlerOptions) sourceLabel(i int) []byte {
+	if i >= len(h.SourceNames) {
+		return nil
+	}
+	width := 0
+	for _, name := range h.SourceNames {
+		if n := utf8.RuneCountInString(name); n > width {
+			width = n
+		}
+	}
+	name := h.SourceNames[i]
+	label := name
+	for n := utf8.RuneCountInString(name); n < width; n++ {
+		label += " "
+	}
+	return []byte(h.paint(sourceColors[i%len(sourceColors)], label) + " | ")
+}
+
+// ScannerInterleave is like ScannerMerge, but writes lines as soon as they
+// are read from any of the sources rather than in chronological order, so
+// that it works with sources that never end, like followed files. Lines
+// are written whole, never mixed with those of another source.
+//
+// With SortWindow, lines are held for that long before they are written,
+// for those of other sources logged earlier but read later to be written
+// before them. Lines without a time go along with the line before them.
+//
+// GrepContext, ContextBefore and ContextAfter are not available in this
+// mode and are ignored.
+func ScannerInterleave(srcs []io.Reader, dst io.Writer, opts *HandlerOptions) error {
+	// every source has its own line handler, so limits are enforced here
+	limitOpts := *opts
+	limitOpts.SkipLines, limitOpts.MaxLines = 0, 0
+	limitOpts.GrepContext = 0
+	limitOpts.ContextBefore, limitOpts.ContextAfter = 0, 0
+
+	// repeats are those of the lines written, whatever their source
+	shared := &repeats{}
+	lhs := make([]*lineHandler, len(srcs))
+	for i := range srcs {
+		lhs[i] = newLineHandler(&limitOpts)
+		lhs[i].source = limitOpts.sourceLabel(i)
+		lhs[i].repeats = shared
+	}
+
+	var (
+		// lines are handled one at a time, for relative times to follow
+		// the order they are written in
+		mu      sync.Mutex
+		buf     = bytes.NewBuffer(nil)
+		printed uint64
+		errc    = make(chan error, len(srcs))
+		limited = make(chan struct{})
+		sorted  *sortWindow
+	)
+	if opts.SortWindow > 0 {
+		sorted = &sortWindow{window: opts.SortWindow}
+		stop := make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(sortFlushInterval(opts.SortWindow))
+			defer ticker.Stop()
+			for {
+				select {
+				case now := <-ticker.C:
+					mu.Lock()
+					sorted.flush(dst, now)
+					mu.Unlock()
+				case <-stop:
+					return
+				}
+			}
+		}()
+		defer func() {
+			close(stop)
+			mu.Lock()
+			sorted.flush(dst, time.Time{})
+			mu.Unlock()
+		}()
+	}
+	// handle reports whether more lines can be written
+	handle := func(lh *lineHandler, line, raw []byte) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		opts.copyRaw(raw)
+		if opts.MaxLines > 0 && printed >= opts.SkipLines+opts.MaxLines {
+			return false
+		}
+		buf.Reset()
+		lh.handle(buf, line)
+		if buf.Len() == 0 {
+			// filtered out
+			return true
+		}
+		printed++
+		switch {
+		case printed <= opts.SkipLines:
+		case sorted != nil:
+			sorted.hold(lh.lastLogged, buf.Bytes(), time.Now())
+		default:
+			dst.Write(buf.Bytes())
+		}
+		if opts.MaxLines > 0 && printed == opts.SkipLines+opts.MaxLines {
+			close(limited)
+			return false
+		}
+		return true
+	}
+
+	for i, src := range srcs {
+		go func(lh *lineHandler, src io.Reader) {
+			in := opts.lineReader(src)
+			for in.Scan() {
+				if !handle(lh, in.Bytes(), in.Raw()) {
+					return
+				}
+			}
+			errc <- in.Err()
+		}(lhs[i], src)
+	}
+
+	for range srcs {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-limited:
+			return nil
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if sorted != nil {
+		sorted.flush(dst, time.Time{})
+	}
+	shared.finish(dst, &limitOpts)
+	return nil
+}
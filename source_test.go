@@ -0,0 +1,122 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScannerMergeSourceNames(t *testing.T) {
+	a := `time="2018-10-24T08:00:01Z" level=info msg="a1"`
+	b := `time="2018-10-24T08:00:02Z" level=info msg="b2"`
+
+	opts := *DefaultOptions
+	opts.SourceNames = []string{"a.log", "other.log"}
+	dst := bytes.NewBuffer(nil)
+	err := ScannerMerge([]io.Reader{strings.NewReader(a), strings.NewReader(b)}, dst, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "a.log     | ") || !strings.Contains(lines[0], "a1") {
+		t.Fatalf("want the first line labelled with its padded source, got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "other.log | ") || !strings.Contains(lines[1], "b2") {
+		t.Fatalf("want the second line labelled with its source, got %q", lines[1])
+	}
+}
+
+func TestScannerInterleave(t *testing.T) {
+	a := strings.Join([]string{
+		`time="2018-10-24T08:00:01Z" level=info msg="a1"`,
+		`time="2018-10-24T08:00:02Z" level=debug msg="a2"`,
+		`time="2018-10-24T08:00:03Z" level=info msg="a3"`,
+	}, "\n")
+	b := strings.Join([]string{
+		`time="2018-10-24T08:00:01Z" level=info msg="b1"`,
+		`time="2018-10-24T08:00:02Z" level=info msg="b2"`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.MinLevel = InfoLevel
+	opts.SourceNames = []string{"a", "b"}
+	dst := bytes.NewBuffer(nil)
+	err := ScannerInterleave([]io.Reader{strings.NewReader(a), strings.NewReader(b)}, dst, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want 4 lines, got %q", lines)
+	}
+	var fromA []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "a | ") && !strings.HasPrefix(line, "b | ") {
+			t.Fatalf("want every line labelled with its source, got %q", line)
+		}
+		if strings.HasPrefix(line, "a | ") {
+			fromA = append(fromA, line)
+		}
+	}
+	if len(fromA) != 2 || !strings.Contains(fromA[0], "a1") || !strings.Contains(fromA[1], "a3") {
+		t.Fatalf("want the lines of a source in order, got %q", fromA)
+	}
+
+	opts.MaxLines = 3
+	dst.Reset()
+	err = ScannerInterleave([]io.Reader{strings.NewReader(a), strings.NewReader(b)}, dst, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(dst.String(), "\n"); n != 3 {
+		t.Fatalf("want 3 lines with MaxLines, got %q", dst.String())
+	}
+}
+
+func TestScannerInterleaveSortWindow(t *testing.T) {
+	opts := *DefaultOptions
+	opts.SortWindow = 100 * time.Millisecond
+
+	ar, aw := io.Pipe()
+	br, bw := io.Pipe()
+	dst := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- ScannerInterleave([]io.Reader{ar, br}, dst, &opts)
+	}()
+
+	io.WriteString(aw, `time="2018-10-24T08:00:03Z" level=info msg="a3"`+"\n")
+	time.Sleep(20 * time.Millisecond)
+	io.WriteString(bw, `time="2018-10-24T08:00:01Z" level=info msg="b1"`+"\n")
+	io.WriteString(bw, "no time, after b1\n")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for strings.Count(dst.String(), "\n") < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("want the lines written once their window is over, got %q", dst.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	aw.Close()
+	bw.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	want := []string{"b1", "no time, after b1", "a3"}
+	if len(lines) != len(want) {
+		t.Fatalf("want %d lines, got %q", len(want), lines)
+	}
+	for i, w := range want {
+		if !strings.Contains(lines[i], w) {
+			t.Fatalf("want line %d to contain %q, got %q", i, w, lines)
+		}
+	}
+}
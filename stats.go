@@ -0,0 +1,113 @@
+package humanlog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statsTopKeys is how many of the most common keys Stats reports.
+const statsTopKeys = 5
+
+// Stats counts the lines that pass the filters, by level, by format and by
+// key, to give the shape of a log at a glance. It is safe for concurrent
+// use.
+type Stats struct {
+	mu      sync.Mutex
+	start   time.Time
+	lines   uint64
+	levels  map[string]uint64
+	formats map[string]uint64
+	keys    map[string]uint64
+}
+
+// NewStats starts counting lines, their rate being reckoned from now.
+func NewStats() *Stats {
+	return &Stats{
+		start:   time.Now(),
+		levels:  make(map[string]uint64),
+		formats: make(map[string]uint64),
+		keys:    make(map[string]uint64),
+	}
+}
+
+// add counts a line of format.
+func (s *Stats) add(format string, ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines++
+	s.formats[format]++
+	if format != rawFormat {
+		s.levels[ev.Level]++
+	}
+	for k := range ev.Fields {
+		s.keys[k]++
+	}
+}
+
+// WriteTo writes a summary of the lines counted so far to w, like:
+//
+//	1234 lines, 411.3/s
+//	levels:  INFO 1200  WARN 30  ERRO 4
+//	formats: json 1230  raw 4
+//	keys:    status 1200  path 1200  user 800
+func (s *Stats) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	rate := float64(s.lines) / time.Since(s.start).Seconds()
+	fmt.Fprintf(&b, "%d lines, %.1f/s\n", s.lines, rate)
+
+	var levels []string
+	for level := range s.levels {
+		levels = append(levels, level)
+	}
+	// from the least to the most severe, like a scale
+	sort.Slice(levels, func(i, j int) bool { return levelSeverity(levels[i]) < levelSeverity(levels[j]) })
+	if len(levels) > 0 {
+		b.WriteString("levels: ")
+		for _, level := range levels {
+			label := defaultLevelLabels[level]
+			if label == "" {
+				label = level
+			}
+			fmt.Fprintf(&b, " %s %d", label, s.levels[level])
+		}
+		b.WriteByte('\n')
+	}
+	writeCounts(&b, "formats:", s.formats, 0)
+	writeCounts(&b, "keys:   ", s.keys, statsTopKeys)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeCounts writes the counts in decreasing order, only the first max of
+// them if max isn't zero.
+func writeCounts(b *strings.Builder, title string, counts map[string]uint64, max int) {
+	if len(counts) == 0 {
+		return
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if max > 0 && len(names) > max {
+		names = names[:max]
+	}
+	b.WriteString(title)
+	for _, name := range names {
+		fmt.Fprintf(b, " %s %d", name, counts[name])
+	}
+	b.WriteByte('\n')
+}
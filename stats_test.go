@@ -0,0 +1,29 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Stats = NewStats()
+	src := strings.NewReader(`{"time":"2020-01-01T00:00:00Z","level":"info","msg":"one","user":"a"}
+{"time":"2020-01-01T00:00:00Z","level":"info","msg":"two","user":"b","path":"/"}
+{"time":"2020-01-01T00:00:00Z","level":"error","msg":"three"}
+not structured
+`)
+	if err := Scanner(src, bytes.NewBuffer(nil), &opts); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := opts.Stats.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"4 lines", "INFO 2", "ERRO 1", "json 3", "raw 1", "user 2", "path 1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want %q in:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package humanlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyslogHandler can handle RFC5424 syslog lines, as well as the older BSD
+// style ones of RFC3164.
+type SyslogHandler struct {
+	renderer *Renderer
+
+	Opts *HandlerOptions
+
+	Level   string
+	Time    time.Time
+	Message string
+	Fields  map[string]string
+}
+
+func (h *SyslogHandler) clear() {
+	h.Level = ""
+	h.Time = time.Time{}
+	h.Message = ""
+	h.Fields = make(map[string]string)
+}
+
+// parse tells if d was handled by this handler, which then holds it until
+// the next call to Prettify.
+func (h *SyslogHandler) parse(d []byte) bool {
+	if len(d) == 0 || d[0] != '<' {
+		return false
+	}
+	err := h.UnmarshalSyslog(d)
+	if err != nil {
+		h.clear()
+		return false
+	}
+	return true
+}
+
+const syslogNilValue = "-"
+
+// UnmarshalSyslog sets the fields of the handler from an RFC5424 line:
+//
+//	<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+//
+// or from an RFC3164 one, which has no version.
+func (h *SyslogHandler) UnmarshalSyslog(data []byte) error {
+	line := string(data)
+
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return fmt.Errorf("invalid syslog priority")
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri > 191 {
+		return fmt.Errorf("invalid syslog priority %q", line[1:end])
+	}
+	line = line[end+1:]
+
+	if h.Fields == nil {
+		h.Fields = make(map[string]string)
+	}
+	h.Level = strconv.Itoa(pri % 8)
+	h.Fields["facility"] = syslogFacility(pri / 8)
+
+	if line != "" && (line[0] < '0' || line[0] > '9') {
+		// no version, must be RFC3164
+		return h.unmarshalBSD(line)
+	}
+
+	header := make([]string, 0, 6)
+	for len(header) < 6 {
+		sp := strings.IndexByte(line, ' ')
+		if sp <= 0 {
+			return fmt.Errorf("truncated syslog header")
+		}
+		header = append(header, line[:sp])
+		line = line[sp+1:]
+	}
+	version, timestamp, hostname, appName, procID, msgID := header[0], header[1], header[2], header[3], header[4], header[5]
+	if _, err := strconv.Atoi(version); err != nil {
+		return fmt.Errorf("invalid syslog version %q", version)
+	}
+
+	if timestamp != syslogNilValue {
+		h.Time, err = time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return err
+		}
+	}
+	for _, kv := range [][2]string{
+		{"host", hostname},
+		{"app", appName},
+		{"procid", procID},
+		{"msgid", msgID},
+	} {
+		if kv[1] != syslogNilValue {
+			h.Fields[kv[0]] = kv[1]
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(line, syslogNilValue):
+		line = line[len(syslogNilValue):]
+	case strings.HasPrefix(line, "["):
+		line, err = h.parseStructuredData(line)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid syslog structured data")
+	}
+
+	line = strings.TrimPrefix(line, " ")
+	h.Message = strings.TrimPrefix(line, "\ufeff")
+	return nil
+}
+
+var bsdSyslogHeader = regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (\S+) ([^\s\[:]+)(?:\[([^\]\s]*)\])?: ?`)
+
+// unmarshalBSD sets the fields of the handler from what follows the priority
+// of an RFC3164 line:
+//
+//	Oct 11 22:14:15 HOSTNAME TAG[PID]: MSG
+//
+// The year isn't logged, so the most recent one that doesn't put the line
+// in the future is assumed.
+func (h *SyslogHandler) unmarshalBSD(line string) error {
+	m := bsdSyslogHeader.FindStringSubmatch(line)
+	if m == nil {
+		return fmt.Errorf("invalid syslog header")
+	}
+	t, err := time.ParseInLocation(time.Stamp, m[1], time.Local)
+	if err != nil {
+		return err
+	}
+	h.Time = inferYear(t, time.Now())
+	h.Fields["host"] = m[2]
+	h.Fields["app"] = m[3]
+	if m[4] != "" {
+		h.Fields["procid"] = m[4]
+	}
+	h.Message = line[len(m[0]):]
+	return nil
+}
+
+var syslogFacilities = [...]string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "audit", "alert", "clock",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogFacility names the facility encoded in a syslog priority.
+func syslogFacility(code int) string {
+	if code < 0 || code >= len(syslogFacilities) {
+		return strconv.Itoa(code)
+	}
+	return syslogFacilities[code]
+}
+
+// parseStructuredData explodes the `[id param="value"...]` elements at the
+// start of line into fields, returning what follows them.
+func (h *SyslogHandler) parseStructuredData(line string) (string, error) {
+	for strings.HasPrefix(line, "[") {
+		line = line[1:]
+		end := strings.IndexAny(line, " ]")
+		if end <= 0 {
+			return "", fmt.Errorf("invalid syslog structured data id")
+		}
+		id := line[:end]
+		line = line[end:]
+
+		for strings.HasPrefix(line, " ") {
+			line = line[1:]
+			eq := strings.Index(line, `="`)
+			if eq <= 0 {
+				return "", fmt.Errorf("invalid syslog structured data param in %q", id)
+			}
+			name := line[:eq]
+			line = line[eq+2:]
+
+			var val strings.Builder
+			closed := false
+			for i := 0; i < len(line); i++ {
+				switch c := line[i]; {
+				case c == '\\' && i+1 < len(line) && strings.IndexByte(`"\]`, line[i+1]) >= 0:
+					val.WriteByte(line[i+1])
+					i++
+				case c == '"':
+					line = line[i+1:]
+					closed = true
+				default:
+					val.WriteByte(c)
+				}
+				if closed {
+					break
+				}
+			}
+			if !closed {
+				return "", fmt.Errorf("unterminated syslog structured data param %q in %q", name, id)
+			}
+
+			key := name
+			if _, exists := h.Fields[key]; exists {
+				key = id + "." + name
+			}
+			h.Fields[key] = val.String()
+		}
+		if !strings.HasPrefix(line, "]") {
+			return "", fmt.Errorf("unterminated syslog structured data %q", id)
+		}
+		line = line[1:]
+	}
+	return line, nil
+}
+
+// TryHandle parses d into an event, if it is in the format of the handler.
+// The handler holds the line until the next call to Prettify.
+func (h *SyslogHandler) TryHandle(d []byte) (Event, bool) {
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if !h.parse(d) {
+		return Event{}, false
+	}
+	return h.event(), true
+}
+
+// Prettify the output in a logrus like fashion.
+func (h *SyslogHandler) Prettify(skipUnchanged bool) []byte {
+	defer h.clear()
+	if h.Opts == nil {
+		h.Opts = DefaultOptions
+	}
+	if h.renderer == nil {
+		h.renderer = NewRenderer(h.Opts)
+	}
+	return h.renderer.Render(h.event(), skipUnchanged)
+}
+
+func (h *SyslogHandler) event() Event {
+	return Event{
+		Time:   h.Time,
+		Level:  h.Opts.mapLevel(h.Level, normalizeSyslogLevel),
+		Msg:    h.Message,
+		Fields: h.Fields,
+	}
+}
@@ -0,0 +1,135 @@
+package humanlog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSyslogHandler(t *testing.T) {
+	line := []byte(`<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high" note="say \"hi\""] 'su root' failed for lonvick on /dev/pts/8`)
+
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := SyslogHandler{Opts: &opts}
+	if _, ok := h.TryHandle(line); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	if want := time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC); !h.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, h.Time)
+	}
+	if h.Level != "2" {
+		t.Fatalf("want severity 2, got %q", h.Level)
+	}
+	if want := "'su root' failed for lonvick on /dev/pts/8"; h.Message != want {
+		t.Fatalf("want message %q, got %q", want, h.Message)
+	}
+	for k, want := range map[string]string{
+		"facility":    "auth",
+		"host":        "mymachine.example.com",
+		"app":         "su",
+		"msgid":       "ID47",
+		"iut":         "3",
+		"eventSource": "Application",
+		"class":       "high",
+		"note":        `say "hi"`,
+	} {
+		if got := h.Fields[k]; got != want {
+			t.Fatalf("want field %q to be %q, got %q", k, want, got)
+		}
+	}
+	if _, ok := h.Fields["procid"]; ok {
+		t.Fatal("want NILVALUE procid to be omitted")
+	}
+
+	out := h.Prettify(false)
+	for _, want := range []string{"|FATA|", "'su root' failed", "iut=3"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+}
+
+func TestSyslogHandlerNilValues(t *testing.T) {
+	h := SyslogHandler{Opts: DefaultOptions}
+	if _, ok := h.TryHandle([]byte(`<165>1 - - - - - -`)); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	if !h.Time.IsZero() || h.Message != "" || len(h.Fields) != 1 {
+		t.Fatalf("want nothing but the priority parsed, got time=%v msg=%q fields=%v", h.Time, h.Message, h.Fields)
+	}
+	if h.Fields["facility"] != "local4" {
+		t.Fatalf("want facility local4, got %q", h.Fields["facility"])
+	}
+	if h.Level != "5" {
+		t.Fatalf("want severity 5, got %q", h.Level)
+	}
+}
+
+func TestSyslogHandlerRejectsOtherLines(t *testing.T) {
+	h := SyslogHandler{Opts: DefaultOptions}
+	for _, line := range []string{
+		"<34>not syslog at all",
+		"hello world",
+		`{"time":"2018-10-24T08:19:50Z"}`,
+	} {
+		if _, ok := h.TryHandle([]byte(line)); ok {
+			t.Fatalf("expected %q not to be handled", line)
+		}
+	}
+}
+
+func TestSyslogHandlerRFC3164(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	h := SyslogHandler{Opts: &opts}
+	if _, ok := h.TryHandle([]byte(`<34>Oct  1 22:14:15 mymachine su[123]: 'su root' failed for lonvick`)); !ok {
+		t.Fatal("expected line to be handled")
+	}
+	if h.Time.Month() != time.October || h.Time.Day() != 1 || h.Time.Hour() != 22 {
+		t.Fatalf("wrong time %v", h.Time)
+	}
+	if h.Time.After(time.Now().Add(24 * time.Hour)) {
+		t.Fatalf("want a time that isn't in the future, got %v", h.Time)
+	}
+	if h.Level != "2" {
+		t.Fatalf("want severity 2, got %q", h.Level)
+	}
+	if want := "'su root' failed for lonvick"; h.Message != want {
+		t.Fatalf("want message %q, got %q", want, h.Message)
+	}
+	for k, want := range map[string]string{
+		"facility": "auth",
+		"host":     "mymachine",
+		"app":      "su",
+		"procid":   "123",
+	} {
+		if got := h.Fields[k]; got != want {
+			t.Fatalf("want field %q to be %q, got %q", k, want, got)
+		}
+	}
+
+	h.Prettify(false)
+	if _, ok := h.TryHandle([]byte(`<13>Feb 25 10:21:15 host kernel: oops`)); !ok {
+		t.Fatal("expected a line without pid to be handled")
+	}
+	if _, ok := h.Fields["procid"]; ok || h.Fields["app"] != "kernel" {
+		t.Fatalf("want app kernel and no procid, got %v", h.Fields)
+	}
+
+	if _, ok := h.TryHandle([]byte(`<13>not a syslog line`)); ok {
+		t.Fatal("want a line without header rejected")
+	}
+}
+
+func TestInferYear(t *testing.T) {
+	now := time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC)
+	dec := time.Date(0, time.December, 31, 23, 0, 0, 0, time.UTC)
+	if got := inferYear(dec, now); got.Year() != 2020 {
+		t.Fatalf("want last year for a date ahead of now, got %v", got)
+	}
+	jan := time.Date(0, time.January, 1, 23, 0, 0, 0, time.UTC)
+	if got := inferYear(jan, now); got.Year() != 2021 {
+		t.Fatalf("want this year, got %v", got)
+	}
+}
@@ -0,0 +1,126 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// maxSyslogFrame bounds the length of octet counted messages, so that a
+// garbled length doesn't exhaust memory.
+const maxSyslogFrame = 1 << 20
+
+// SyslogServer prettifies the syslog messages it receives onto a single
+// destination, from any number of connections at once. Messages are
+// written whole, never mixed with one another.
+type SyslogServer struct {
+	w *lineWriter
+}
+
+// NewSyslogServer prettifies the messages it receives onto dst.
+func NewSyslogServer(dst io.Writer, opts *HandlerOptions) *SyslogServer {
+	return &SyslogServer{w: &lineWriter{dst: dst, lh: newLineHandler(opts)}}
+}
+
+// ListenSyslog prettifies onto dst the syslog messages received on addr,
+// over both UDP and TCP, until either of them fails.
+func ListenSyslog(addr string, dst io.Writer, opts *HandlerOptions) error {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	s := NewSyslogServer(dst, opts)
+	errc := make(chan error, 2)
+	go func() { errc <- s.ServePacket(pc) }()
+	go func() { errc <- s.Serve(l) }()
+	return <-errc
+}
+
+// ServePacket reads messages from pc until it fails, one per datagram.
+func (s *SyslogServer) ServePacket(pc net.PacketConn) error {
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := pc.ReadFrom(buf)
+		if msg := trimSyslogFrame(buf[:n]); len(msg) > 0 {
+			s.w.writeLine(msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Serve accepts connections on l until it fails, reading messages either
+// preceded by their length or ended by a newline, the two framings of
+// RFC 6587.
+func (s *SyslogServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *SyslogServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	in := bufio.NewReader(conn)
+	for {
+		msg, err := readSyslogFrame(in)
+		if len(msg) > 0 {
+			s.w.writeLine(msg)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readSyslogFrame reads the next message of in, which is either preceded
+// by its length, like `11 <34>1 - - -`, or ended by a newline.
+func readSyslogFrame(in *bufio.Reader) ([]byte, error) {
+	first, err := in.Peek(1)
+	// some senders end octet counted messages with a newline too
+	for err == nil && (first[0] == '\n' || first[0] == '\r') {
+		in.Discard(1)
+		first, err = in.Peek(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if first[0] < '1' || first[0] > '9' {
+		line, err := in.ReadBytes('\n')
+		return trimSyslogFrame(line), err
+	}
+
+	prefix, err := in.ReadSlice(' ')
+	if err != nil {
+		return nil, fmt.Errorf("invalid syslog frame length: %v", err)
+	}
+	n, err := strconv.Atoi(string(prefix[:len(prefix)-1]))
+	if err != nil || n > maxSyslogFrame {
+		return nil, fmt.Errorf("invalid syslog frame length %q", prefix[:len(prefix)-1])
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(in, msg); err != nil {
+		return nil, err
+	}
+	return trimSyslogFrame(msg), nil
+}
+
+// trimSyslogFrame removes the line endings and NUL bytes some senders end
+// their messages with.
+func trimSyslogFrame(msg []byte) []byte {
+	return bytes.TrimRight(msg, "\r\n\x00")
+}
@@ -0,0 +1,100 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadSyslogFrame(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("" +
+		"19 <34>1 - - - - - - a\n" +
+		"<34>1 - - - - - - b\r\n" +
+		"21 <34>1 - - - - - - c\nd" +
+		"<34>1 - - - - - - e"))
+
+	for _, want := range []string{
+		"<34>1 - - - - - - a",
+		"<34>1 - - - - - - b",
+		"<34>1 - - - - - - c\nd",
+		"<34>1 - - - - - - e",
+	} {
+		got, err := readSyslogFrame(in)
+		if string(got) != want {
+			t.Fatalf("want %q, got %q (%v)", want, got, err)
+		}
+	}
+	if _, err := readSyslogFrame(in); err == nil {
+		t.Fatal("want an error at the end of the input")
+	}
+
+	if _, err := readSyslogFrame(bufio.NewReader(strings.NewReader("99999999 <34>"))); err == nil {
+		t.Fatal("want a huge length rejected")
+	}
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSyslogServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("can't listen: %v", err)
+	}
+	defer l.Close()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("can't listen: %v", err)
+	}
+	defer pc.Close()
+
+	dst := &lockedBuffer{}
+	s := NewSyslogServer(dst, DefaultOptions)
+	go s.Serve(l)
+	go s.ServePacket(pc)
+
+	tcp, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	tcp.Write([]byte("<11>1 2018-10-24T08:00:00Z host app - - - over tcp\n"))
+
+	udp, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	udp.Write([]byte("<14>1 2018-10-24T08:00:01Z host app - - - over udp"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		got := dst.String()
+		if strings.Contains(got, "over tcp") && strings.Contains(got, "over udp") {
+			if strings.Contains(got, "<11>") {
+				t.Fatalf("want messages prettified, got %q", got)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("want both messages, got %q", dst.String())
+}
@@ -0,0 +1,123 @@
+package humanlog
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// templateColors are the colors templates can paint text with.
+var templateColors = map[string]color.Attribute{
+	"black":     color.FgBlack,
+	"red":       color.FgRed,
+	"green":     color.FgGreen,
+	"yellow":    color.FgYellow,
+	"blue":      color.FgBlue,
+	"magenta":   color.FgMagenta,
+	"cyan":      color.FgCyan,
+	"white":     color.FgWhite,
+	"hiblack":   color.FgHiBlack,
+	"hired":     color.FgHiRed,
+	"higreen":   color.FgHiGreen,
+	"hiyellow":  color.FgHiYellow,
+	"hiblue":    color.FgHiBlue,
+	"himagenta": color.FgHiMagenta,
+	"hicyan":    color.FgHiCyan,
+	"hiwhite":   color.FgHiWhite,
+	"bold":      color.Bold,
+	"faint":     color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+}
+
+// ParseTemplate parses a text/template rendering lines, that is an Event
+// whose Fields are unquoted, in place of the handlers. Besides the usual functions, it can use:
+//
+//	color NAME TEXT         paints TEXT, NAME being like red, bold+hiblue, bgred, 208 or #ff8700
+//	levelcolor LEVEL TEXT   paints TEXT in the color of LEVEL, like .Level
+//	pad N TEXT              pads TEXT with spaces on its right to N characters
+//	lpad N TEXT             pads TEXT with spaces on its left to N characters
+//	trunc N TEXT            truncates TEXT to N characters
+//	timefmt TIME            renders TIME, like .Time, as TimeFormat and TimeMode tell
+//	logfmt FIELDS           renders FIELDS, like .Fields, as sorted key=value pairs
+//
+// Colors are left out when DisableColors is set. For example:
+//
+//	{{timefmt .Time}} {{levelcolor .Level (pad 5 .Level)}} {{.Msg}} {{logfmt .Fields}}
+func (h *HandlerOptions) ParseTemplate(text string) (*template.Template, error) {
+	// missing fields render as empty strings rather than "<no value>"
+	return template.New("format").Option("missingkey=zero").Funcs(h.templateFuncs()).Parse(text)
+}
+
+func (h *HandlerOptions) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"color": func(name, text string) (string, error) {
+			c, err := h.parseColor(name)
+			if err != nil {
+				return "", err
+			}
+			return h.paint(c, text), nil
+		},
+		"levelcolor": func(level, text string) string {
+			return h.paint(h.levelColor(level), text)
+		},
+		"pad": func(width int, text string) string {
+			if n := utf8.RuneCountInString(text); n < width {
+				text += strings.Repeat(" ", width-n)
+			}
+			return text
+		},
+		"lpad": func(width int, text string) string {
+			if n := utf8.RuneCountInString(text); n < width {
+				text = strings.Repeat(" ", width-n) + text
+			}
+			return text
+		},
+		"trunc": func(width int, text string) string {
+			if utf8.RuneCountInString(text) > width {
+				text = string([]rune(text)[:width])
+			}
+			return text
+		},
+		"timefmt": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return h.formatTime(t)
+		},
+		"logfmt": func(fields map[string]string) string {
+			keys := make([]string, 0, len(fields))
+			for key := range fields {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			buf := bytes.NewBuffer(nil)
+			for _, key := range keys {
+				writeLogfmtPair(buf, key, fields[key])
+			}
+			return buf.String()
+		},
+	}
+}
+
+// executeTemplate renders ev with Template, or the error that prevented
+// it from being rendered.
+func (h *HandlerOptions) executeTemplate(ev Event) []byte {
+	if len(ev.Fields) > 0 {
+		fields := make(map[string]string, len(ev.Fields))
+		for key, val := range ev.Fields {
+			fields[key] = unquoteValue(val)
+		}
+		ev.Fields = fields
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := h.Template.Execute(buf, ev); err != nil {
+		return []byte(err.Error())
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
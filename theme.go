@@ -0,0 +1,299 @@
+package humanlog
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// DefaultTheme is the name of the theme used by DefaultOptions.
+const DefaultTheme = "default"
+
+var themes = map[string]*HandlerOptions{
+	DefaultTheme: {
+		KeyColor:              color.New(color.FgGreen),
+		ValColor:              color.New(color.FgHiWhite),
+		BoolColor:             color.New(color.FgHiYellow),
+		NumberColor:           color.New(color.FgHiCyan),
+		StringColor:           color.New(color.FgHiWhite),
+		NullColor:             color.New(color.FgHiBlack),
+		TimeLightBgColor:      color.New(color.FgBlack),
+		TimeDarkBgColor:       color.New(color.FgWhite),
+		MsgLightBgColor:       color.New(color.FgBlack),
+		MsgAbsentLightBgColor: color.New(color.FgHiBlack),
+		MsgDarkBgColor:        color.New(color.FgHiWhite),
+		MsgAbsentDarkBgColor:  color.New(color.FgWhite),
+		DebugLevelColor:       color.New(color.FgMagenta),
+		InfoLevelColor:        color.New(color.FgCyan),
+		WarnLevelColor:        color.New(color.FgYellow),
+		ErrorLevelColor:       color.New(color.FgRed),
+		PanicLevelColor:       color.New(color.BgRed),
+		FatalLevelColor:       color.New(color.BgHiRed, color.FgHiWhite),
+		UnknownLevelColor:     color.New(color.FgMagenta),
+		RawColor:              color.New(color.Faint),
+		StatusSuccessColor:    color.New(color.FgGreen),
+		StatusClientErrColor:  color.New(color.FgYellow),
+		StatusServerErrColor:  color.New(color.FgRed),
+	},
+	// the default colors, with the time and messages always in the colors
+	// of dark backgrounds, whatever LightBg tells
+	"dark": {
+		KeyColor:              color.New(color.FgGreen),
+		ValColor:              color.New(color.FgHiWhite),
+		BoolColor:             color.New(color.FgHiYellow),
+		NumberColor:           color.New(color.FgHiCyan),
+		StringColor:           color.New(color.FgHiWhite),
+		NullColor:             color.New(color.FgHiBlack),
+		TimeLightBgColor:      color.New(color.FgWhite),
+		TimeDarkBgColor:       color.New(color.FgWhite),
+		MsgLightBgColor:       color.New(color.FgHiWhite),
+		MsgAbsentLightBgColor: color.New(color.FgWhite),
+		MsgDarkBgColor:        color.New(color.FgHiWhite),
+		MsgAbsentDarkBgColor:  color.New(color.FgWhite),
+		DebugLevelColor:       color.New(color.FgMagenta),
+		InfoLevelColor:        color.New(color.FgCyan),
+		WarnLevelColor:        color.New(color.FgYellow),
+		ErrorLevelColor:       color.New(color.FgRed),
+		PanicLevelColor:       color.New(color.BgRed),
+		FatalLevelColor:       color.New(color.BgHiRed, color.FgHiWhite),
+		UnknownLevelColor:     color.New(color.FgMagenta),
+		RawColor:              color.New(color.Faint),
+		StatusSuccessColor:    color.New(color.FgGreen),
+		StatusClientErrColor:  color.New(color.FgYellow),
+		StatusServerErrColor:  color.New(color.FgRed),
+	},
+	// the bright colors wash out on light backgrounds, so it sticks to the
+	// darker ones
+	"light": {
+		KeyColor:              color.New(color.FgBlue),
+		ValColor:              color.New(color.FgBlack),
+		BoolColor:             color.New(color.FgYellow),
+		NumberColor:           color.New(color.FgCyan),
+		StringColor:           color.New(color.FgBlack),
+		NullColor:             color.New(color.FgHiBlack),
+		TimeLightBgColor:      color.New(color.FgHiBlack),
+		TimeDarkBgColor:       color.New(color.FgHiBlack),
+		MsgLightBgColor:       color.New(color.FgBlack, color.Bold),
+		MsgAbsentLightBgColor: color.New(color.FgHiBlack),
+		MsgDarkBgColor:        color.New(color.FgBlack, color.Bold),
+		MsgAbsentDarkBgColor:  color.New(color.FgHiBlack),
+		DebugLevelColor:       color.New(color.FgMagenta),
+		InfoLevelColor:        color.New(color.FgBlue),
+		WarnLevelColor:        color.New(color.FgYellow),
+		ErrorLevelColor:       color.New(color.FgRed),
+		PanicLevelColor:       color.New(color.BgRed, color.FgWhite),
+		FatalLevelColor:       color.New(color.BgRed, color.FgWhite, color.Bold),
+		UnknownLevelColor:     color.New(color.FgMagenta),
+		RawColor:              color.New(color.FgHiBlack),
+		StatusSuccessColor:    color.New(color.FgGreen),
+		StatusClientErrColor:  color.New(color.FgYellow),
+		StatusServerErrColor:  color.New(color.FgRed),
+	},
+	// solarized terminal palettes map base0/base1 and friends onto the
+	// bright ANSI colors, which is what these rely on.
+	"solarized-dark": {
+		KeyColor:              color.New(color.FgBlue),
+		ValColor:              color.New(color.FgHiBlue),
+		BoolColor:             color.New(color.FgYellow),
+		NumberColor:           color.New(color.FgCyan),
+		StringColor:           color.New(color.FgHiBlue),
+		NullColor:             color.New(color.FgHiGreen),
+		TimeLightBgColor:      color.New(color.FgHiGreen),
+		TimeDarkBgColor:       color.New(color.FgHiGreen),
+		MsgLightBgColor:       color.New(color.FgHiCyan),
+		MsgAbsentLightBgColor: color.New(color.FgHiGreen),
+		MsgDarkBgColor:        color.New(color.FgHiCyan),
+		MsgAbsentDarkBgColor:  color.New(color.FgHiGreen),
+		DebugLevelColor:       color.New(color.FgMagenta),
+		InfoLevelColor:        color.New(color.FgCyan),
+		WarnLevelColor:        color.New(color.FgYellow),
+		ErrorLevelColor:       color.New(color.FgRed),
+		PanicLevelColor:       color.New(color.BgRed),
+		FatalLevelColor:       color.New(color.BgRed, color.FgHiCyan),
+		UnknownLevelColor:     color.New(color.FgHiMagenta),
+		RawColor:              color.New(color.FgHiGreen),
+		StatusSuccessColor:    color.New(color.FgGreen),
+		StatusClientErrColor:  color.New(color.FgYellow),
+		StatusServerErrColor:  color.New(color.FgRed),
+	},
+	"solarized-light": {
+		KeyColor:              color.New(color.FgBlue),
+		ValColor:              color.New(color.FgHiYellow),
+		BoolColor:             color.New(color.FgYellow),
+		NumberColor:           color.New(color.FgCyan),
+		StringColor:           color.New(color.FgHiYellow),
+		NullColor:             color.New(color.FgHiCyan),
+		TimeLightBgColor:      color.New(color.FgHiCyan),
+		TimeDarkBgColor:       color.New(color.FgHiCyan),
+		MsgLightBgColor:       color.New(color.FgHiGreen),
+		MsgAbsentLightBgColor: color.New(color.FgHiCyan),
+		MsgDarkBgColor:        color.New(color.FgHiGreen),
+		MsgAbsentDarkBgColor:  color.New(color.FgHiCyan),
+		DebugLevelColor:       color.New(color.FgMagenta),
+		InfoLevelColor:        color.New(color.FgCyan),
+		WarnLevelColor:        color.New(color.FgYellow),
+		ErrorLevelColor:       color.New(color.FgRed),
+		PanicLevelColor:       color.New(color.BgRed),
+		FatalLevelColor:       color.New(color.BgRed, color.FgWhite),
+		UnknownLevelColor:     color.New(color.FgHiMagenta),
+		RawColor:              color.New(color.FgHiCyan),
+		StatusSuccessColor:    color.New(color.FgGreen),
+		StatusClientErrColor:  color.New(color.FgYellow),
+		StatusServerErrColor:  color.New(color.FgRed),
+	},
+	"monochrome": {
+		KeyColor:              noColor(),
+		ValColor:              noColor(),
+		BoolColor:             noColor(),
+		NumberColor:           noColor(),
+		StringColor:           noColor(),
+		NullColor:             noColor(),
+		TimeLightBgColor:      noColor(),
+		TimeDarkBgColor:       noColor(),
+		MsgLightBgColor:       noColor(),
+		MsgAbsentLightBgColor: noColor(),
+		MsgDarkBgColor:        noColor(),
+		MsgAbsentDarkBgColor:  noColor(),
+		DebugLevelColor:       noColor(),
+		InfoLevelColor:        noColor(),
+		WarnLevelColor:        noColor(),
+		ErrorLevelColor:       noColor(),
+		PanicLevelColor:       noColor(),
+		FatalLevelColor:       noColor(),
+		UnknownLevelColor:     noColor(),
+		RawColor:              noColor(),
+		StatusSuccessColor:    noColor(),
+		StatusClientErrColor:  noColor(),
+		StatusServerErrColor:  noColor(),
+	},
+}
+
+func noColor() *color.Color {
+	c := color.New()
+	c.DisableColor()
+	return c
+}
+
+// Themes returns the names of the known themes, sorted.
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyTheme sets the colors of the options to those of the named theme.
+// Colors that were changed from the previously applied theme are considered
+// explicit overrides and are left untouched.
+func (h *HandlerOptions) ApplyTheme(name string) error {
+	next, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q, must be one of %v", name, Themes())
+	}
+	prevName := h.Theme
+	if prevName == "" {
+		prevName = DefaultTheme
+	}
+	prev := themes[prevName]
+	if prev == nil {
+		prev = themes[DefaultTheme]
+	}
+
+	dst, from, to := h.colors(), prev.colors(), next.colors()
+	for i, c := range dst {
+		if *c == nil || (*c).Equals(*from[i]) {
+			*c = *to[i]
+		}
+	}
+	h.Theme = name
+	return nil
+}
+
+func (h *HandlerOptions) colors() []**color.Color {
+	return []**color.Color{
+		&h.KeyColor,
+		&h.ValColor,
+		&h.BoolColor,
+		&h.NumberColor,
+		&h.StringColor,
+		&h.NullColor,
+		&h.TimeLightBgColor,
+		&h.TimeDarkBgColor,
+		&h.MsgLightBgColor,
+		&h.MsgAbsentLightBgColor,
+		&h.MsgDarkBgColor,
+		&h.MsgAbsentDarkBgColor,
+		&h.DebugLevelColor,
+		&h.InfoLevelColor,
+		&h.WarnLevelColor,
+		&h.ErrorLevelColor,
+		&h.PanicLevelColor,
+		&h.FatalLevelColor,
+		&h.UnknownLevelColor,
+		&h.RawColor,
+		&h.StatusSuccessColor,
+		&h.StatusClientErrColor,
+		&h.StatusServerErrColor,
+	}
+}
+
+// themeElements are the parts of lines SetColor paints, by name.
+func (h *HandlerOptions) themeElements() map[string][]**color.Color {
+	return map[string][]**color.Color{
+		"key":            {&h.KeyColor},
+		"value":          {&h.ValColor},
+		"bool":           {&h.BoolColor},
+		"number":         {&h.NumberColor},
+		"string":         {&h.StringColor},
+		"null":           {&h.NullColor},
+		"time":           {&h.TimeLightBgColor, &h.TimeDarkBgColor},
+		"message":        {&h.MsgLightBgColor, &h.MsgDarkBgColor},
+		"message-absent": {&h.MsgAbsentLightBgColor, &h.MsgAbsentDarkBgColor},
+		"debug":          {&h.DebugLevelColor},
+		"info":           {&h.InfoLevelColor},
+		"warn":           {&h.WarnLevelColor},
+		"error":          {&h.ErrorLevelColor},
+		"panic":          {&h.PanicLevelColor},
+		"fatal":          {&h.FatalLevelColor},
+		"unknown":        {&h.UnknownLevelColor},
+		"raw":            {&h.RawColor},
+		"status-2xx":     {&h.StatusSuccessColor},
+		"status-4xx":     {&h.StatusClientErrColor},
+		"status-5xx":     {&h.StatusServerErrColor},
+	}
+}
+
+// ThemeElements returns the names of the parts of lines SetColor paints,
+// sorted.
+func (h *HandlerOptions) ThemeElements() []string {
+	elements := h.themeElements()
+	names := make([]string, 0, len(elements))
+	for name := range elements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetColor paints an element of lines, like key, info or time, in the
+// colors of spec, as understood by templates' color, like `bold+hiblue`.
+// A spec of `none` leaves the element uncolored. Since it
+// changes colors away from those of the theme, it is to be done after
+// ApplyTheme.
+func (h *HandlerOptions) SetColor(element, spec string) error {
+	colors, ok := h.themeElements()[element]
+	if !ok {
+		return fmt.Errorf("unknown element %q, must be one of %v", element, h.ThemeElements())
+	}
+	c, err := h.parseColor(spec)
+	if err != nil {
+		return err
+	}
+	for _, dst := range colors {
+		*dst = c
+	}
+	return nil
+}
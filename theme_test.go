@@ -0,0 +1,95 @@
+package humanlog
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestApplyTheme(t *testing.T) {
+	t.Run("sets theme colors", func(t *testing.T) {
+		opts := *DefaultOptions
+		if err := opts.ApplyTheme("solarized-dark"); err != nil {
+			t.Fatal(err)
+		}
+		want := themes["solarized-dark"]
+		if !opts.KeyColor.Equals(want.KeyColor) {
+			t.Fatalf("want key color from theme")
+		}
+		if !opts.ErrorLevelColor.Equals(want.ErrorLevelColor) {
+			t.Fatalf("want error level color from theme")
+		}
+		if opts.Theme != "solarized-dark" {
+			t.Fatalf("want theme name to be recorded, got %q", opts.Theme)
+		}
+	})
+	t.Run("explicit overrides survive", func(t *testing.T) {
+		opts := *DefaultOptions
+		override := color.New(color.FgHiRed, color.Underline)
+		opts.KeyColor = override
+		if err := opts.ApplyTheme("solarized-light"); err != nil {
+			t.Fatal(err)
+		}
+		if opts.KeyColor != override {
+			t.Fatalf("want overridden key color to survive")
+		}
+		if !opts.ValColor.Equals(themes["solarized-light"].ValColor) {
+			t.Fatalf("want val color from theme")
+		}
+	})
+	t.Run("monochrome disables colors", func(t *testing.T) {
+		defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+		color.NoColor = false
+
+		opts := *DefaultOptions
+		if err := opts.ApplyTheme("monochrome"); err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range opts.colors() {
+			if got := (*c).Sprint("hello"); got != "hello" {
+				t.Fatalf("want no color, got %q", got)
+			}
+		}
+	})
+	t.Run("unknown theme", func(t *testing.T) {
+		opts := *DefaultOptions
+		if err := opts.ApplyTheme("nope"); err == nil {
+			t.Fatal("want an error")
+		}
+	})
+}
+
+func TestSetColor(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	if err := opts.ApplyTheme("light"); err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.SetColor("time", "bold+bgblue"); err != nil {
+		t.Fatal(err)
+	}
+	if err := opts.SetColor("info", "none"); err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b[1;44mnow\x1b[0m"
+	if got := opts.TimeLightBgColor.Sprint("now"); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if got := opts.TimeDarkBgColor.Sprint("now"); got != want {
+		t.Fatalf("want both backgrounds painted, got %q", got)
+	}
+	if got := opts.InfoLevelColor.Sprint("INFO"); got != "INFO" {
+		t.Fatalf("want no color, got %q", got)
+	}
+	if !opts.KeyColor.Equals(themes["light"].KeyColor) {
+		t.Fatalf("want the other colors of the theme left alone")
+	}
+
+	for _, bad := range [][2]string{{"nope", "red"}, {"key", "reddish"}, {"key", "bgbold"}} {
+		if err := opts.SetColor(bad[0], bad[1]); err == nil {
+			t.Errorf("%s=%s: want an error", bad[0], bad[1])
+		}
+	}
+}
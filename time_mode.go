@@ -0,0 +1,67 @@
+package humanlog
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ways of rendering the time of lines.
+const (
+	// TimeModeAbsolute renders times as they are, with TimeFormat.
+	TimeModeAbsolute = "absolute"
+	// TimeModeRelative renders the time elapsed since the first line.
+	TimeModeRelative = "relative"
+	// TimeModeDelta renders the time elapsed since the line before, like
+	// `+1.24s`.
+	TimeModeDelta = "delta"
+)
+
+// timeModeWidth is what relative times are padded to, so that what follows
+// them stays aligned.
+const timeModeWidth = 10
+
+// timeClock remembers the times needed to render relative ones.
+type timeClock struct {
+	first time.Time
+	prev  time.Time
+}
+
+// resetClock starts the clock of relative times over, for a new stream.
+func (h *HandlerOptions) resetClock() {
+	if h.TimeMode == TimeModeRelative || h.TimeMode == TimeModeDelta {
+		h.clock = &timeClock{}
+	}
+}
+
+// formatTime renders t according to TimeMode, with TimeFormat and in
+// Location if one is set when rendering it as is.
+func (h *HandlerOptions) formatTime(t time.Time) string {
+	if (h.TimeMode == TimeModeRelative || h.TimeMode == TimeModeDelta) && !t.IsZero() {
+		if h.clock == nil {
+			h.clock = &timeClock{}
+		}
+		if h.clock.first.IsZero() {
+			h.clock.first, h.clock.prev = t, t
+		}
+		var d string
+		if h.TimeMode == TimeModeRelative {
+			d = formatElapsed(t.Sub(h.clock.first))
+		} else {
+			d = formatElapsed(t.Sub(h.clock.prev))
+			if d[0] != '-' {
+				d = "+" + d
+			}
+		}
+		h.clock.prev = t
+		return fmt.Sprintf("%*s", timeModeWidth, d)
+	}
+	if h.Location != nil {
+		t = t.In(h.Location)
+	}
+	return t.Format(h.TimeFormat)
+}
+
+// formatElapsed renders d to the millisecond, like `1m2.345s`.
+func formatElapsed(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
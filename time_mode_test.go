@@ -0,0 +1,60 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeModes(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"first"}`,
+		`{"time":"2018-10-24T08:19:51.240Z","level":"info","msg":"second"}`,
+		`no time here`,
+		`{"time":"2018-10-24T08:21:00Z","level":"info","msg":"third"}`,
+	}, "\n")
+
+	for mode, want := range map[string][]string{
+		TimeModeRelative: {"        0s |INFO| first", "     1.24s |INFO| second", "no time here", "     1m10s |INFO| third"},
+		TimeModeDelta:    {"       +0s |INFO| first", "    +1.24s |INFO| second", "no time here", "  +1m8.76s |INFO| third"},
+	} {
+		opts := *DefaultOptions
+		opts.TimeMode = mode
+		dst := bytes.NewBuffer(nil)
+		if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+		if len(lines) != len(want) {
+			t.Fatalf("%s: want %d lines, got %q", mode, len(want), lines)
+		}
+		for i, w := range want {
+			if !strings.HasPrefix(lines[i], w) {
+				t.Fatalf("%s: want line %d to start with %q, got %q", mode, i, w, lines[i])
+			}
+		}
+
+		// a new stream starts over
+		dst.Reset()
+		if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(dst.String(), want[0]) {
+			t.Fatalf("%s: want the clock reset, got %q", mode, dst.String())
+		}
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                         "0s",
+		1240 * time.Millisecond:   "1.24s",
+		350500 * time.Microsecond: "351ms",
+		-500 * time.Millisecond:   "-500ms",
+	} {
+		if got := formatElapsed(d); got != want {
+			t.Fatalf("want %v rendered as %s, got %s", d, want, got)
+		}
+	}
+}
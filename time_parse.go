@@ -1,12 +1,16 @@
 package humanlog
 
 import (
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
 var formats = []string{
-	"2006-01-02 15:04:05.999999999 -0700 MST",
+	// first as most lines have it, no other layout parsing the same times
 	time.RFC3339,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
 	time.RFC3339Nano,
 	time.RFC822,
 	time.RFC822Z,
@@ -32,12 +36,70 @@ func parseTimeFloat64(value float64) time.Time {
 	case v > 1e12:
 		v *= 1e6
 	default:
-		return time.Unix(v, 0)
+		// a float64 of seconds since the epoch doesn't hold much more
+		// than microseconds
+		return time.Unix(v, int64(math.Round((value-float64(v))*1e6))*1e3)
 	}
 
 	return time.Unix(v/1e9, v%1e9)
 }
 
+// parseEpoch parses a string of digits, with an optional fraction, as a time
+// since the epoch in the unit its magnitude suggests, like parseTimeFloat64
+// does. Being parsed as integers, nanoseconds keep their precision.
+func parseEpoch(s string) (time.Time, bool) {
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+		if !isDigits(frac) {
+			return time.Time{}, false
+		}
+	}
+	if !isDigits(whole) {
+		return time.Time{}, false
+	}
+	v, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// how many digits of fraction make a nanosecond, in that unit
+	var digits int
+	switch {
+	case v > 1e18:
+		digits = 0
+	case v > 1e15:
+		digits = 3
+	case v > 1e12:
+		digits = 6
+	default:
+		digits = 9
+	}
+	var nanos int64
+	if digits > 0 && frac != "" {
+		if len(frac) > digits {
+			frac = frac[:digits]
+		}
+		frac += strings.Repeat("0", digits-len(frac))
+		nanos, _ = strconv.ParseInt(frac, 10, 64)
+	}
+	perSec := int64(math.Pow10(9 - digits))
+	unit := int64(math.Pow10(digits))
+	return time.Unix(v/perSec, v%perSec*unit+nanos), true
+}
+
+// parseTime is tryParseTime, trying TimeLayouts first.
+func (h *HandlerOptions) parseTime(value interface{}) (time.Time, bool) {
+	if s, ok := value.(string); ok && h != nil {
+		for _, layout := range h.TimeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return tryParseTime(value)
+}
+
 // tries to parse time using a couple of formats before giving up
 func tryParseTime(value interface{}) (time.Time, bool) {
 	var t time.Time
@@ -50,6 +112,10 @@ func tryParseTime(value interface{}) (time.Time, bool) {
 				return t, true
 			}
 		}
+		if t, ok := parseEpoch(value.(string)); ok {
+			return t, true
+		}
+		return tryParseISO8601(value.(string))
 	case float32:
 		return parseTimeFloat64(float64(value.(float32))), true
 	case float64:
@@ -63,3 +129,36 @@ func tryParseTime(value interface{}) (time.Time, bool) {
 	}
 	return t, false
 }
+
+var iso8601Formats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// tryParseISO8601 parses the usual RFC3339 and ISO8601 timestamp layouts.
+func tryParseISO8601(value string) (time.Time, bool) {
+	// cheap check before going through all layouts
+	if len(value) < len("2006-01-02T15:04:05") || value[4] != '-' || value[7] != '-' {
+		return time.Time{}, false
+	}
+	for _, layout := range iso8601Formats {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// inferYear puts t, which was logged without its year, in the most recent
+// year that doesn't make it more than a day ahead of now.
+func inferYear(t, now time.Time) time.Time {
+	t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
@@ -1,7 +1,9 @@
 package humanlog
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTimeParseFloat64(t *testing.T) {
@@ -34,3 +36,64 @@ func TestTimeParseFloat64(t *testing.T) {
 		}
 	})
 }
+
+func TestParseEpoch(t *testing.T) {
+	for s, want := range map[string]time.Time{
+		"1540369190":          time.Unix(1540369190, 0),
+		"1540369190.466951":   time.Unix(1540369190, 466951000),
+		"1540369190466":       time.Unix(1540369190, 466000000),
+		"1540369190466.5":     time.Unix(1540369190, 466500000),
+		"1540369190466951":    time.Unix(1540369190, 466951000),
+		"1540369190466951764": time.Unix(1540369190, 466951764),
+	} {
+		got, ok := parseEpoch(s)
+		if !ok || !got.Equal(want) {
+			t.Fatalf("want %s parsed as %v, got %v (%v)", s, want, got, ok)
+		}
+	}
+	for _, s := range []string{"", "abc", "12a", "1.2.3", "-5", "1."} {
+		if _, ok := parseEpoch(s); ok {
+			t.Fatalf("want %q rejected", s)
+		}
+	}
+}
+
+func TestJSONEpochTimes(t *testing.T) {
+	h := JSONHandler{Opts: DefaultOptions}
+	for _, ev := range []string{
+		`{"time":1540369190466,"msg":"millis"}`,
+		`{"ts":"1540369190466951","msg":"micros as a string"}`,
+		`{"timestamp":1540369190.466,"msg":"float seconds"}`,
+	} {
+		if _, ok := h.TryHandle([]byte(ev)); !ok {
+			t.Fatalf("should handle %s", ev)
+		}
+		if got, want := h.Time.Truncate(time.Millisecond), time.Unix(1540369190, 466e6); !got.Equal(want) {
+			t.Fatalf("want %s at %v, got %v", ev, want, got)
+		}
+		h.Prettify(false)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	opts := *DefaultOptions
+	opts.TimeFormat = time.RFC3339
+	line := []byte(`{"time":"2018-10-24T08:19:50+02:00","level":"info","msg":"hi"}`)
+
+	out, _ := Prettify(line, &opts)
+	if !strings.HasPrefix(string(out), "2018-10-24T08:19:50+02:00 ") {
+		t.Fatalf("want the time kept in its zone, got %q", out)
+	}
+
+	opts.Location = time.UTC
+	out, _ = Prettify(line, &opts)
+	if !strings.HasPrefix(string(out), "2018-10-24T06:19:50Z ") {
+		t.Fatalf("want the time converted to UTC, got %q", out)
+	}
+
+	opts.Location = time.FixedZone("EST", -5*3600)
+	out, _ = Prettify([]byte(`{"_SOURCE_REALTIME_TIMESTAMP":"1540369190000000","MESSAGE":"journal"}`), &opts)
+	if !strings.HasPrefix(string(out), "2018-10-24T03:19:50-05:00 ") {
+		t.Fatalf("want the journal time converted, got %q", out)
+	}
+}
@@ -0,0 +1,50 @@
+package humanlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeBoundLayouts are the layouts of absolute bounds, the ones without a
+// zone being in local time.
+var timeBoundLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTimeBound parses a bound of the Since..Until range, either an
+// absolute time like `2018-10-24T08:00:00Z` or `2018-10-24 08:00`, or a
+// duration before now like `15m`, `1h30m` or `2d`.
+func ParseTimeBound(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "now" {
+		return now, nil
+	}
+	for _, layout := range timeBoundLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := parseDurationDays(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("want a time like 2006-01-02T15:04:05Z07:00 or a duration ago like 15m: %q", s)
+}
+
+// parseDurationDays is time.ParseDuration, also accepting a number of days
+// like `2d`.
+func parseDurationDays(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
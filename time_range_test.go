@@ -0,0 +1,33 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2018, 10, 24, 8, 0, 0, 0, time.UTC)
+	for s, want := range map[string]time.Time{
+		"now":                       now,
+		"15m":                       now.Add(-15 * time.Minute),
+		"1h30m":                     now.Add(-90 * time.Minute),
+		"2d":                        now.Add(-48 * time.Hour),
+		"2018-10-23T08:00:00Z":      time.Date(2018, 10, 23, 8, 0, 0, 0, time.UTC),
+		"2018-10-23T08:00:00+02:00": time.Date(2018, 10, 23, 6, 0, 0, 0, time.UTC),
+		"2018-10-23 08:00":          time.Date(2018, 10, 23, 8, 0, 0, 0, time.Local),
+		"2018-10-23":                time.Date(2018, 10, 23, 0, 0, 0, 0, time.Local),
+	} {
+		got, err := ParseTimeBound(s, now)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("want %q parsed as %v, got %v", s, want, got)
+		}
+	}
+	for _, s := range []string{"", "yesterday", "15", "xd"} {
+		if _, err := ParseTimeBound(s, now); err == nil {
+			t.Fatalf("want %q rejected", s)
+		}
+	}
+}
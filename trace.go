@@ -0,0 +1,110 @@
+package humanlog
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// shortTraceIDLen is how many characters of trace and span IDs are kept,
+// which is plenty to tell apart the traces of a stream.
+const shortTraceIDLen = 8
+
+// traceColors are the colors of trace IDs, one for each trace, so that the
+// lines of a trace can be followed by eye.
+var traceColors = []color.Attribute{
+	color.FgRed, color.FgGreen, color.FgYellow, color.FgBlue, color.FgMagenta, color.FgCyan,
+	color.FgHiRed, color.FgHiGreen, color.FgHiYellow, color.FgHiBlue, color.FgHiMagenta, color.FgHiCyan,
+}
+
+// traceKind tells if key holds a trace ID, a span ID or a W3C traceparent,
+// whatever the way it is written, like `trace_id`, `traceId` or
+// `dd.trace_id`.
+func traceKind(key string) string {
+	key = strings.ToLower(key)
+	key = traceKeySeparators.Replace(key)
+	switch {
+	case key == "traceparent":
+		return "traceparent"
+	case strings.HasSuffix(key, "traceid"):
+		return "trace"
+	case strings.HasSuffix(key, "spanid"):
+		return "span"
+	default:
+		return ""
+	}
+}
+
+// traceKeySeparators are dropped from keys before matching them: building
+// the replacer is what costs, so it's done once.
+var traceKeySeparators = strings.NewReplacer("_", "", "-", "", ".", "")
+
+// traceValue renders the value of key if it holds a trace or span ID:
+// shortened, trace IDs in a color of their own, and linked to TraceURL if
+// set, with `{trace_id}` and `{span_id}` replaced by the full IDs.
+func (h *HandlerOptions) traceValue(key, val string) (string, bool) {
+	kind := traceKind(key)
+	if kind == "" {
+		return "", false
+	}
+	id := unquoteValue(val)
+	var traceID, spanID string
+	switch kind {
+	case "trace":
+		traceID = id
+	case "span":
+		spanID = id
+	case "traceparent":
+		// version-trace-span-flags
+		parts := strings.Split(id, "-")
+		if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+			return "", false
+		}
+		traceID, spanID = parts[1], parts[2]
+	}
+	if traceID == "" && spanID == "" || strings.ContainsAny(id, " \t\"") {
+		return "", false
+	}
+
+	var out string
+	if traceID != "" {
+		out = h.paint(traceColor(traceID), shortenID(traceID))
+	}
+	if spanID != "" {
+		if out != "" {
+			out += "/"
+		}
+		out += h.paint(h.ValColor, shortenID(spanID))
+	}
+	if h.TraceURL != "" && traceID != "" {
+		url := strings.NewReplacer("{trace_id}", traceID, "{span_id}", spanID).Replace(h.TraceURL)
+		out = h.hyperlink(url, out)
+	}
+	return out, true
+}
+
+func shortenID(id string) string {
+	if len(id) > shortTraceIDLen {
+		return id[:shortTraceIDLen]
+	}
+	return id
+}
+
+// traceColor picks the color of a trace by its ID, so that it is the same
+// on every line of the trace.
+func traceColor(traceID string) *color.Color {
+	hash := fnv.New32a()
+	hash.Write([]byte(traceID))
+	return color.New(traceColors[hash.Sum32()%uint32(len(traceColors))])
+}
+
+// hyperlink makes text a link to url in the terminals that know OSC 8,
+// which the others leave out. Like colors, links are only written to
+// terminals.
+func (h *HandlerOptions) hyperlink(url, text string) string {
+	if h.DisableColors || color.NoColor {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
@@ -0,0 +1,56 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestTraceValue(t *testing.T) {
+	opts := *DefaultOptions
+	opts.DisableColors = true
+	for _, tt := range []struct {
+		key, val, want string
+	}{
+		{"trace_id", `"4bf92f3577b34da6a3ce929d0e0e4736"`, "4bf92f35"},
+		{"spanId", "00f067aa0ba902b7", "00f067aa"},
+		{"dd.trace_id", "1234", "1234"},
+		{"traceparent", `"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"`, "4bf92f35/00f067aa"},
+	} {
+		got, ok := opts.traceValue(tt.key, tt.val)
+		if !ok || got != tt.want {
+			t.Errorf("%s=%s: want %q, got %q, %v", tt.key, tt.val, tt.want, got, ok)
+		}
+	}
+	for _, tt := range [][2]string{{"trace", "abc"}, {"traceparent", `"garbage"`}, {"trace_id", `"not an id"`}} {
+		if got, ok := opts.traceValue(tt[0], tt[1]); ok {
+			t.Errorf("%s=%s: want it left alone, got %q", tt[0], tt[1], got)
+		}
+	}
+}
+
+func TestTraceValueColorAndLink(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	opts := *DefaultOptions
+	opts.TraceURL = "http://localhost:16686/trace/{trace_id}?span={span_id}"
+	first, _ := opts.traceValue("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736")
+	again, _ := opts.traceValue("traceId", "4bf92f3577b34da6a3ce929d0e0e4736")
+	if first != again {
+		t.Fatalf("want a trace in the same color everywhere, got %q and %q", first, again)
+	}
+	link := "\x1b]8;;http://localhost:16686/trace/4bf92f3577b34da6a3ce929d0e0e4736?span=\x1b\\"
+	if !strings.HasPrefix(first, link) || !strings.HasSuffix(first, "\x1b]8;;\x1b\\") {
+		t.Fatalf("want a link to the trace, got %q", first)
+	}
+	if w := visibleWidth(first); w != shortTraceIDLen {
+		t.Fatalf("want links to take no room, got a width of %d", w)
+	}
+
+	opts.DisableColors = true
+	if plain, _ := opts.traceValue("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"); plain != "4bf92f35" {
+		t.Fatalf("want no link without colors, got %q", plain)
+	}
+}
@@ -0,0 +1,37 @@
+package humanlog
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// truncateLength tells how long the values of key are let to be before they
+// are truncated, going by TruncateKeys and then by Truncates. It reports
+// false if they aren't truncated.
+func (h *HandlerOptions) truncateLength(key string) (int, bool) {
+	if n, ok := h.TruncateKeys[key]; ok {
+		return n, n > 0
+	}
+	return h.TruncateLength, h.Truncates
+}
+
+// writeExpanded writes the values of the fields of ev whose keys are in
+// keys below a prettified line, each under its key and in full, strings
+// unquoted so that their lines are written as lines.
+func (h *HandlerOptions) writeExpanded(buf *bytes.Buffer, ev Event, keys []string) {
+	sort.Strings(keys)
+	for _, key := range keys {
+		v := h.sanitize(ev.Fields[key])
+		if unquoted, err := strconv.Unquote(v); err == nil {
+			v = unquoted
+		}
+		buf.WriteString("\n    ")
+		buf.WriteString(h.paint(h.KeyColor, h.displayKey(key)+":"))
+		for _, line := range strings.Split(strings.TrimRight(v, "\n"), "\n") {
+			buf.WriteString("\n        ")
+			buf.WriteString(h.paintHighlighted(h.valColor(line), strings.Replace(line, "\t", "    ", -1)))
+		}
+	}
+}
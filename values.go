@@ -0,0 +1,180 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ways of rendering the nested objects of JSON lines.
+const (
+	// NestedObjectsInline renders nested objects in place, as Go would.
+	NestedObjectsInline = "inline"
+	// NestedObjectsFlatten spreads nested objects into fields with dotted
+	// keys, like `http.status=200`.
+	NestedObjectsFlatten = "flatten"
+	// NestedObjectsExpand renders nested objects as indented JSON, below
+	// the line.
+	NestedObjectsExpand = "expand"
+)
+
+// setFields renders the values of raw into fields. Nested objects go where
+// NestedObjects says, the ones to expand below the line being returned as
+// indented JSON by key.
+func (h *HandlerOptions) setFields(fields map[string]string, raw map[string]interface{}) map[string]string {
+	var nested map[string]string
+	for key, val := range raw {
+		if h.NestedObjects != NestedObjectsFlatten && h.NestedObjects != NestedObjectsExpand {
+			fields[key] = h.formatValue(val)
+			continue
+		}
+		if !isNested(val) {
+			fields[key] = h.formatValue(val)
+			continue
+		}
+		if obj, ok := val.(map[string]interface{}); ok && h.NestedObjects == NestedObjectsFlatten {
+			flat := make(map[string]interface{})
+			flattenValue(flat, key, obj)
+			for k, v := range flat {
+				fields[k] = h.formatValue(v)
+			}
+			continue
+		}
+		indent := ""
+		if h.NestedObjects == NestedObjectsExpand {
+			indent = "  "
+		}
+		str, err := marshalJSON(val, indent)
+		if err != nil {
+			fields[key] = h.formatValue(val)
+			continue
+		}
+		if indent == "" {
+			// arrays of objects, when flattening
+			fields[key] = str
+			continue
+		}
+		if nested == nil {
+			nested = make(map[string]string)
+		}
+		nested[key] = str
+	}
+	if len(h.Select) > 0 {
+		h.selectPaths(fields, raw)
+	}
+	return nested
+}
+
+// isNested tells if val is an object, or an array that isn't only made of
+// scalars.
+func isNested(val interface{}) bool {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		for _, elem := range v {
+			switch elem.(type) {
+			case map[string]interface{}, []interface{}:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// marshalJSON renders val as JSON, without escaping HTML characters.
+func marshalJSON(val interface{}, indent string) (string, error) {
+	buf := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(val); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// writeNested writes the nested objects that are expanded below a
+// prettified line, sorted by key.
+func (h *HandlerOptions) writeNested(buf *bytes.Buffer, nested map[string]string) {
+	keys := make([]string, 0, len(nested))
+	for key := range nested {
+		if h.shouldShowKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeStacktrace(buf, h.paint(h.KeyColor, h.displayKey(key))+"="+h.sanitize(nested[key]))
+	}
+}
+
+// formatValue renders a value decoded from JSON.
+func (h *HandlerOptions) formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case float64:
+		return formatNumber(v)
+	case string:
+		return h.quote(v)
+	case nil:
+		return "null"
+	case []interface{}:
+		return h.formatArray(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func formatNumber(v float64) string {
+	if v-math.Floor(v) < 0.000001 && v < 1e9 {
+		// looks like an integer that's not too large
+		return strconv.Itoa(int(v))
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatArray renders an array like `[api,auth,1,true]`. Strings are only
+// quoted when they need it, or have a comma or a bracket that would make
+// them look like many elements. Objects and arrays are rendered as JSON.
+// Past MaxArrayElements, the elements left are only counted, as in
+// `[a,b,...+3]`, and long strings are truncated along with other values.
+func (h *HandlerOptions) formatArray(arr []interface{}) string {
+	shown := arr
+	if h != nil && h.MaxArrayElements > 0 && len(arr) > h.MaxArrayElements {
+		shown = arr[:h.MaxArrayElements]
+	}
+	elems := make([]string, 0, len(shown)+1)
+	for _, elem := range shown {
+		switch v := elem.(type) {
+		case float64:
+			elems = append(elems, formatNumber(v))
+		case string:
+			v = h.sanitize(v)
+			if h != nil && h.Truncates && len(v) > h.TruncateLength {
+				v = v[:h.TruncateLength] + "..."
+			}
+			if needsQuotes(v) || strings.ContainsAny(v, ",[]") {
+				v = strconv.Quote(v)
+			}
+			elems = append(elems, v)
+		case bool:
+			elems = append(elems, strconv.FormatBool(v))
+		case nil:
+			elems = append(elems, "null")
+		default:
+			str, err := marshalJSON(v, "")
+			if err != nil {
+				str = fmt.Sprintf("%v", v)
+			}
+			elems = append(elems, str)
+		}
+	}
+	if len(shown) < len(arr) {
+		elems = append(elems, fmt.Sprintf("...+%d", len(arr)-len(shown)))
+	}
+	return "[" + strings.Join(elems, ",") + "]"
+}
@@ -0,0 +1,91 @@
+package humanlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScalarArrays(t *testing.T) {
+	h := JSONHandler{Opts: DefaultOptions}
+	ev := []byte(`{"time":"2018-10-24T08:19:50Z","msg":"hi","tags":["a","b","c"],"nums":[1,2.5,-3],"mixed":["x",1,true,null],"nested":[{"a":1}]}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	for k, want := range map[string]string{
+		"tags":   `[a,b,c]`,
+		"nums":   `[1,2.5,-3]`,
+		"mixed":  `[x,1,true,null]`,
+		"nested": `[{"a":1}]`,
+	} {
+		if got := h.Fields[k]; got != want {
+			t.Fatalf("want %s=%s, got %s", k, want, got)
+		}
+	}
+}
+
+func TestArrays(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = true
+	opts.TruncateLength = 10
+	opts.MaxArrayElements = 3
+	for _, tt := range []struct {
+		arr  []interface{}
+		want string
+	}{
+		{[]interface{}{}, `[]`},
+		{[]interface{}{"api", "auth", "v2"}, `[api,auth,v2]`},
+		{[]interface{}{"a b", "a,b", "", "true"}, `["a b","a,b","",...+1]`},
+		{[]interface{}{"true", "[x]"}, `["true","[x]"]`},
+		{[]interface{}{"a", "b", "c", "d", "e"}, `[a,b,c,...+2]`},
+		{[]interface{}{"averylongelement"}, `[averylonge...]`},
+		{[]interface{}{[]interface{}{1.0, 2.0}, map[string]interface{}{"k": "v"}}, `[[1,2],{"k":"v"}]`},
+	} {
+		if got := opts.formatArray(tt.arr); got != tt.want {
+			t.Fatalf("want %v rendered as %s, got %s", tt.arr, tt.want, got)
+		}
+	}
+}
+
+func TestNestedObjectsFlatten(t *testing.T) {
+	opts := *DefaultOptions
+	opts.NestedObjects = NestedObjectsFlatten
+	h := JSONHandler{Opts: &opts}
+	ev := []byte(`{"time":"2018-10-24T08:19:50Z","msg":"hi","http":{"status":200,"req":{"method":"GET"}},"items":[{"id":1}],"tags":["a"]}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	for k, want := range map[string]string{
+		"http.status":     "200",
+		"http.req.method": `"GET"`,
+		"items":           `[{"id":1}]`,
+		"tags":            `[a]`,
+	} {
+		if got := h.Fields[k]; got != want {
+			t.Fatalf("want %s=%s, got %s", k, want, got)
+		}
+	}
+	if _, ok := h.Fields["http"]; ok {
+		t.Fatal("want the object itself gone once flattened")
+	}
+}
+
+func TestNestedObjectsExpand(t *testing.T) {
+	opts := *DefaultOptions
+	opts.NestedObjects = NestedObjectsExpand
+	h := JSONHandler{Opts: &opts}
+	ev := []byte(`{"time":"2018-10-24T08:19:50Z","msg":"hi","user":"bob","http":{"status":200,"url":"/a?b=<c>"}}`)
+	if _, ok := h.TryHandle(ev); !ok {
+		t.Fatal("should handle the line")
+	}
+	if _, ok := h.Fields["http"]; ok {
+		t.Fatal("want the object out of the fields")
+	}
+	out := string(h.Prettify(false))
+	want := "\n    http={\n      \"status\": 200,\n      \"url\": \"/a?b=<c>\"\n    }"
+	if !strings.HasSuffix(out, want) {
+		t.Fatalf("want the object expanded below the line, got %q", out)
+	}
+	if !strings.Contains(out, `user="bob"`) {
+		t.Fatalf("want scalars kept on the line, got %q", out)
+	}
+}
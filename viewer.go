@@ -0,0 +1,473 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultViewerLines is how many lines a Viewer keeps, unless told.
+const DefaultViewerLines = 100000
+
+// Prompts of a Viewer, for what is being typed in.
+const (
+	viewerSearchPrompt = "/"
+	viewerWherePrompt  = "where: "
+)
+
+// viewerLevels are the minimum levels a Viewer cycles through.
+var viewerLevels = []string{"", DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+
+// Viewer keeps the lines written by a Scanner, through the Written option,
+// and draws them full screen on a terminal, to be scrolled through,
+// searched and filtered by level or fields while more come in. It is
+// driven by the names of the keys pressed, see Key, and can be used from
+// several goroutines.
+type Viewer struct {
+	mu sync.Mutex
+
+	max     int
+	seq     uint64
+	lines   []viewerLine
+	paused  bool
+	pending []viewerLine
+	ended   string
+	changed bool
+
+	minLevel string
+	where    []Predicate
+	search   string
+
+	// shown are the indexes in lines of the ones that pass the filters
+	shown []int
+	// sel is the seq of the selected line, which is the last one shown
+	// while following
+	sel    uint64
+	follow bool
+	top    int
+	rows   int
+	detail bool
+
+	prompt string
+	input  string
+	// saved is the selection before an incremental search, to go back to
+	// if it is cancelled
+	saved  uint64
+	status string
+}
+
+type viewerLine struct {
+	seq uint64
+	ev  Event
+	out string
+	raw string
+}
+
+// NewViewer keeps up to max lines, dropping the oldest ones beyond, or up
+// to DefaultViewerLines if max isn't positive.
+func NewViewer(max int) *Viewer {
+	if max <= 0 {
+		max = DefaultViewerLines
+	}
+	return &Viewer{max: max, follow: true, changed: true}
+}
+
+// Add keeps a line, as an Event, as it was written and as it was read. It
+// fits HandlerOptions.Written.
+func (v *Viewer) Add(ev Event, out, raw []byte) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.seq++
+	l := viewerLine{seq: v.seq, ev: ev, out: string(out), raw: string(raw)}
+	v.changed = true
+	if v.paused {
+		v.pending = append(v.pending, l)
+		if len(v.pending) > v.max {
+			v.pending = v.pending[len(v.pending)-v.max:]
+		}
+		return
+	}
+	v.add(l)
+}
+
+func (v *Viewer) add(l viewerLine) {
+	v.lines = append(v.lines, l)
+	if v.pass(l) {
+		v.shown = append(v.shown, len(v.lines)-1)
+		if v.follow {
+			v.sel = l.seq
+		}
+	}
+	if len(v.lines) > v.max {
+		// a tenth more goes at once, not to filter again at every line
+		v.lines = v.lines[len(v.lines)-v.max+v.max/10:]
+		v.filter()
+	}
+}
+
+// End tells that no more lines are coming, because of err if not nil.
+func (v *Viewer) End(err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.ended = "end of input"
+	if err != nil {
+		v.ended = err.Error()
+	}
+	v.changed = true
+}
+
+// Changed tells if the Viewer has to be drawn again since it last was.
+func (v *Viewer) Changed() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.changed
+}
+
+func (v *Viewer) pass(l viewerLine) bool {
+	if v.minLevel != "" && levelSeverity(l.ev.Level) < levelSeverity(v.minLevel) {
+		return false
+	}
+	return matchPredicates(v.where, l.ev.Fields, l.ev.Msg, l.ev.Level)
+}
+
+// filter finds the lines shown again, after the filters changed. The
+// selection stays on the same line or moves to the next one shown.
+func (v *Viewer) filter() {
+	v.shown = v.shown[:0]
+	for i, l := range v.lines {
+		if v.pass(l) {
+			v.shown = append(v.shown, i)
+		}
+	}
+	if len(v.shown) == 0 {
+		return
+	}
+	pos := v.pos()
+	if v.follow {
+		pos = len(v.shown) - 1
+	}
+	v.sel = v.lines[v.shown[pos]].seq
+}
+
+// pos is the index in shown of the selected line, or of the next one
+// shown if it isn't, or of the last one.
+func (v *Viewer) pos() int {
+	pos := sort.Search(len(v.shown), func(i int) bool {
+		return v.lines[v.shown[i]].seq >= v.sel
+	})
+	if pos == len(v.shown) && pos > 0 {
+		pos--
+	}
+	return pos
+}
+
+func (v *Viewer) moveTo(pos int) {
+	if len(v.shown) == 0 {
+		return
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	if pos >= len(v.shown) {
+		pos = len(v.shown) - 1
+	}
+	v.sel = v.lines[v.shown[pos]].seq
+	v.follow = pos == len(v.shown)-1
+}
+
+// Key acts on a key pressed: a character, or one of up, down, pgup, pgdn,
+// home, end, enter, esc, backspace, or ctrl- and a letter. It tells if the
+// Viewer is to be quit.
+func (v *Viewer) Key(key string) (quit bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.changed = true
+	if v.prompt != "" {
+		v.edit(key)
+		return false
+	}
+	v.status = ""
+	page := v.rows
+	if page <= 0 {
+		page = 1
+	}
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "up", "k":
+		v.moveTo(v.pos() - 1)
+	case "down", "j":
+		v.moveTo(v.pos() + 1)
+	case "pgup", "ctrl-b":
+		v.moveTo(v.pos() - page)
+	case "pgdn", "ctrl-f":
+		v.moveTo(v.pos() + page)
+	case "home", "g":
+		v.moveTo(0)
+	case "end", "G":
+		v.moveTo(len(v.shown) - 1)
+	case "/":
+		v.prompt, v.input, v.saved = viewerSearchPrompt, "", v.sel
+	case "n", "N":
+		if v.search == "" {
+			v.status = "no search, start one with /"
+			break
+		}
+		dir := 1
+		if key == "N" {
+			dir = -1
+		}
+		if !v.find(v.search, v.pos()+dir, dir) {
+			v.status = fmt.Sprintf("no match for %q", v.search)
+		}
+	case "l":
+		for i, level := range viewerLevels {
+			if level == v.minLevel {
+				v.minLevel = viewerLevels[(i+1)%len(viewerLevels)]
+				break
+			}
+		}
+		v.filter()
+	case "f":
+		where := make([]string, 0, len(v.where))
+		for _, p := range v.where {
+			where = append(where, p.String())
+		}
+		v.prompt, v.input = viewerWherePrompt, strings.Join(where, " ")
+	case "F":
+		v.where = nil
+		v.filter()
+	case " ":
+		v.paused = !v.paused
+		if !v.paused {
+			for _, l := range v.pending {
+				v.add(l)
+			}
+			v.pending = nil
+		}
+	case "enter":
+		v.detail = !v.detail
+	case "esc":
+		v.detail = false
+	}
+	return false
+}
+
+// edit acts on a key pressed while typing in after a prompt.
+func (v *Viewer) edit(key string) {
+	switch key {
+	case "enter":
+		v.accept()
+		v.prompt = ""
+		return
+	case "esc", "ctrl-c":
+		if v.prompt == viewerSearchPrompt {
+			v.sel = v.saved
+		}
+		v.prompt = ""
+		return
+	case "backspace":
+		if v.input != "" {
+			_, size := utf8.DecodeLastRuneInString(v.input)
+			v.input = v.input[:len(v.input)-size]
+		}
+	default:
+		if utf8.RuneCountInString(key) != 1 {
+			return
+		}
+		v.input += key
+	}
+	// searches go along as they are typed in
+	if v.prompt == viewerSearchPrompt && v.input != "" {
+		v.sel = v.saved
+		v.find(v.input, v.pos(), 1)
+	}
+}
+
+func (v *Viewer) accept() {
+	switch v.prompt {
+	case viewerSearchPrompt:
+		v.search = v.input
+		if v.search != "" && !v.find(v.search, v.pos(), 1) {
+			v.status = fmt.Sprintf("no match for %q", v.search)
+		}
+	case viewerWherePrompt:
+		var where []Predicate
+		for _, expr := range strings.Fields(v.input) {
+			p, err := ParsePredicate(expr)
+			if err != nil {
+				v.status = err.Error()
+				return
+			}
+			where = append(where, p)
+		}
+		v.where = where
+		v.filter()
+	}
+}
+
+// find selects the first line shown from pos on, going in dir and around,
+// that holds query, regardless of the case. It tells if there was one.
+func (v *Viewer) find(query string, pos, dir int) bool {
+	query = strings.ToLower(query)
+	n := len(v.shown)
+	for i := 0; i < n; i++ {
+		p := ((pos+i*dir)%n + n) % n
+		l := v.lines[v.shown[p]]
+		if strings.Contains(strings.ToLower(l.raw), query) ||
+			strings.Contains(strings.ToLower(plainText(l.out)), query) {
+			v.moveTo(p)
+			return true
+		}
+	}
+	return false
+}
+
+// plainText is s without its escape sequences.
+func plainText(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// Draw draws the Viewer on a terminal of this size: the lines shown, the
+// details of the selected one if asked for, and a status line.
+func (v *Viewer) Draw(w io.Writer, width, height int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.changed = false
+	if width <= 0 || height <= 1 {
+		return nil
+	}
+
+	var detail []string
+	v.rows = height - 1
+	if v.detail && len(v.shown) > 0 && height >= 5 {
+		detail = v.detailLines(width)
+		n := (height - 1) / 2
+		if len(detail) > n {
+			detail = append(detail[:n-1], "…")
+		}
+		v.rows = height - 2 - n
+		for len(detail) < n {
+			detail = append(detail, "")
+		}
+	}
+
+	pos := v.pos()
+	switch {
+	case pos < v.top:
+		v.top = pos
+	case pos >= v.top+v.rows:
+		v.top = pos - v.rows + 1
+	}
+	if v.top > len(v.shown)-v.rows {
+		v.top = len(v.shown) - v.rows
+	}
+	if v.top < 0 {
+		v.top = 0
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H")
+	for i := 0; i < v.rows; i++ {
+		if p := v.top + i; p < len(v.shown) {
+			line := v.lines[v.shown[p]].out
+			if i := strings.IndexByte(line, '\n'); i >= 0 {
+				line = line[:i]
+			}
+			if p == pos {
+				buf.WriteString(reverseVideo(plainText(line), width))
+			} else {
+				buf.WriteString(truncateVisible(line, width))
+			}
+		}
+		buf.WriteString("\x1b[K\r\n")
+	}
+	if detail != nil {
+		buf.WriteString("\x1b[2m" + strings.Repeat("─", width) + "\x1b[0m\x1b[K\r\n")
+		for _, line := range detail {
+			buf.WriteString(truncateVisible(line, width))
+			buf.WriteString("\x1b[K\r\n")
+		}
+	}
+	buf.WriteString(reverseVideo(v.statusLine(pos), width))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// reverseVideo writes s in reverse video, cut or padded to width.
+func reverseVideo(s string, width int) string {
+	s = truncateVisible(s, width)
+	if n := visibleWidth(s); n < width {
+		s += strings.Repeat(" ", width-n)
+	}
+	return "\x1b[7m" + s + "\x1b[0m"
+}
+
+// detailLines are the lines telling all of the selected line: as it was
+// written, whole, and as it was read, indented if it is JSON.
+func (v *Viewer) detailLines(width int) []string {
+	l := v.lines[v.shown[v.pos()]]
+	lines := strings.Split(strings.TrimRight(l.out, "\n"), "\n")
+	lines = append(lines, "")
+
+	raw := []byte(l.raw)
+	var indented bytes.Buffer
+	if json.Indent(&indented, bytes.TrimSpace(raw), "", "  ") == nil {
+		raw = indented.Bytes()
+	}
+	for _, line := range strings.Split(plainText(string(raw)), "\n") {
+		line = strings.Replace(line, "\t", "    ", -1)
+		for utf8.RuneCountInString(line) > width {
+			cut := 0
+			for i := 0; i < width; i++ {
+				_, size := utf8.DecodeRuneInString(line[cut:])
+				cut += size
+			}
+			lines = append(lines, line[:cut])
+			line = line[cut:]
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (v *Viewer) statusLine(pos int) string {
+	if v.prompt != "" {
+		return v.prompt + v.input + "█"
+	}
+	parts := []string{fmt.Sprintf(" %d/%d", pos+1, len(v.shown))}
+	if len(v.shown) == 0 {
+		parts[0] = " 0/0"
+	}
+	if len(v.shown) != len(v.lines) {
+		parts[0] += fmt.Sprintf(" of %d", len(v.lines))
+	}
+	if v.minLevel != "" {
+		parts = append(parts, "level>="+v.minLevel)
+	}
+	for _, p := range v.where {
+		parts = append(parts, p.String())
+	}
+	if v.search != "" {
+		parts = append(parts, "/"+v.search)
+	}
+	switch {
+	case v.paused:
+		parts = append(parts, fmt.Sprintf("paused, %d more", len(v.pending)))
+	case v.ended != "":
+		parts = append(parts, v.ended)
+	case v.follow:
+		parts = append(parts, "following")
+	}
+	if v.status != "" {
+		parts = append(parts, v.status)
+	} else {
+		parts = append(parts, "q quit, / search, l level, f where, space pause, enter details")
+	}
+	return strings.Join(parts, "  │  ")
+}
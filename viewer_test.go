@@ -0,0 +1,117 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func newTestViewer(t *testing.T, lines ...string) *Viewer {
+	v := NewViewer(0)
+	opts := *DefaultOptions
+	opts.Written = v.Add
+	if err := Scanner(strings.NewReader(strings.Join(lines, "\n")), &bytes.Buffer{}, &opts); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func drawViewer(t *testing.T, v *Viewer) []string {
+	var buf bytes.Buffer
+	if err := v.Draw(&buf, 120, 10); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(plainText(buf.String()), "\r\n")
+}
+
+func typeKeys(v *Viewer, keys ...string) {
+	for _, key := range keys {
+		v.Key(key)
+	}
+}
+
+func TestViewerFilters(t *testing.T) {
+	v := newTestViewer(t,
+		`{"time":"2018-10-24T08:19:50Z","level":"debug","msg":"one","status":200}`,
+		`{"time":"2018-10-24T08:19:51Z","level":"warn","msg":"two","status":503}`,
+		`{"time":"2018-10-24T08:19:52Z","level":"error","msg":"three","status":404}`,
+	)
+	screen := drawViewer(t, v)
+	if len(screen) != 10 || !strings.Contains(screen[0], "one") || !strings.Contains(screen[2], "three") {
+		t.Fatalf("want the three lines, got %q", screen)
+	}
+	if !strings.HasPrefix(screen[9], " 3/3") {
+		t.Errorf("want the last line selected, got %q", screen[9])
+	}
+
+	// minimum levels go: debug, info, warn
+	typeKeys(v, "l", "l", "l")
+	screen = drawViewer(t, v)
+	if !strings.Contains(screen[0], "two") || !strings.Contains(screen[1], "three") || !strings.Contains(screen[9], "2/2 of 3") {
+		t.Errorf("want the lines from warn on, got %q", screen)
+	}
+
+	typeKeys(v, "F", "l", "l", "f", "s", "t", "a", "t", "u", "s", ">", "=", "5", "0", "0", "enter")
+	screen = drawViewer(t, v)
+	if !strings.Contains(screen[0], "two") || strings.Contains(screen[1], "three") || !strings.Contains(screen[9], "status>=500") {
+		t.Errorf("want the lines where status>=500, got %q", screen)
+	}
+
+	typeKeys(v, "f", "backspace", "backspace", "backspace", "backspace", "backspace", "backspace", "backspace", "backspace", "backspace", "backspace", "!", "enter")
+	if screen = drawViewer(t, v); !strings.Contains(screen[9], "operator") {
+		t.Errorf("want an invalid predicate told, got %q", screen[9])
+	}
+}
+
+func TestViewerSearch(t *testing.T) {
+	v := newTestViewer(t,
+		`{"time":"2018-10-24T08:19:50Z","level":"info","msg":"hello","user":"alice"}`,
+		`{"time":"2018-10-24T08:19:51Z","level":"info","msg":"world"}`,
+		`{"time":"2018-10-24T08:19:52Z","level":"info","msg":"hello again","user":"ALICE"}`,
+	)
+	typeKeys(v, "g", "/", "a", "l", "i", "c", "e", "enter")
+	if screen := drawViewer(t, v); !strings.HasPrefix(screen[9], " 1/3") {
+		t.Errorf("want the first match selected, got %q", screen[9])
+	}
+	typeKeys(v, "n")
+	if screen := drawViewer(t, v); !strings.HasPrefix(screen[9], " 3/3") {
+		t.Errorf("want the next match selected regardless of the case, got %q", screen[9])
+	}
+	typeKeys(v, "n")
+	if screen := drawViewer(t, v); !strings.HasPrefix(screen[9], " 1/3") {
+		t.Errorf("want the search to go around, got %q", screen[9])
+	}
+
+	typeKeys(v, "/", "w", "esc")
+	if screen := drawViewer(t, v); !strings.HasPrefix(screen[9], " 1/3") || !strings.Contains(screen[9], "/alice") {
+		t.Errorf("want a cancelled search to change nothing, got %q", screen[9])
+	}
+}
+
+func TestViewerPause(t *testing.T) {
+	v := newTestViewer(t, `{"time":"2018-10-24T08:19:50Z","level":"info","msg":"one"}`)
+	typeKeys(v, " ")
+	v.Add(Event{Level: InfoLevel, Msg: "two"}, []byte("two"), []byte("two"))
+	screen := drawViewer(t, v)
+	if strings.Contains(screen[1], "two") || !strings.Contains(screen[9], "paused, 1 more") {
+		t.Errorf("want lines held while paused, got %q", screen)
+	}
+	typeKeys(v, " ")
+	screen = drawViewer(t, v)
+	if !strings.Contains(screen[1], "two") || !strings.HasPrefix(screen[9], " 2/2") {
+		t.Errorf("want the lines held when resumed, got %q", screen)
+	}
+}
+
+func TestViewerDetail(t *testing.T) {
+	v := newTestViewer(t, `{"time":"2018-10-24T08:19:50Z","level":"info","msg":"one","user":"alice"}`)
+	typeKeys(v, "enter")
+	var buf bytes.Buffer
+	if err := v.Draw(&buf, 120, 30); err != nil {
+		t.Fatal(err)
+	}
+	screen := plainText(buf.String())
+	if !strings.Contains(screen, `  "user": "alice"`) {
+		t.Errorf("want the raw line indented in the details, got %q", screen)
+	}
+}
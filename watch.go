@@ -0,0 +1,80 @@
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Watch follows the last value of some keys among the lines that pass the
+// filters, and how many times it changed, to keep an eye on a progress
+// counter or the version of a config while lines stream by. It is safe for
+// concurrent use.
+type Watch struct {
+	mu      sync.Mutex
+	keys    []string
+	values  map[string]string
+	changes map[string]int
+}
+
+// NewWatch follows the values of keys.
+func NewWatch(keys []string) *Watch {
+	return &Watch{
+		keys:    keys,
+		values:  make(map[string]string, len(keys)),
+		changes: make(map[string]int, len(keys)),
+	}
+}
+
+// add notes the values ev has for the keys followed.
+func (w *Watch) add(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, key := range w.keys {
+		v, ok := ev.Fields[key]
+		if !ok {
+			continue
+		}
+		// on a single line, whatever it holds
+		v = strings.Join(strings.Fields(ansiEscape.ReplaceAllString(unquoteValue(v), "")), " ")
+		if last, seen := w.values[key]; seen && last != v {
+			w.changes[key]++
+		}
+		w.values[key] = v
+	}
+}
+
+// String renders the last values of the keys followed, in their order,
+// like `shard=7 (2 changes) │ version=v42`. The keys not seen yet are left
+// out.
+func (w *Watch) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	parts := make([]string, 0, len(w.keys))
+	for _, key := range w.keys {
+		v, ok := w.values[key]
+		if !ok {
+			continue
+		}
+		part := key + "=" + v
+		switch n := w.changes[key]; n {
+		case 0:
+		case 1:
+			part += " (1 change)"
+		default:
+			part += " (" + strconv.Itoa(n) + " changes)"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " │ ")
+}
+
+// Line renders the last values of the keys followed as String does, cut
+// to width visible runes if it is positive.
+func (w *Watch) Line(width int) string {
+	line := w.String()
+	if width > 0 && visibleWidth(line) > width {
+		line = truncateVisible(line, width-1) + "…"
+	}
+	return line
+}
@@ -0,0 +1,29 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWatch(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2024-01-02T03:04:05Z","level":"info","msg":"copying","shard":"7","done":1}`,
+		`{"time":"2024-01-02T03:04:06Z","level":"debug","msg":"copying","shard":"7","done":2}`,
+		`{"time":"2024-01-02T03:04:07Z","level":"info","msg":"copying","shard":"8","done":3}`,
+		`{"time":"2024-01-02T03:04:08Z","level":"info","msg":"copying","shard":"9"}`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	opts.MinLevel = InfoLevel
+	opts.Watch = NewWatch([]string{"shard", "version", "done"})
+	if err := Scanner(strings.NewReader(src), bytes.NewBuffer(nil), &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := opts.Watch.String(), "shard=9 (2 changes) │ done=3 (1 change)"; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if got, want := opts.Watch.Line(12), "shard=9 (2 …"; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
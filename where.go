@@ -0,0 +1,126 @@
+package humanlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Predicate is a condition on a field of a line, like `status>=500` or
+// `service=checkout`. Values are compared as numbers when both sides are
+// numbers, and as strings otherwise. Lines that don't have the field never
+// satisfy it.
+//
+// The message and the normalized level of a line can be used as the `msg`
+// and `level` fields, unless the line has fields by those names.
+type Predicate struct {
+	Key   string
+	Op    string
+	Value string
+
+	num   float64
+	isNum bool
+}
+
+// predicateOps are the operators of predicates, the ones that are prefixes
+// of others coming last.
+var predicateOps = []string{"!=", ">=", "<=", "=", ">", "<"}
+
+// ParsePredicate parses a predicate like `key>=value`.
+func ParsePredicate(expr string) (Predicate, error) {
+	i := strings.IndexAny(expr, "!=<>")
+	if i <= 0 {
+		return Predicate{}, fmt.Errorf("want key, an operator and a value, like status>=500: %q", expr)
+	}
+	p := Predicate{Key: strings.TrimSpace(expr[:i])}
+	for _, op := range predicateOps {
+		if strings.HasPrefix(expr[i:], op) {
+			p.Op = op
+			break
+		}
+	}
+	if p.Op == "" {
+		return Predicate{}, fmt.Errorf("unknown operator in %q, want one of %s", expr, strings.Join(predicateOps, " "))
+	}
+	p.Value = strings.TrimSpace(expr[i+len(p.Op):])
+	p.num, p.isNum = parseNumber(p.Value)
+	return p, nil
+}
+
+func (p Predicate) String() string { return p.Key + p.Op + p.Value }
+
+// match tells if val, the value of the field of the predicate, satisfies it.
+func (p Predicate) match(val string) bool {
+	var cmp int
+	if n, ok := parseNumber(val); ok && p.isNum {
+		switch {
+		case n < p.num:
+			cmp = -1
+		case n > p.num:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(val, p.Value)
+	}
+	switch p.Op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+func parseNumber(s string) (float64, bool) {
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
+
+// unquoteValue undoes the quoting of string values, so that they compare
+// as they were logged.
+func unquoteValue(val string) string {
+	if len(val) < 2 || val[0] != '"' {
+		return val
+	}
+	if s, err := strconv.Unquote(val); err == nil {
+		return s
+	}
+	return val
+}
+
+// matchWhere tells if the line held since the last call to match satisfies
+// all the predicates of Where.
+func (lh *lineHandler) matchWhere() bool {
+	fields, msg := lh.fields()
+	return matchPredicates(lh.opts.Where, fields, msg, lh.level())
+}
+
+// matchPredicates tells if the line of these fields, message and level
+// satisfies all the predicates.
+func matchPredicates(preds []Predicate, fields map[string]string, msg, level string) bool {
+	for _, p := range preds {
+		val, ok := fields[p.Key]
+		switch {
+		case ok:
+			val = unquoteValue(val)
+		case p.Key == "msg":
+			val = msg
+		case p.Key == "level":
+			val = level
+		default:
+			return false
+		}
+		if !p.match(val) {
+			return false
+		}
+	}
+	return true
+}
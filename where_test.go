@@ -0,0 +1,95 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParsePredicate(t *testing.T) {
+	for expr, want := range map[string]Predicate{
+		"status>=500":      {Key: "status", Op: ">=", Value: "500"},
+		"service=checkout": {Key: "service", Op: "=", Value: "checkout"},
+		"user != bob":      {Key: "user", Op: "!=", Value: "bob"},
+		"latency<0.5":      {Key: "latency", Op: "<", Value: "0.5"},
+		"path=/a=b":        {Key: "path", Op: "=", Value: "/a=b"},
+		"empty=":           {Key: "empty", Op: "=", Value: ""},
+	} {
+		got, err := ParsePredicate(expr)
+		if err != nil {
+			t.Fatalf("%q: %v", expr, err)
+		}
+		if got.Key != want.Key || got.Op != want.Op || got.Value != want.Value {
+			t.Fatalf("want %q parsed as %v, got %v", expr, want, got)
+		}
+	}
+	for _, expr := range []string{"", "status", ">=500", "a!b"} {
+		if _, err := ParsePredicate(expr); err == nil {
+			t.Fatalf("want %q rejected", expr)
+		}
+	}
+}
+
+func TestPredicateMatch(t *testing.T) {
+	for _, tt := range []struct {
+		expr string
+		val  string
+		want bool
+	}{
+		{"status>=500", "503", true},
+		{"status>=500", "404", false},
+		// as numbers, not as strings
+		{"status>=500", "1000", true},
+		{"latency<0.5", "0.25", true},
+		{"service=checkout", "checkout", true},
+		{"service!=checkout", "cart", true},
+		{"name>m", "zed", true},
+		// falls back to strings when either side isn't a number
+		{"status<500", "unknown", false},
+	} {
+		p, err := ParsePredicate(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.match(tt.val); got != tt.want {
+			t.Fatalf("want %q on %q to be %v", tt.expr, tt.val, tt.want)
+		}
+	}
+}
+
+func TestScannerWhere(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"info","msg":"ok","service":"checkout","status":200}`,
+		`{"time":"2018-10-24T08:00:01Z","level":"error","msg":"boom","service":"checkout","status":503}`,
+		`{"time":"2018-10-24T08:00:02Z","level":"error","msg":"other","service":"cart","status":500}`,
+		`time="2018-10-24T08:00:03Z" level=error msg="logfmt" service=checkout status=502`,
+		`raw line`,
+	}, "\n")
+
+	opts := *DefaultOptions
+	for _, expr := range []string{"status>=500", "service=checkout"} {
+		p, err := ParsePredicate(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Where = append(opts.Where, p)
+	}
+	dst := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	got := dst.String()
+	if strings.Count(got, "\n") != 2 || !strings.Contains(got, "boom") || !strings.Contains(got, "logfmt") {
+		t.Fatalf("want only the failed checkouts, got %q", got)
+	}
+
+	p, _ := ParsePredicate("level=error")
+	opts.Where = []Predicate{p}
+	dst.Reset()
+	if err := Scanner(strings.NewReader(src), dst, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.String(); strings.Count(got, "\n") != 3 || strings.Contains(got, "ok") {
+		t.Fatalf("want the level usable as a field, got %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ways of handling the lines wider than HandlerOptions.Width.
+const (
+	// OverflowNone lets wide lines be, for the terminal to wrap them.
+	OverflowNone = "none"
+	// OverflowTruncate cuts wide lines at the width, ending them with an
+	// ellipsis.
+	OverflowTruncate = "truncate"
+	// OverflowWrap moves the fields that don't fit onto indented lines
+	// below the line.
+	OverflowWrap = "wrap"
+)
+
+// wrapIndent is written before the fields moved onto a line of their own
+// by OverflowWrap.
+const wrapIndent = "    "
+
+// visibleWidth counts the runes of s that show up on a terminal, that is
+// all of them but the ANSI escape sequences.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// fitMessage pads or truncates the rendered message to MessageWidth, if
+// set, so that the fields that follow it line up.
+func (h *HandlerOptions) fitMessage(msg string) string {
+	if h.MessageWidth <= 0 {
+		return msg
+	}
+	width := visibleWidth(msg)
+	if width <= h.MessageWidth {
+		return msg + strings.Repeat(" ", h.MessageWidth-width)
+	}
+	return truncateVisible(msg, h.MessageWidth-1) + "…"
+}
+
+// truncateVisible keeps the first n visible runes of s, along with the
+// escape sequences among them. If any, colors are reset at the end.
+func truncateVisible(s string, n int) string {
+	var (
+		out     strings.Builder
+		escaped bool
+	)
+	for n > 0 && s != "" {
+		if loc := ansiEscape.FindStringIndex(s); loc != nil && loc[0] == 0 {
+			out.WriteString(s[:loc[1]])
+			s = s[loc[1]:]
+			escaped = true
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		out.WriteString(s[:size])
+		s = s[size:]
+		n--
+	}
+	if escaped {
+		out.WriteString("\x1b[0m")
+	}
+	return out.String()
+}
+
+// fitWidth truncates line to Width, if it is set and line is wider.
+func (h *HandlerOptions) fitWidth(line string) string {
+	if h.Width <= 0 || visibleWidth(line) <= h.Width {
+		return line
+	}
+	return truncateVisible(line, h.Width-1) + "…"
+}
+
+// wrapCells writes head and then cells separated by spaces, going to an
+// indented line when a cell would make the line wider than Width. A cell
+// wider than a line of its own is written whole.
+func (h *HandlerOptions) wrapCells(buf *bytes.Buffer, head string, cells []string) {
+	buf.WriteString(head)
+	width := visibleWidth(head)
+	for _, cell := range cells {
+		w := visibleWidth(cell)
+		if width+1+w > h.Width && width > len(wrapIndent) {
+			buf.WriteByte('\n')
+			buf.WriteString(wrapIndent)
+			buf.WriteString(cell)
+			width = len(wrapIndent) + w
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(cell)
+		width += 1 + w
+	}
+}
@@ -0,0 +1,134 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// windowsAuditFailure is the keyword of the events of failed audits, like
+// a logon with a wrong password.
+const windowsAuditFailure = 0x10000000000000
+
+// windowsEvent is an event of the Windows Event Log, as exported by
+// `wevtutil qe <log> /f:xml` or `(Get-WinEvent ...).ToXml()`, which write
+// one per line. EVTX files can be exported so with `wevtutil qe <file>
+// /lf /f:xml`.
+type windowsEvent struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		}
+		EventID       string
+		Level         string
+		Task          string
+		Keywords      string
+		EventRecordID string
+		TimeCreated   struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		}
+		Execution struct {
+			ProcessID string `xml:"ProcessID,attr"`
+			ThreadID  string `xml:"ThreadID,attr"`
+		}
+		Channel  string
+		Computer string
+		Security struct {
+			UserID string `xml:"UserID,attr"`
+		}
+	}
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		}
+	}
+	RenderingInfo struct {
+		Message string
+	}
+}
+
+// matchWindowsEvent tells if the line held by lh is an event of the
+// Windows Event Log exported as XML, like
+//
+//	<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><Provider Name='Service Control Manager'/><EventID>7036</EventID>...</System><EventData><Data Name='param1'>Windows Update</Data></EventData></Event>
+//
+// in which case the logrus handler gets its parts. Its rendered message,
+// if it was exported along with it, is its message, and its level sets the
+// level. Its ID, provider, channel, computer and data are its fields.
+func (lh *lineHandler) matchWindowsEvent() bool {
+	line := bytes.TrimSpace(lh.lineData)
+	if !bytes.HasPrefix(line, []byte("<Event")) || !bytes.HasSuffix(line, []byte("</Event>")) {
+		return false
+	}
+	var ev windowsEvent
+	if err := xml.Unmarshal(line, &ev); err != nil || ev.System.EventID == "" {
+		return false
+	}
+	sys := ev.System
+
+	h := &lh.logrusEntry
+	if t, err := time.Parse(time.RFC3339Nano, sys.TimeCreated.SystemTime); err == nil {
+		h.Time = t
+	}
+	h.setLevel([]byte(windowsEventLevel(sys.Level, sys.Keywords)))
+	// the first line of the message tells what happened, the rest are the
+	// details already in the data
+	msg := strings.TrimSpace(ev.RenderingInfo.Message)
+	if i := strings.IndexAny(msg, "\r\n"); i >= 0 {
+		msg = strings.TrimSpace(msg[:i])
+	}
+	h.setMessage([]byte(msg))
+
+	for _, kv := range [][2]string{
+		{"event_id", sys.EventID},
+		{"provider", sys.Provider.Name},
+		{"channel", sys.Channel},
+		{"computer", sys.Computer},
+		{"record_id", sys.EventRecordID},
+		{"task", sys.Task},
+		{"pid", sys.Execution.ProcessID},
+		{"tid", sys.Execution.ThreadID},
+		{"user_id", sys.Security.UserID},
+	} {
+		if kv[1] != "" {
+			h.setField([]byte(kv[0]), []byte(kv[1]))
+		}
+	}
+	for i, data := range ev.EventData.Data {
+		key := data.Name
+		if key == "" {
+			key = "data" + strconv.Itoa(i+1)
+		}
+		if val := strings.TrimSpace(data.Value); val != "" && val != "-" {
+			h.setField([]byte(key), []byte(val))
+		}
+	}
+	return true
+}
+
+// windowsEventLevel maps the level of an event onto a normalized level.
+// Audit events all have the level 0, those of failed audits are warnings.
+func windowsEventLevel(level, keywords string) string {
+	switch level {
+	case "0", "":
+		kw, err := strconv.ParseUint(strings.TrimPrefix(keywords, "0x"), 16, 64)
+		if err == nil && kw&windowsAuditFailure != 0 {
+			return WarnLevel
+		}
+		return InfoLevel
+	case "1":
+		return FatalLevel
+	case "2":
+		return ErrorLevel
+	case "3":
+		return WarnLevel
+	case "4":
+		return InfoLevel
+	case "5":
+		return DebugLevel
+	}
+	return UnknownLevel
+}
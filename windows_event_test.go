@@ -0,0 +1,57 @@
+package humanlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWindowsEventLine(t *testing.T) {
+	opts := *DefaultOptions
+	opts.Truncates = false
+	opts.ShowHandler = true
+	lh := newLineHandler(&opts)
+
+	line := `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><Provider Name='Microsoft-Windows-Security-Auditing' Guid='{54849625-5478-4994-a5ba-3e3b0328c30d}'/><EventID>4625</EventID><Version>0</Version><Level>0</Level><Task>12544</Task><Opcode>0</Opcode><Keywords>0x8010000000000000</Keywords><TimeCreated SystemTime='2023-01-10T12:00:00.1234567Z'/><EventRecordID>4242</EventRecordID><Correlation/><Execution ProcessID='612' ThreadID='3320'/><Channel>Security</Channel><Computer>DC01.corp.local</Computer><Security/></System><EventData><Data Name='TargetUserName'>alice</Data><Data Name='IpAddress'>10.0.0.7</Data><Data Name='SubjectLogonId'>-</Data></EventData><RenderingInfo Culture='en-US'><Message>An account failed to log on.&#13;&#10;&#13;&#10;Subject:</Message></RenderingInfo></Event>`
+	if format := lh.match([]byte(line)); format != "winevent" {
+		t.Fatalf("want winevent format, got %q", format)
+	}
+	if want := time.Date(2023, 1, 10, 12, 0, 0, 123456700, time.UTC); !lh.ev.Time.Equal(want) {
+		t.Fatalf("want time %v, got %v", want, lh.ev.Time)
+	}
+	dst := bytes.NewBuffer(nil)
+	lh.write(dst)
+	out := dst.String()
+	for _, want := range []string{
+		"[winevent]", "|WARN| An account failed to log on.",
+		"event_id=4625", "provider=Microsoft-Windows-Security-Auditing", "channel=Security",
+		"computer=DC01.corp.local", "TargetUserName=alice", "IpAddress=10.0.0.7",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("want %q in output, got %q", want, out)
+		}
+	}
+	if strings.Contains(out, "SubjectLogonId") {
+		t.Fatalf("want empty data left out, got %q", out)
+	}
+}
+
+func TestWindowsEventLevels(t *testing.T) {
+	for _, test := range []struct {
+		level, keywords, want string
+	}{
+		{"0", "0x8020000000000000", InfoLevel},
+		{"0", "0x8010000000000000", WarnLevel},
+		{"1", "0x80000000000000", FatalLevel},
+		{"2", "0x80000000000000", ErrorLevel},
+		{"3", "0x80000000000000", WarnLevel},
+		{"4", "0x80000000000000", InfoLevel},
+		{"5", "0x80000000000000", DebugLevel},
+		{"9", "", UnknownLevel},
+	} {
+		if got := windowsEventLevel(test.level, test.keywords); got != test.want {
+			t.Fatalf("want level %s with keywords %s to be %s, got %s", test.level, test.keywords, test.want, got)
+		}
+	}
+}
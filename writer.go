@@ -0,0 +1,74 @@
+package humanlog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// NewWriter prettifies what is written to it onto dst, line by line, as
+// Scanner would with a pipe. Writes don't need to end on a line boundary,
+// partial lines are held until the rest of them comes or Close is called.
+// It is safe for concurrent use, so it can stand for the output of a
+// logger or of a command. Closing it doesn't close dst.
+//
+// JSONArrayInput, JournalExportInput, MySQLSlowLogInput and CSVInput aren't
+// line based and are ignored.
+func NewWriter(dst io.Writer, opts *HandlerOptions) io.WriteCloser {
+	return &lineWriter{dst: dst, lh: newLineHandler(opts)}
+}
+
+type lineWriter struct {
+	mu      sync.Mutex
+	dst     io.Writer
+	lh      *lineHandler
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := p
+	if len(w.partial) > 0 {
+		data = append(w.partial, p...)
+	}
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		w.handle(data[:i])
+		data = data[i+1:]
+	}
+	w.partial = append(w.partial[:0], data...)
+	return len(p), nil
+}
+
+// Close writes out the partial line held, if any.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.handle(w.partial)
+		w.partial = w.partial[:0]
+	}
+	w.lh.finish(w.dst)
+	return nil
+}
+
+func (w *lineWriter) handle(line []byte) {
+	if w.lh.done() {
+		return
+	}
+	w.lh.opts.copyRaw(line)
+	w.lh.handle(w.dst, truncateLine(trimCR(line), w.lh.opts.maxLineLength()))
+}
+
+// writeLine handles line as a whole, even if it holds newlines, for inputs
+// that frame lines themselves.
+func (w *lineWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handle(line)
+}
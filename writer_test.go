@@ -0,0 +1,45 @@
+package humanlog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewWriter(t *testing.T) {
+	src := strings.Join([]string{
+		`{"time":"2018-10-24T08:00:00Z","level":"info","msg":"first"}`,
+		`time="2018-10-24T08:00:01Z" level=warn msg="second"`,
+		`raw third`,
+		`{"time":"2018-10-24T08:00:02Z","level":"error","msg":"unterminated"}`,
+	}, "\r\n")
+
+	want := bytes.NewBuffer(nil)
+	if err := Scanner(strings.NewReader(src), want, DefaultOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	// written a few bytes at a time, cutting lines anywhere
+	got := bytes.NewBuffer(nil)
+	w := NewWriter(got, DefaultOptions)
+	for i := 0; i < len(src); i += 7 {
+		end := i + 7
+		if end > len(src) {
+			end = len(src)
+		}
+		n, err := fmt.Fprint(w, src[i:end])
+		if err != nil || n != end-i {
+			t.Fatalf("want %d bytes written, got %d: %v", end-i, n, err)
+		}
+	}
+	if strings.Contains(got.String(), "unterminated") {
+		t.Fatal("want the partial line held until closing")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Fatalf("want\n%q\ngot\n%q", want.String(), got.String())
+	}
+}